@@ -0,0 +1,106 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Purpose-Dev/flowcraft/internal/agent"
+	"github.com/Purpose-Dev/flowcraft/internal/client"
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// joinTokenEnv holds the token the agent registers with.
+const joinTokenEnv = "FLOWCRAFT_JOIN_TOKEN"
+
+var rootCmd = &cobra.Command{
+	Use:   "flowcraft-agent",
+	Short: "flowcraft-agent runs the jobs scheduled by a flowcraft-server.",
+	Long: `A flowcraft worker. It registers with a flowcraft-server, leases jobs,
+runs their steps in a fresh copy of the run's workspace and streams the
+output back. When an agent disappears, its leases expire and the jobs
+are given to another agent.
+
+A server requiring authentication only accepts agents presenting a join
+token, read from $FLOWCRAFT_JOIN_TOKEN (see 'flowcraft token create
+--scope agent-register'). Each agent then receives a token of its own.`,
+	Version: fmt.Sprintf("%s (commit %s, built %s)", version, commit, date),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverURL, _ := cmd.Flags().GetString("server")
+		name, _ := cmd.Flags().GetString("name")
+		tags, _ := cmd.Flags().GetStringSlice("tags")
+		capacity, _ := cmd.Flags().GetInt("capacity")
+		workDir, _ := cmd.Flags().GetString("work-dir")
+		cpu, _ := cmd.Flags().GetFloat64("cpu")
+		mem, _ := cmd.Flags().GetString("mem")
+
+		memory, err := config.ParseQuantity(mem)
+		if err != nil {
+			return fmt.Errorf("invalid --mem: %w", err)
+		}
+
+		if name == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return fmt.Errorf("failed to determine the agent name, use --name: %w", err)
+			}
+			name = hostname
+		}
+
+		c := client.New(serverURL)
+		c.SetToken(os.Getenv(joinTokenEnv))
+		a := agent.New(c, agent.Options{
+			Name:     name,
+			Tags:     tags,
+			Capacity: capacity,
+			CPU:      cpu,
+			Memory:   memory,
+			WorkDir:  workDir,
+		})
+		return a.Run(cmd.Context())
+	},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	rootCmd.Flags().String("server", "http://localhost:8080", "URL of the flowcraft-server")
+	rootCmd.Flags().String("name", "", "Name of the agent (defaults to the hostname)")
+	rootCmd.Flags().StringSlice("tags", nil, "Tags describing the agent, e.g. linux,docker")
+	rootCmd.Flags().Int("capacity", 1, "Number of jobs run concurrently")
+	cpu, memory := agent.DetectResources()
+	rootCmd.Flags().Float64("cpu", cpu, "CPUs shared by the jobs according to their resources (0 for unlimited)")
+	rootCmd.Flags().String("mem", config.FormatQuantity(memory), "Memory shared by the jobs according to their resources, e.g. 16Gi (0 for unlimited)")
+	rootCmd.Flags().String("work-dir", "", "Directory holding the working directories of the jobs (defaults to a temporary directory)")
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "An error occured: '%s'\n", err)
+		os.Exit(1)
+	}
+}
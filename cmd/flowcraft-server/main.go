@@ -0,0 +1,183 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/agent"
+	"github.com/Purpose-Dev/flowcraft/internal/server"
+	"github.com/Purpose-Dev/flowcraft/internal/tracing"
+	"github.com/spf13/cobra"
+)
+
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// webhookSecretEnv holds the secret shared with GitHub and GitLab.
+const webhookSecretEnv = "FLOWCRAFT_WEBHOOK_SECRET"
+
+var rootCmd = &cobra.Command{
+	Use:   "flowcraft-server",
+	Short: "flowcraft-server runs submitted pipelines from a persistent queue.",
+	Long: `The central flowcraft orchestrator. It exposes an HTTP/JSON API to submit
+pipelines (a flow.toml and a workspace tarball), inspect, cancel and
+stream runs, and schedules them with the same engine as 'flowcraft run'.
+Jobs are leased to flowcraft-agent workers; --local-agents runs some in
+the server process itself. A web dashboard is served at /ui/, and
+Prometheus metrics at /metrics.
+
+API requests need a token, obtained with 'flowcraft login'. The first
+start creates an admin token and logs it once; 'flowcraft token create'
+makes others, including the join tokens agents register with.
+--auth=false turns authentication off, for a server nobody else can
+reach.
+
+With --webhook-config, GitHub and GitLab push and pull request events
+posted to /api/v1/webhooks/github or /api/v1/webhooks/gitlab start runs
+of that pipeline, as selected by its [triggers]. The shared secret is
+read from $FLOWCRAFT_WEBHOOK_SECRET.
+
+With --otlp-endpoint (by default $OTEL_EXPORTER_OTLP_ENDPOINT), each run
+is exported as an OpenTelemetry trace to that OTLP/HTTP collector, such
+as Jaeger on http://localhost:4318.`,
+	Version: fmt.Sprintf("%s (commit %s, built %s)", version, commit, date),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		workers, _ := cmd.Flags().GetInt("workers")
+		localAgents, _ := cmd.Flags().GetInt("local-agents")
+		leaseTTL, _ := cmd.Flags().GetDuration("lease-ttl")
+		localTags, _ := cmd.Flags().GetStringSlice("local-agent-tags")
+		webhookConfig, _ := cmd.Flags().GetString("webhook-config")
+		auth, _ := cmd.Flags().GetBool("auth")
+		otlpEndpoint, _ := cmd.Flags().GetString("otlp-endpoint")
+
+		webhookSecret := os.Getenv(webhookSecretEnv)
+		if webhookConfig != "" && webhookSecret == "" {
+			return fmt.Errorf("--webhook-config requires a secret in $%s", webhookSecretEnv)
+		}
+
+		var tracer *tracing.Tracer
+		if otlpEndpoint != "" {
+			exporter, err := tracing.NewOTLPExporter(otlpEndpoint, "flowcraft-server")
+			if err != nil {
+				return err
+			}
+			tracer = tracing.NewTracer(exporter)
+			log.Printf("Exporting the traces of runs to %s.", otlpEndpoint)
+		}
+
+		srv, err := server.New(server.Options{
+			DataDir:       dataDir,
+			Workers:       workers,
+			LeaseTTL:      leaseTTL,
+			WebhookConfig: webhookConfig,
+			WebhookSecret: webhookSecret,
+			Auth:          auth,
+			Tracer:        tracer,
+		})
+		if err != nil {
+			return err
+		}
+		defer srv.Close()
+
+		ctx := cmd.Context()
+		httpServer := &http.Server{Addr: addr, Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			srv.Start(ctx)
+		}()
+
+		// The local agents share the host.
+		cpu, memory := agent.DetectResources()
+		if localAgents > 1 {
+			cpu /= float64(localAgents)
+			memory /= int64(localAgents)
+		}
+		for i := 1; i <= localAgents; i++ {
+			a := agent.New(srv, agent.Options{
+				Name:    fmt.Sprintf("local-%d", i),
+				Tags:    localTags,
+				CPU:     cpu,
+				Memory:  memory,
+				WorkDir: filepath.Join(dataDir, "agents", fmt.Sprintf("local-%d", i)),
+			})
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := a.Run(ctx); err != nil {
+					log.Print(err)
+				}
+			}()
+		}
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_ = httpServer.Shutdown(shutdownCtx)
+		}()
+
+		if !auth {
+			log.Print("Authentication is disabled: anyone reaching the server can run jobs on its agents.")
+		}
+		log.Printf("flowcraft-server listening on %s (data: %s, workers: %d, local agents: %d)", addr, dataDir, workers, localAgents)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		wg.Wait()
+		log.Print("flowcraft-server stopped.")
+		return nil
+	},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	rootCmd.Flags().String("addr", ":8080", "Address to listen on")
+	rootCmd.Flags().String("data-dir", "flowcraft-data", "Directory holding the database and workspaces")
+	rootCmd.Flags().Int("workers", 2, "Number of runs executed concurrently")
+	rootCmd.Flags().Int("local-agents", 1, "Number of agents running jobs inside the server process")
+	rootCmd.Flags().StringSlice("local-agent-tags", nil, "Tags of the local agents, matched against the jobs' runs_on")
+	rootCmd.Flags().Duration("lease-ttl", 30*time.Second, "How long an agent may go without heartbeat before its job is requeued")
+	rootCmd.Flags().String("webhook-config", "", "flow.toml of the pipeline started by GitHub and GitLab webhooks")
+	rootCmd.Flags().Bool("auth", true, "Require API tokens, and join tokens from agents")
+	rootCmd.Flags().String("otlp-endpoint", tracing.DefaultEndpoint(), "OTLP/HTTP collector receiving the traces of runs, e.g. http://localhost:4318")
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "An error occured: '%s'\n", err)
+		os.Exit(1)
+	}
+}
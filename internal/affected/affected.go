@@ -0,0 +1,165 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package affected selects the jobs of a pipeline affected by a set of
+// changed files, for monorepos where only the jobs whose sources changed
+// should run.
+package affected
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
+)
+
+// Job is the decision taken for a job of the pipeline.
+type Job struct {
+	Name     string `json:"name"`
+	Selected bool   `json:"selected"`
+	// Reason explains why the job was selected or skipped.
+	Reason string `json:"reason"`
+	// Files are the changed files matching the paths of the job.
+	Files []string `json:"files,omitempty"`
+}
+
+// Selection is the result of Select.
+type Selection struct {
+	Changed []string `json:"changed"`
+	// Jobs holds every job of the pipeline, in the order they run.
+	Jobs []Job `json:"jobs"`
+}
+
+// Selected returns the names of the selected jobs.
+func (s *Selection) Selected() []string {
+	names := []string{}
+	for _, job := range s.Jobs {
+		if job.Selected {
+			names = append(names, job.Name)
+		}
+	}
+	return names
+}
+
+// Select selects the jobs of graph affected by the changed files: the
+// jobs whose paths match one of them, or that have no paths, along with
+// the jobs depending on those. The jobs the selected ones depend on are
+// selected as well, since they must run first.
+func Select(graph *engine.Graph, changed []string) (*Selection, error) {
+	levels, err := graph.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+	var order []*engine.Node
+	for _, level := range levels {
+		slices.SortFunc(level, func(a, b *engine.Node) int { return strings.Compare(a.Name, b.Name) })
+		order = append(order, level...)
+	}
+
+	jobs := make(map[string]*Job, len(order))
+	for _, node := range order {
+		job := &Job{Name: node.Name}
+		jobs[node.Name] = job
+
+		files, err := match(node.Job.Paths, changed)
+		if err != nil {
+			return nil, fmt.Errorf("job '%s': %w", node.Name, err)
+		}
+		switch {
+		case len(changed) == 0:
+			job.Reason = "no file changed"
+		case len(node.Job.Paths) == 0:
+			job.Selected = true
+			job.Reason = "it has no paths, so any change affects it"
+		case len(files) > 0:
+			job.Selected = true
+			job.Files = files
+			job.Reason = fmt.Sprintf("%s matches its paths", describe(files))
+		default:
+			job.Reason = "no changed file matches its paths"
+		}
+
+		// Dependencies come first in order, so they are decided.
+		if !job.Selected {
+			for _, dep := range sorted(node.Dependencies) {
+				if jobs[dep.Name].Selected {
+					job.Selected = true
+					job.Reason = fmt.Sprintf("it depends on '%s'", dep.Name)
+					break
+				}
+			}
+		}
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		node := order[i]
+		job := jobs[node.Name]
+		if job.Selected {
+			continue
+		}
+		for _, dependent := range sorted(node.Dependents) {
+			if jobs[dependent.Name].Selected {
+				job.Selected = true
+				job.Reason = fmt.Sprintf("'%s' depends on it", dependent.Name)
+				break
+			}
+		}
+	}
+
+	s := &Selection{Changed: changed, Jobs: make([]Job, 0, len(order))}
+	if s.Changed == nil {
+		s.Changed = []string{}
+	}
+	for _, node := range order {
+		s.Jobs = append(s.Jobs, *jobs[node.Name])
+	}
+	return s, nil
+}
+
+// match returns the files matching one of patterns.
+func match(patterns, files []string) ([]string, error) {
+	globs := make([]*workspace.Glob, 0, len(patterns))
+	for _, pattern := range patterns {
+		g, err := workspace.CompileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		globs = append(globs, g)
+	}
+	var matched []string
+	for _, file := range files {
+		if slices.ContainsFunc(globs, func(g *workspace.Glob) bool { return g.Match(file) }) {
+			matched = append(matched, file)
+		}
+	}
+	return matched, nil
+}
+
+// describe names the first file and counts the others.
+func describe(files []string) string {
+	if len(files) == 1 {
+		return files[0]
+	}
+	return fmt.Sprintf("%s (and %d more)", files[0], len(files)-1)
+}
+
+func sorted(nodes []*engine.Node) []*engine.Node {
+	nodes = slices.Clone(nodes)
+	slices.SortFunc(nodes, func(a, b *engine.Node) int { return strings.Compare(a.Name, b.Name) })
+	return nodes
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package affected
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+)
+
+func monorepo(t *testing.T) *engine.Graph {
+	t.Helper()
+	graph, err := engine.BuildDag(&config.Config{
+		Jobs: map[string]config.Job{
+			"setup": {Paths: []string{"go.mod"}},
+			"api":   {DependsOn: []string{"setup"}, Paths: []string{"api/**"}},
+			"web":   {DependsOn: []string{"setup"}, Paths: []string{"web/**"}},
+			"e2e":   {DependsOn: []string{"api", "web"}, Paths: []string{"e2e/**"}},
+			"docs":  {Paths: []string{"docs/**"}},
+			"lint":  {},
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	return graph
+}
+
+func TestSelect(t *testing.T) {
+	graph := monorepo(t)
+
+	s, err := Select(graph, []string{"api/main.go", "api/routes.go", "README.md"})
+	if err != nil {
+		t.Fatalf("Select() returned an unexpected error: %v", err)
+	}
+	if want := []string{"lint", "setup", "api", "web", "e2e"}; !reflect.DeepEqual(s.Selected(), want) {
+		t.Errorf("Expected %v to be selected, got %v", want, s.Selected())
+	}
+	reasons := make(map[string]string)
+	for _, job := range s.Jobs {
+		reasons[job.Name] = job.Reason
+	}
+	want := map[string]string{
+		"setup": "'api' depends on it",
+		"lint":  "it has no paths, so any change affects it",
+		"api":   "api/main.go (and 1 more) matches its paths",
+		"web":   "'e2e' depends on it",
+		"e2e":   "it depends on 'api'",
+		"docs":  "no changed file matches its paths",
+	}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("Expected the reasons %v, got %v", want, reasons)
+	}
+
+	s, err = Select(graph, []string{"docs/index.md"})
+	if err != nil {
+		t.Fatalf("Select() returned an unexpected error: %v", err)
+	}
+	if want := []string{"docs", "lint"}; !reflect.DeepEqual(s.Selected(), want) {
+		t.Errorf("Expected %v to be selected, got %v", want, s.Selected())
+	}
+	if docs := s.Jobs[0]; !reflect.DeepEqual(docs.Files, []string{"docs/index.md"}) {
+		t.Errorf("Expected the files matching the paths of docs, got %+v", docs)
+	}
+
+	s, err = Select(graph, nil)
+	if err != nil {
+		t.Fatalf("Select() returned an unexpected error: %v", err)
+	}
+	if len(s.Selected()) != 0 || s.Jobs[0].Reason != "no file changed" {
+		t.Errorf("Expected no job to be selected without changes, got %+v", s.Jobs)
+	}
+}
+
+func TestChanged_RejectsOptions(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "written")
+	for _, ref := range []string{"--output=" + output, "-p", ""} {
+		if _, err := Changed(context.Background(), dir, ref); err == nil || !strings.Contains(err.Error(), "invalid revision") {
+			t.Errorf("Changed(%q) error = %v, want an invalid revision", ref, err)
+		}
+	}
+	if _, err := os.Stat(output); err == nil {
+		t.Error("Expected the ref not to be passed to git as an option")
+	}
+}
+
+func TestChanged(t *testing.T) {
+	dir := t.TempDir()
+	cmd := exec.Command("bash", "-c", `set -e
+git init -q -b main .
+git config user.name Flowcraft
+git config user.email ci@example.com
+mkdir -p api web docs
+echo 1 > api/main.go; echo 1 > web/app.js; echo 1 > docs/old.md; echo 'tmp/' > .gitignore
+git add . && git commit -q -m "Initial commit"
+git checkout -q -b feature
+echo 2 > api/main.go
+git mv docs/old.md docs/new.md
+git commit -q -am "Change the api"
+git checkout -q main
+echo 2 > web/app.js && git commit -q -am "Change main"
+git checkout -q feature
+echo 3 > api/routes.go
+mkdir tmp && echo 1 > tmp/cache
+echo 3 > web/dirty.js`)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create the repository: %v\n%s", err, out)
+	}
+
+	// The commits of main since the branch point are not changes.
+	files, err := Changed(context.Background(), dir, "main")
+	if err != nil {
+		t.Fatalf("Changed() returned an unexpected error: %v", err)
+	}
+	want := []string{"api/main.go", "api/routes.go", "docs/new.md", "docs/old.md", "web/dirty.js"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected %v, got %v", want, files)
+	}
+
+	files, err = Changed(context.Background(), filepath.Join(dir, "api"), "main")
+	if err != nil {
+		t.Fatalf("Changed() returned an unexpected error: %v", err)
+	}
+	if want := []string{"main.go", "routes.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected the paths to be relative to the directory, got %v", files)
+	}
+
+	if _, err := Changed(context.Background(), dir, "nope"); err == nil {
+		t.Error("Expected an error for an unknown revision")
+	}
+}
@@ -0,0 +1,349 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package agent implements the worker side of flowcraft-server: it
+// leases jobs, runs their steps locally and reports the results.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/client"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/junit"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/Purpose-Dev/flowcraft/internal/tracing"
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
+)
+
+// Coordinator hands out leases. *client.Client implements it over HTTP
+// and *server.Server implements it in process.
+type Coordinator interface {
+	Register(ctx context.Context, reg api.AgentRegistration) (*api.Agent, error)
+	// Lease waits up to wait for a job and returns nil if none came.
+	Lease(ctx context.Context, agentID string, wait time.Duration) (*api.Lease, error)
+	Heartbeat(ctx context.Context, leaseID string) (*api.HeartbeatResponse, error)
+	SendEvents(ctx context.Context, leaseID string, events []api.Event) error
+	Complete(ctx context.Context, leaseID string, result api.LeaseResult) error
+	// Workspace returns the run's workspace tarball, or nil if it has none.
+	Workspace(ctx context.Context, leaseID string) (io.ReadCloser, error)
+}
+
+var _ Coordinator = (*client.Client)(nil)
+
+// Options configures an Agent.
+type Options struct {
+	Name string
+	Tags []string
+	// Capacity is the number of jobs run concurrently. Defaults to 1.
+	Capacity int
+	// CPU and Memory (in bytes) are shared by the jobs according to
+	// their resources. Zero means unlimited; see DetectResources.
+	CPU    float64
+	Memory int64
+	// WorkDir holds the working directories of the leased jobs.
+	WorkDir string
+	// PollWait is how long a lease request waits for a job. Defaults to 20s.
+	PollWait time.Duration
+}
+
+const (
+	// eventsInterval is how often the output of a job is sent to the server.
+	eventsInterval = 300 * time.Millisecond
+	// retryDelay is the pause after a failed exchange with the server.
+	retryDelay = 2 * time.Second
+)
+
+// Agent leases jobs from a Coordinator and runs them.
+type Agent struct {
+	coord Coordinator
+	opts  Options
+
+	mu sync.Mutex
+	id string
+}
+
+func New(coord Coordinator, opts Options) *Agent {
+	if opts.Capacity <= 0 {
+		opts.Capacity = 1
+	}
+	if opts.PollWait <= 0 {
+		opts.PollWait = 20 * time.Second
+	}
+	if opts.WorkDir == "" {
+		opts.WorkDir = filepath.Join(os.TempDir(), "flowcraft-agent")
+	}
+	return &Agent{coord: coord, opts: opts}
+}
+
+// Run registers the agent and runs leased jobs until ctx is cancelled.
+// Jobs interrupted by the cancellation are not reported: their leases
+// expire and the server gives them to another agent.
+func (a *Agent) Run(ctx context.Context) error {
+	if err := os.MkdirAll(a.opts.WorkDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+	if _, err := a.register(ctx, ""); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < a.opts.Capacity; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.loop(ctx)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// register (re-)registers the agent, unless another loop already did so
+// since stale was obtained.
+func (a *Agent) register(ctx context.Context, stale string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.id != stale {
+		return a.id, nil
+	}
+
+	agent, err := a.coord.Register(ctx, api.AgentRegistration{
+		Name:     a.opts.Name,
+		Tags:     a.opts.Tags,
+		Capacity: a.opts.Capacity,
+		CPU:      a.opts.CPU,
+		Memory:   a.opts.Memory,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to register agent: %w", err)
+	}
+	a.id = agent.ID
+	log.Printf("Agent '%s' registered as %s.", a.opts.Name, agent.ID)
+	return a.id, nil
+}
+
+func (a *Agent) agentID() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.id
+}
+
+func (a *Agent) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		id := a.agentID()
+		lease, err := a.coord.Lease(ctx, id, a.opts.PollWait)
+		switch {
+		case errors.Is(err, api.ErrUnknownAgent):
+			log.Printf("Agent %s is no longer known to the server, registering again.", id)
+			if _, err := a.register(ctx, id); err != nil {
+				log.Print(err)
+				sleep(ctx, retryDelay)
+			}
+		case err != nil:
+			if ctx.Err() == nil {
+				log.Printf("Failed to lease a job: %v", err)
+				sleep(ctx, retryDelay)
+			}
+		case lease != nil:
+			a.execute(ctx, lease)
+		}
+	}
+}
+
+// execute runs a leased job. The lease is kept alive with heartbeats,
+// which also tell the agent when the run was cancelled.
+func (a *Agent) execute(ctx context.Context, lease *api.Lease) {
+	log.Printf("Running job '%s' of run %s (attempt %d).", lease.Job, lease.RunID, lease.Attempt)
+	start := time.Now()
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// lost and cancelled are only read once the heartbeats stopped.
+	var lost, cancelled bool
+	hbDone := make(chan struct{})
+	hbStop := make(chan struct{})
+	go func() {
+		defer close(hbDone)
+		ticker := time.NewTicker(heartbeatInterval(lease))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-hbStop:
+				return
+			case <-ticker.C:
+			}
+			resp, err := a.coord.Heartbeat(jobCtx, lease.ID)
+			switch {
+			case errors.Is(err, api.ErrLeaseLost):
+				lost = true
+				cancel()
+				return
+			case err != nil:
+				log.Printf("Heartbeat of job '%s' failed: %v", lease.Job, err)
+			case resp.Cancelled:
+				cancelled = true
+				cancel()
+			}
+		}
+	}()
+
+	fwd := newForwarder(a.coord, lease.ID)
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+	logger.AddSink(fwd.log)
+	jobLogger := logger.WithJob(lease.Job)
+
+	dir := filepath.Join(a.opts.WorkDir, lease.ID)
+	err := a.prepare(jobCtx, lease, dir)
+	if err != nil {
+		jobLogger.Error(fmt.Sprintf("Failed to prepare the workspace of job '%s': %v", lease.Job, err))
+	}
+	env := lease.Env
+	if err == nil && len(lease.Secrets) > 0 {
+		env, err = a.resolveSecrets(jobCtx, lease, logger)
+		if err != nil {
+			jobLogger.Error(fmt.Sprintf("Failed to resolve the secrets of job '%s': %v", lease.Job, err))
+		}
+	}
+	opts := runner.Options{
+		Env: runner.EnvPolicy{
+			Inherit:        lease.InheritEnv,
+			Pass:           lease.PassEnv,
+			WarnUndeclared: lease.WarnUndeclaredEnv,
+		},
+		Workdir:    dir,
+		Outputs:    runner.NewOutputs(lease.Outputs),
+		ProcessEnv: lease.ProcessEnv,
+	}
+	// tests is reported with the result: the server records it as the
+	// job's test report.
+	var tests *junit.Summary
+	recordTests := func(e engine.Event) {
+		if e.Type == engine.EventTestReport {
+			tests = e.Tests
+		}
+	}
+	if err == nil {
+		execCtx := jobCtx
+		if sc, parseErr := tracing.ParseTraceParent(lease.TraceParent); parseErr == nil {
+			// The server records the spans of the steps from their events.
+			execCtx = tracing.ContextWithRemoteParent(jobCtx, sc)
+		}
+		err = engine.ExecuteJob(execCtx, lease.Job, lease.Spec, env, opts, jobLogger, fwd.event, recordTests)
+	}
+
+	close(hbStop)
+	<-hbDone
+	fwd.close(ctx)
+	if rmErr := os.RemoveAll(dir); rmErr != nil {
+		log.Printf("Failed to clean up %s: %v", dir, rmErr)
+	}
+
+	if lost {
+		log.Printf("Lease of job '%s' was lost, result discarded.", lease.Job)
+		return
+	}
+	if ctx.Err() != nil {
+		// The agent is shutting down: let the lease expire so that the
+		// job is given to another agent.
+		return
+	}
+
+	result := api.LeaseResult{Status: engine.StatusSuccess, Outputs: opts.Outputs.Values()}
+	switch {
+	case err == nil:
+	case cancelled:
+		result = api.LeaseResult{Status: engine.StatusCancelled, Error: err.Error()}
+	default:
+		result = api.LeaseResult{Status: engine.StatusFailed, Error: err.Error()}
+	}
+	result.Tests = tests
+	if err := a.coord.Complete(ctx, lease.ID, result); err != nil {
+		log.Printf("Failed to report the result of job '%s': %v", lease.Job, err)
+		return
+	}
+	log.Printf("Job '%s' of run %s finished in %s: %s.", lease.Job, lease.RunID, time.Since(start).Round(time.Millisecond), result.Status)
+}
+
+// resolveSecrets resolves the secrets of a leased job on the agent's
+// host and returns the environment of the job with their values, which
+// are masked from then on.
+func (a *Agent) resolveSecrets(ctx context.Context, lease *api.Lease, logger *runner.Logger) (map[string]string, error) {
+	values, masked, err := engine.ResolveSecrets(ctx, lease.SecretSettings, lease.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	logger.SetSecretsToMask(masked)
+	env := maps.Clone(lease.Env)
+	if env == nil {
+		env = make(map[string]string, len(values))
+	}
+	maps.Copy(env, values)
+	return env, nil
+}
+
+// prepare creates the job's working directory and unpacks the run's
+// workspace into it.
+func (a *Agent) prepare(ctx context.Context, lease *api.Lease, dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if !lease.HasWorkspace {
+		return nil
+	}
+
+	rc, err := a.coord.Workspace(ctx, lease.ID)
+	if err != nil {
+		return err
+	}
+	if rc == nil {
+		return nil
+	}
+	defer rc.Close()
+	return workspace.Unpack(rc, dir)
+}
+
+// heartbeatInterval sends at least three heartbeats per lease TTL, and
+// one every two seconds so that cancellations are noticed quickly.
+func heartbeatInterval(lease *api.Lease) time.Duration {
+	ttl := time.Duration(lease.LeaseTTLSeconds) * time.Second
+	return max(min(ttl/3, 2*time.Second), 100*time.Millisecond)
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
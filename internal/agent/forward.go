@@ -0,0 +1,122 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package agent
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+// forwarder batches the step events and log lines of a job and sends
+// them to the server every eventsInterval.
+type forwarder struct {
+	coord   Coordinator
+	leaseID string
+
+	mu      sync.Mutex
+	pending []api.Event
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newForwarder(coord Coordinator, leaseID string) *forwarder {
+	f := &forwarder{
+		coord:   coord,
+		leaseID: leaseID,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go f.loop()
+	return f
+}
+
+func (f *forwarder) loop() {
+	defer close(f.done)
+	ticker := time.NewTicker(eventsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			f.flush(context.Background())
+		}
+	}
+}
+
+func (f *forwarder) add(e api.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending = append(f.pending, e)
+}
+
+// log is a runner.Sink.
+func (f *forwarder) log(e runner.Entry) {
+	f.add(api.Event{
+		Type:    api.EventLog,
+		Time:    e.Time,
+		Job:     e.Job,
+		Level:   string(e.Level),
+		Message: e.Message,
+	})
+}
+
+// event is an engine.Listener.
+func (f *forwarder) event(e engine.Event) {
+	f.add(api.Event{
+		Type:       string(e.Type),
+		Time:       e.Time,
+		Job:        e.Job,
+		Step:       e.Step,
+		Attempt:    e.Attempt,
+		Status:     e.Status,
+		Error:      e.Error,
+		DurationMs: e.Duration.Milliseconds(),
+		Message:    e.Message,
+		ExitCode:   e.ExitCode,
+		SpanID:     e.SpanID,
+		Tests:      e.Tests,
+	})
+}
+
+func (f *forwarder) flush(ctx context.Context) {
+	f.mu.Lock()
+	events := f.pending
+	f.pending = nil
+	f.mu.Unlock()
+
+	if len(events) == 0 {
+		return
+	}
+	if err := f.coord.SendEvents(ctx, f.leaseID, events); err != nil {
+		log.Printf("Failed to send %d event(s): %v", len(events), err)
+	}
+}
+
+// close stops the periodic flush and sends the remaining events.
+func (f *forwarder) close(ctx context.Context) {
+	close(f.stop)
+	<-f.done
+	f.flush(ctx)
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/junit"
+)
+
+var (
+	// ErrUnknownAgent is returned when an agent is not, or no longer,
+	// registered. The agent must register again.
+	ErrUnknownAgent = errors.New("unknown agent")
+	// ErrLeaseLost is returned when a lease expired or was revoked. The
+	// agent must stop working on it.
+	ErrLeaseLost = errors.New("lease lost")
+)
+
+// AgentRegistration is sent by an agent when it connects.
+type AgentRegistration struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags,omitempty"`
+	// Capacity is the number of jobs the agent runs concurrently.
+	Capacity int `json:"capacity"`
+	// CPU and Memory (in bytes) are shared by the jobs of the agent
+	// according to their resources. Zero means unlimited.
+	CPU    float64 `json:"cpu,omitempty"`
+	Memory int64   `json:"memory,omitempty"`
+}
+
+// Agent is a registered worker.
+type Agent struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Tags     []string `json:"tags,omitempty"`
+	Capacity int      `json:"capacity"`
+	CPU      float64  `json:"cpu,omitempty"`
+	Memory   int64    `json:"memory,omitempty"`
+	// UsedCPU and UsedMemory are reserved by the agent's current leases.
+	UsedCPU    float64   `json:"used_cpu"`
+	UsedMemory int64     `json:"used_memory"`
+	LastSeen   time.Time `json:"last_seen"`
+	// Leases lists the IDs of the leases held by the agent.
+	Leases []string `json:"leases"`
+	// Jobs describes the job attempt of each lease, in the same order.
+	Jobs []LeasedJob `json:"jobs"`
+	// LeaseTTLSeconds is how long a lease survives without heartbeat.
+	LeaseTTLSeconds int `json:"lease_ttl_seconds"`
+	// Token authenticates the lease requests of the agent. It is only
+	// returned by the registration.
+	Token string `json:"token,omitempty"`
+}
+
+// LeasedJob is a job attempt held by an agent.
+type LeasedJob struct {
+	Lease     string    `json:"lease"`
+	RunID     string    `json:"run_id"`
+	Job       string    `json:"job"`
+	Attempt   int       `json:"attempt"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Lease grants an agent one attempt of a job.
+type Lease struct {
+	ID      string     `json:"id"`
+	RunID   string     `json:"run_id"`
+	Job     string     `json:"job"`
+	Attempt int        `json:"attempt"`
+	Spec    config.Job `json:"spec"`
+	// Env holds the merged global and job variables.
+	Env map[string]string `json:"env"`
+	// Secrets holds the declarations of the secrets used by the job.
+	// The agent resolves them itself, with SecretSettings configuring
+	// the providers, and masks their values: the server never resolves
+	// the secrets of a submitted pipeline.
+	Secrets           map[string]config.Secret `json:"secrets,omitempty"`
+	SecretSettings    config.Settings          `json:"secret_settings"`
+	InheritEnv        bool                     `json:"inherit_env"`
+	PassEnv           []string                 `json:"pass_env,omitempty"`
+	WarnUndeclaredEnv bool                     `json:"warn_undeclared_env,omitempty"`
+	// Outputs holds the values exported by the jobs done so far, such
+	// as the git.sha of a checkout.
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// ProcessEnv holds the variables only set in the environment of the
+	// steps, such as the CI_* variables of a webhook.
+	ProcessEnv map[string]string `json:"process_env,omitempty"`
+	// HasWorkspace tells whether the run came with a workspace tarball.
+	HasWorkspace    bool      `json:"has_workspace"`
+	LeaseTTLSeconds int       `json:"lease_ttl_seconds"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	AgentID         string    `json:"agent_id"`
+	// TraceParent is the span of the job attempt when the run is traced.
+	// The agent's steps continue that trace.
+	TraceParent string `json:"traceparent,omitempty"`
+}
+
+// HeartbeatResponse tells an agent whether to keep working on a lease.
+type HeartbeatResponse struct {
+	Cancelled bool `json:"cancelled"`
+}
+
+// LeaseResult is reported by an agent when it is done with a lease.
+type LeaseResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Outputs holds the values exported by the job.
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// Tests summarizes the test reports of the job, if it has any.
+	Tests *junit.Summary `json:"tests,omitempty"`
+}
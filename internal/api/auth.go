@@ -0,0 +1,108 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Scopes of the API tokens.
+const (
+	// ScopeRead lists and inspects runs and agents.
+	ScopeRead = "read"
+	// ScopeSubmit submits runs, cancels them and answers their approval gates.
+	ScopeSubmit = "submit"
+	// ScopeAdmin manages tokens and implies every other scope.
+	ScopeAdmin = "admin"
+	// ScopeAgentRegister is the only scope of join tokens: it lets an
+	// agent register, which gives the agent a token of its own.
+	ScopeAgentRegister = "agent-register"
+)
+
+// Scopes lists the valid scopes.
+var Scopes = []string{ScopeRead, ScopeSubmit, ScopeAdmin, ScopeAgentRegister}
+
+// Errors of the device login flow, sent as the error of 400 responses
+// while the CLI polls for its token.
+var (
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	ErrAccessDenied         = errors.New("access_denied")
+	ErrExpiredToken         = errors.New("expired_token")
+)
+
+// Token describes an API token. The secret itself is only shown once,
+// when the token is created.
+type Token struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Allows reports whether the token grants scope.
+func (t Token) Allows(scope string) bool {
+	return slices.Contains(t.Scopes, scope) || slices.Contains(t.Scopes, ScopeAdmin)
+}
+
+// TokenRequest asks for a new token.
+type TokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// NewToken is a token just created, with its secret.
+type NewToken struct {
+	Token
+	Secret string `json:"secret"`
+}
+
+// DeviceCode starts a device login: the user confirms UserCode on the
+// dashboard at VerificationURL while the CLI polls with DeviceCode.
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURL string `json:"verification_url"`
+	// ExpiresIn and Interval are in seconds.
+	ExpiresIn int `json:"expires_in"`
+	Interval  int `json:"interval"`
+}
+
+// DeviceRequest starts a device login from the named client.
+type DeviceRequest struct {
+	Name string `json:"name"`
+}
+
+// DeviceTokenRequest polls for the token of a device login.
+type DeviceTokenRequest struct {
+	DeviceCode string `json:"device_code"`
+}
+
+// DeviceApproval answers a device login on behalf of the caller.
+type DeviceApproval struct {
+	UserCode string `json:"user_code"`
+	Approved bool   `json:"approved"`
+}
+
+// DeviceLogin describes a pending device login, as shown to the user
+// asked to confirm it.
+type DeviceLogin struct {
+	UserCode  string    `json:"user_code"`
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package api defines the JSON types exchanged between flowcraft-server
+// and its clients.
+package api
+
+import (
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/junit"
+)
+
+// RunStatus is the state of a submitted pipeline run.
+type RunStatus string
+
+const (
+	RunQueued    RunStatus = "queued"
+	RunRunning   RunStatus = "running"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+	RunCancelled RunStatus = "cancelled"
+)
+
+// Done reports whether the run reached a final state.
+func (s RunStatus) Done() bool {
+	return s == RunSucceeded || s == RunFailed || s == RunCancelled
+}
+
+// Job statuses, matching the statuses reported by the engine.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSuccess   = "success"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+	JobSkipped   = "skipped"
+	// JobAwaitingApproval is the status of a job held at its approval
+	// gate until someone approves or rejects it.
+	JobAwaitingApproval = "awaiting_approval"
+)
+
+// JobState is the last known state of a job within a run.
+type JobState struct {
+	Status     string     `json:"status"`
+	DependsOn  []string   `json:"depends_on,omitempty"`
+	Attempt    int        `json:"attempt,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	// Tests summarizes the test reports of the job's last attempt.
+	Tests *junit.Summary `json:"tests,omitempty"`
+}
+
+// Run is a pipeline submitted to the server.
+type Run struct {
+	ID         string               `json:"id"`
+	Status     RunStatus            `json:"status"`
+	CreatedAt  time.Time            `json:"created_at"`
+	StartedAt  *time.Time           `json:"started_at,omitempty"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+	Error      string               `json:"error,omitempty"`
+	Jobs       map[string]*JobState `json:"jobs"`
+	// Trigger is set on runs started by a webhook.
+	Trigger *Trigger `json:"trigger,omitempty"`
+}
+
+// Trigger describes the push or pull request that started a run.
+type Trigger struct {
+	// Provider is "github" or "gitlab".
+	Provider string `json:"provider"`
+	// Event is "push" or "pull_request".
+	Event      string `json:"event"`
+	Repository string `json:"repository"`
+	CloneURL   string `json:"clone_url,omitempty"`
+	// Branch is the pushed branch, or the source branch of a pull request.
+	Branch  string `json:"branch"`
+	SHA     string `json:"sha"`
+	Message string `json:"message,omitempty"`
+	Author  string `json:"author,omitempty"`
+	// PullRequest and BaseBranch are only set for pull requests.
+	PullRequest int    `json:"pull_request,omitempty"`
+	BaseBranch  string `json:"base_branch,omitempty"`
+}
+
+// Webhook delivery outcomes.
+const (
+	WebhookTriggered = "triggered"
+	WebhookIgnored   = "ignored"
+)
+
+// WebhookResponse is the answer to a webhook delivery: the run it
+// started, or why it was ignored.
+type WebhookResponse struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Run    *Run   `json:"run,omitempty"`
+}
+
+// EventLog is the type of events carrying a line of log output.
+// The other event types are the engine's lifecycle events.
+const EventLog = "log"
+
+// Event is an entry of a run's event stream. Seq increases by one for
+// each event of a run, so clients can resume a stream after a given
+// event.
+type Event struct {
+	Seq        uint64    `json:"seq"`
+	Type       string    `json:"type"`
+	Time       time.Time `json:"time"`
+	Job        string    `json:"job,omitempty"`
+	Step       string    `json:"step,omitempty"`
+	Attempt    int       `json:"attempt,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms,omitempty"`
+	Level      string    `json:"level,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	ExitCode   int       `json:"exit_code,omitempty"`
+	SpanID     string    `json:"span_id,omitempty"`
+	// Tests is set on test_report events.
+	Tests *junit.Summary `json:"tests,omitempty"`
+}
+
+// ErrorResponse is the body of every non-2xx response.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
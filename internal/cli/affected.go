@@ -0,0 +1,120 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"text/tabwriter"
+
+	"github.com/Purpose-Dev/flowcraft/internal/affected"
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var affectedCmd = &cobra.Command{
+	Use:   "affected",
+	Short: "Lists the jobs affected by the changes since a git revision",
+	Long: `Lists the jobs that 'flowcraft run --changed-since <ref>' would run,
+and why each job is selected or skipped.
+
+A job is affected when one of the files changed since <ref> matches its
+paths = [...] globs, or on any change when it has no paths. The jobs
+depending on an affected job are affected too, and the jobs they depend
+on are selected so that they can run first. Files are compared with the
+merge base of <ref> and HEAD, including the uncommitted and untracked
+files.
+
+--json prints the selection for other tooling.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, _ := cmd.Flags().GetString("file")
+		since, _ := cmd.Flags().GetString("changed-since")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		cfg, err := config.LoadConfig(filePath)
+		if err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+		graph, err := engine.BuildDag(cfg)
+		if err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+		selection, err := selectChanged(cmd.Context(), graph, since)
+		if err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			err := enc.Encode(struct {
+				Since    string   `json:"since"`
+				Selected []string `json:"selected"`
+				*affected.Selection
+			}{since, selection.Selected(), selection})
+			if err != nil {
+				log.Fatalf("Critical error: %v", err)
+			}
+			return
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "JOB\tSELECTED\tREASON")
+		for _, job := range selection.Jobs {
+			selected := "no"
+			if job.Selected {
+				selected = "yes"
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", job.Name, selected, job.Reason)
+		}
+		_ = w.Flush()
+	},
+}
+
+// selectChanged selects the jobs of graph affected by the files of the
+// working directory changed since ref.
+func selectChanged(ctx context.Context, graph *engine.Graph, ref string) (*affected.Selection, error) {
+	changed, err := affected.Changed(ctx, ".", ref)
+	if err != nil {
+		return nil, fmt.Errorf("listing the files changed since %s: %w", ref, err)
+	}
+	return affected.Select(graph, changed)
+}
+
+// logSelection logs why each job is run or skipped.
+func logSelection(logger *runner.Logger, ref string, s *affected.Selection) {
+	logger.Info(fmt.Sprintf("%d file(s) changed since %s.", len(s.Changed), ref))
+	for _, job := range s.Jobs {
+		if job.Selected {
+			logger.Info(fmt.Sprintf("Running '%s': %s.", job.Name, job.Reason))
+		} else {
+			logger.Info(fmt.Sprintf("Skipping '%s': %s.", job.Name, job.Reason))
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(affectedCmd)
+	affectedCmd.Flags().StringP("file", "f", "flow.toml", "Path to the flow.toml configuration file")
+	affectedCmd.Flags().String("changed-since", "", "Git revision the working tree is compared with, e.g. origin/main")
+	affectedCmd.Flags().Bool("json", false, "Print the selection as JSON")
+	_ = affectedCmd.MarkFlagRequired("changed-since")
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"golang.org/x/term"
+)
+
+// newApprover returns the approver of a local run, or nil when no job
+// of graph has an approval gate. Gates are confirmed on the terminal, or all
+// approved with --auto-approve. A run that would need someone to type
+// an answer but has no terminal fails before any job starts.
+func newApprover(graph *engine.Graph, logger *runner.Logger, auto bool) (engine.Approver, error) {
+	var gated []string
+	for name, node := range graph.Nodes {
+		if node.Job.Approve {
+			gated = append(gated, "'"+name+"'")
+		}
+	}
+	if len(gated) == 0 {
+		return nil, nil
+	}
+	sort.Strings(gated)
+
+	if auto {
+		logger.Info(fmt.Sprintf("Approving %s with --auto-approve.", strings.Join(gated, ", ")))
+		return func(ctx context.Context, req engine.ApprovalRequest) (bool, error) {
+			return true, nil
+		}, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("job(s) %s need approval, but stdin is not a terminal: run with --auto-approve to approve them",
+			strings.Join(gated, ", "))
+	}
+
+	a := &ttyApprover{logger: logger, out: os.Stdout, turn: make(chan struct{}, 1)}
+	a.lines = readLines(os.Stdin)
+	return a.approve, nil
+}
+
+// ttyApprover asks on the terminal whether a gated job may run. Gates
+// may open at the same time, so the questions are asked one at a time.
+type ttyApprover struct {
+	logger *runner.Logger
+	out    io.Writer
+	lines  <-chan string
+	turn   chan struct{}
+}
+
+func (a *ttyApprover) approve(ctx context.Context, req engine.ApprovalRequest) (bool, error) {
+	select {
+	case a.turn <- struct{}{}:
+		defer func() { <-a.turn }()
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		// The gate timed out while another question was asked.
+		return false, err
+	}
+
+	a.logger.StartGroup(fmt.Sprintf("Approval required: %s", req.Job))
+	for _, line := range req.Spec.Describe() {
+		a.logger.Info(line)
+	}
+	a.logger.EndGroup()
+	// The gate's timeout runs while it waits for its turn: show what is left.
+	left := req.Timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		left = time.Until(deadline).Round(time.Second)
+	}
+	fmt.Fprintf(a.out, "Run job '%s'? It is rejected in %s without an answer. [y/N] ", req.Job, left)
+
+	select {
+	case answer, ok := <-a.lines:
+		if !ok {
+			fmt.Fprintln(a.out)
+		}
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes", nil
+	case <-ctx.Done():
+		fmt.Fprintln(a.out)
+		return false, ctx.Err()
+	}
+}
+
+// readLines reads r line by line in the background, so that a prompt
+// can stop waiting for an answer. The channel is closed at EOF.
+func readLines(r io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent prompts.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestTTYApprover_TwoGatesAtOnce(t *testing.T) {
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+	lines := make(chan string)
+	out := &syncBuffer{}
+	a := &ttyApprover{logger: logger, out: out, lines: lines, turn: make(chan struct{}, 1)}
+
+	type answer struct {
+		approved bool
+		err      error
+	}
+	ask := func(ctx context.Context, job string) <-chan answer {
+		done := make(chan answer, 1)
+		go func() {
+			approved, err := a.approve(ctx, engine.ApprovalRequest{Job: job, Timeout: time.Hour})
+			done <- answer{approved, err}
+		}()
+		return done
+	}
+	waitPrompt := func(job string) {
+		t.Helper()
+		for start := time.Now(); !strings.Contains(out.String(), "Run job '"+job+"'"); {
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("Expected the prompt of '%s', got %q", job, out.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	first := ask(context.Background(), "deploy")
+	waitPrompt("deploy")
+
+	// The second gate opened with the first: its timeout runs out while
+	// it waits for its turn, and it is never shown.
+	shortCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if got := <-ask(shortCtx, "expired"); !errors.Is(got.err, context.DeadlineExceeded) {
+		t.Errorf("Expected the queued gate to time out, got %+v", got)
+	}
+
+	// The third gate is shown with the time it has left, not its full timeout.
+	queuedCtx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	third := ask(queuedCtx, "release")
+	lines <- "y"
+	if got := <-first; !got.approved || got.err != nil {
+		t.Errorf("Expected 'deploy' to be approved, got %+v", got)
+	}
+	waitPrompt("release")
+	lines <- "n"
+	if got := <-third; got.approved || got.err != nil {
+		t.Errorf("Expected 'release' to be rejected, got %+v", got)
+	}
+
+	prompts := out.String()
+	if strings.Contains(prompts, "'expired'") {
+		t.Errorf("Expected the expired gate not to be shown, got %q", prompts)
+	}
+	if !strings.Contains(prompts, "Run job 'deploy'? It is rejected in 1h0m0s") {
+		t.Errorf("Expected the first gate to show its full timeout, got %q", prompts)
+	}
+	if !strings.Contains(prompts, "Run job 'release'? It is rejected in 30m0s") {
+		t.Errorf("Expected the queued gate to show the time it has left, got %q", prompts)
+	}
+}
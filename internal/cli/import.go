@@ -0,0 +1,103 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/ghactions"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Converts the pipelines of other CI systems to flow.toml",
+}
+
+var importGitHubActionsCmd = &cobra.Command{
+	Use:   "github-actions <workflow>",
+	Short: "Converts a GitHub Actions workflow to flow.toml",
+	Long: `Converts a workflow of .github/workflows to a flow.toml. Jobs become
+jobs, needs their dependencies, run steps their steps, and env,
+working-directory and secrets are carried over. A strategy.matrix is
+expanded to a job per combination.
+
+What has no equivalent, such as uses: actions, if conditions and
+timeout-minutes, is reported and left as TODO comments in the file.
+
+An existing file is only replaced with --force. --dry-run prints the
+file instead of writing it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, _ := cmd.Flags().GetString("file")
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		logger := newLogger()
+		if dryRun {
+			// Keep stdout for the file.
+			logger.SetOutput(os.Stderr)
+		}
+		if _, err := os.Stat(filePath); err == nil && !force && !dryRun {
+			log.Fatalf("Critical error: %s already exists, use --force to replace it", filePath)
+		}
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+		result, err := ghactions.Convert(data, args[0])
+		if err != nil {
+			log.Fatalf("Critical error: cannot convert %s: %v", args[0], err)
+		}
+		cfg, err := config.Parse(result.Config)
+		if err == nil {
+			_, err = engine.BuildDag(cfg)
+		}
+		if err != nil {
+			log.Fatalf("Critical error: the converted configuration is invalid: %v", err)
+		}
+
+		for _, issue := range result.Issues {
+			logger.Warn(issue.String())
+		}
+		if dryRun {
+			_, _ = cmd.OutOrStdout().Write(result.Config)
+			return
+		}
+		if err := os.WriteFile(filePath, result.Config, 0o644); err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+		if len(result.Issues) > 0 {
+			logger.Success(fmt.Sprintf("Wrote %s with %d job(s). %d part(s) of the workflow could not be converted: resolve the TODO comments.", filePath, len(cfg.Jobs), len(result.Issues)))
+			return
+		}
+		logger.Success(fmt.Sprintf("Wrote %s with %d job(s).", filePath, len(cfg.Jobs)))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.AddCommand(importGitHubActionsCmd)
+	importGitHubActionsCmd.Flags().StringP("file", "f", "flow.toml", "Path of the configuration file to generate")
+	importGitHubActionsCmd.Flags().Bool("force", false, "Replace the file if it exists")
+	importGitHubActionsCmd.Flags().Bool("dry-run", false, "Print the file instead of writing it")
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/scaffold"
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generates a starter flow.toml for the projects of the current directory",
+	Long: `Looks for go.mod, package.json, Cargo.toml, pyproject.toml, Makefile
+and Dockerfile files in the current directory and the directories right
+under it, and generates a flow.toml with lint, test and build jobs for
+each project found. Makefile targets named lint, check, test or build
+are used instead of the default commands of a project next to them, and
+a Dockerfile gets a job building its image once the tests passed.
+
+Jobs of projects found in a subdirectory run there, and their paths are
+set to it for 'flowcraft run --changed-since'.
+
+An existing file is only replaced with --force. --dry-run prints the
+file instead of writing it.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, _ := cmd.Flags().GetString("file")
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		logger := newLogger()
+		if dryRun {
+			// Keep stdout for the file.
+			logger.SetOutput(os.Stderr)
+		}
+		if _, err := os.Stat(filePath); err == nil && !force && !dryRun {
+			log.Fatalf("Critical error: %s already exists, use --force to replace it", filePath)
+		}
+
+		projects, err := scaffold.Detect(".")
+		if err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+		if len(projects) == 0 {
+			logger.Warn("No project found, generating a placeholder job.")
+		}
+		for _, p := range projects {
+			tasks := make([]string, 0, len(p.Tasks))
+			for _, task := range p.Tasks {
+				tasks = append(tasks, task.Name)
+			}
+			logger.Info(fmt.Sprintf("Found %s (%s): %s.", p.File, p.Description, strings.Join(tasks, ", ")))
+		}
+
+		data := scaffold.Generate(projects)
+		cfg, err := config.Parse(data)
+		if err == nil {
+			_, err = engine.BuildDag(cfg)
+		}
+		if err != nil {
+			log.Fatalf("Critical error: the generated configuration is invalid: %v", err)
+		}
+
+		if dryRun {
+			_, _ = cmd.OutOrStdout().Write(data)
+			return
+		}
+		if err := os.WriteFile(filePath, data, 0o644); err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+		logger.Success(fmt.Sprintf("Wrote %s with %d job(s). Review the commands, then run 'flowcraft run'.", filePath, len(cfg.Jobs)))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().StringP("file", "f", "flow.toml", "Path of the configuration file to generate")
+	initCmd.Flags().Bool("force", false, "Replace the file if it exists")
+	initCmd.Flags().Bool("dry-run", false, "Print the file instead of writing it")
+}
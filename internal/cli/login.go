@@ -0,0 +1,203 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/client"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login <server>",
+	Short: "Connects the CLI to a flowcraft-server",
+	Long: `Obtains a token for a flowcraft-server and stores it in the per-user
+credentials file (e.g. ~/.config/flowcraft/credentials.json, readable by
+you only). The remote commands use it: 'run --remote', 'logs', 'cancel'
+and 'token'. The last server logged in to is their default.
+
+By default, login prints a code to confirm on the server's dashboard by
+someone already logged in; the new token gets their scopes. With
+--with-token, a token created with 'flowcraft token create' (or the
+admin token logged by a new server) is read from stdin instead.
+
+$FLOWCRAFT_TOKEN, when set, takes precedence over the stored token.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		logger := newLogger()
+		c := client.New(args[0])
+
+		// A server without authentication accepts anonymous requests.
+		if _, err := c.Whoami(ctx); err == nil {
+			saveCredentials(c.URL(), client.ServerCredentials{})
+			logger.Success(fmt.Sprintf("%s does not require authentication; it is now the default server.", c.URL()))
+			return
+		} else if !errors.Is(err, client.ErrUnauthorized) {
+			log.Fatalf("Failed to reach %s: %v", c.URL(), err)
+		}
+
+		var secret string
+		var err error
+		if withToken, _ := cmd.Flags().GetBool("with-token"); withToken {
+			secret, err = readToken(cmd.InOrStdin())
+		} else {
+			name, _ := cmd.Flags().GetString("name")
+			secret, err = deviceLogin(ctx, c, name, logger)
+		}
+		if err != nil {
+			log.Fatalf("Login failed: %v", err)
+		}
+
+		c.SetToken(secret)
+		token, err := c.Whoami(ctx)
+		if err != nil {
+			log.Fatalf("Login failed: %v", err)
+		}
+		path := saveCredentials(c.URL(), client.ServerCredentials{
+			Token:   secret,
+			TokenID: token.ID,
+			Name:    token.Name,
+			Scopes:  token.Scopes,
+		})
+		logger.Success(fmt.Sprintf("Logged in to %s with token '%s' (scopes: %s). Credentials saved to %s.",
+			c.URL(), token.Name, strings.Join(token.Scopes, ", "), path))
+	},
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout [server]",
+	Short: "Forgets the stored token of a flowcraft-server",
+	Long: `Removes the token of a server, by default the one of the last login,
+from the credentials file. The token stays valid on the server until
+an admin revokes it with 'flowcraft token revoke'.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger()
+		path, creds := loadCredentials()
+		server := creds.Default
+		if len(args) == 1 {
+			server = args[0]
+		}
+		if server == "" || !creds.Remove(server) {
+			logger.Info("Not logged in, nothing to do.")
+			return
+		}
+		if err := creds.Save(path); err != nil {
+			log.Fatalf("Failed to save %s: %v", path, err)
+		}
+		logger.Success(fmt.Sprintf("Logged out of %s.", client.NormalizeURL(server)))
+	},
+}
+
+// deviceLogin asks the server for a code to confirm on its dashboard
+// and waits for the token.
+func deviceLogin(ctx context.Context, c *client.Client, name string, logger *runner.Logger) (string, error) {
+	if name == "" {
+		host, _ := os.Hostname()
+		name = "flowcraft CLI on " + host
+	}
+	code, err := c.StartDeviceLogin(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	logger.Info(fmt.Sprintf("To log in, open %s", code.VerificationURL))
+	logger.Info(fmt.Sprintf("and confirm the code %s. Waiting for the confirmation...", code.UserCode))
+
+	interval := time.Duration(max(code.Interval, 1)) * time.Second
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+		token, err := c.DeviceToken(ctx, code.DeviceCode)
+		switch {
+		case err == nil:
+			return token.Secret, nil
+		case errors.Is(err, api.ErrAuthorizationPending):
+		case errors.Is(err, api.ErrAccessDenied):
+			return "", errors.New("the login was denied")
+		default:
+			return "", err
+		}
+	}
+	return "", errors.New("the code expired before it was confirmed, run 'flowcraft login' again")
+}
+
+// readToken reads a pasted token, without echoing it on a terminal.
+func readToken(in io.Reader) (string, error) {
+	var data []byte
+	var err error
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		_, _ = fmt.Fprint(os.Stderr, "Paste your token: ")
+		data, err = term.ReadPassword(int(f.Fd()))
+		_, _ = fmt.Fprintln(os.Stderr)
+	} else {
+		data, err = io.ReadAll(io.LimitReader(in, 4096))
+	}
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", errors.New("no token given")
+	}
+	return token, nil
+}
+
+func loadCredentials() (string, *client.Credentials) {
+	path, err := client.CredentialsPath()
+	if err != nil {
+		log.Fatalf("Critical error: %v", err)
+	}
+	creds, err := client.LoadCredentials(path)
+	if err != nil {
+		log.Fatalf("Critical error: %v", err)
+	}
+	return path, creds
+}
+
+// saveCredentials stores the credentials of server and returns the
+// path of the credentials file.
+func saveCredentials(server string, serverCreds client.ServerCredentials) string {
+	path, creds := loadCredentials()
+	creds.Set(server, serverCreds)
+	if err := creds.Save(path); err != nil {
+		log.Fatalf("Failed to save %s: %v", path, err)
+	}
+	return path
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+	loginCmd.Flags().Bool("with-token", false, "Read a token from stdin instead of confirming a code on the dashboard")
+	loginCmd.Flags().String("name", "", "Name of the token created by the login (defaults to the hostname)")
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <run_id>",
+	Short: "Shows the logs of a run of a flowcraft-server",
+	Long: `Replays the logs of a remote run, or of one of its jobs with --job,
+and follows them until the run finishes. The server defaults to the one
+of the last 'flowcraft login'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		logger := newLogger()
+		server, _ := cmd.Flags().GetString("server")
+		job, _ := cmd.Flags().GetString("job")
+
+		c, err := remoteClient(server)
+		if err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+		run, err := c.Run(ctx, args[0])
+		if err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+		if _, ok := run.Jobs[job]; job != "" && !ok {
+			log.Fatalf("Critical error: run %s has no job '%s'", run.ID, job)
+		}
+
+		if err := replayRun(ctx, c, run.ID, job, logger); err != nil {
+			log.Fatalf("Lost track of run %s: %v", run.ID, err)
+		}
+		if run, err = c.Run(ctx, run.ID); err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+
+		switch run.Status {
+		case api.RunSucceeded:
+			logger.Success(fmt.Sprintf("Run %s succeeded.", run.ID))
+		case api.RunCancelled:
+			logger.Warn(fmt.Sprintf("Run %s was cancelled.", run.ID))
+		default:
+			logger.Error(fmt.Sprintf("Run %s failed: %s", run.ID, run.Error))
+		}
+	},
+}
+
+var cancelCmd = &cobra.Command{
+	Use:   "cancel <run_id>",
+	Short: "Cancels a run of a flowcraft-server",
+	Long: `Cancels a queued or running remote run. With --job, only that job is
+cancelled (or rejected, if it waits for approval), which fails the run.
+The server defaults to the one of the last 'flowcraft login'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		logger := newLogger()
+		server, _ := cmd.Flags().GetString("server")
+		job, _ := cmd.Flags().GetString("job")
+
+		c, err := remoteClient(server)
+		if err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+		if job != "" {
+			if _, err := c.CancelJob(ctx, args[0], job); err != nil {
+				log.Fatalf("Failed to cancel job '%s': %v", job, err)
+			}
+			logger.Success(fmt.Sprintf("Job '%s' of run %s cancelled.", job, args[0]))
+			return
+		}
+
+		run, err := c.Cancel(ctx, args[0])
+		if err != nil {
+			log.Fatalf("Failed to cancel run %s: %v", args[0], err)
+		}
+		if run.Status.Done() && run.Status != api.RunCancelled {
+			logger.Info(fmt.Sprintf("Run %s already finished: %s.", run.ID, run.Status))
+			return
+		}
+		logger.Success(fmt.Sprintf("Run %s cancelled.", run.ID))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(cancelCmd)
+	for _, cmd := range []*cobra.Command{logsCmd, cancelCmd} {
+		cmd.Flags().String("server", "", "Address of the flowcraft-server (defaults to the last login)")
+		cmd.Flags().String("job", "", "Only this job of the run")
+	}
+}
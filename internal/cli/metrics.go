@@ -0,0 +1,81 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/metrics"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+// runMetrics collects the metrics of a local run, served on an address
+// while the run lasts and/or written to a textfile collector's file
+// at its end.
+type runMetrics struct {
+	reg    *metrics.Registry
+	server *http.Server
+	file   string
+}
+
+// newRunMetrics returns nil when neither addr nor file is set.
+func newRunMetrics(addr, file string, logger *runner.Logger) (*runMetrics, error) {
+	if addr == "" && file == "" {
+		return nil, nil
+	}
+	m := &runMetrics{reg: metrics.NewRegistry(), file: file}
+	if addr == "" {
+		return m, nil
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s for metrics: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m.reg.Handler())
+	m.server = &http.Server{Handler: mux}
+	go func() {
+		if err := m.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Warn(fmt.Sprintf("Metrics endpoint stopped: %v", err))
+		}
+	}()
+	logger.Info(fmt.Sprintf("Serving metrics on http://%s/metrics while the pipeline runs.", ln.Addr()))
+	return m, nil
+}
+
+func (m *runMetrics) option() engine.Option {
+	return engine.WithMetrics(engine.NewMetrics(m.reg))
+}
+
+// finish writes the metrics file and stops the endpoint.
+func (m *runMetrics) finish(logger *runner.Logger) {
+	if m.file != "" {
+		if err := m.reg.WriteFile(m.file); err != nil {
+			logger.Warn(err.Error())
+		} else {
+			logger.Info(fmt.Sprintf("Metrics written to %s.", m.file))
+		}
+	}
+	if m.server != nil {
+		_ = m.server.Close()
+	}
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/client"
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
+)
+
+const (
+	// cancelGrace is how long the output of a cancelled remote run is
+	// still followed, waiting for the server to stop it.
+	cancelGrace = 30 * time.Second
+	// maxReconnects is how many times in a row a broken event stream is
+	// resumed before giving up.
+	maxReconnects = 5
+	// tokenEnv overrides the stored token of the remote commands, e.g. in CI.
+	tokenEnv = "FLOWCRAFT_TOKEN"
+)
+
+// runRemote submits the pipeline and the working tree to a
+// flowcraft-server and replays the run's output. It exits with the
+// status of the remote run.
+func runRemote(ctx context.Context, logger *runner.Logger, filePath, server string, detach bool) {
+	configData, err := os.ReadFile(filePath)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error loading configuration: %v", err))
+		log.Fatalf("Critical error: %v", err)
+	}
+	cfg, err := config.Parse(configData)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error loading configuration: %v", err))
+		log.Fatalf("Critical error: %v", err)
+	}
+	if _, err := engine.BuildDag(cfg); err != nil {
+		logger.Error(fmt.Sprintf("Error building DAG: %v", err))
+		log.Fatalf("Critical error: %v", err)
+	}
+	logger.Info(fmt.Sprintf("Configuration loaded successfully. Found %d job(s).", len(cfg.Jobs)))
+
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("Critical error: %v", err)
+	}
+
+	c, err := remoteClient(server)
+	if err != nil {
+		log.Fatalf("Critical error: %v", err)
+	}
+	logger.Info(fmt.Sprintf("Submitting pipeline and working tree to %s...", c.URL()))
+	run, err := c.Submit(ctx, configData, func(w io.Writer) error {
+		return workspace.Pack(dir, w)
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to submit the pipeline: %v", err))
+		log.Fatalf("Critical error: %v", err)
+	}
+
+	if detach {
+		fmt.Println(run.ID)
+		return
+	}
+	logger.Info(fmt.Sprintf("Run %s queued, streaming its output...", run.ID))
+
+	final, err := followRun(ctx, c, run.ID, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Lost track of run %s: %v", run.ID, err))
+		log.Fatalf("Critical error: %v", err)
+	}
+
+	switch final.Status {
+	case api.RunSucceeded:
+		logger.Success("Flowcraft execution finished successfully.")
+	case api.RunCancelled:
+		logger.Error(fmt.Sprintf("Remote run %s was cancelled.", final.ID))
+		log.Fatal("Execution cancelled.")
+	default:
+		logger.Error(fmt.Sprintf("Pipeline execution failed: %s", final.Error))
+		log.Fatalf("Critical error: %s", final.Error)
+	}
+}
+
+// followRun replays the log of a run until it finishes, and returns
+// its final state. Cancelling ctx cancels the remote run.
+func followRun(ctx context.Context, c *client.Client, id string, logger *runner.Logger) (*api.Run, error) {
+	streamCtx, stopStream := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopStream()
+
+	go func() {
+		select {
+		case <-streamCtx.Done():
+			return
+		case <-ctx.Done():
+		}
+		logger.Warn(fmt.Sprintf("Cancelling remote run %s...", id))
+		if _, err := c.Cancel(streamCtx, id); err != nil {
+			logger.Error(fmt.Sprintf("Failed to cancel remote run %s: %v", id, err))
+			stopStream()
+			return
+		}
+		select {
+		case <-streamCtx.Done():
+		case <-time.After(cancelGrace):
+			stopStream()
+		}
+	}()
+
+	if err := replayRun(streamCtx, c, id, "", logger); err != nil {
+		return nil, err
+	}
+	return c.Run(streamCtx, id)
+}
+
+// replayRun replays the log of a run, or of one of its jobs when job
+// is not empty, until the run finishes. A broken stream is resumed.
+func replayRun(ctx context.Context, c *client.Client, id, job string, logger *runner.Logger) error {
+	var after uint64
+	for failures := 0; ; {
+		last, err := c.Events(ctx, id, after, func(e api.Event) {
+			if e.Type == api.EventLog && (job == "" || e.Job == job) {
+				logger.Replay(runner.Entry{Time: e.Time, Level: runner.Level(e.Level), Job: e.Job, Message: e.Message})
+			}
+		})
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		if last > after {
+			failures = 0
+		}
+		after = last
+		if failures++; failures > maxReconnects {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// remoteClient returns a client for server, or for the server of the
+// last login when server is empty. It authenticates with
+// $FLOWCRAFT_TOKEN, else with the token stored by 'flowcraft login'.
+func remoteClient(server string) (*client.Client, error) {
+	token := os.Getenv(tokenEnv)
+	if server == "" || token == "" {
+		path, err := client.CredentialsPath()
+		if err != nil {
+			return nil, err
+		}
+		creds, err := client.LoadCredentials(path)
+		if err != nil {
+			return nil, err
+		}
+		if server == "" {
+			server = creds.Default
+		}
+		if stored, ok := creds.Get(server); ok && token == "" {
+			token = stored.Token
+		}
+	}
+	if server == "" {
+		return nil, errors.New("no server given: use --server, or log in with 'flowcraft login <server>'")
+	}
+
+	c := client.New(server)
+	c.SetToken(token)
+	return c, nil
+}
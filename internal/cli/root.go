@@ -20,6 +20,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
 
 	"github.com/spf13/cobra"
 )
@@ -28,9 +31,24 @@ var rootCmd = &cobra.Command{
 	Use:   "flowcraft",
 	Short: "flowcraft is a fast and portable local build orchestrator.",
 	Long: `A modern task orchestrator designed for performance and CI/CD portability.
-It reads a flow.toml, builds an execution graph (DAG) and runs tasks in parallel.`,
+It reads a flow.toml, builds an execution graph (DAG) and runs tasks in parallel.
+
+The output follows the conventions of the CI provider running flowcraft
+(GitHub Actions, GitLab CI, Buildkite, Azure Pipelines or TeamCity),
+detected from its environment. --log-format overrides the detection.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("log-format")
+		if _, err := runner.NewFormatter(format); err != nil {
+			return err
+		}
+		logFormat = format
+		return nil
+	},
 }
 
+// logFormat is the format of the output, set by --log-format.
+var logFormat = runner.FormatAuto
+
 var (
 	version = "dev"
 	commit  = "none"
@@ -43,6 +61,21 @@ func SetVersionInfo(v, c, d string) {
 	date = d
 }
 
+func init() {
+	rootCmd.PersistentFlags().String("log-format", runner.FormatAuto,
+		"Format of the output: "+strings.Join(runner.LogFormats, ", "))
+}
+
+// newLogger returns a logger writing in the format chosen with
+// --log-format.
+func newLogger() *runner.Logger {
+	logger := runner.NewLogger()
+	if formatter, err := runner.NewFormatter(logFormat); err == nil {
+		logger.SetFormatter(formatter)
+	}
+	return logger
+}
+
 func Execute(ctx context.Context) {
 	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "An error occured: '%s'", err)
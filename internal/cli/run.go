@@ -20,10 +20,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/Purpose-Dev/flowcraft/internal/config"
 	"github.com/Purpose-Dev/flowcraft/internal/engine"
-	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/Purpose-Dev/flowcraft/internal/junit"
+	"github.com/Purpose-Dev/flowcraft/internal/tracing"
+	"github.com/Purpose-Dev/flowcraft/internal/tui"
 	"github.com/spf13/cobra"
 )
 
@@ -31,16 +34,88 @@ var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Runs a flowcraft pipeline from a configuration file",
 	Long: `Executes a flowcraft pipeline by reading a flow.toml file,
-building the dependency graph (DAG), and executing the jobs.`,
+building the dependency graph (DAG), and executing the jobs.
+
+With --remote, the pipeline and the working tree (minus the files
+ignored by .gitignore) are sent to a flowcraft-server instead, and the
+run's output is streamed back. Ctrl+C cancels the remote run.
+
+Jobs with approve = true wait for a confirmation on the terminal before
+they run, while the rest of the pipeline goes on. Use --auto-approve
+where nobody can answer, such as in CI.
+
+--metrics-addr serves Prometheus metrics of the run on /metrics while
+it lasts, and --metrics-file writes them at its end for node_exporter's
+textfile collector (give it a .prom file in the collector's directory).
+
+--otlp-endpoint (by default $OTEL_EXPORTER_OTLP_ENDPOINT) exports the
+run as an OpenTelemetry trace over OTLP/HTTP, with a span per job,
+attempt and step. Steps receive their span in $TRACEPARENT so that
+instrumented tools can add their own spans to the trace.
+
+--junit writes the results of the jobs and steps as a JUnit XML report,
+with a test suite per job and a test case per step, for CI systems that
+display test results. The test reports of the jobs themselves (their
+reports = [...] globs) are summarized at the end of the run.
+
+--changed-since <ref> only runs the jobs affected by the files changed
+since that git revision (see 'flowcraft affected'), and logs why each
+job is run or skipped.
+
+--tui shows the run in a full-screen interface: the jobs with their
+status and timers, and the log of the selected job. Keys cancel a
+running job (c), retry a failed one with the jobs depending on it (r)
+and approve or deny a gate (a, d); q quits. Without a terminal, the
+plain output is shown instead.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := cmd.Context()
 
-		logger := runner.NewLogger()
+		logger := newLogger()
+		remote, _ := cmd.Flags().GetString("remote")
+		detach, _ := cmd.Flags().GetBool("detach")
+		if detach {
+			if remote == "" {
+				log.Fatal("Critical error: --detach requires --remote")
+			}
+			// Keep stdout for the run ID.
+			logger.SetOutput(os.Stderr)
+		}
 		logger.Info("Flowcraft execution started.")
 
 		filePath, _ := cmd.Flags().GetString("file")
 		logger.Info(fmt.Sprintf("Loading configuration from: %s", filePath))
 
+		metricsAddr, _ := cmd.Flags().GetString("metrics-addr")
+		metricsFile, _ := cmd.Flags().GetString("metrics-file")
+		if remote != "" && (metricsAddr != "" || metricsFile != "") {
+			log.Fatal("Critical error: --metrics-addr and --metrics-file only apply to local runs, flowcraft-server serves its metrics on /metrics")
+		}
+		otlpEndpoint, _ := cmd.Flags().GetString("otlp-endpoint")
+		if remote != "" && cmd.Flags().Changed("otlp-endpoint") {
+			log.Fatal("Critical error: --otlp-endpoint only applies to local runs, flowcraft-server exports the traces of its runs itself")
+		}
+		junitPath, _ := cmd.Flags().GetString("junit")
+		if remote != "" && junitPath != "" {
+			log.Fatal("Critical error: --junit only applies to local runs")
+		}
+		changedSince, _ := cmd.Flags().GetString("changed-since")
+		if remote != "" && changedSince != "" {
+			log.Fatal("Critical error: --changed-since only applies to local runs")
+		}
+		useTUI, _ := cmd.Flags().GetBool("tui")
+		if remote != "" && useTUI {
+			log.Fatal("Critical error: --tui only applies to local runs")
+		}
+		if useTUI && !tui.Available() {
+			logger.Warn("--tui needs a terminal, showing the plain output instead.")
+			useTUI = false
+		}
+
+		if remote != "" {
+			runRemote(ctx, logger, filePath, remote, detach)
+			return
+		}
+
 		cfg, err := config.LoadConfig(filePath)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Error loading configuration: %v", err))
@@ -57,7 +132,74 @@ building the dependency graph (DAG), and executing the jobs.`,
 		}
 		logger.Success("DAG built and validated successfully (no cycles found).")
 
-		if err := engine.Run(ctx, cfg, graph, logger); err != nil {
+		if changedSince != "" {
+			selection, err := selectChanged(ctx, graph, changedSince)
+			if err != nil {
+				log.Fatalf("Critical error: %v", err)
+			}
+			logSelection(logger, changedSince, selection)
+			selected := selection.Selected()
+			if len(selected) == 0 {
+				logger.Success(fmt.Sprintf("No job is affected by the changes since %s.", changedSince))
+				return
+			}
+			graph = graph.Subgraph(selected)
+		}
+
+		var options []engine.Option
+		autoApprove, _ := cmd.Flags().GetBool("auto-approve")
+		// The interface answers the approval gates itself.
+		if !useTUI || autoApprove {
+			approver, err := newApprover(graph, logger, autoApprove)
+			if err != nil {
+				logger.Error(fmt.Sprintf("Error setting up approval gates: %v", err))
+				log.Fatalf("Critical error: %v", err)
+			}
+			if approver != nil {
+				options = append(options, engine.WithApprover(approver))
+			}
+		}
+
+		runMetrics, err := newRunMetrics(metricsAddr, metricsFile, logger)
+		if err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+		if runMetrics != nil {
+			options = append(options, runMetrics.option())
+		}
+		runTracer, err := newRunTracer(otlpEndpoint)
+		if err != nil {
+			log.Fatalf("Critical error: %v", err)
+		}
+		if runTracer != nil {
+			options = append(options, runTracer.option(filePath))
+		}
+
+		var junitReport *engine.JUnitReport
+		if junitPath != "" {
+			junitReport = engine.NewJUnitReport()
+			options = append(options, engine.WithListener(junitReport.Observe))
+		}
+
+		if useTUI {
+			err = tui.Run(ctx, cfg, graph, logger, options...)
+		} else {
+			err = engine.Run(ctx, cfg, graph, logger, options...)
+		}
+		if junitReport != nil {
+			if err := junit.WriteFile(junitPath, junitReport.Testsuites()); err != nil {
+				logger.Warn(fmt.Sprintf("Failed to write the JUnit report: %v", err))
+			} else {
+				logger.Info(fmt.Sprintf("JUnit report written to %s.", junitPath))
+			}
+		}
+		if runMetrics != nil {
+			runMetrics.finish(logger)
+		}
+		if runTracer != nil {
+			runTracer.finish(logger)
+		}
+		if err != nil {
 			if err == context.Canceled {
 				logger.Error("Pipeline execution cancelled by user (Ctrl+C).")
 				log.Fatal("Execution cancelled.")
@@ -74,4 +216,13 @@ building the dependency graph (DAG), and executing the jobs.`,
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().StringP("file", "f", "flow.toml", "Path to the flow.toml configuration file")
+	runCmd.Flags().String("remote", "", "Execute the pipeline on the flowcraft-server at this address")
+	runCmd.Flags().Bool("detach", false, "With --remote, print the run ID and return without waiting")
+	runCmd.Flags().Bool("auto-approve", false, "Approve every job with an approval gate without asking")
+	runCmd.Flags().String("metrics-addr", "", "Serve Prometheus metrics on this address (e.g. :9464) while the pipeline runs")
+	runCmd.Flags().String("metrics-file", "", "Write Prometheus metrics to this file at the end of the run (textfile collector)")
+	runCmd.Flags().String("otlp-endpoint", tracing.DefaultEndpoint(), "OTLP/HTTP collector receiving the trace of the run, e.g. http://localhost:4318")
+	runCmd.Flags().String("junit", "", "Write the results of the jobs and steps to this file as a JUnit XML report")
+	runCmd.Flags().String("changed-since", "", "Only run the jobs affected by the files changed since this git revision")
+	runCmd.Flags().Bool("tui", false, "Show the run in a full-screen terminal interface")
 }
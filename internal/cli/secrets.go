@@ -0,0 +1,253 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manages age-encrypted secrets files",
+	Long: `Encrypts, decrypts, lists and edits secrets files used by the
+'encrypted_file' provider. Decrypted content is only printed to stdout
+or kept in memory; it is never written next to the encrypted file.`,
+}
+
+var secretsEncryptCmd = &cobra.Command{
+	Use:   "encrypt <file|->",
+	Short: "Encrypts a TOML or dotenv secrets file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger()
+		settings := loadEncryptedFileSettings(cmd)
+
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			if args[0] == "-" {
+				log.Fatal("--output is required when reading from stdin")
+			}
+			output = args[0] + ".age"
+		}
+
+		var plaintext []byte
+		var err error
+		if args[0] == "-" {
+			plaintext, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			plaintext, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			log.Fatalf("Failed to read plaintext: %v", err)
+		}
+		if _, err := secrets.ParseSecretsFile(output, plaintext); err != nil {
+			log.Fatalf("Refusing to encrypt an invalid secrets file: %v", err)
+		}
+
+		recipientFlags, _ := cmd.Flags().GetStringArray("recipient")
+		if err := encryptTo(output, plaintext, settings, recipientFlags); err != nil {
+			log.Fatalf("Encryption failed: %v", err)
+		}
+		logger.Success(fmt.Sprintf("Encrypted secrets written to %s", output))
+	},
+}
+
+var secretsDecryptCmd = &cobra.Command{
+	Use:   "decrypt <file>",
+	Short: "Decrypts a secrets file to stdout",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		plaintext := decryptOrDie(args[0], loadEncryptedFileSettings(cmd))
+		_, _ = cmd.OutOrStdout().Write(plaintext)
+	},
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:   "list <file>",
+	Short: "Lists the keys of a secrets file without their values",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		plaintext := decryptOrDie(args[0], loadEncryptedFileSettings(cmd))
+		values, err := secrets.ParseSecretsFile(args[0], plaintext)
+		if err != nil {
+			log.Fatalf("Failed to parse %s: %v", args[0], err)
+		}
+		for _, key := range secrets.SortedKeys(values) {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), key)
+		}
+	},
+}
+
+var secretsEditCmd = &cobra.Command{
+	Use:   "edit <file>",
+	Short: "Edits a secrets file in $EDITOR and re-encrypts it",
+	Long: `Decrypts the file into a memory-backed temporary directory (/dev/shm or
+$XDG_RUNTIME_DIR), opens it in $EDITOR, re-encrypts it and removes the
+plaintext. If no memory-backed directory is available, the command
+refuses to run; use 'decrypt' and 'encrypt -' in a pipeline instead.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger()
+		settings := loadEncryptedFileSettings(cmd)
+		path := args[0]
+
+		var plaintext []byte
+		if _, err := os.Stat(path); err == nil {
+			plaintext = decryptOrDie(path, settings)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			log.Fatalf("Failed to read %s: %v", path, err)
+		}
+
+		edited, err := editInMemory(path, plaintext)
+		if err != nil {
+			log.Fatalf("Edit failed: %v", err)
+		}
+		if bytes.Equal(edited, plaintext) {
+			logger.Info("No changes made.")
+			return
+		}
+		if _, err := secrets.ParseSecretsFile(path, edited); err != nil {
+			log.Fatalf("Edited content is invalid, nothing was saved: %v", err)
+		}
+
+		recipientFlags, _ := cmd.Flags().GetStringArray("recipient")
+		if err := encryptTo(path, edited, settings, recipientFlags); err != nil {
+			log.Fatalf("Encryption failed: %v", err)
+		}
+		logger.Success(fmt.Sprintf("Encrypted secrets written to %s", path))
+	},
+}
+
+// loadEncryptedFileSettings reads [settings.encrypted_file] from the
+// config file when it exists, so that keys and recipients configured
+// for runs are also used by these commands.
+func loadEncryptedFileSettings(cmd *cobra.Command) config.EncryptedFileSettings {
+	filePath, _ := cmd.Flags().GetString("file")
+	if _, err := os.Stat(filePath); err != nil {
+		return config.EncryptedFileSettings{}
+	}
+	cfg, err := config.LoadConfig(filePath)
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+	return cfg.Settings.EncryptedFile
+}
+
+func decryptOrDie(path string, settings config.EncryptedFileSettings) []byte {
+	identities, err := secrets.LoadIdentities(settings)
+	if err != nil {
+		log.Fatalf("Decryption failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", path, err)
+	}
+	plaintext, err := secrets.Decrypt(data, identities)
+	if err != nil {
+		log.Fatalf("Failed to decrypt %s: %v", path, err)
+	}
+	return plaintext
+}
+
+func encryptTo(path string, plaintext []byte, settings config.EncryptedFileSettings, recipientFlags []string) error {
+	recipients, err := secrets.LoadRecipients(settings, recipientFlags)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := secrets.Encrypt(plaintext, recipients)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, ciphertext, 0o644)
+}
+
+// editInMemory lets the user edit plaintext in $EDITOR through a file
+// in a tmpfs directory, and removes it before returning.
+func editInMemory(path string, plaintext []byte) ([]byte, error) {
+	dir := memoryBackedDir()
+	if dir == "" {
+		return nil, errors.New("no memory-backed directory available for the plaintext (/dev/shm or $XDG_RUNTIME_DIR)")
+	}
+
+	tmpDir, err := os.MkdirTemp(dir, "flowcraft-secrets-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Keep the extension so that the editor and the parser pick the right format.
+	tmpFile := filepath.Join(tmpDir, filepath.Base(trimAgeSuffix(path)))
+	if err := os.WriteFile(tmpFile, plaintext, 0o600); err != nil {
+		return nil, err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	editCmd := exec.Command("sh", "-c", editor+` "$1"`, "editor", tmpFile)
+	editCmd.Stdin = os.Stdin
+	editCmd.Stdout = os.Stdout
+	editCmd.Stderr = os.Stderr
+	if err := editCmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor exited with an error: %w", err)
+	}
+
+	return os.ReadFile(tmpFile)
+}
+
+func memoryBackedDir() string {
+	for _, dir := range []string{"/dev/shm", os.Getenv("XDG_RUNTIME_DIR")} {
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+func trimAgeSuffix(path string) string {
+	if ext := filepath.Ext(path); ext == ".age" {
+		return path[:len(path)-len(ext)]
+	}
+	return path
+}
+
+func init() {
+	rootCmd.AddCommand(secretsCmd)
+	secretsCmd.PersistentFlags().StringP("file", "f", "flow.toml", "Path to the flow.toml configuration file")
+
+	for _, c := range []*cobra.Command{secretsEncryptCmd, secretsEditCmd} {
+		c.Flags().StringArrayP("recipient", "r", nil, "age recipient to encrypt to (repeatable)")
+	}
+	secretsEncryptCmd.Flags().StringP("output", "o", "", "Path of the encrypted file (default: <file>.age)")
+
+	secretsCmd.AddCommand(secretsEncryptCmd, secretsDecryptCmd, secretsListCmd, secretsEditCmd)
+}
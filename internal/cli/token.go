@@ -0,0 +1,113 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manages the API tokens of a flowcraft-server",
+	Long: `Creates, lists and revokes the API tokens of a flowcraft-server. These
+commands need a token with the admin scope.
+
+Scopes: read (inspect runs and agents), submit (start, cancel and
+approve runs), admin (everything, including tokens) and agent-register.
+A token with agent-register is a join token: it lets agents register,
+and nothing else. Each agent then receives a token of its own.`,
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Creates a token and prints its secret",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		scopes, _ := cmd.Flags().GetStringSlice("scope")
+
+		c := tokenClient(cmd)
+		token, err := c.CreateToken(cmd.Context(), api.TokenRequest{Name: name, Scopes: scopes})
+		if err != nil {
+			log.Fatalf("Failed to create the token: %v", err)
+		}
+
+		// Keep stdout for the secret, e.g. for $(flowcraft token create ...).
+		logger := newLogger()
+		logger.SetOutput(os.Stderr)
+		logger.Success(fmt.Sprintf("Token %s ('%s') created with scopes %s. Its secret is not shown again:",
+			token.ID, token.Name, strings.Join(token.Scopes, ", ")))
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), token.Secret)
+	},
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the tokens",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tokens, err := tokenClient(cmd).Tokens(cmd.Context())
+		if err != nil {
+			log.Fatalf("Failed to list the tokens: %v", err)
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tNAME\tSCOPES\tCREATED")
+		for _, token := range tokens {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", token.ID, token.Name, strings.Join(token.Scopes, ","), token.CreatedAt.Format("2006-01-02 15:04"))
+		}
+		_ = w.Flush()
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revokes a token",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := tokenClient(cmd).RevokeToken(cmd.Context(), args[0]); err != nil {
+			log.Fatalf("Failed to revoke token %s: %v", args[0], err)
+		}
+		newLogger().Success(fmt.Sprintf("Token %s revoked.", args[0]))
+	},
+}
+
+func tokenClient(cmd *cobra.Command) *client.Client {
+	server, _ := cmd.Flags().GetString("server")
+	c, err := remoteClient(server)
+	if err != nil {
+		log.Fatalf("Critical error: %v", err)
+	}
+	return c
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+	tokenCmd.AddCommand(tokenCreateCmd, tokenListCmd, tokenRevokeCmd)
+	tokenCmd.PersistentFlags().String("server", "", "Address of the flowcraft-server (defaults to the last login)")
+	tokenCreateCmd.Flags().String("name", "", "Name of the token, e.g. the person or the machine using it")
+	tokenCreateCmd.Flags().StringSlice("scope", nil, "Scopes of the token: read, submit, admin or agent-register (repeatable)")
+	_ = tokenCreateCmd.MarkFlagRequired("name")
+	_ = tokenCreateCmd.MarkFlagRequired("scope")
+}
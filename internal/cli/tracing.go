@@ -0,0 +1,61 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/Purpose-Dev/flowcraft/internal/tracing"
+)
+
+// runTracer exports the trace of a local run to an OTLP collector.
+type runTracer struct {
+	tracer   *tracing.Tracer
+	endpoint string
+}
+
+// newRunTracer returns nil without an endpoint.
+func newRunTracer(endpoint string) (*runTracer, error) {
+	if endpoint == "" {
+		return nil, nil
+	}
+	exporter, err := tracing.NewOTLPExporter(endpoint, "flowcraft")
+	if err != nil {
+		return nil, err
+	}
+	return &runTracer{tracer: tracing.NewTracer(exporter), endpoint: endpoint}, nil
+}
+
+func (t *runTracer) option(configPath string) engine.Option {
+	return engine.WithTracer(t.tracer, tracing.String("flowcraft.config", configPath))
+}
+
+// finish exports the spans of the run. A collector that cannot be
+// reached does not fail the run.
+func (t *runTracer) finish(logger *runner.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := t.tracer.Flush(ctx); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to export the trace to %s: %v", t.endpoint, err))
+		return
+	}
+	logger.Info(fmt.Sprintf("Trace exported to %s.", t.endpoint))
+}
@@ -22,7 +22,6 @@ import (
 
 	"github.com/Purpose-Dev/flowcraft/internal/config"
 	"github.com/Purpose-Dev/flowcraft/internal/engine"
-	"github.com/Purpose-Dev/flowcraft/internal/runner"
 	"github.com/spf13/cobra"
 )
 
@@ -33,7 +32,7 @@ var validateCmd = &cobra.Command{
 to check for syntax errors and circular dependencies.
 This command does not execute any jobs.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger := runner.NewLogger()
+		logger := newLogger()
 
 		filePath, _ := cmd.Flags().GetString("file")
 		logger.Info(fmt.Sprintf("Validating configuration from: %s", filePath))
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [jobs...]",
+	Short: "Re-runs the jobs affected by file changes",
+	Long: `Runs the pipeline, or the given jobs and their dependencies, then
+watches the working tree and re-runs the jobs whose watch = [...] globs
+match the changed files, along with the jobs depending on them. A job
+without watch globs is re-run on any change. Files ignored by .gitignore
+are not watched, so keep the outputs of the jobs there.
+
+Changes are debounced (--debounce). A change affecting the jobs of the
+run in progress cancels it and starts over; other changes wait for it
+to end. Jobs whose dependencies failed are re-run with them.
+
+Changes are reported by inotify on Linux; elsewhere, or with --poll,
+the tree is scanned every --poll-interval. Ctrl+C stops watching.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		logger := newLogger()
+
+		filePath, _ := cmd.Flags().GetString("file")
+		cfg, err := config.LoadConfig(filePath)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error loading configuration: %v", err))
+			log.Fatalf("Critical error: %v", err)
+		}
+		graph, err := engine.BuildDag(cfg)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error building DAG: %v", err))
+			log.Fatalf("Critical error: %v", err)
+		}
+
+		var options []engine.Option
+		autoApprove, _ := cmd.Flags().GetBool("auto-approve")
+		approver, err := newApprover(graph, logger, autoApprove)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error setting up approval gates: %v", err))
+			log.Fatalf("Critical error: %v", err)
+		}
+		if approver != nil {
+			options = append(options, engine.WithApprover(approver))
+		}
+
+		poll, _ := cmd.Flags().GetBool("poll")
+		interval, _ := cmd.Flags().GetDuration("poll-interval")
+		if interval <= 0 {
+			log.Fatal("Critical error: --poll-interval must be positive")
+		}
+		var watcher watch.Watcher
+		if poll {
+			watcher, err = watch.NewPoller(".", interval)
+		} else {
+			watcher, err = watch.New(".", interval)
+		}
+		if err != nil {
+			log.Fatalf("Critical error: watching the working tree: %v", err)
+		}
+		defer watcher.Close()
+
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+		err = watch.Run(ctx, cfg, graph, watcher, logger, watch.Options{
+			Jobs:       args,
+			Debounce:   debounce,
+			RunOptions: options,
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("Watch failed: %v", err))
+			log.Fatalf("Critical error: %v", err)
+		}
+		logger.Info("Stopped watching.")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().StringP("file", "f", "flow.toml", "Path to the flow.toml configuration file")
+	watchCmd.Flags().Duration("debounce", watch.DefaultDebounce, "How long changes must settle before the jobs re-run")
+	watchCmd.Flags().Bool("poll", false, "Scan the working tree for changes instead of using file system notifications")
+	watchCmd.Flags().Duration("poll-interval", 500*time.Millisecond, "How often the working tree is scanned when polling")
+	watchCmd.Flags().Bool("auto-approve", false, "Approve every job with an approval gate without asking")
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+)
+
+// ErrUnauthorized is returned when the server requires a token and
+// none, or an invalid one, was sent.
+var ErrUnauthorized = errors.New("the server requires a valid token: run 'flowcraft login <server>'")
+
+// Whoami describes the token of the client.
+func (c *Client) Whoami(ctx context.Context) (*api.Token, error) {
+	var token api.Token
+	if err := c.do(ctx, http.MethodGet, "/api/v1/whoami", nil, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// StartDeviceLogin starts a device login for the named client.
+func (c *Client) StartDeviceLogin(ctx context.Context, name string) (*api.DeviceCode, error) {
+	var code api.DeviceCode
+	if err := c.do(ctx, http.MethodPost, "/api/v1/login/device", api.DeviceRequest{Name: name}, &code); err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// DeviceToken polls for the token of a device login. It returns
+// api.ErrAuthorizationPending until the login is approved.
+func (c *Client) DeviceToken(ctx context.Context, deviceCode string) (*api.NewToken, error) {
+	var token api.NewToken
+	if err := c.do(ctx, http.MethodPost, "/api/v1/login/device/token", api.DeviceTokenRequest{DeviceCode: deviceCode}, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// CreateToken creates an API token.
+func (c *Client) CreateToken(ctx context.Context, req api.TokenRequest) (*api.NewToken, error) {
+	var token api.NewToken
+	if err := c.do(ctx, http.MethodPost, "/api/v1/tokens", req, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Tokens lists the API tokens.
+func (c *Client) Tokens(ctx context.Context) ([]api.Token, error) {
+	var tokens []api.Token
+	if err := c.do(ctx, http.MethodGet, "/api/v1/tokens", nil, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeToken deletes an API token.
+func (c *Client) RevokeToken(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/tokens/"+url.PathEscape(id), nil, nil)
+}
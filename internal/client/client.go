@@ -0,0 +1,239 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package client talks to the HTTP API of flowcraft-server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+)
+
+// Client is a flowcraft-server API client.
+type Client struct {
+	base  string
+	http  *http.Client
+	token string
+
+	mu sync.Mutex
+	// agentToken replaces token once the client registered an agent.
+	agentToken string
+}
+
+// New returns a client for the server at base, e.g. http://ci:8080.
+func New(base string) *Client {
+	return &Client{base: NormalizeURL(base), http: &http.Client{}}
+}
+
+// NormalizeURL returns the canonical form of a server address, as used
+// by New and as the key of the stored credentials.
+func NormalizeURL(base string) string {
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	return strings.TrimRight(base, "/")
+}
+
+// URL returns the address of the server.
+func (c *Client) URL() string {
+	return c.base
+}
+
+// SetToken sets the API token sent with every request. Agents use a
+// join token, which is only good for registering.
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// Register registers an agent. The requests that follow use the token
+// the server gave the agent, while registering again still uses the
+// join token.
+func (c *Client) Register(ctx context.Context, reg api.AgentRegistration) (*api.Agent, error) {
+	var agent api.Agent
+	if err := c.doAs(ctx, c.token, http.MethodPost, "/api/v1/agents", reg, &agent); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.agentToken = agent.Token
+	c.mu.Unlock()
+	return &agent, nil
+}
+
+// Agents lists the registered agents.
+func (c *Client) Agents(ctx context.Context) ([]api.Agent, error) {
+	var agents []api.Agent
+	if err := c.do(ctx, http.MethodGet, "/api/v1/agents", nil, &agents); err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+// Lease waits up to wait for a job to run. It returns nil when none
+// became available in time.
+func (c *Client) Lease(ctx context.Context, agentID string, wait time.Duration) (*api.Lease, error) {
+	path := fmt.Sprintf("/api/v1/agents/%s/lease?wait=%s", url.PathEscape(agentID), url.QueryEscape(wait.String()))
+	var lease *api.Lease
+	if err := c.do(ctx, http.MethodPost, path, nil, &lease); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// Heartbeat keeps a lease alive.
+func (c *Client) Heartbeat(ctx context.Context, leaseID string) (*api.HeartbeatResponse, error) {
+	var resp api.HeartbeatResponse
+	if err := c.do(ctx, http.MethodPost, leasePath(leaseID, "heartbeat"), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SendEvents reports the step events and log lines of a lease.
+func (c *Client) SendEvents(ctx context.Context, leaseID string, events []api.Event) error {
+	return c.do(ctx, http.MethodPost, leasePath(leaseID, "events"), events, nil)
+}
+
+// Complete reports the result of a lease.
+func (c *Client) Complete(ctx context.Context, leaseID string, result api.LeaseResult) error {
+	return c.do(ctx, http.MethodPost, leasePath(leaseID, "complete"), result, nil)
+}
+
+// Workspace downloads the workspace tarball of a lease's run. It returns
+// nil when the run was submitted without a workspace.
+func (c *Client) Workspace(ctx context.Context, leaseID string) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, http.MethodGet, leasePath(leaseID, "workspace"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		resp.Body.Close()
+		return nil, nil
+	}
+	return resp.Body, nil
+}
+
+func leasePath(leaseID, action string) string {
+	return fmt.Sprintf("/api/v1/leases/%s/%s", url.PathEscape(leaseID), action)
+}
+
+// credential returns the token to send: the agent's own token once it
+// registered, else the token set with SetToken.
+func (c *Client) credential() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.agentToken != "" {
+		return c.agentToken
+	}
+	return c.token
+}
+
+// do sends body as JSON and decodes the response into out, unless the
+// server answered 204 No Content.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	return c.doAs(ctx, c.credential(), method, path, body, out)
+}
+
+func (c *Client) doAs(ctx context.Context, token, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	resp, err := c.sendAs(ctx, token, method, path, reader)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from server: %w", err)
+	}
+	return nil
+}
+
+// send performs a request and turns error responses into errors. The
+// caller must close the body of successful responses.
+func (c *Client) send(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	return c.sendAs(ctx, c.credential(), method, path, body)
+}
+
+func (c *Client) sendAs(ctx context.Context, token, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	return nil, responseError(resp)
+}
+
+func responseError(resp *http.Response) error {
+	var body api.ErrorResponse
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) != nil || body.Error == "" {
+		body.Error = strings.TrimSpace(string(data))
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusGone:
+		return api.ErrLeaseLost
+	case resp.StatusCode == http.StatusNotFound && body.Error == api.ErrUnknownAgent.Error():
+		return api.ErrUnknownAgent
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case resp.StatusCode == http.StatusBadRequest:
+		for _, err := range []error{api.ErrAuthorizationPending, api.ErrAccessDenied, api.ErrExpiredToken} {
+			if body.Error == err.Error() {
+				return err
+			}
+		}
+	}
+	if body.Error == "" {
+		return errors.New(resp.Status)
+	}
+	return fmt.Errorf("server error (%s): %s", resp.Status, body.Error)
+}
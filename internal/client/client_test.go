@@ -0,0 +1,130 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+)
+
+func TestEvents_StopsAtEnd(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("after"); got != "4" {
+			t.Errorf("Expected to resume after 4, got %q", got)
+		}
+		fmt.Fprint(w, "id: 5\nevent: log\ndata: {\"seq\":5,\"type\":\"log\",\"message\":\"hello\"}\n\n")
+		fmt.Fprint(w, "id: 6\nevent: run_finished\ndata: {\"seq\":6,\"type\":\"run_finished\"}\n\n")
+		fmt.Fprint(w, "event: end\ndata: {}\n\n")
+	}))
+	defer ts.Close()
+
+	var got []api.Event
+	last, err := New(ts.URL).Events(context.Background(), "run", 4, func(e api.Event) {
+		got = append(got, e)
+	})
+	if err != nil {
+		t.Fatalf("Events() returned an unexpected error: %v", err)
+	}
+	if last != 6 || len(got) != 2 || got[0].Message != "hello" {
+		t.Errorf("Expected events 5 and 6, got %+v (last %d)", got, last)
+	}
+}
+
+func TestEvents_BrokenStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "id: 1\nevent: log\ndata: {\"seq\":1,\"type\":\"log\"}\n\n")
+	}))
+	defer ts.Close()
+
+	last, err := New(ts.URL).Events(context.Background(), "run", 0, func(api.Event) {})
+	if !errors.Is(err, io.ErrUnexpectedEOF) || last != 1 {
+		t.Errorf("Expected io.ErrUnexpectedEOF after event 1, got %v (last %d)", err, last)
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{http.StatusGone, `{"error":"lease lost"}`, api.ErrLeaseLost},
+		{http.StatusNotFound, `{"error":"unknown agent"}`, api.ErrUnknownAgent},
+		{http.StatusNotFound, `{"error":"run not found"}`, nil},
+		{http.StatusUnauthorized, `{"error":"missing or invalid token"}`, ErrUnauthorized},
+		{http.StatusBadRequest, `{"error":"authorization_pending"}`, api.ErrAuthorizationPending},
+		{http.StatusBadRequest, `{"error":"access_denied"}`, api.ErrAccessDenied},
+	}
+
+	for _, tt := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, tt.body)
+		}))
+		_, err := New(ts.URL).Run(context.Background(), "x")
+		ts.Close()
+
+		if err == nil {
+			t.Errorf("Expected an error for status %d", tt.status)
+			continue
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("Expected %v for status %d, got %v", tt.want, tt.status, err)
+		}
+		if tt.want == nil && (errors.Is(err, api.ErrLeaseLost) || errors.Is(err, api.ErrUnknownAgent)) {
+			t.Errorf("Expected a plain error for status %d, got %v", tt.status, err)
+		}
+	}
+}
+
+func TestRegister_SwitchesToAgentToken(t *testing.T) {
+	var auths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+		if r.URL.Path == "/api/v1/agents" {
+			fmt.Fprint(w, `{"id":"agent-1","token":"fca_agent"}`)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := New(ts.URL)
+	c.SetToken("fct_join")
+	ctx := context.Background()
+	if _, err := c.Register(ctx, api.AgentRegistration{Name: "a"}); err != nil {
+		t.Fatalf("Register() returned an unexpected error: %v", err)
+	}
+	_, _ = c.Lease(ctx, "agent-1", 0)
+	_, _ = c.Register(ctx, api.AgentRegistration{Name: "a"})
+
+	want := []string{
+		"POST /api/v1/agents Bearer fct_join",
+		"POST /api/v1/agents/agent-1/lease Bearer fca_agent",
+		"POST /api/v1/agents Bearer fct_join",
+	}
+	if fmt.Sprint(auths) != fmt.Sprint(want) {
+		t.Errorf("Expected requests %q, got %q", want, auths)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Credentials are the tokens of the servers the user logged in to,
+// kept in a file only the user can read.
+type Credentials struct {
+	// Default is the server of the last login, used by the remote
+	// commands when no server is given.
+	Default string                       `json:"default,omitempty"`
+	Servers map[string]ServerCredentials `json:"servers"`
+}
+
+// ServerCredentials is the token used for one server.
+type ServerCredentials struct {
+	Token   string   `json:"token"`
+	TokenID string   `json:"token_id,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Scopes  []string `json:"scopes,omitempty"`
+}
+
+// CredentialsPath returns the per-user credentials file,
+// e.g. ~/.config/flowcraft/credentials.json on Linux.
+func CredentialsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user configuration directory: %w", err)
+	}
+	return filepath.Join(dir, "flowcraft", "credentials.json"), nil
+}
+
+// LoadCredentials reads the credentials file at path. A missing file
+// holds no credentials. Like ssh, it refuses a file others can read.
+func LoadCredentials(path string) (*Credentials, error) {
+	creds := &Credentials{Servers: make(map[string]ServerCredentials)}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return creds, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("credentials file %s is accessible by other users (mode %04o): run 'chmod 600 %s'", path, info.Mode().Perm(), path)
+	}
+	if err := json.NewDecoder(f).Decode(creds); err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %w", path, err)
+	}
+	if creds.Servers == nil {
+		creds.Servers = make(map[string]ServerCredentials)
+	}
+	return creds, nil
+}
+
+// Save writes the credentials to path with 0600 permissions.
+func (c *Credentials) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write a temporary file first so that a failure keeps the old one.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".credentials-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get returns the credentials of server, in any form accepted by New.
+func (c *Credentials) Get(server string) (ServerCredentials, bool) {
+	creds, ok := c.Servers[NormalizeURL(server)]
+	return creds, ok
+}
+
+// Set stores the credentials of server and makes it the default.
+func (c *Credentials) Set(server string, creds ServerCredentials) {
+	server = NormalizeURL(server)
+	c.Servers[server] = creds
+	c.Default = server
+}
+
+// Remove forgets the credentials of server. It reports whether there
+// were any.
+func (c *Credentials) Remove(server string) bool {
+	server = NormalizeURL(server)
+	_, ok := c.Servers[server]
+	delete(c.Servers, server)
+	if c.Default == server {
+		c.Default = ""
+	}
+	return ok
+}
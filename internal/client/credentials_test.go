@@ -0,0 +1,70 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCredentials_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flowcraft", "credentials.json")
+
+	creds, err := LoadCredentials(path)
+	if err != nil || len(creds.Servers) != 0 {
+		t.Fatalf("Expected no credentials before the first login, got %+v, %v", creds, err)
+	}
+	creds.Set("ci.example.com:8080/", ServerCredentials{Token: "fct_secret", Name: "laptop"})
+	if err := creds.Save(path); err != nil {
+		t.Fatalf("Save() returned an unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected the credentials file to exist: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("Expected mode 0600, got %04o", perm)
+	}
+
+	loaded, err := LoadCredentials(path)
+	if err != nil {
+		t.Fatalf("LoadCredentials() returned an unexpected error: %v", err)
+	}
+	if loaded.Default != "http://ci.example.com:8080" {
+		t.Errorf("Expected the normalized server as the default, got %q", loaded.Default)
+	}
+	if got, ok := loaded.Get("http://ci.example.com:8080"); !ok || got.Token != "fct_secret" {
+		t.Errorf("Expected the token of the server, got %+v", got)
+	}
+
+	if !loaded.Remove("ci.example.com:8080") || loaded.Default != "" {
+		t.Errorf("Expected the server and the default to be removed, got %+v", loaded)
+	}
+}
+
+func TestCredentials_RefusesReadableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, []byte(`{"servers":{}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCredentials(path); err == nil || !strings.Contains(err.Error(), "chmod 600") {
+		t.Errorf("Expected a file readable by others to be refused, got %v", err)
+	}
+}
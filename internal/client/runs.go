@@ -0,0 +1,159 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+)
+
+// Submit queues a pipeline. pack, if not nil, writes the workspace
+// tarball; it is streamed to the server as it is produced.
+func (c *Client) Submit(ctx context.Context, configData []byte, pack func(io.Writer) error) (*api.Run, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		err := writeSubmission(mw, configData, pack)
+		if cerr := mw.Close(); err == nil {
+			err = cerr
+		}
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+"/api/v1/runs", pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if token := c.credential(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, responseError(resp)
+	}
+
+	var run api.Run
+	if err := json.NewDecoder(resp.Body).Decode(&run); err != nil {
+		return nil, fmt.Errorf("invalid response from server: %w", err)
+	}
+	return &run, nil
+}
+
+func writeSubmission(mw *multipart.Writer, configData []byte, pack func(io.Writer) error) error {
+	part, err := mw.CreateFormFile("config", "flow.toml")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(configData); err != nil {
+		return err
+	}
+	if pack == nil {
+		return nil
+	}
+	part, err = mw.CreateFormFile("workspace", "workspace.tar.gz")
+	if err != nil {
+		return err
+	}
+	return pack(part)
+}
+
+// Run returns the current state of a run.
+func (c *Client) Run(ctx context.Context, id string) (*api.Run, error) {
+	var run api.Run
+	if err := c.do(ctx, http.MethodGet, "/api/v1/runs/"+url.PathEscape(id), nil, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Cancel cancels a queued or running run.
+func (c *Client) Cancel(ctx context.Context, id string) (*api.Run, error) {
+	var run api.Run
+	if err := c.do(ctx, http.MethodPost, "/api/v1/runs/"+url.PathEscape(id)+"/cancel", nil, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// CancelJob cancels one job of a running run, which fails the run.
+func (c *Client) CancelJob(ctx context.Context, id, job string) (*api.Run, error) {
+	var run api.Run
+	path := fmt.Sprintf("/api/v1/runs/%s/jobs/%s/cancel", url.PathEscape(id), url.PathEscape(job))
+	if err := c.do(ctx, http.MethodPost, path, nil, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Events streams the events of a run after the given sequence number
+// to fn until the run finishes. It returns the sequence number of the
+// last event received, to resume from if the stream broke off with
+// io.ErrUnexpectedEOF.
+func (c *Client) Events(ctx context.Context, id string, after uint64, fn func(api.Event)) (uint64, error) {
+	path := fmt.Sprintf("/api/v1/runs/%s/events?after=%d", url.PathEscape(id), after)
+	resp, err := c.send(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return after, err
+	}
+	defer resp.Body.Close()
+
+	var eventType string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), 4<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			if seq, err := strconv.ParseUint(strings.TrimPrefix(line, "id: "), 10, 64); err == nil {
+				after = seq
+			}
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if eventType == "end" {
+				return after, nil
+			}
+			var e api.Event
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e); err != nil {
+				return after, fmt.Errorf("invalid event from server: %w", err)
+			}
+			fn(e)
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() != nil {
+		return after, ctx.Err()
+	}
+	return after, io.ErrUnexpectedEOF
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultApproveTimeout is how long an approval gate waits when the job
+// sets no approve_timeout.
+const DefaultApproveTimeout = time.Hour
+
+// ApprovalTimeout returns how long the job's approval gate waits before
+// rejecting the job.
+func (j Job) ApprovalTimeout() (time.Duration, error) {
+	if j.ApproveTimeout == "" {
+		return DefaultApproveTimeout, nil
+	}
+	d, err := time.ParseDuration(j.ApproveTimeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid approve_timeout '%s' (expected e.g. 30m)", j.ApproveTimeout)
+	}
+	return d, nil
+}
+
+// Describe lists what the job runs, for its approval prompt.
+func (j Job) Describe() []string {
+	var lines []string
+	for _, step := range j.Steps {
+		lines = append(lines, fmt.Sprintf("Step '%s': %s", step.Name, step.describe()))
+	}
+	for _, step := range j.Parallel {
+		lines = append(lines, fmt.Sprintf("Parallel step '%s': %s", step.Name, step.describe()))
+	}
+	if len(j.Secrets) > 0 {
+		lines = append(lines, fmt.Sprintf("Secrets: %s", strings.Join(j.Secrets, ", ")))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "The job has no steps.")
+	}
+	return lines
+}
+
+func (s Step) describe() string {
+	c := s.Checkout
+	if c == nil {
+		return s.Cmd
+	}
+	repo, ref := c.Repository, c.Ref
+	if repo == "" {
+		repo = "$CI_REPOSITORY_URL"
+	}
+	if ref == "" {
+		ref = "$CI_SHA"
+	}
+	return fmt.Sprintf("checkout %s@%s", repo, ref)
+}
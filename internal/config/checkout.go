@@ -0,0 +1,54 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SecretNames returns the secrets used by the job: its secrets and the
+// credentials of its checkout steps.
+func (j Job) SecretNames() []string {
+	names := slices.Clone(j.Secrets)
+	for _, step := range j.Steps {
+		if c := step.Checkout; c != nil && c.Credentials != "" && !slices.Contains(names, c.Credentials) {
+			names = append(names, c.Credentials)
+		}
+	}
+	return names
+}
+
+func (j Job) validateSteps() error {
+	for _, step := range j.Steps {
+		if step.Checkout == nil {
+			continue
+		}
+		if step.Cmd != "" {
+			return fmt.Errorf("step '%s' cannot have both a cmd and a checkout", step.Name)
+		}
+		if step.Checkout.Depth < 0 {
+			return fmt.Errorf("step '%s': checkout depth must not be negative", step.Name)
+		}
+	}
+	for _, step := range j.Parallel {
+		if step.Checkout != nil {
+			return fmt.Errorf("checkout step '%s' must be a sequential step", step.Name)
+		}
+	}
+	return nil
+}
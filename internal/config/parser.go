@@ -21,6 +21,8 @@ import (
 	"os"
 
 	"github.com/BurntSushi/toml"
+
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
 )
 
 func LoadConfig(path string) (*Config, error) {
@@ -29,10 +31,61 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("error during reading file %s: %w", path, err)
 	}
 
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("error during parsing toml of file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Parse decodes a flow.toml document, e.g. one submitted to the server.
+func Parse(data []byte) (*Config, error) {
 	var cfg Config
 	if err := toml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("error during parsing toml of file %s: %w", path, err)
+		return nil, err
+	}
+
+	for name, limit := range cfg.Settings.Semaphores {
+		if limit < 1 {
+			return nil, fmt.Errorf("semaphore '%s' must allow at least 1 job, got %d", name, limit)
+		}
+	}
+	if err := cfg.Triggers.validate(); err != nil {
+		return nil, fmt.Errorf("triggers: %w", err)
+	}
+	for name, job := range cfg.Jobs {
+		if err := job.Resources.validate(); err != nil {
+			return nil, fmt.Errorf("job '%s': %w", name, err)
+		}
+		if _, err := job.ApprovalTimeout(); err != nil {
+			return nil, fmt.Errorf("job '%s': %w", name, err)
+		}
+		if err := job.validateTimeouts(); err != nil {
+			return nil, fmt.Errorf("job '%s': %w", name, err)
+		}
+		if err := job.validateSteps(); err != nil {
+			return nil, fmt.Errorf("job '%s': %w", name, err)
+		}
+		if err := validateGlobs("reports", job.Reports); err != nil {
+			return nil, fmt.Errorf("job '%s': %w", name, err)
+		}
+		if err := validateGlobs("watch", job.Watch); err != nil {
+			return nil, fmt.Errorf("job '%s': %w", name, err)
+		}
+		if err := validateGlobs("paths", job.Paths); err != nil {
+			return nil, fmt.Errorf("job '%s': %w", name, err)
+		}
 	}
 
 	return &cfg, nil
 }
+
+func validateGlobs(key string, patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := workspace.CompileGlob(pattern); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -20,6 +20,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig_FileNotFound(t *testing.T) {
@@ -54,3 +55,118 @@ func TestLoadConfig_InvalidTOML(t *testing.T) {
 		t.Errorf("Expected error to contain 'parsing', got: %v", err)
 	}
 }
+
+func TestParse_InvalidSemaphore(t *testing.T) {
+	_, err := Parse([]byte("[settings.semaphores]\ne2e-browser = 0\n"))
+	if err == nil || !strings.Contains(err.Error(), "semaphore 'e2e-browser'") {
+		t.Errorf("Expected an invalid semaphore error, got: %v", err)
+	}
+}
+
+func TestParse_ApproveTimeout(t *testing.T) {
+	cfg, err := Parse([]byte("[jobs.deploy]\napprove = true\n\n[jobs.release]\napprove = true\napprove_timeout = \"15m\"\n"))
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	if d, _ := cfg.Jobs["deploy"].ApprovalTimeout(); d != DefaultApproveTimeout {
+		t.Errorf("Expected the default approval timeout, got %v", d)
+	}
+	if d, _ := cfg.Jobs["release"].ApprovalTimeout(); d != 15*time.Minute {
+		t.Errorf("Expected a 15m approval timeout, got %v", d)
+	}
+
+	_, err = Parse([]byte("[jobs.deploy]\napprove = true\napprove_timeout = \"soon\"\n"))
+	if err == nil || !strings.Contains(err.Error(), "approve_timeout 'soon'") {
+		t.Errorf("Expected an invalid approve_timeout error, got: %v", err)
+	}
+}
+
+func TestParse_Timeouts(t *testing.T) {
+	cfg, err := Parse([]byte("[jobs.build]\ntimeout = \"1h\"\n\n[[jobs.build.steps]]\nname = \"Test\"\ncmd = \"go test\"\ntimeout = \"10m\"\n"))
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	job := cfg.Jobs["build"]
+	if d, _ := job.TimeoutDuration(); d != time.Hour {
+		t.Errorf("Expected a 1h job timeout, got %v", d)
+	}
+	if d, _ := job.Steps[0].TimeoutDuration(); d != 10*time.Minute {
+		t.Errorf("Expected a 10m step timeout, got %v", d)
+	}
+
+	_, err = Parse([]byte("[jobs.build]\n\n[[jobs.build.parallel]]\nname = \"Lint\"\ncmd = \"lint\"\ntimeout = \"-1m\"\n"))
+	if err == nil || !strings.Contains(err.Error(), "step 'Lint': invalid timeout '-1m'") {
+		t.Errorf("Expected an invalid timeout error, got: %v", err)
+	}
+}
+
+func TestParse_Triggers(t *testing.T) {
+	cfg, err := Parse([]byte("[triggers]\nbranches = [\"main\", \"release/*\"]\npaths = [\"src/**\"]\n"))
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	if events := cfg.Triggers.EventTypes(); len(events) != 1 || events[0] != TriggerPush {
+		t.Errorf("Expected triggers to default to push events, got %v", events)
+	}
+
+	_, err = Parse([]byte("[triggers]\nevents = [\"tag\"]\n"))
+	if err == nil || !strings.Contains(err.Error(), "unknown event 'tag'") {
+		t.Errorf("Expected an unknown event error, got: %v", err)
+	}
+	_, err = Parse([]byte("[triggers]\nbranches = [\"release/[\"]\n"))
+	if err == nil || !strings.Contains(err.Error(), "triggers:") {
+		t.Errorf("Expected an invalid pattern error, got: %v", err)
+	}
+}
+
+func TestParse_Reports(t *testing.T) {
+	cfg, err := Parse([]byte("[jobs.test]\nreports = [\"**/junit.xml\"]\n"))
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	if reports := cfg.Jobs["test"].Reports; len(reports) != 1 || reports[0] != "**/junit.xml" {
+		t.Errorf("Expected the reports of the job, got %v", reports)
+	}
+
+	_, err = Parse([]byte("[jobs.test]\nreports = [\"out/[\"]\n"))
+	if err == nil || !strings.Contains(err.Error(), "job 'test': reports:") {
+		t.Errorf("Expected an invalid pattern error, got: %v", err)
+	}
+	_, err = Parse([]byte("[jobs.test]\nwatch = [\"src/[\"]\n"))
+	if err == nil || !strings.Contains(err.Error(), "job 'test': watch:") {
+		t.Errorf("Expected an invalid pattern error, got: %v", err)
+	}
+	_, err = Parse([]byte("[jobs.test]\npaths = [\"api/[\"]\n"))
+	if err == nil || !strings.Contains(err.Error(), "job 'test': paths:") {
+		t.Errorf("Expected an invalid pattern error, got: %v", err)
+	}
+}
+
+func TestParse_Checkout(t *testing.T) {
+	cfg, err := Parse([]byte(`
+[jobs.build]
+secrets = ["NPM_TOKEN"]
+[[jobs.build.steps]]
+name = "Checkout"
+checkout = { repository = "https://example.com/app.git", ref = "main", depth = 1, sparse = ["src"], credentials = "GIT_TOKEN" }
+`))
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	job := cfg.Jobs["build"]
+	if c := job.Steps[0].Checkout; c == nil || c.Ref != "main" || c.Depth != 1 || len(c.Sparse) != 1 {
+		t.Errorf("Unexpected checkout: %+v", c)
+	}
+	if names := job.SecretNames(); len(names) != 2 || names[1] != "GIT_TOKEN" {
+		t.Errorf("Expected the checkout credentials among the job's secrets, got %v", names)
+	}
+
+	_, err = Parse([]byte("[jobs.build]\nparallel = [{ name = \"co\", checkout = {} }]\n"))
+	if err == nil || !strings.Contains(err.Error(), "must be a sequential step") {
+		t.Errorf("Expected parallel checkouts to be rejected, got: %v", err)
+	}
+	_, err = Parse([]byte("[jobs.build]\nsteps = [{ name = \"co\", cmd = \"make\", checkout = {} }]\n"))
+	if err == nil || !strings.Contains(err.Error(), "both a cmd and a checkout") {
+		t.Errorf("Expected a step with a cmd and a checkout to be rejected, got: %v", err)
+	}
+}
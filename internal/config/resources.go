@@ -0,0 +1,85 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var quantitySuffixes = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+	{"K", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+}
+
+// ParseQuantity parses a memory quantity in bytes: a plain number or a
+// number followed by a binary (Ki, Mi, Gi, Ti) or decimal (K, M, G, T)
+// suffix. The empty string is zero.
+func ParseQuantity(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	number := s
+	for _, q := range quantitySuffixes {
+		if strings.HasSuffix(s, q.suffix) {
+			multiplier = q.multiplier
+			number = strings.TrimSuffix(s, q.suffix)
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid quantity '%s' (expected e.g. 512Mi or 4Gi)", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// FormatQuantity formats bytes with the largest binary suffix that
+// keeps the value whole, e.g. 4Gi.
+func FormatQuantity(bytes int64) string {
+	for i := 3; i >= 0; i-- {
+		q := quantitySuffixes[i]
+		if bytes >= q.multiplier && bytes%q.multiplier == 0 {
+			return strconv.FormatInt(bytes/q.multiplier, 10) + q.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10)
+}
+
+// MemBytes returns the requested memory in bytes.
+func (r Resources) MemBytes() int64 {
+	bytes, _ := ParseQuantity(r.Mem)
+	return bytes
+}
+
+func (r Resources) validate() error {
+	if r.CPU < 0 {
+		return fmt.Errorf("resources.cpu must not be negative")
+	}
+	if _, err := ParseQuantity(r.Mem); err != nil {
+		return fmt.Errorf("resources.mem: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"", 0},
+		{"1024", 1024},
+		{"512Mi", 512 << 20},
+		{"4Gi", 4 << 30},
+		{"1.5Gi", 3 << 29},
+		{"2G", 2e9},
+		{"100K", 1e5},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuantity(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseQuantity(%q) = %d, %v; expected %d", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"4GB", "lots", "-1Gi"} {
+		if _, err := ParseQuantity(in); err == nil {
+			t.Errorf("Expected ParseQuantity(%q) to fail", in)
+		}
+	}
+}
+
+func TestFormatQuantity(t *testing.T) {
+	for bytes, want := range map[int64]string{4 << 30: "4Gi", 1536 << 20: "1536Mi", 1000: "1000", 0: "0"} {
+		if got := FormatQuantity(bytes); got != want {
+			t.Errorf("FormatQuantity(%d) = %q, expected %q", bytes, got, want)
+		}
+	}
+}
+
+func TestParse_Resources(t *testing.T) {
+	cfg, err := Parse([]byte(`
+[jobs.build]
+runs_on = ["linux", "docker"]
+resources = { cpu = 2, mem = "4Gi" }
+`))
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	job := cfg.Jobs["build"]
+	if len(job.RunsOn) != 2 || job.Resources.CPU != 2 || job.Resources.MemBytes() != 4<<30 {
+		t.Errorf("Unexpected job placement settings: %+v", job)
+	}
+
+	_, err = Parse([]byte("[jobs.build]\nresources = { mem = \"4GB\" }\n"))
+	if err == nil || !strings.Contains(err.Error(), "job 'build'") {
+		t.Errorf("Expected an invalid mem error naming the job, got: %v", err)
+	}
+}
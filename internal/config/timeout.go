@@ -0,0 +1,57 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeoutDuration returns how long the job may run, or 0 when it has no
+// timeout.
+func (j Job) TimeoutDuration() (time.Duration, error) {
+	return parseTimeout(j.Timeout)
+}
+
+// TimeoutDuration returns how long the step may run, or 0 when it has
+// no timeout.
+func (s Step) TimeoutDuration() (time.Duration, error) {
+	return parseTimeout(s.Timeout)
+}
+
+func parseTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout '%s' (expected e.g. 1h)", value)
+	}
+	return d, nil
+}
+
+func (j Job) validateTimeouts() error {
+	if _, err := j.TimeoutDuration(); err != nil {
+		return err
+	}
+	for _, step := range append(j.Steps, j.Parallel...) {
+		if _, err := step.TimeoutDuration(); err != nil {
+			return fmt.Errorf("step '%s': %w", step.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
+)
+
+// Trigger event types.
+const (
+	TriggerPush        = "push"
+	TriggerPullRequest = "pull_request"
+)
+
+// EventTypes returns the event types starting a run.
+func (t Triggers) EventTypes() []string {
+	if len(t.Events) == 0 {
+		return []string{TriggerPush}
+	}
+	return t.Events
+}
+
+func (t Triggers) validate() error {
+	for _, event := range t.Events {
+		if event != TriggerPush && event != TriggerPullRequest {
+			return fmt.Errorf("unknown event '%s' (expected '%s' or '%s')", event, TriggerPush, TriggerPullRequest)
+		}
+	}
+	for _, pattern := range slices.Concat(t.Branches, t.Paths) {
+		if _, err := workspace.CompileGlob(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
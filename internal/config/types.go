@@ -18,11 +18,77 @@ package config
 
 type Settings struct {
 	Parallelism int `toml:"parallelism"`
+	// WeightBudget bounds the total weight of the jobs running at the
+	// same time. It defaults to the number of CPUs.
+	WeightBudget float64 `toml:"weight_budget"`
+	// Semaphores sets how many jobs may hold each named lock at the
+	// same time. Locks not listed here are mutexes.
+	Semaphores map[string]int `toml:"semaphores"`
+	// InheritEnv controls whether steps see the host environment.
+	// It defaults to true; set it to false for hermetic runs.
+	InheritEnv *bool `toml:"inherit_env"`
+	// PassEnv lists host variables that are always passed to steps,
+	// even when InheritEnv is false.
+	PassEnv []string `toml:"pass_env"`
+	// WarnUndeclaredEnv reports host variables read by a step that are
+	// neither declared in the config nor listed in PassEnv.
+	WarnUndeclaredEnv bool `toml:"warn_undeclared_env"`
+	// SecretProviders holds per-provider options, keyed by provider name.
+	SecretProviders map[string]SecretProviderSettings `toml:"secret_providers"`
+	Vault           VaultSettings                     `toml:"vault"`
+	EncryptedFile   EncryptedFileSettings             `toml:"encrypted_file"`
+}
+
+// EncryptedFileSettings configures the "encrypted_file" provider and the
+// 'flowcraft secrets' commands.
+type EncryptedFileSettings struct {
+	// IdentityEnv names the variable holding the age identity
+	// (AGE-SECRET-KEY-...). Defaults to "FLOWCRAFT_AGE_KEY".
+	IdentityEnv string `toml:"identity_env"`
+	// IdentityFile is read when IdentityEnv is unset. Defaults to
+	// $FLOWCRAFT_AGE_KEY_FILE.
+	IdentityFile string `toml:"identity_file"`
+	// Recipients used when encrypting. Defaults to the recipient of
+	// the loaded identity.
+	Recipients []string `toml:"recipients"`
+}
+
+// VaultSettings configures the "vault" secret provider. Credentials are
+// never read from flow.toml: the token and the AppRole secret ID come
+// from the environment.
+type VaultSettings struct {
+	// Address of the Vault server. Defaults to $VAULT_ADDR.
+	Address string `toml:"address"`
+	// Namespace sent as X-Vault-Namespace. Defaults to $VAULT_NAMESPACE.
+	Namespace string `toml:"namespace"`
+	// Mount is the KV v2 mount path. Defaults to "secret".
+	Mount string `toml:"mount"`
+	// Auth is either "token" (default, reads $VAULT_TOKEN) or "approle".
+	Auth string `toml:"auth"`
+	// RoleID and SecretIDEnv configure AppRole authentication.
+	// SecretIDEnv defaults to "VAULT_SECRET_ID".
+	RoleID       string `toml:"role_id"`
+	SecretIDEnv  string `toml:"secret_id_env"`
+	AppRoleMount string `toml:"approle_mount"`
+}
+
+type SecretProviderSettings struct {
+	// Timeout bounds a single resolution (e.g. "5s"). Defaults to 10s.
+	Timeout string `toml:"timeout"`
 }
 
 type Secret struct {
 	Provider string `toml:"provider"`
-	Key      string `toml:"key"`
+	// Key is the variable name for the "env", "dotenv" and "encrypted_file" providers,
+	// and the field name inside the secret for the "vault" provider.
+	Key string `toml:"key"`
+	// Path is the file read by the "file", "dotenv" and "encrypted_file" providers, or
+	// the secret path under the KV mount for the "vault" provider.
+	Path string `toml:"path"`
+	// Command is the helper run by the "command" provider.
+	Command string `toml:"command"`
+	// Mount overrides settings.vault.mount for the "vault" provider.
+	Mount string `toml:"mount"`
 }
 
 type Config struct {
@@ -30,6 +96,22 @@ type Config struct {
 	Secrets  map[string]Secret `toml:"secrets"`
 	Jobs     map[string]Job    `toml:"jobs"`
 	Env      map[string]string `toml:"env"`
+	Triggers Triggers          `toml:"triggers"`
+}
+
+// Triggers selects the webhook deliveries that start a run of the
+// pipeline on a flowcraft-server.
+type Triggers struct {
+	// Events lists the event types starting a run: "push" and
+	// "pull_request". Defaults to ["push"].
+	Events []string `toml:"events"`
+	// Branches are glob patterns matched against the pushed branch, or
+	// the target branch of a pull request. Empty matches every branch.
+	Branches []string `toml:"branches"`
+	// Paths are glob patterns matched against the files changed by a
+	// push; a push touching none of them is ignored. Empty matches
+	// every push.
+	Paths []string `toml:"paths"`
 }
 
 type Job struct {
@@ -39,10 +121,75 @@ type Job struct {
 	DependsOn []string          `toml:"depends_on"`
 	Secrets   []string          `toml:"secrets"`
 	Retry     int               `toml:"retry"`
+	// InheritEnv and PassEnv override the settings of the same name
+	// for this job only. PassEnv is appended to the global allow-list.
+	InheritEnv *bool    `toml:"inherit_env"`
+	PassEnv    []string `toml:"pass_env"`
+	// Weight is the share of settings.weight_budget the job takes while
+	// it runs. It defaults to resources.cpu, or 1.
+	Weight float64 `toml:"weight"`
+	// Locks names the mutexes and semaphores held while the job runs.
+	Locks []string `toml:"locks"`
+	// RunsOn lists the tags an agent must have to run this job.
+	RunsOn []string `toml:"runs_on"`
+	// Resources is the share of an agent reserved while the job runs.
+	Resources Resources `toml:"resources"`
+	// Approve pauses the job before it runs until someone approves it.
+	Approve bool `toml:"approve"`
+	// ApproveTimeout is how long the approval gate waits before the job
+	// is rejected (e.g. "30m"). Defaults to 1h.
+	ApproveTimeout string `toml:"approve_timeout"`
+	// Timeout is how long the job may run (e.g. "1h") before it is
+	// stopped and fails. Empty means no limit.
+	Timeout string `toml:"timeout"`
+	// Reports are glob patterns, relative to the working directory, of
+	// the JUnit XML reports read after the job runs (e.g. "**/junit.xml").
+	Reports []string `toml:"reports"`
+	// Watch are glob patterns of the files whose changes re-run the job
+	// under 'flowcraft watch'. Empty watches every file.
+	Watch []string `toml:"watch"`
+	// Paths are glob patterns of the files whose changes affect the job,
+	// for 'flowcraft run --changed-since'. Empty is affected by any change.
+	Paths []string `toml:"paths"`
+}
+
+// Resources is the CPU and memory a job needs.
+type Resources struct {
+	CPU float64 `toml:"cpu"`
+	// Mem is a quantity such as "512Mi", "4Gi" or "2G".
+	Mem string `toml:"mem"`
 }
 
 type Step struct {
 	Name string `toml:"name"`
 	Cmd  string `toml:"cmd"`
 	Dir  string `toml:"dir"`
+	// Timeout is how long the step may run (e.g. "10m") before it is
+	// stopped and fails. Empty means no limit.
+	Timeout string `toml:"timeout"`
+	// Checkout makes the step clone or fetch a git repository instead
+	// of running Cmd.
+	Checkout *Checkout `toml:"checkout"`
+}
+
+// Checkout describes the repository checked out by a checkout step.
+type Checkout struct {
+	// Repository is the URL to fetch. Defaults to $CI_REPOSITORY_URL.
+	Repository string `toml:"repository"`
+	// Ref is the branch, tag or commit to check out. Defaults to
+	// $CI_SHA, else the default branch of the repository.
+	Ref string `toml:"ref"`
+	// Path is the destination, relative to the working directory.
+	// Defaults to the working directory itself.
+	Path string `toml:"path"`
+	// Depth limits the fetched history to that many commits. Zero
+	// fetches the whole history.
+	Depth int `toml:"depth"`
+	// Submodules also checks out the submodules, recursively.
+	Submodules bool `toml:"submodules"`
+	// Sparse limits the working tree to these directories.
+	Sparse []string `toml:"sparse"`
+	// Credentials names a secret holding a token, or "user:token",
+	// sent to the repository's host over HTTP(S).
+	Credentials string `toml:"credentials"`
 }
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+// ApprovalRequest describes a job paused at its approval gate.
+type ApprovalRequest struct {
+	Job  string
+	Spec config.Job
+	// Timeout is how long the gate stays open before the job is rejected.
+	Timeout time.Duration
+}
+
+// Approver decides whether a job paused at its approval gate may run.
+// It blocks until someone decides and must return ctx.Err() once ctx is
+// done, which happens when the gate times out or the run stops.
+type Approver func(ctx context.Context, req ApprovalRequest) (bool, error)
+
+// WithApprover sets who decides on the jobs with approve = true.
+// Without an approver, those jobs are rejected.
+func WithApprover(approver Approver) Option {
+	return func(o *runOptions) {
+		o.approver = approver
+	}
+}
+
+type approvalResult struct {
+	node *Node
+	err  error
+}
+
+// approve holds node at its approval gate until the approver decides or
+// the gate times out. It returns nil when the job may run.
+func (p *pipeline) approve(ctx context.Context, node *Node) error {
+	timeout, err := node.Job.ApprovalTimeout()
+	if err != nil {
+		return err
+	}
+	p.logger.Warn(fmt.Sprintf("Job '%s' is waiting for approval (rejected after %s).", node.Name, timeout))
+	p.events.emit(Event{Type: EventApprovalRequested, Job: node.Name, Message: fmt.Sprintf("timeout %s", timeout)})
+
+	approved := false
+	if p.opts.approver == nil {
+		err = fmt.Errorf("job '%s' requires approval, but no approver is available", node.Name)
+	} else {
+		gateCtx, cancel := context.WithTimeout(ctx, timeout)
+		approved, err = p.opts.approver(gateCtx, ApprovalRequest{Job: node.Name, Spec: node.Job, Timeout: timeout})
+		cancel()
+	}
+	if ctx.Err() != nil {
+		// The run stopped while the gate was open: the job is skipped.
+		return ctx.Err()
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		err = fmt.Errorf("approval of job '%s' timed out after %s", node.Name, timeout)
+	case err == nil && !approved:
+		err = fmt.Errorf("job '%s' was rejected at its approval gate", node.Name)
+	}
+
+	if err != nil {
+		p.logger.Error(err.Error())
+		p.events.emit(Event{Type: EventApprovalResolved, Job: node.Name, Status: StatusRejected, Error: err.Error()})
+		return err
+	}
+	p.logger.Info(fmt.Sprintf("Job '%s' approved.", node.Name))
+	p.events.emit(Event{Type: EventApprovalResolved, Job: node.Name, Status: StatusApproved})
+	return nil
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+func gatedJob(timeout string, deps ...string) config.Job {
+	job := sleepJob(1, deps...)
+	job.Approve = true
+	job.ApproveTimeout = timeout
+	return job
+}
+
+// runGated runs cfg with approver and returns the events of the run.
+func runGated(t *testing.T, cfg *config.Config, approver Approver) ([]Event, error) {
+	t.Helper()
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+
+	var mu sync.Mutex
+	var events []Event
+	options := []Option{WithListener(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})}
+	if approver != nil {
+		options = append(options, WithApprover(approver))
+	}
+	err = Run(context.Background(), cfg, graph, logger, options...)
+	return events, err
+}
+
+func findEvent(events []Event, typ EventType, job string) *Event {
+	for i := range events {
+		if events[i].Type == typ && events[i].Job == job {
+			return &events[i]
+		}
+	}
+	return nil
+}
+
+func TestApproval_GateDoesNotHoldWorkers(t *testing.T) {
+	cfg := &config.Config{
+		Settings: config.Settings{Parallelism: 1, WeightBudget: 1},
+		Jobs: map[string]config.Job{
+			"deploy": gatedJob(""),
+			"lint":   sleepJob(1),
+			"test":   sleepJob(1, "lint"),
+		},
+	}
+
+	testDone := make(chan struct{})
+	var once sync.Once
+	graph, _ := BuildDag(cfg)
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+	err := Run(context.Background(), cfg, graph, logger,
+		WithListener(func(e Event) {
+			if e.Type == EventJobFinished && e.Job == "test" {
+				once.Do(func() { close(testDone) })
+			}
+		}),
+		WithApprover(func(ctx context.Context, req ApprovalRequest) (bool, error) {
+			// Only approve once the rest of the pipeline went through.
+			select {
+			case <-testDone:
+				return true, nil
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Expected the pipeline to run around the open gate, got: %v", err)
+	}
+}
+
+func TestApproval_Rejected(t *testing.T) {
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{
+			"deploy": gatedJob(""),
+			"notify": sleepJob(1, "deploy"),
+		},
+	}
+
+	events, err := runGated(t, cfg, func(ctx context.Context, req ApprovalRequest) (bool, error) {
+		return false, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "job 'deploy' was rejected") {
+		t.Fatalf("Expected the pipeline to fail on the rejected gate, got: %v", err)
+	}
+
+	if e := findEvent(events, EventApprovalRequested, "deploy"); e == nil {
+		t.Error("Expected an approval_requested event for 'deploy'")
+	}
+	if e := findEvent(events, EventApprovalResolved, "deploy"); e == nil || e.Status != StatusRejected {
+		t.Errorf("Expected 'deploy' to be rejected, got %+v", e)
+	}
+	if e := findEvent(events, EventJobStarted, "deploy"); e != nil {
+		t.Error("Expected 'deploy' never to start")
+	}
+	if e := findEvent(events, EventJobFinished, "deploy"); e == nil || e.Status != StatusFailed {
+		t.Errorf("Expected 'deploy' to be reported as failed, got %+v", e)
+	}
+	if e := findEvent(events, EventJobSkipped, "notify"); e == nil {
+		t.Error("Expected 'notify' to be skipped")
+	}
+}
+
+func TestApproval_TimesOut(t *testing.T) {
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{"deploy": gatedJob("50ms")},
+	}
+
+	_, err := runGated(t, cfg, func(ctx context.Context, req ApprovalRequest) (bool, error) {
+		<-ctx.Done()
+		return false, ctx.Err()
+	})
+	if err == nil || !strings.Contains(err.Error(), "approval of job 'deploy' timed out after 50ms") {
+		t.Errorf("Expected the gate to time out, got: %v", err)
+	}
+}
+
+func TestApproval_NoApprover(t *testing.T) {
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{"deploy": gatedJob("")},
+	}
+
+	_, err := runGated(t, cfg, nil)
+	if err == nil || !strings.Contains(err.Error(), "no approver is available") {
+		t.Errorf("Expected the gate to be rejected without an approver, got: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrJobCancelled is the error of a job cancelled by a JobCanceller.
+var ErrJobCancelled = fmt.Errorf("job cancelled: %w", context.Canceled)
+
+// JobCanceller cancels single jobs of a run in progress. Unlike a
+// failure, a cancelled job does not stop the rest of the pipeline: only
+// the jobs depending on it are skipped, and the run fails at its end.
+type JobCanceller struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelCauseFunc
+}
+
+func NewJobCanceller() *JobCanceller {
+	return &JobCanceller{cancels: make(map[string]context.CancelCauseFunc)}
+}
+
+// WithJobCanceller lets c cancel the running jobs of the run.
+func WithJobCanceller(c *JobCanceller) Option {
+	return func(o *runOptions) {
+		o.canceller = c
+	}
+}
+
+// Cancel cancels the job if it is running, and reports whether it was.
+func (c *JobCanceller) Cancel(job string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cancel, ok := c.cancels[job]
+	if ok {
+		cancel(ErrJobCancelled)
+	}
+	return ok
+}
+
+// track returns the context of a job started under ctx, and the function
+// to call once it is done. A nil JobCanceller returns ctx.
+func (c *JobCanceller) track(ctx context.Context, job string) (context.Context, func()) {
+	if c == nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	c.mu.Lock()
+	c.cancels[job] = cancel
+	c.mu.Unlock()
+	return ctx, func() {
+		c.mu.Lock()
+		delete(c.cancels, job)
+		c.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// cancelled reports whether the job running under ctx was cancelled by
+// a JobCanceller.
+func cancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrJobCancelled)
+}
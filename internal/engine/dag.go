@@ -18,6 +18,8 @@ package engine
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 
 	"github.com/Purpose-Dev/flowcraft/internal/config"
 )
@@ -188,3 +190,82 @@ func (g *Graph) TopologicalSort() ([][]*Node, error) {
 
 	return levels, nil
 }
+
+// WithDependents returns the named jobs along with every job depending
+// on them, directly or not, sorted by name.
+func (g *Graph) WithDependents(names []string) []string {
+	return g.closure(names, func(n *Node) []*Node { return n.Dependents })
+}
+
+// WithDependencies returns the named jobs along with every job they
+// depend on, directly or not, sorted by name.
+func (g *Graph) WithDependencies(names []string) []string {
+	return g.closure(names, func(n *Node) []*Node { return n.Dependencies })
+}
+
+// Rerun returns the jobs to run for the named jobs to run again after
+// a run in which the jobs in ok succeeded: the named jobs and every job
+// depending on them, along with the dependencies of those that did not
+// succeed and their own dependents, sorted by name.
+func (g *Graph) Rerun(names []string, ok map[string]bool) []string {
+	jobs := g.WithDependents(names)
+	for {
+		var failed []string
+		for _, name := range g.WithDependencies(jobs) {
+			if !ok[name] && !slices.Contains(jobs, name) {
+				failed = append(failed, name)
+			}
+		}
+		if len(failed) == 0 {
+			return jobs
+		}
+		jobs = g.WithDependents(append(jobs, failed...))
+	}
+}
+
+func (g *Graph) closure(names []string, next func(*Node) []*Node) []string {
+	seen := make(map[string]bool)
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		if seen[n.Name] {
+			return
+		}
+		seen[n.Name] = true
+		for _, m := range next(n) {
+			visit(m)
+		}
+	}
+	for _, name := range names {
+		if node, ok := g.Nodes[name]; ok {
+			visit(node)
+		}
+	}
+
+	result := make([]string, 0, len(seen))
+	for name := range seen {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Subgraph returns the graph of the named jobs and of the dependencies
+// between them. Their dependencies on other jobs are dropped: those are
+// treated as already done.
+func (g *Graph) Subgraph(names []string) *Graph {
+	sub := NewGraph()
+	for _, name := range names {
+		if node, ok := g.Nodes[name]; ok {
+			sub.Nodes[name] = &Node{Name: name, Job: node.Job, Dependencies: []*Node{}, Dependents: []*Node{}}
+		}
+	}
+	for name, node := range sub.Nodes {
+		for _, dep := range g.Nodes[name].Dependencies {
+			if depNode, ok := sub.Nodes[dep.Name]; ok {
+				node.Dependencies = append(node.Dependencies, depNode)
+				depNode.Dependents = append(depNode.Dependents, node)
+			}
+		}
+	}
+	return sub
+}
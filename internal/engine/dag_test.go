@@ -130,3 +130,47 @@ func TestTopologicalSort(t *testing.T) {
 		t.Errorf("Expected level 4 to be %v, got %v", expectedLevel4, getLevelNames(levels[3]))
 	}
 }
+
+func TestGraph_Closures(t *testing.T) {
+	graph, err := BuildDag(newTestConfig(map[string]config.Job{
+		"setup":  {},
+		"api":    {DependsOn: []string{"setup"}},
+		"web":    {DependsOn: []string{"setup"}},
+		"e2e":    {DependsOn: []string{"api", "web"}},
+		"docs":   {},
+		"deploy": {DependsOn: []string{"e2e"}},
+	}))
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+
+	if got, want := graph.WithDependents([]string{"api"}), []string{"api", "deploy", "e2e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WithDependents(api) = %v, want %v", got, want)
+	}
+	if got, want := graph.WithDependencies([]string{"e2e", "unknown"}), []string{"api", "e2e", "setup", "web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WithDependencies(e2e) = %v, want %v", got, want)
+	}
+
+	ok := map[string]bool{"setup": true, "api": true, "docs": true}
+	if got, want := graph.Rerun([]string{"api"}, ok), []string{"api", "deploy", "e2e", "web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Rerun(api) = %v, want %v", got, want)
+	}
+
+	sub := graph.Subgraph([]string{"api", "e2e", "deploy"})
+	levels, err := sub.TopologicalSort()
+	if err != nil {
+		t.Fatalf("TopologicalSort() of the subgraph returned an unexpected error: %v", err)
+	}
+	var order []string
+	for _, level := range levels {
+		for _, node := range level {
+			order = append(order, node.Name)
+		}
+	}
+	if want := []string{"api", "e2e", "deploy"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected the subgraph to run %v, got %v", want, order)
+	}
+	if len(graph.Nodes["e2e"].Dependencies) != 2 {
+		t.Error("Subgraph() modified the original graph")
+	}
+}
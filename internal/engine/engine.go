@@ -19,16 +19,188 @@ package engine
 import (
 	"context"
 	"fmt"
-	"os"
+	"maps"
+	"math"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/junit"
 	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/Purpose-Dev/flowcraft/internal/secrets"
+	"github.com/Purpose-Dev/flowcraft/internal/tracing"
 )
 
-func Run(ctx context.Context, cfg *config.Config, graph *Graph, logger *runner.Logger) error {
+// Option configures a pipeline run.
+type Option func(*runOptions)
+
+type runOptions struct {
+	listeners []Listener
+	workdir   string
+	executor  JobExecutor
+	approver  Approver
+	canceller *JobCanceller
+	metrics   *Metrics
+	tracer    *tracing.Tracer
+	// traceAttributes are set on the span of the run.
+	traceAttributes []tracing.Attribute
+	outputs         map[string]string
+	// processEnv is set in the environment of every step.
+	processEnv map[string]string
+	// deferSecrets leaves the resolution of the secrets to the executor.
+	deferSecrets bool
+}
+
+// JobSpec is everything needed to execute one attempt of a job.
+type JobSpec struct {
+	Name    string
+	Job     config.Job
+	Attempt int
+	// Env holds the merged global, job and secret variables.
+	Env     map[string]string
+	Options runner.Options
+	// Secrets holds the resolved secret values to mask in the output.
+	Secrets []string
+	// SecretRefs holds the declarations of the job's secrets when the
+	// executor resolves them, see WithDeferredSecrets. SecretSettings
+	// configures their providers.
+	SecretRefs     map[string]config.Secret
+	SecretSettings config.Settings
+	// Report records the summary of the job's test reports.
+	Report func(junit.Summary)
+}
+
+// JobExecutor executes one attempt of a job. The default executor runs
+// the steps locally; the server replaces it to dispatch jobs to agents.
+// The values exported by the steps go to spec.Options.Outputs, and the
+// summary of the test reports to spec.Report.
+type JobExecutor func(ctx context.Context, spec JobSpec, logger *runner.Logger) error
+
+// WithListener registers a listener called for every event of the run.
+func WithListener(l Listener) Option {
+	return func(o *runOptions) {
+		o.listeners = append(o.listeners, l)
+	}
+}
+
+// WithWorkdir runs the steps in dir instead of the current directory.
+func WithWorkdir(dir string) Option {
+	return func(o *runOptions) {
+		o.workdir = dir
+	}
+}
+
+// WithProcessEnv sets the variables in m in the environment of every
+// step, without expanding them into the text of the commands. The server
+// passes the CI_* variables of a webhook this way.
+func WithProcessEnv(m map[string]string) Option {
+	return func(o *runOptions) {
+		o.processEnv = m
+	}
+}
+
+// WithExecutor replaces the local execution of jobs. Retries, failure
+// propagation and events are still handled by the scheduler.
+func WithExecutor(executor JobExecutor) Option {
+	return func(o *runOptions) {
+		o.executor = executor
+	}
+}
+
+// WithOutputs starts the run with the outputs in m, as if exported by
+// jobs outside of the run, and adds the outputs of the run's jobs to m.
+// m must not be used until Run returns.
+func WithOutputs(m map[string]string) Option {
+	return func(o *runOptions) {
+		o.outputs = m
+	}
+}
+
+// WithDeferredSecrets leaves the secrets unresolved: the run only checks
+// that the secrets of its jobs are declared, and the executor resolves
+// them from JobSpec.SecretRefs where the job runs. The server uses it so
+// that a submitted pipeline never runs a helper or reads a file or a
+// variable of the server's host.
+func WithDeferredSecrets() Option {
+	return func(o *runOptions) {
+		o.deferSecrets = true
+	}
+}
+
+// WithTracer records the run as a trace: a span for the run, one per
+// job with a child per attempt, and one per step under its attempt.
+// attrs are set on the span of the run.
+func WithTracer(tracer *tracing.Tracer, attrs ...tracing.Attribute) Option {
+	return func(o *runOptions) {
+		o.tracer = tracer
+		o.traceAttributes = attrs
+	}
+}
+
+// pipeline holds the state of a single run.
+type pipeline struct {
+	cfg     *config.Config
+	opts    runOptions
+	events  emitter
+	secrets map[string]string
+	masked  []string
+	logger  *runner.Logger
+
+	mu      sync.Mutex
+	started map[string]bool
+	// outputs holds the values exported by the jobs that succeeded.
+	outputs map[string]string
+	// tests holds the test report of the last attempt of each job.
+	tests map[string]junit.Summary
+}
+
+func Run(ctx context.Context, cfg *config.Config, graph *Graph, logger *runner.Logger, options ...Option) error {
+	p := &pipeline{
+		cfg:     cfg,
+		logger:  logger,
+		started: make(map[string]bool),
+		outputs: make(map[string]string),
+		tests:   make(map[string]junit.Summary),
+	}
+	for _, opt := range options {
+		opt(&p.opts)
+	}
+	if p.opts.outputs != nil {
+		p.outputs = p.opts.outputs
+	}
+	p.events = append(emitter{p.recordTests}, p.opts.listeners...)
+	if p.opts.metrics != nil {
+		// Record the metrics before the listeners learn about an event.
+		p.events = append(emitter{p.opts.metrics.Observe}, p.events...)
+	}
+
+	attrs := append([]tracing.Attribute{tracing.Int("flowcraft.jobs", len(graph.Nodes))}, p.opts.traceAttributes...)
+	ctx, span := p.opts.tracer.Start(ctx, "run", attrs...)
+	defer span.End()
+
+	p.events.emit(Event{Type: EventRunStarted, Message: fmt.Sprintf("%d job(s)", len(graph.Nodes))})
+	err := p.run(ctx, graph)
+	span.SetError(err)
+
+	// Jobs that never started were skipped because of an earlier failure.
+	for name := range graph.Nodes {
+		p.mu.Lock()
+		started := p.started[name]
+		p.mu.Unlock()
+		if !started {
+			p.events.emit(Event{Type: EventJobSkipped, Job: name, Status: StatusSkipped})
+		}
+	}
+	p.events.emit(Event{Type: EventRunFinished, Status: statusOf(err), Error: errorString(err)})
+
+	return err
+}
+
+func (p *pipeline) run(ctx context.Context, graph *Graph) error {
+	cfg, logger := p.cfg, p.logger
+
 	levels, err := graph.TopologicalSort()
 	if err != nil {
 		return fmt.Errorf("failed to sort graph: %w", err)
@@ -46,113 +218,172 @@ func Run(ctx context.Context, cfg *config.Config, graph *Graph, logger *runner.L
 		numWorkers = runtime.NumCPU()
 	}
 	logger.Info(fmt.Sprintf("Concurrency limit set to %d worker(s).", numWorkers))
+	if budget := p.budget(); !math.IsInf(budget, 1) {
+		logger.Info(fmt.Sprintf("Weight budget set to %s.", formatWeight(budget)))
+	}
 
-	resolvedSecrets, secretValues, err := resolveSecrets(cfg, logger)
-	if err != nil {
-		return fmt.Errorf("failed to resolve secrets: %w", err)
-	}
-
-	logger.SetSecretsToMask(secretValues)
-
-	for i, level := range levels {
-		levelNum := i + 1
-		logger.StartGroup(fmt.Sprintf("Level %d/%d (Executing %d job(s) in parallel)", levelNum, len(levels), len(level)))
-
-		levelCtx, cancel := context.WithCancel(ctx)
-
-		var wg sync.WaitGroup
-		var firstError error
-		var errMutex sync.Mutex
-
-		jobQueue := make(chan *Node, len(level))
-
-		wg.Add(len(level))
-
-		for w := 0; w < numWorkers; w++ {
-			go func() {
-				for node := range jobQueue {
-					var jobErr error
-					totalAttempts := 1 + node.Job.Retry
-
-					for attempt := 1; attempt <= totalAttempts; attempt++ {
-						jobEnvs := mergeEnvs(cfg.Env, node.Job.Env)
-						for _, secretName := range node.Job.Secrets {
-							if val, ok := resolvedSecrets[secretName]; ok {
-								jobEnvs[secretName] = val
-							}
-						}
-
-						jobErr = executeJob(levelCtx, node.Name, node.Job, jobEnvs, logger)
-						if jobErr == nil {
-							break
-						}
-
-						if attempt < totalAttempts {
-							logger.Error(fmt.Sprintf("Job '%s' failed (attempt %d/%d), retrying...", node.Name, attempt, totalAttempts))
-							time.Sleep(3 * time.Second)
-						}
-					}
-
-					if jobErr != nil {
-						errMutex.Lock()
-						if firstError == nil {
-							firstError = jobErr
-							cancel()
-						}
-						errMutex.Unlock()
-					}
-					wg.Done()
-				}
-			}()
+	if p.opts.deferSecrets {
+		if err := checkSecretsDeclared(cfg, requiredSecrets(graph)); err != nil {
+			return fmt.Errorf("failed to resolve secrets: %w", err)
 		}
-
-		for _, node := range level {
-			jobQueue <- node
+	} else {
+		resolvedSecrets, secretValues, err := resolveSecrets(ctx, cfg, requiredSecrets(graph))
+		if err != nil {
+			return fmt.Errorf("failed to resolve secrets: %w", err)
 		}
+		p.secrets = resolvedSecrets
+		p.masked = secretValues
 
-		close(jobQueue)
+		logger.SetSecretsToMask(secretValues)
+	}
 
-		wg.Wait()
-		cancel()
+	if err := p.schedule(ctx, graph, levels, numWorkers, p.budget()); err != nil {
+		return err
+	}
+
+	logger.Success("Pipeline finished successfully. All jobs completed.")
+	return nil
+}
 
-		if firstError != nil {
-			logger.EndGroup()
-			if firstError == context.Canceled {
-				logger.Error(fmt.Sprintf("Level %d failed and was cancelled.", levelNum))
-				return fmt.Errorf("pipeline failed at level %d", levelNum)
+// runJob executes a job with its retries and reports its lifecycle events.
+func (p *pipeline) runJob(ctx context.Context, node *Node) error {
+	p.mu.Lock()
+	p.started[node.Name] = true
+	p.mu.Unlock()
+
+	jobLogger := p.logger.WithJob(node.Name)
+	opts := runner.Options{Env: envPolicy(p.cfg.Settings, node.Job), Workdir: p.opts.workdir, ProcessEnv: p.opts.processEnv}
+	ctx, span := tracing.Start(ctx, "job "+node.Name, tracing.String("flowcraft.job", node.Name))
+	ctx, done := p.opts.canceller.track(ctx, node.Name)
+	defer done()
+
+	var jobErr error
+	totalAttempts := 1 + node.Job.Retry
+	start := time.Now()
+	attempts := 0
+
+	for attempt := 1; attempt <= totalAttempts; attempt++ {
+		attempts = attempt
+		p.events.emit(Event{Type: EventJobStarted, Job: node.Name, Attempt: attempt})
+		attemptCtx, attemptSpan := tracing.Start(ctx, fmt.Sprintf("attempt %d", attempt),
+			tracing.String("flowcraft.job", node.Name), tracing.Int("flowcraft.attempt", attempt))
+
+		jobEnvs := mergeEnvs(p.cfg.Env, node.Job.Env)
+		for _, secretName := range node.Job.SecretNames() {
+			if val, ok := p.secrets[secretName]; ok {
+				jobEnvs[secretName] = val
 			}
-			logger.Error(fmt.Sprintf("Failed on level %d: %v", levelNum, firstError))
-			return fmt.Errorf("pipeline failed at level %d: %w", levelNum, firstError)
 		}
 
-		if err := ctx.Err(); err != nil {
-			logger.EndGroup()
-			return err
+		// Each attempt starts from the outputs of the jobs done so far.
+		p.mu.Lock()
+		opts.Outputs = runner.NewOutputs(p.outputs)
+		p.mu.Unlock()
+
+		if p.opts.executor != nil {
+			spec := JobSpec{
+				Name:    node.Name,
+				Job:     node.Job,
+				Attempt: attempt,
+				Env:     jobEnvs,
+				Options: opts,
+				Secrets: p.masked,
+				Report: func(summary junit.Summary) {
+					p.events.emit(Event{Type: EventTestReport, Job: node.Name, Attempt: attempt, Tests: &summary})
+				},
+			}
+			if p.opts.deferSecrets {
+				spec.SecretRefs = jobSecrets(p.cfg, node.Job)
+				spec.SecretSettings = p.cfg.Settings
+			}
+			jobErr = p.opts.executor(attemptCtx, spec, jobLogger)
+		} else {
+			jobErr = executeJob(attemptCtx, node.Name, node.Job, jobEnvs, opts, jobLogger, p.events)
+		}
+		if jobErr != nil && cancelled(ctx) {
+			jobErr = ErrJobCancelled
+		}
+		attemptSpan.SetError(jobErr)
+		attemptSpan.End()
+		if jobErr == nil {
+			p.mu.Lock()
+			maps.Copy(p.outputs, opts.Outputs.Values())
+			p.mu.Unlock()
+			break
+		}
+		if isCancellation(jobErr) {
+			// A cancelled job is not retried.
+			break
+		}
+
+		if attempt < totalAttempts {
+			jobLogger.Error(fmt.Sprintf("Job '%s' failed (attempt %d/%d), retrying...", node.Name, attempt, totalAttempts))
+			time.Sleep(3 * time.Second)
 		}
+	}
+
+	duration := time.Since(start)
+	p.opts.metrics.jobFinished(node.Name, statusOf(jobErr), attempts, duration)
+	span.SetAttributes(tracing.Int("flowcraft.attempts", attempts), tracing.String("flowcraft.status", statusOf(jobErr)))
+	span.SetError(jobErr)
+	span.End()
+	p.events.emit(Event{
+		Type:     EventJobFinished,
+		Job:      node.Name,
+		Status:   statusOf(jobErr),
+		Error:    errorString(jobErr),
+		Duration: duration,
+	})
+	return jobErr
+}
 
-		logger.Success(fmt.Sprintf("Level %d/%d completed successfully.", levelNum, len(levels)))
-		logger.EndGroup()
+// recordTests keeps the test reports for the summary of the run.
+func (p *pipeline) recordTests(e Event) {
+	if e.Type != EventTestReport || e.Tests == nil {
+		return
 	}
+	p.mu.Lock()
+	p.tests[e.Job] = *e.Tests
+	p.mu.Unlock()
+}
 
-	logger.Success("Pipeline finished successfully. All jobs completed.")
-	return nil
+// resolveSecrets resolves the secrets needed by the scheduled jobs. They
+// must all resolve, otherwise the run is aborted before any job starts.
+// Only these are resolved: a command helper or a Vault lookup must not
+// run for a job left out by --only.
+func resolveSecrets(ctx context.Context, cfg *config.Config, required map[string]bool) (map[string]string, []string, error) {
+	if err := checkSecretsDeclared(cfg, required); err != nil {
+		return nil, nil, err
+	}
+	declared := make(map[string]config.Secret, len(required))
+	for name := range required {
+		declared[name] = cfg.Secrets[name]
+	}
+	return ResolveSecrets(ctx, cfg.Settings, declared)
 }
 
-func resolveSecrets(cfg *config.Config, logger *runner.Logger) (map[string]string, []string, error) {
+// ResolveSecrets resolves the declared secrets through their providers,
+// as agents do for the secrets of a leased job. It returns the values by
+// name and the values to mask.
+func ResolveSecrets(ctx context.Context, settings config.Settings, declared map[string]config.Secret) (map[string]string, []string, error) {
 	resolved := make(map[string]string)
 	var valuesToMask []string
 
-	for logicalName, secret := range cfg.Secrets {
-		if secret.Provider != "env" {
-			logger.Error(fmt.Sprintf("Secret '%s': provider '%s' is not supported (only 'env' is supported in v0.3.0)", logicalName, secret.Provider))
-			continue
-		}
-		if secret.Key == "" {
-			return nil, nil, fmt.Errorf("secret '%s': 'key' in config cannot be empty", logicalName)
-		}
-		val, exists := os.LookupEnv(secret.Key)
-		if !exists {
-			logger.Error(fmt.Sprintf("Secret '%s': environment variable '%s' is not set", logicalName, secret.Key))
+	resolver, err := secrets.NewResolver(settings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, logicalName := range names {
+		val, err := resolver.Resolve(ctx, logicalName, declared[logicalName])
+		if err != nil {
+			return nil, nil, err
 		}
 
 		resolved[logicalName] = val
@@ -164,6 +395,51 @@ func resolveSecrets(cfg *config.Config, logger *runner.Logger) (map[string]strin
 	return resolved, valuesToMask, nil
 }
 
+func checkSecretsDeclared(cfg *config.Config, required map[string]bool) error {
+	for name := range required {
+		if _, declared := cfg.Secrets[name]; !declared {
+			return fmt.Errorf("secret '%s' is used by a job but not declared in [secrets]", name)
+		}
+	}
+	return nil
+}
+
+// jobSecrets returns the declarations of the secrets used by job.
+func jobSecrets(cfg *config.Config, job config.Job) map[string]config.Secret {
+	declared := make(map[string]config.Secret)
+	for _, name := range job.SecretNames() {
+		declared[name] = cfg.Secrets[name]
+	}
+	return declared
+}
+
+// requiredSecrets returns the secrets referenced by the jobs of the graph.
+func requiredSecrets(graph *Graph) map[string]bool {
+	required := make(map[string]bool)
+	for _, node := range graph.Nodes {
+		for _, name := range node.Job.SecretNames() {
+			required[name] = true
+		}
+	}
+	return required
+}
+
+// envPolicy combines the global and job-level environment settings.
+// A job's inherit_env wins over the global one, and its pass_env
+// extends the global allow-list.
+func envPolicy(settings config.Settings, job config.Job) runner.EnvPolicy {
+	policy := runner.DefaultEnvPolicy()
+	if settings.InheritEnv != nil {
+		policy.Inherit = *settings.InheritEnv
+	}
+	if job.InheritEnv != nil {
+		policy.Inherit = *job.InheritEnv
+	}
+	policy.Pass = append(append([]string{}, settings.PassEnv...), job.PassEnv...)
+	policy.WarnUndeclared = settings.WarnUndeclaredEnv
+	return policy
+}
+
 func mergeEnvs(globalEnv, jobEnv map[string]string) map[string]string {
 	merged := make(map[string]string)
 
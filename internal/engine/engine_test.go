@@ -17,12 +17,20 @@
 package engine
 
 import (
+	"context"
+	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"filippo.io/age"
 	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/gittest"
 	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/Purpose-Dev/flowcraft/internal/secrets"
 )
 
 func TestMergeEnvs(t *testing.T) {
@@ -47,6 +55,29 @@ func TestMergeEnvs(t *testing.T) {
 	}
 }
 
+func TestEnvPolicy(t *testing.T) {
+	hermetic := false
+	inherit := true
+	settings := config.Settings{InheritEnv: &hermetic, PassEnv: []string{"PATH"}}
+
+	policy := envPolicy(settings, config.Job{PassEnv: []string{"GOPATH"}})
+	if policy.Inherit {
+		t.Error("Expected global inherit_env = false to be applied")
+	}
+	if !reflect.DeepEqual(policy.Pass, []string{"PATH", "GOPATH"}) {
+		t.Errorf("Expected pass_env to be merged, got %v", policy.Pass)
+	}
+
+	policy = envPolicy(settings, config.Job{InheritEnv: &inherit})
+	if !policy.Inherit {
+		t.Error("Expected job inherit_env to override the global setting")
+	}
+
+	if !envPolicy(config.Settings{}, config.Job{}).Inherit {
+		t.Error("Expected the host environment to be inherited by default")
+	}
+}
+
 func TestResolveSecrets(t *testing.T) {
 	os.Setenv("MY_TEST_SECRET_KEY", "secret-value-123")
 	os.Setenv("ANOTHER_KEY", "hello")
@@ -70,9 +101,8 @@ func TestResolveSecrets(t *testing.T) {
 		},
 	}
 
-	logger := runner.NewLogger()
-
-	resolved, values, err := resolveSecrets(cfg, logger)
+	required := map[string]bool{"db_pass": true, "api_key": true}
+	resolved, values, err := resolveSecrets(context.Background(), cfg, required)
 	if err != nil {
 		t.Fatalf("resolveSecrets()_test.go returned an unexpected error: %v", err)
 	}
@@ -83,12 +113,217 @@ func TestResolveSecrets(t *testing.T) {
 	if resolved["api_key"] != "hello" {
 		t.Errorf("Expected 'api_key' to be 'hello', got '%s'", resolved["api_key"])
 	}
-	if resolved["missing_secret"] != "" {
-		t.Errorf("Expected 'missing_secret' to be '', got '%s'", resolved["missing_secret"])
+	if _, ok := resolved["missing_secret"]; ok {
+		t.Error("Expected the unused 'missing_secret' not to be resolved")
 	}
 
-	expectedValues := []string{"secret-value-123", "hello"}
+	// Secrets are resolved in name order: api_key, db_pass.
+	expectedValues := []string{"hello", "secret-value-123"}
 	if !reflect.DeepEqual(values, expectedValues) {
 		t.Errorf("Expected secret values %v, got %v", expectedValues, values)
 	}
 }
+
+func TestResolveSecrets_RequiredMissing(t *testing.T) {
+	cfg := &config.Config{
+		Secrets: map[string]config.Secret{
+			"missing_secret": {Provider: "env", Key: "I_DONT_EXIST"},
+		},
+	}
+
+	_, _, err := resolveSecrets(context.Background(), cfg, map[string]bool{"missing_secret": true})
+	if err == nil {
+		t.Fatal("Expected an error for a required secret that cannot be resolved, got nil")
+	}
+	if !strings.Contains(err.Error(), "I_DONT_EXIST") {
+		t.Errorf("Expected error to mention the variable name, got: %v", err)
+	}
+}
+
+func TestResolveSecrets_Undeclared(t *testing.T) {
+	cfg := &config.Config{Secrets: map[string]config.Secret{}}
+
+	_, _, err := resolveSecrets(context.Background(), cfg, map[string]bool{"ghost": true})
+	if err == nil || !strings.Contains(err.Error(), "not declared") {
+		t.Errorf("Expected an error about an undeclared secret, got: %v", err)
+	}
+}
+
+func TestResolveSecrets_MasksOnlyResolvedValues(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+	t.Setenv(secrets.DefaultIdentityEnv, identity.String())
+	ciphertext, err := secrets.Encrypt([]byte("port = 5432\nenabled = true\n[db]\npassword = \"db-pass\"\nuser = \"app\"\n"), []age.Recipient{identity.Recipient()})
+	if err != nil {
+		t.Fatalf("Encrypt() returned an unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "secrets.toml.age")
+	if err := os.WriteFile(path, ciphertext, 0o644); err != nil {
+		t.Fatalf("Failed to write encrypted file: %v", err)
+	}
+	cfg := &config.Config{
+		Secrets: map[string]config.Secret{
+			"db_pass": {Provider: "encrypted_file", Path: path, Key: "db.password"},
+		},
+	}
+
+	_, values, err := resolveSecrets(context.Background(), cfg, map[string]bool{"db_pass": true})
+	if err != nil {
+		t.Fatalf("resolveSecrets() returned an unexpected error: %v", err)
+	}
+	if expected := []string{"db-pass"}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected only %v to be masked, got %v", expected, values)
+	}
+}
+
+func TestRun_DeferredSecrets(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	cfg := &config.Config{
+		Secrets: map[string]config.Secret{
+			"token": {Provider: "command", Command: "touch " + marker + " && echo value"},
+		},
+		Jobs: map[string]config.Job{
+			"deploy": {Secrets: []string{"token"}, Steps: []config.Step{{Name: "Deploy", Cmd: "true"}}},
+		},
+	}
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+
+	var got JobSpec
+	executor := func(_ context.Context, spec JobSpec, _ *runner.Logger) error {
+		got = spec
+		return nil
+	}
+	if err := Run(context.Background(), cfg, graph, logger, WithExecutor(executor), WithDeferredSecrets()); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Expected the helper not to run when secrets are deferred")
+	}
+	if _, ok := got.Env["token"]; ok {
+		t.Error("Expected the job's environment not to hold the secret")
+	}
+	if got.SecretRefs["token"].Command != cfg.Secrets["token"].Command {
+		t.Errorf("Expected the executor to get the declaration of the secret, got %+v", got.SecretRefs)
+	}
+}
+
+func TestResolveSecrets_SkipsUnused(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	cfg := &config.Config{
+		Secrets: map[string]config.Secret{
+			"unused": {Provider: "command", Command: "touch " + marker + " && echo value"},
+		},
+	}
+
+	if _, _, err := resolveSecrets(context.Background(), cfg, map[string]bool{}); err != nil {
+		t.Fatalf("resolveSecrets() returned an unexpected error: %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Expected the helper of an unused secret not to run")
+	}
+}
+
+func TestRun_OutputsReachLaterJobs(t *testing.T) {
+	repo, commits := gittest.BareRepo(t)
+	sha := commits[1]
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{
+			"checkout": {Steps: []config.Step{{Name: "Checkout", Checkout: &config.Checkout{Repository: repo, Path: "app"}}}},
+			"build": {
+				DependsOn: []string{"checkout"},
+				Steps:     []config.Step{{Name: "Build", Cmd: `test "${git.sha}" = ` + sha + ` && test "$GIT_BRANCH" = main`}},
+			},
+		},
+	}
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+
+	if err := Run(context.Background(), cfg, graph, logger, WithWorkdir(t.TempDir())); err != nil {
+		t.Errorf("Expected 'build' to see the outputs of the checkout, got: %v", err)
+	}
+}
+
+func TestRun_WithOutputs(t *testing.T) {
+	repo, commits := gittest.BareRepo(t)
+	sha := commits[1]
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{
+			"checkout": {Steps: []config.Step{{Name: "Checkout", Checkout: &config.Checkout{Repository: repo, Path: "app"}}}},
+			"build": {
+				DependsOn: []string{"checkout"},
+				Steps:     []config.Step{{Name: "Build", Cmd: `test "${git.sha}" = ` + sha}},
+			},
+		},
+	}
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+	dir := t.TempDir()
+
+	outputs := make(map[string]string)
+	if err := Run(context.Background(), cfg, graph.Subgraph([]string{"checkout"}), logger, WithWorkdir(dir), WithOutputs(outputs)); err != nil {
+		t.Fatalf("Run() of the checkout returned an unexpected error: %v", err)
+	}
+	if outputs["git.sha"] != sha {
+		t.Fatalf("Expected the outputs of the run in the map, got %v", outputs)
+	}
+	if err := Run(context.Background(), cfg, graph.Subgraph([]string{"build"}), logger, WithWorkdir(dir), WithOutputs(outputs)); err != nil {
+		t.Errorf("Expected 'build' to see the outputs of the earlier run, got: %v", err)
+	}
+}
+
+func TestExecuteJob_Timeouts(t *testing.T) {
+	tests := []struct {
+		name string
+		job  config.Job
+		want string
+	}{
+		{
+			name: "step",
+			job:  config.Job{Steps: []config.Step{{Name: "Slow", Cmd: "sleep 5", Timeout: "100ms"}}},
+			want: "step 'Slow' timed out after 100ms",
+		},
+		{
+			name: "job",
+			job:  config.Job{Timeout: "100ms", Steps: []config.Step{{Name: "Quick", Cmd: "true"}, {Name: "Slow", Cmd: "sleep 5"}}},
+			want: "job 'build' timed out after 100ms",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := runner.NewLogger()
+			logger.SetOutput(io.Discard)
+			var status string
+			listener := func(e Event) {
+				if e.Type == EventStepFinished && e.Step == "Slow" {
+					status = e.Status
+				}
+			}
+			start := time.Now()
+			err := ExecuteJob(context.Background(), "build", tt.job, nil, runner.Options{Workdir: t.TempDir()}, logger, listener)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Expected %q, got %v", tt.want, err)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Expected the job to stop at its timeout, took %s", elapsed)
+			}
+			if status != StatusFailed {
+				t.Errorf("Expected the slow step to fail, got %q", status)
+			}
+		})
+	}
+}
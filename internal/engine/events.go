@@ -0,0 +1,115 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/junit"
+)
+
+// EventType identifies a pipeline lifecycle event.
+type EventType string
+
+const (
+	EventRunStarted        EventType = "run_started"
+	EventRunFinished       EventType = "run_finished"
+	EventJobWaiting        EventType = "job_waiting"
+	EventApprovalRequested EventType = "approval_requested"
+	EventApprovalResolved  EventType = "approval_resolved"
+	EventJobStarted        EventType = "job_started"
+	EventJobFinished       EventType = "job_finished"
+	EventJobSkipped        EventType = "job_skipped"
+	EventStepStarted       EventType = "step_started"
+	EventStepFinished      EventType = "step_finished"
+	// EventTestReport carries the outcome of the test reports of a job.
+	EventTestReport EventType = "test_report"
+)
+
+// Status values carried by finished events.
+const (
+	StatusSuccess   = "success"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+	StatusSkipped   = "skipped"
+)
+
+// Status values carried by approval_resolved events.
+const (
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Event describes a change in the state of a run, a job or a step.
+type Event struct {
+	Type     EventType     `json:"type"`
+	Time     time.Time     `json:"time"`
+	Job      string        `json:"job,omitempty"`
+	Step     string        `json:"step,omitempty"`
+	Attempt  int           `json:"attempt,omitempty"`
+	Status   string        `json:"status,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Message  string        `json:"message,omitempty"`
+	// ExitCode is the exit code of a finished step's process.
+	ExitCode int `json:"exit_code,omitempty"`
+	// SpanID identifies the span of a finished step when it is traced.
+	SpanID string `json:"span_id,omitempty"`
+	// Tests summarizes the JUnit reports of a job in test_report events.
+	Tests *junit.Summary `json:"tests,omitempty"`
+}
+
+// Listener is called synchronously for every event of a run.
+// It must not block for long, as it runs on the scheduler's goroutines.
+type Listener func(Event)
+
+// emitter fans events out to the listeners of a run.
+type emitter []Listener
+
+func (em emitter) emit(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	for _, l := range em {
+		l(e)
+	}
+}
+
+// statusOf maps a job or step error to a finished status.
+func statusOf(err error) string {
+	switch {
+	case err == nil:
+		return StatusSuccess
+	case isCancellation(err):
+		return StatusCancelled
+	default:
+		return StatusFailed
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func isCancellation(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
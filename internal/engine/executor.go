@@ -18,23 +18,53 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"os/exec"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/junit"
 	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/Purpose-Dev/flowcraft/internal/tracing"
 )
 
-// executeJob runs all steps for a single job.
+// ExecuteJob runs the steps of a single job outside of a pipeline, as
+// agents do for the jobs leased to them. Step events are reported to
+// the listeners.
+func ExecuteJob(ctx context.Context, jobName string, job config.Job, envVars map[string]string, opts runner.Options, logger *runner.Logger, listeners ...Listener) error {
+	return executeJob(ctx, jobName, job, envVars, opts, logger, emitter(listeners))
+}
+
+// executeJob runs all steps for a single job, then reads its test
+// reports, whether the steps succeeded or not.
 // It acts as a "micro-orchestrator" for a job.
-func executeJob(ctx context.Context, jobName string, job config.Job, envVars map[string]string, logger *runner.Logger) error {
+func executeJob(ctx context.Context, jobName string, job config.Job, envVars map[string]string, opts runner.Options, logger *runner.Logger, events emitter) error {
 	logger.StartGroup(fmt.Sprintf("Job: %s", jobName))
 	defer logger.EndGroup()
 
+	timeout, _ := job.TimeoutDuration()
+	stepsCtx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	err := executeSteps(stepsCtx, jobName, job, envVars, opts, logger, events)
+	if err != nil && timedOut(stepsCtx) {
+		err = fmt.Errorf("job '%s' timed out after %s", jobName, timeout)
+		logger.Error(err.Error())
+	}
+	if len(job.Reports) > 0 && !isCancellation(err) {
+		collectReports(jobName, job.Reports, opts.Workdir, logger, events)
+	}
+	return err
+}
+
+func executeSteps(ctx context.Context, jobName string, job config.Job, envVars map[string]string, opts runner.Options, logger *runner.Logger, events emitter) error {
 	if len(job.Steps) > 0 {
 		logger.Info(fmt.Sprintf("Starting %d sequential steps for '%s'", len(job.Steps), jobName))
 		for _, step := range job.Steps {
-			if err := runner.Execute(ctx, step, envVars, logger); err != nil {
+			if err := executeStep(ctx, jobName, step, envVars, opts, logger, events); err != nil {
 				return fmt.Errorf("sequential step '%s' in job '%s' failed: %w", step.Name, jobName, err)
 			}
 			if err := ctx.Err(); err != nil {
@@ -60,7 +90,7 @@ func executeJob(ctx context.Context, jobName string, job config.Job, envVars map
 			go func(s config.Step) {
 				defer wg.Done()
 
-				err := runner.Execute(jobCtx, s, envVars, logger)
+				err := executeStep(jobCtx, jobName, s, envVars, opts, logger, events)
 				if err != nil {
 					errMutex.Lock()
 					if firstError == nil {
@@ -88,3 +118,103 @@ func executeJob(ctx context.Context, jobName string, job config.Job, envVars map
 	logger.Success(fmt.Sprintf("Job '%s' finished successfully.", jobName))
 	return nil
 }
+
+// collectReports reads the JUnit reports of a job from dir, logs their
+// outcome and reports it in a test_report event. Unreadable reports
+// are only warned about: they do not fail the job.
+func collectReports(jobName string, patterns []string, dir string, logger *runner.Logger, events emitter) {
+	summary, err := junit.Collect(dir, patterns)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Failed to read the test reports of job '%s': %v", jobName, err))
+	}
+	if summary.Reports == 0 {
+		logger.Warn(fmt.Sprintf("No test report of job '%s' matched %s.", jobName, strings.Join(patterns, ", ")))
+		return
+	}
+
+	line := fmt.Sprintf("Tests of job '%s': %s (%d report(s)).", jobName, summary, summary.Reports)
+	if summary.Failed > 0 {
+		logger.Error(line)
+		for _, name := range summary.Failures {
+			logger.Error(fmt.Sprintf("  FAIL %s", name))
+		}
+		if hidden := summary.Failed - len(summary.Failures); hidden > 0 {
+			logger.Error(fmt.Sprintf("  ... and %d more", hidden))
+		}
+	} else {
+		logger.Success(line)
+	}
+	events.emit(Event{Type: EventTestReport, Job: jobName, Tests: &summary})
+}
+
+// executeStep runs a single step and reports its lifecycle events.
+// When the job is traced, the step gets a span whose traceparent is
+// passed to the process in $TRACEPARENT.
+func executeStep(ctx context.Context, jobName string, step config.Step, envVars map[string]string, opts runner.Options, logger *runner.Logger, events emitter) error {
+	events.emit(Event{Type: EventStepStarted, Job: jobName, Step: step.Name})
+	start := time.Now()
+
+	ctx, span := tracing.Start(ctx, "step "+step.Name,
+		tracing.String("flowcraft.job", jobName), tracing.String("flowcraft.step", step.Name))
+	if traceParent := span.Context().TraceParent(); traceParent != "" {
+		envVars = maps.Clone(envVars)
+		envVars[tracing.TraceParentEnv] = traceParent
+	}
+
+	timeout, _ := step.TimeoutDuration()
+	stepCtx, cancel := withTimeout(ctx, timeout)
+	err := runner.Execute(stepCtx, step, envVars, opts, logger)
+	if err != nil && timedOut(stepCtx) && !timedOut(ctx) {
+		err = fmt.Errorf("step '%s' timed out after %s", step.Name, timeout)
+		logger.Error(err.Error())
+	}
+	cancel()
+
+	code, exited := exitCode(err)
+	if exited {
+		span.SetAttributes(tracing.Int("process.exit.code", code))
+	}
+	span.SetError(err)
+	span.End()
+
+	finished := Event{
+		Type:     EventStepFinished,
+		Job:      jobName,
+		Step:     step.Name,
+		Status:   statusOf(err),
+		Error:    errorString(err),
+		Duration: time.Since(start),
+		ExitCode: code,
+	}
+	if sc := span.Context(); sc.IsValid() {
+		finished.SpanID = sc.SpanID.String()
+	}
+	events.emit(finished)
+	return err
+}
+
+// withTimeout returns ctx as it is when timeout is 0.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timedOut reports whether the deadline of ctx passed.
+func timedOut(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+// exitCode returns the exit code of a step process, and false when the
+// step failed without exiting, e.g. because it could not start.
+func exitCode(err error) (int, bool) {
+	if err == nil {
+		return 0, true
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/junit"
+)
+
+// JUnitReport builds a JUnit report of a run from its events: a test
+// suite per job, with a test case per step of its last attempt. Jobs
+// that ran no step, such as skipped jobs, get a single test case named
+// after the job.
+type JUnitReport struct {
+	mu       sync.Mutex
+	started  time.Time
+	duration time.Duration
+	suites   map[string]*junit.Testsuite
+}
+
+func NewJUnitReport() *JUnitReport {
+	return &JUnitReport{suites: make(map[string]*junit.Testsuite)}
+}
+
+// Observe is a Listener recording the results of the jobs and steps.
+func (r *JUnitReport) Observe(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch e.Type {
+	case EventRunStarted:
+		r.started = e.Time
+	case EventRunFinished:
+		r.duration = e.Time.Sub(r.started)
+	case EventJobStarted:
+		// Only the last attempt is reported.
+		suite := r.suite(e.Job)
+		suite.Cases = nil
+		suite.Timestamp = e.Time.Format(time.RFC3339)
+	case EventStepFinished:
+		suite := r.suite(e.Job)
+		suite.Cases = append(suite.Cases, testcase(e.Job, e.Step, e.Status, e.Error, e.ExitCode, e.Duration))
+	case EventJobFinished, EventJobSkipped:
+		suite := r.suite(e.Job)
+		suite.Time = junit.Seconds(e.Duration.Seconds())
+		if len(suite.Cases) == 0 {
+			suite.Cases = append(suite.Cases, testcase(e.Job, e.Job, e.Status, e.Error, 0, e.Duration))
+		}
+	}
+}
+
+func (r *JUnitReport) suite(job string) *junit.Testsuite {
+	if r.suites[job] == nil {
+		r.suites[job] = &junit.Testsuite{Name: job}
+	}
+	return r.suites[job]
+}
+
+// testcase maps the outcome of a step, or of a job without steps, to a
+// test case. Cancelled and skipped ones are reported as skipped.
+func testcase(job, name, status, errMsg string, exitCode int, d time.Duration) junit.Testcase {
+	c := junit.Testcase{Name: name, Classname: job, Time: junit.Seconds(d.Seconds())}
+	switch status {
+	case StatusFailed:
+		c.Failure = &junit.Result{Message: errMsg, Type: StatusFailed}
+		if exitCode != 0 {
+			c.Failure.Text = fmt.Sprintf("exit code %d", exitCode)
+		}
+	case StatusCancelled, StatusSkipped:
+		message := errMsg
+		if message == "" {
+			message = status
+		}
+		c.Skipped = &junit.Result{Message: message}
+	}
+	return c
+}
+
+// Testsuites returns the report of the run, with the suites sorted by
+// job name.
+func (r *JUnitReport) Testsuites() junit.Testsuites {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := junit.Testsuites{Name: "flowcraft", Time: junit.Seconds(r.duration.Seconds())}
+	for _, suite := range r.suites {
+		s := *suite
+		s.Cases = append([]junit.Testcase(nil), suite.Cases...)
+		report.Suites = append(report.Suites, s)
+	}
+	sort.Slice(report.Suites, func(i, j int) bool {
+		return report.Suites[i].Name < report.Suites[j].Name
+	})
+	return report
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/junit"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+const failingReport = `<testsuite name="unit">
+<testcase classname="calc" name="TestAdd"/>
+<testcase classname="calc" name="TestDiv"><failure message="division by zero"/></testcase>
+<testcase classname="calc" name="TestMod"><skipped/></testcase>
+</testsuite>`
+
+func TestRun_TestReports(t *testing.T) {
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{
+			"test": {
+				Steps: []config.Step{
+					{Name: "Unit", Cmd: "mkdir -p out && printf '%s' '" + failingReport + "' > out/junit.xml && exit 1"},
+				},
+				Reports: []string{"**/junit.xml"},
+			},
+			"lint": {
+				Steps:   []config.Step{{Name: "Vet", Cmd: "true"}},
+				Reports: []string{"lint.xml"},
+			},
+		},
+	}
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	var b strings.Builder
+	logger := runner.NewLogger()
+	logger.SetOutput(&b)
+
+	var mu sync.Mutex
+	var reports []Event
+	listener := func(e Event) {
+		if e.Type == EventTestReport {
+			mu.Lock()
+			reports = append(reports, e)
+			mu.Unlock()
+		}
+	}
+	if err := Run(context.Background(), cfg, graph, logger, WithWorkdir(t.TempDir()), WithListener(listener)); err == nil {
+		t.Fatal("Run() returned no error for a failing job")
+	}
+
+	if len(reports) != 1 || reports[0].Job != "test" {
+		t.Fatalf("Expected a single test_report event of 'test', got %+v", reports)
+	}
+	got := reports[0].Tests
+	if got.Tests != 3 || got.Passed != 1 || got.Failed != 1 || got.Skipped != 1 || len(got.Failures) != 1 || got.Failures[0] != "calc.TestDiv" {
+		t.Errorf("Unexpected summary %+v", got)
+	}
+	for _, want := range []string{
+		"Tests of job 'test': 1 passed, 1 failed, 1 skipped (1 report(s)).",
+		"No test report of job 'lint' matched lint.xml.",
+		"Test report",
+		"FAIL test: calc.TestDiv",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected %q in the output, got:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestRun_TestReportsFromExecutor(t *testing.T) {
+	cfg := &config.Config{Jobs: map[string]config.Job{"test": {Steps: []config.Step{{Name: "Unit", Cmd: "true"}}}}}
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+
+	executor := func(_ context.Context, spec JobSpec, _ *runner.Logger) error {
+		spec.Report(junit.Summary{Reports: 1, Tests: 2, Passed: 2})
+		return nil
+	}
+	var got []Event
+	listener := func(e Event) {
+		if e.Type == EventTestReport {
+			got = append(got, e)
+		}
+	}
+	if err := Run(context.Background(), cfg, graph, logger, WithExecutor(executor), WithListener(listener)); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Attempt != 1 || got[0].Tests.Passed != 2 {
+		t.Errorf("Expected the executor's test report, got %+v", got)
+	}
+}
+
+func TestJUnitReport(t *testing.T) {
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{
+			"build": {
+				Steps:    []config.Step{{Name: "Compile", Cmd: "true"}},
+				Parallel: []config.Step{{Name: "Vet", Cmd: "true"}, {Name: "Unit", Cmd: "exit 2"}},
+			},
+			"deploy": {
+				DependsOn: []string{"build"},
+				Steps:     []config.Step{{Name: "Ship", Cmd: "true"}},
+			},
+		},
+	}
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+
+	report := NewJUnitReport()
+	_ = Run(context.Background(), cfg, graph, logger, WithWorkdir(t.TempDir()), WithListener(report.Observe))
+
+	suites := report.Testsuites()
+	if len(suites.Suites) != 2 || suites.Suites[0].Name != "build" || suites.Suites[1].Name != "deploy" {
+		t.Fatalf("Expected the suites 'build' and 'deploy', got %+v", suites.Suites)
+	}
+	cases := make(map[string]junit.Testcase)
+	for _, c := range suites.Suites[0].Cases {
+		cases[c.Name] = c
+	}
+	if len(cases) != 3 || cases["Compile"].Failed() || cases["Unit"].Failure == nil || cases["Unit"].Failure.Text != "exit code 2" {
+		t.Errorf("Unexpected cases of 'build': %+v", suites.Suites[0].Cases)
+	}
+	deploy := suites.Suites[1].Cases
+	if len(deploy) != 1 || deploy[0].Name != "deploy" || deploy[0].Skipped == nil {
+		t.Errorf("Expected 'deploy' to be skipped, got %+v", deploy)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import "slices"
+
+// lockTable tracks the jobs holding each named lock. A lock admits as
+// many holders as its semaphore limit, one by default.
+type lockTable struct {
+	limits  map[string]int
+	holders map[string][]string
+}
+
+func newLockTable(limits map[string]int) *lockTable {
+	return &lockTable{limits: limits, holders: make(map[string][]string)}
+}
+
+func (t *lockTable) limit(name string) int {
+	if limit, ok := t.limits[name]; ok {
+		return limit
+	}
+	return 1
+}
+
+// blocked returns the first of the locks that is full, and its holders.
+func (t *lockTable) blocked(names []string) (string, []string) {
+	for _, name := range names {
+		if len(t.holders[name]) >= t.limit(name) {
+			return name, t.holders[name]
+		}
+	}
+	return "", nil
+}
+
+// acquire takes all the locks at once, so that jobs never hold some
+// locks while waiting for others.
+func (t *lockTable) acquire(job string, names []string) {
+	for _, name := range names {
+		if !slices.Contains(t.holders[name], job) {
+			t.holders[name] = append(t.holders[name], job)
+		}
+	}
+}
+
+func (t *lockTable) release(job string, names []string) {
+	for _, name := range names {
+		t.holders[name] = slices.DeleteFunc(t.holders[name], func(holder string) bool {
+			return holder == job
+		})
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/metrics"
+)
+
+// Metrics records the jobs and steps of runs. A single Metrics may be
+// shared by concurrent runs, as the server does.
+type Metrics struct {
+	runs          *metrics.Counter
+	jobDuration   *metrics.Histogram
+	stepDuration  *metrics.Histogram
+	retries       *metrics.Counter
+	queueWait     *metrics.Histogram
+	activeWorkers *metrics.Gauge
+}
+
+// NewMetrics registers the metrics of the engine in reg.
+func NewMetrics(reg *metrics.Registry) *Metrics {
+	return &Metrics{
+		runs: reg.Counter("flowcraft_runs_total",
+			"Pipeline runs by final status.", "status"),
+		jobDuration: reg.Histogram("flowcraft_job_duration_seconds",
+			"Duration of the jobs, retries included, by job and final status.", metrics.DurationBuckets, "job", "status"),
+		stepDuration: reg.Histogram("flowcraft_step_duration_seconds",
+			"Duration of the steps, by job, step and status.", metrics.DurationBuckets, "job", "step", "status"),
+		retries: reg.Counter("flowcraft_job_retries_total",
+			"Attempts of the jobs beyond their first one.", "job"),
+		queueWait: reg.Histogram("flowcraft_job_queue_wait_seconds",
+			"Time the jobs spent ready to run, waiting for a worker, the weight budget or a lock.", metrics.DurationBuckets, "job"),
+		activeWorkers: reg.Gauge("flowcraft_active_workers",
+			"Workers currently running a job."),
+	}
+}
+
+// WithMetrics records the run in m.
+func WithMetrics(m *Metrics) Option {
+	return func(o *runOptions) {
+		o.metrics = m
+	}
+}
+
+// Observe records the events carrying a metric: finished steps and
+// runs. The server also feeds it the step events reported by agents.
+func (m *Metrics) Observe(e Event) {
+	if m == nil {
+		return
+	}
+	switch e.Type {
+	case EventStepFinished:
+		m.stepDuration.Observe(e.Duration.Seconds(), e.Job, e.Step, e.Status)
+	case EventRunFinished:
+		m.runs.Inc(e.Status)
+	}
+}
+
+// jobStarted records that a job took a worker after waiting in the
+// ready queue for wait.
+func (m *Metrics) jobStarted(job string, wait time.Duration) {
+	if m == nil {
+		return
+	}
+	m.queueWait.Observe(wait.Seconds(), job)
+	m.activeWorkers.Add(1)
+}
+
+// jobFinished records a job that ran attempts times and released its
+// worker.
+func (m *Metrics) jobFinished(job, status string, attempts int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.activeWorkers.Add(-1)
+	m.jobDuration.Observe(duration.Seconds(), job, status)
+	if attempts > 1 {
+		m.retries.Add(float64(attempts-1), job)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/metrics"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+func TestRun_Metrics(t *testing.T) {
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{
+			"build": {Steps: []config.Step{{Name: "Compile", Cmd: "true"}}},
+			"test": {
+				DependsOn: []string{"build"},
+				Steps:     []config.Step{{Name: "Unit", Cmd: "false"}},
+			},
+		},
+	}
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+
+	reg := metrics.NewRegistry()
+	_ = Run(context.Background(), cfg, graph, logger, WithWorkdir(t.TempDir()), WithMetrics(NewMetrics(reg)))
+
+	var b strings.Builder
+	if err := reg.WriteText(&b); err != nil {
+		t.Fatalf("WriteText() returned an unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		`flowcraft_runs_total{status="failed"} 1`,
+		`flowcraft_job_duration_seconds_count{job="build",status="success"} 1`,
+		`flowcraft_job_duration_seconds_count{job="test",status="failed"} 1`,
+		`flowcraft_step_duration_seconds_count{job="build",step="Compile",status="success"} 1`,
+		`flowcraft_step_duration_seconds_count{job="test",step="Unit",status="failed"} 1`,
+		`flowcraft_job_queue_wait_seconds_count{job="test"} 1`,
+		`flowcraft_active_workers 0`,
+	} {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("Expected %q in the metrics, got:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestMetrics_Retries(t *testing.T) {
+	reg := metrics.NewRegistry()
+	m := NewMetrics(reg)
+	m.jobStarted("flaky", time.Second)
+	m.jobFinished("flaky", StatusSuccess, 3, time.Minute)
+
+	var b strings.Builder
+	_ = reg.WriteText(&b)
+	if !strings.Contains(b.String(), `flowcraft_job_retries_total{job="flaky"} 2`+"\n") {
+		t.Errorf("Expected 2 retries of 'flaky', got:\n%s", b.String())
+	}
+
+	// A run without metrics uses a nil *Metrics.
+	var none *Metrics
+	none.jobStarted("job", 0)
+	none.jobFinished("job", StatusFailed, 1, 0)
+	none.Observe(Event{Type: EventRunFinished})
+}
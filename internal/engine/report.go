@@ -0,0 +1,189 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/junit"
+)
+
+// jobTiming records when a job became ready, how long it waited for
+// locks and how long it ran.
+type jobTiming struct {
+	name     string
+	ready    time.Time
+	started  time.Time
+	finished time.Time
+	status   string
+	// blockedAt is when the job first found one of its locks full.
+	blockedAt time.Time
+	lockWait  time.Duration
+	waitedFor []string
+}
+
+// timings collects the jobTiming of every job of a run. It is only
+// used by the scheduler's goroutine.
+type timings map[string]*jobTiming
+
+func (t timings) get(name string) *jobTiming {
+	if t[name] == nil {
+		t[name] = &jobTiming{name: name}
+	}
+	return t[name]
+}
+
+func (t timings) ready(name string, now time.Time) {
+	t.get(name).ready = now
+}
+
+// blocked records that a ready job waits for lock. It reports whether
+// this is the first time the job waits for it.
+func (t timings) blocked(name, lock string, now time.Time) bool {
+	jt := t.get(name)
+	if jt.blockedAt.IsZero() {
+		jt.blockedAt = now
+	}
+	for _, l := range jt.waitedFor {
+		if l == lock {
+			return false
+		}
+	}
+	jt.waitedFor = append(jt.waitedFor, lock)
+	return true
+}
+
+func (t timings) started(name string, now time.Time) {
+	jt := t.get(name)
+	jt.started = now
+	if !jt.blockedAt.IsZero() {
+		jt.lockWait = now.Sub(jt.blockedAt)
+	}
+}
+
+func (t timings) finished(name, status string, now time.Time) {
+	jt := t.get(name)
+	jt.finished = now
+	jt.status = status
+}
+
+// report writes a table of the jobs, in the order they started, with
+// the time they spent queued, waiting for locks and running.
+func (t timings) report(graph *Graph) []string {
+	jobs := make([]*jobTiming, 0, len(graph.Nodes))
+	for name := range graph.Nodes {
+		jt := t.get(name)
+		if jt.status == "" {
+			jt.status = StatusSkipped
+		}
+		jobs = append(jobs, jt)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		a, b := jobs[i], jobs[j]
+		if a.started.IsZero() != b.started.IsZero() {
+			return !a.started.IsZero()
+		}
+		if !a.started.Equal(b.started) {
+			return a.started.Before(b.started)
+		}
+		return a.name < b.name
+	})
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tSTATUS\tQUEUED\tLOCK WAIT\tDURATION")
+	for _, jt := range jobs {
+		queued, wait, duration := "-", "-", "-"
+		if !jt.started.IsZero() {
+			queued = formatDuration(jt.started.Sub(jt.ready) - jt.lockWait)
+			duration = formatDuration(jt.finished.Sub(jt.started))
+		}
+		if len(jt.waitedFor) > 0 {
+			held := "-"
+			if !jt.started.IsZero() {
+				held = formatDuration(jt.lockWait)
+			}
+			wait = fmt.Sprintf("%s (%s)", held, strings.Join(jt.waitedFor, ", "))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", jt.name, jt.status, queued, wait, duration)
+	}
+	w.Flush()
+
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
+
+// logTestReport logs a table of the test reports of the jobs, followed
+// by the names of the failed tests, when any job has reports.
+func (p *pipeline) logTestReport() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.tests) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(p.tests))
+	var total junit.Summary
+	for name, summary := range p.tests {
+		names = append(names, name)
+		total.Add(summary)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tTESTS\tPASSED\tFAILED\tSKIPPED")
+	for _, name := range names {
+		s := p.tests[name]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", name, s.Tests, s.Passed, s.Failed, s.Skipped)
+	}
+	if len(names) > 1 {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", "TOTAL", total.Tests, total.Passed, total.Failed, total.Skipped)
+	}
+	w.Flush()
+
+	p.logger.StartGroup("Test report")
+	defer p.logger.EndGroup()
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		p.logger.Info(line)
+	}
+	for _, name := range names {
+		s := p.tests[name]
+		for _, test := range s.Failures {
+			p.logger.Error(fmt.Sprintf("FAIL %s: %s", name, test))
+		}
+		if hidden := s.Failed - len(s.Failures); hidden > 0 {
+			p.logger.Error(fmt.Sprintf("FAIL %s: ... and %d more", name, hidden))
+		}
+	}
+}
+
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(100 * time.Millisecond).String()
+	}
+}
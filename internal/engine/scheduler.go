@@ -0,0 +1,295 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+// JobWeight is the share of the weight budget a job takes while it
+// runs: its weight, else its CPU request, else 1.
+func JobWeight(job config.Job) float64 {
+	switch {
+	case job.Weight > 0:
+		return job.Weight
+	case job.Resources.CPU > 0:
+		return job.Resources.CPU
+	default:
+		return 1
+	}
+}
+
+// budget returns the total weight of the jobs allowed to run at once:
+// settings.weight_budget, else the number of CPUs. Jobs dispatched to
+// agents are placed by their resources instead, so the budget only
+// applies to local execution.
+func (p *pipeline) budget() float64 {
+	if p.opts.executor != nil {
+		return math.Inf(1)
+	}
+	if budget := p.cfg.Settings.WeightBudget; budget > 0 {
+		return budget
+	}
+	return float64(runtime.NumCPU())
+}
+
+type jobResult struct {
+	node *Node
+	err  error
+}
+
+// schedule runs the levels of the graph in order: the jobs of a level
+// start once every job of the previous levels finished, while at most
+// workers jobs run at once, their total weight stays within budget and
+// their locks are free. Ready jobs start in order, and one that does not
+// fit in the budget holds back the jobs after it until it does, so that
+// lighter jobs cannot starve it. A job heavier than the whole budget runs
+// alone. A job with an approval gate waits for its approval outside of
+// the levels: the next levels don't wait for it nor for its dependents,
+// which run as soon as their dependencies succeeded. The first failure,
+// or a rejected gate, cancels the running jobs and stops scheduling new
+// ones, while a job cancelled by a JobCanceller only keeps its dependents
+// from running. A timing report, and the test report of the jobs that
+// have one, are logged at the end.
+func (p *pipeline) schedule(ctx context.Context, graph *Graph, levels [][]*Node, workers int, budget float64) error {
+	schedCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	times := make(timings, len(graph.Nodes))
+	locks := newLockTable(p.cfg.Settings.Semaphores)
+	start := time.Now()
+
+	results := make(chan jobResult)
+	approvals := make(chan approvalResult)
+	running := 0
+	gated := 0
+	used := 0.0
+	var failed *jobResult
+	var cancelled []string
+
+	levelOf := make(map[string]int, len(graph.Nodes))
+	remaining := make([]int, len(levels))
+	for i, level := range levels {
+		for _, node := range level {
+			levelOf[node.Name] = i
+		}
+		remaining[i] = len(level)
+	}
+	// released holds the jobs the levels no longer wait for: the jobs
+	// that finished, the branches behind an approval gate and the
+	// dependents of a cancelled job.
+	released := make(map[string]bool, len(graph.Nodes))
+	release := func(node *Node) {
+		if !released[node.Name] {
+			released[node.Name] = true
+			remaining[levelOf[node.Name]]--
+		}
+	}
+	var releaseBranch func(node *Node)
+	releaseBranch = func(node *Node) {
+		release(node)
+		for _, dependent := range node.Dependents {
+			releaseBranch(dependent)
+		}
+	}
+	current := -1
+	// advance starts the next levels once the current one is done.
+	advance := func() {
+		for failed == nil && schedCtx.Err() == nil && current < len(levels) && (current < 0 || remaining[current] == 0) {
+			if current >= 0 {
+				p.logger.Success(fmt.Sprintf("Level %d/%d completed successfully.", current+1, len(levels)))
+				p.logger.EndGroup()
+			}
+			current++
+			if current < len(levels) {
+				p.logger.StartGroup(fmt.Sprintf("Level %d/%d (Executing %d job(s) in parallel)", current+1, len(levels), len(levels[current])))
+			}
+		}
+	}
+
+	var ready []*Node
+	// enqueue makes node ready to run, after its approval gate if it has
+	// one. Gates are held outside the worker and weight limits, and the
+	// levels stop waiting for their branch, so that the rest of the
+	// pipeline keeps running while they wait.
+	enqueue := func(node *Node, now time.Time) {
+		if node.Job.Approve {
+			releaseBranch(node)
+			gated++
+			go func() {
+				approvals <- approvalResult{node: node, err: p.approve(schedCtx, node)}
+			}()
+			return
+		}
+		ready = append(ready, node)
+		times.ready(node.Name, now)
+	}
+
+	waiting := make(map[string]int, len(graph.Nodes))
+	var roots []*Node
+	for name, node := range graph.Nodes {
+		waiting[name] = len(node.Dependencies)
+		if len(node.Dependencies) == 0 {
+			roots = append(roots, node)
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Name < roots[j].Name })
+	for _, node := range roots {
+		enqueue(node, start)
+	}
+
+	for {
+		advance()
+		for i := 0; failed == nil && schedCtx.Err() == nil && running < workers && i < len(ready); {
+			node := ready[i]
+			if levelOf[node.Name] > current && !released[node.Name] {
+				// Its level waits for the previous ones.
+				i++
+				continue
+			}
+			if lock, holders := locks.blocked(node.Job.Locks); lock != "" {
+				if times.blocked(node.Name, lock, time.Now()) {
+					p.logger.Info(fmt.Sprintf("Job '%s' is waiting for lock '%s' (held by %s).", node.Name, lock, quoteJobs(holders)))
+					p.events.emit(Event{Type: EventJobWaiting, Job: node.Name, Message: fmt.Sprintf("lock '%s'", lock)})
+				}
+				i++
+				continue
+			}
+			weight := JobWeight(node.Job)
+			if running > 0 && used+weight > budget {
+				// Hold back the jobs after it until it fits, so that
+				// a stream of lighter jobs cannot starve it.
+				break
+			}
+			ready = append(ready[:i], ready[i+1:]...)
+			running++
+			used += weight
+			locks.acquire(node.Name, node.Job.Locks)
+			now := time.Now()
+			times.started(node.Name, now)
+			p.opts.metrics.jobStarted(node.Name, now.Sub(times.get(node.Name).ready))
+			go func() {
+				results <- jobResult{node: node, err: p.runJob(schedCtx, node)}
+			}()
+		}
+		if running == 0 && gated == 0 {
+			break
+		}
+
+		var res jobResult
+		select {
+		case res = <-results:
+		case gate := <-approvals:
+			gated--
+			switch {
+			case gate.err == nil:
+				ready = append(ready, gate.node)
+				times.ready(gate.node.Name, time.Now())
+			case !isCancellation(gate.err):
+				p.reject(gate.node, gate.err)
+				times.finished(gate.node.Name, StatusRejected, time.Now())
+				if failed == nil {
+					failed = &jobResult{node: gate.node, err: gate.err}
+					cancel()
+				}
+			}
+			continue
+		}
+		now := time.Now()
+		running--
+		used -= JobWeight(res.node.Job)
+		locks.release(res.node.Name, res.node.Job.Locks)
+		times.finished(res.node.Name, statusOf(res.err), now)
+		release(res.node)
+
+		if errors.Is(res.err, ErrJobCancelled) {
+			p.logger.Warn(fmt.Sprintf("Job '%s' was cancelled, the jobs depending on it are skipped.", res.node.Name))
+			cancelled = append(cancelled, res.node.Name)
+			releaseBranch(res.node)
+			continue
+		}
+		if res.err != nil {
+			if failed == nil {
+				failed = &res
+				cancel()
+			}
+			continue
+		}
+		for _, dependent := range res.node.Dependents {
+			waiting[dependent.Name]--
+			if waiting[dependent.Name] == 0 && failed == nil && schedCtx.Err() == nil {
+				enqueue(dependent, now)
+			}
+		}
+	}
+
+	if current >= 0 && current < len(levels) {
+		p.logger.EndGroup()
+	}
+
+	p.logger.StartGroup("Timing report")
+	for _, line := range times.report(graph) {
+		p.logger.Info(line)
+	}
+	p.logger.EndGroup()
+	p.logTestReport()
+
+	if err := ctx.Err(); err != nil {
+		p.logger.Error("Pipeline cancelled.")
+		return err
+	}
+	if failed != nil {
+		p.logger.Error(fmt.Sprintf("Job '%s' failed: %v", failed.node.Name, failed.err))
+		return fmt.Errorf("pipeline failed: %w", failed.err)
+	}
+	if len(cancelled) > 0 {
+		sort.Strings(cancelled)
+		p.logger.Error(fmt.Sprintf("Job(s) %s cancelled.", quoteJobs(cancelled)))
+		return fmt.Errorf("pipeline incomplete: job(s) %s cancelled", quoteJobs(cancelled))
+	}
+	return nil
+}
+
+// reject records a job rejected at its approval gate as failed.
+func (p *pipeline) reject(node *Node, err error) {
+	p.mu.Lock()
+	p.started[node.Name] = true
+	p.mu.Unlock()
+	p.events.emit(Event{Type: EventJobFinished, Job: node.Name, Status: StatusFailed, Error: err.Error()})
+}
+
+func quoteJobs(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func formatWeight(w float64) string {
+	return strconv.FormatFloat(w, 'f', -1, 64)
+}
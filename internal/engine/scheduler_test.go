@@ -0,0 +1,415 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+func sleepJob(weight float64, deps ...string) config.Job {
+	return config.Job{
+		Weight:    weight,
+		DependsOn: deps,
+		Steps:     []config.Step{{Name: "Sleep", Cmd: "sleep 0.1"}},
+	}
+}
+
+// runPipeline runs cfg and returns the peak total weight of the jobs
+// running at the same time, and the events of the run.
+func runPipeline(t *testing.T, cfg *config.Config) (float64, []Event, error) {
+	t.Helper()
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+
+	var mu sync.Mutex
+	var current, peak float64
+	var events []Event
+	err = Run(context.Background(), cfg, graph, logger, WithListener(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+		switch e.Type {
+		case EventJobStarted:
+			current += JobWeight(cfg.Jobs[e.Job])
+			peak = max(peak, current)
+		case EventJobFinished:
+			current -= JobWeight(cfg.Jobs[e.Job])
+		}
+	}))
+	return peak, events, err
+}
+
+func TestJobWeight(t *testing.T) {
+	tests := []struct {
+		job  config.Job
+		want float64
+	}{
+		{config.Job{}, 1},
+		{config.Job{Weight: 0.5}, 0.5},
+		{config.Job{Resources: config.Resources{CPU: 4}}, 4},
+		{config.Job{Weight: 2, Resources: config.Resources{CPU: 4}}, 2},
+	}
+	for _, tt := range tests {
+		if got := JobWeight(tt.job); got != tt.want {
+			t.Errorf("JobWeight(%+v) = %v, expected %v", tt.job, got, tt.want)
+		}
+	}
+}
+
+func TestSchedule_RespectsWeightBudget(t *testing.T) {
+	cfg := &config.Config{
+		Settings: config.Settings{Parallelism: 8, WeightBudget: 4},
+		Jobs: map[string]config.Job{
+			"race-tests": sleepJob(3),
+			"lint":       sleepJob(1),
+			"vet":        sleepJob(1),
+			"build":      sleepJob(2),
+			"fmt":        sleepJob(0.5),
+		},
+	}
+
+	peak, _, err := runPipeline(t, cfg)
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if peak > 4 {
+		t.Errorf("Expected the running weight to stay within 4, peaked at %v", peak)
+	}
+	if peak < 3 {
+		t.Errorf("Expected jobs to share the budget, peaked at %v", peak)
+	}
+}
+
+func TestSchedule_HeavyJobRunsAlone(t *testing.T) {
+	cfg := &config.Config{
+		Settings: config.Settings{Parallelism: 8, WeightBudget: 2},
+		Jobs: map[string]config.Job{
+			"huge":  sleepJob(16),
+			"small": sleepJob(1),
+			"after": sleepJob(1, "huge"),
+		},
+	}
+
+	peak, events, err := runPipeline(t, cfg)
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if peak != 16 {
+		t.Errorf("Expected the heavy job to run alone, peak weight was %v", peak)
+	}
+
+	finished := 0
+	for _, e := range events {
+		if e.Type == EventJobFinished && e.Status == StatusSuccess {
+			finished++
+		}
+	}
+	if finished != 3 {
+		t.Errorf("Expected all 3 jobs to finish, got %d", finished)
+	}
+}
+
+func TestSchedule_RunsLevelsInOrder(t *testing.T) {
+	cfg := &config.Config{
+		Settings: config.Settings{Parallelism: 4},
+		Jobs: map[string]config.Job{
+			"quick":  {Steps: []config.Step{{Name: "Quick", Cmd: "true"}}},
+			"slow":   {Steps: []config.Step{{Name: "Slow", Cmd: "true"}}},
+			"follow": {DependsOn: []string{"quick"}, Steps: []config.Step{{Name: "Follow", Cmd: "true"}}},
+		},
+	}
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	var out bytes.Buffer
+	logger.SetOutput(&out)
+
+	// 'slow' only finishes once 'quick' did, so 'follow' is ready while
+	// 'slow' still runs. It then gives 'follow' a chance to start early;
+	// the wait only bounds how long a broken barrier takes to show.
+	quickDone := make(chan struct{})
+	followStarted := make(chan struct{})
+	executor := func(ctx context.Context, spec JobSpec, _ *runner.Logger) error {
+		switch spec.Name {
+		case "slow":
+			select {
+			case <-quickDone:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			select {
+			case <-followStarted:
+			case <-time.After(200 * time.Millisecond):
+			}
+		case "follow":
+			close(followStarted)
+		}
+		return nil
+	}
+	var mu sync.Mutex
+	var order []string
+	listener := func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch e.Type {
+		case EventJobStarted:
+			order = append(order, "start "+e.Job)
+		case EventJobFinished:
+			order = append(order, "finish "+e.Job)
+			if e.Job == "quick" {
+				close(quickDone)
+			}
+		}
+	}
+	if err := Run(context.Background(), cfg, graph, logger, WithExecutor(executor), WithListener(listener)); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+
+	if slices.Index(order, "start follow") < slices.Index(order, "finish slow") {
+		t.Errorf("Expected 'follow' to wait for the first level, got %v", order)
+	}
+	for _, line := range []string{"Level 1/2 (Executing 2 job(s) in parallel)", "Level 2/2 completed successfully."} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected the output to contain %q, got:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestSchedule_HeavyJobIsNotStarved(t *testing.T) {
+	light := func() config.Job {
+		return config.Job{Weight: 1, Steps: []config.Step{{Name: "Light", Cmd: "true"}}}
+	}
+	cfg := &config.Config{
+		Settings: config.Settings{Parallelism: 8, WeightBudget: 2},
+		Jobs: map[string]config.Job{
+			"a-light": light(),
+			"b-heavy": {Weight: 2, Steps: []config.Step{{Name: "Heavy", Cmd: "true"}}},
+			"c-light": light(),
+		},
+	}
+
+	_, events, err := runPipeline(t, cfg)
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+
+	var started []string
+	for _, e := range events {
+		if e.Type == EventJobStarted {
+			started = append(started, e.Job)
+		}
+	}
+	if strings.Join(started, ",") != "a-light,b-heavy,c-light" {
+		t.Errorf("Expected 'c-light' to wait behind 'b-heavy', start order was %v", started)
+	}
+}
+
+func TestSchedule_FailureSkipsDependents(t *testing.T) {
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{
+			"broken": {Steps: []config.Step{{Name: "Fail", Cmd: "exit 3"}}},
+			"deploy": {DependsOn: []string{"broken"}, Steps: []config.Step{{Name: "Deploy", Cmd: "true"}}},
+		},
+	}
+
+	_, events, err := runPipeline(t, cfg)
+	if err == nil || !strings.Contains(err.Error(), "job 'broken'") {
+		t.Fatalf("Expected the pipeline to fail on 'broken', got: %v", err)
+	}
+
+	skipped := false
+	for _, e := range events {
+		if e.Type == EventJobStarted && e.Job == "deploy" {
+			t.Error("Expected 'deploy' never to start")
+		}
+		if e.Type == EventJobSkipped && e.Job == "deploy" {
+			skipped = true
+		}
+	}
+	if !skipped {
+		t.Errorf("Expected 'deploy' to be reported as skipped, got %v", events)
+	}
+}
+
+func TestSchedule_CancelledJobSkipsDependents(t *testing.T) {
+	cfg := &config.Config{
+		Settings: config.Settings{Parallelism: 2, WeightBudget: 2},
+		Jobs: map[string]config.Job{
+			"slow":   {Steps: []config.Step{{Name: "Sleep", Cmd: "sleep 30"}}},
+			"deploy": {DependsOn: []string{"slow"}, Steps: []config.Step{{Name: "Deploy", Cmd: "true"}}},
+			"other":  {Steps: []config.Step{{Name: "Sleep", Cmd: "sleep 0.3"}}},
+		},
+	}
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+
+	canceller := NewJobCanceller()
+	if canceller.Cancel("slow") {
+		t.Error("Expected Cancel() to report that 'slow' is not running")
+	}
+	statuses := make(map[string]string)
+	var mu sync.Mutex
+	err = Run(context.Background(), cfg, graph, logger, WithJobCanceller(canceller), WithListener(func(e Event) {
+		switch e.Type {
+		case EventJobStarted:
+			if e.Job == "slow" {
+				time.AfterFunc(100*time.Millisecond, func() {
+					if !canceller.Cancel("slow") {
+						t.Error("Expected Cancel() to cancel 'slow'")
+					}
+				})
+			}
+		case EventJobFinished, EventJobSkipped:
+			mu.Lock()
+			statuses[e.Job] = e.Status
+			mu.Unlock()
+		}
+	}))
+	if err == nil || !strings.Contains(err.Error(), "job(s) 'slow' cancelled") {
+		t.Fatalf("Expected the pipeline to report the cancelled job, got: %v", err)
+	}
+	want := map[string]string{"slow": StatusCancelled, "deploy": StatusSkipped, "other": StatusSuccess}
+	for job, status := range want {
+		if statuses[job] != status {
+			t.Errorf("Expected '%s' to be %s, got %v", job, status, statuses)
+		}
+	}
+}
+
+func lockedJob(locks ...string) config.Job {
+	job := sleepJob(1)
+	job.Locks = locks
+	return job
+}
+
+func TestSchedule_LocksAreExclusive(t *testing.T) {
+	cfg := &config.Config{
+		Settings: config.Settings{Parallelism: 8, WeightBudget: 8},
+		Jobs: map[string]config.Job{
+			"api-tests":     lockedJob("db-port"),
+			"migrate-tests": lockedJob("db-port"),
+			"lint":          sleepJob(1),
+		},
+	}
+
+	peak, events, err := runPipeline(t, cfg)
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if peak != 2 {
+		t.Errorf("Expected 'lint' to run next to one of the locked jobs, peak was %v", peak)
+	}
+
+	var holding int
+	var waited bool
+	for _, e := range events {
+		if e.Job == "lint" {
+			continue
+		}
+		switch e.Type {
+		case EventJobStarted:
+			holding++
+			if holding > 1 {
+				t.Error("Expected the jobs holding 'db-port' never to overlap")
+			}
+		case EventJobFinished:
+			holding--
+		case EventJobWaiting:
+			waited = e.Job == "migrate-tests" && e.Message == "lock 'db-port'"
+		}
+	}
+	if !waited {
+		t.Error("Expected 'migrate-tests' to report waiting for 'db-port'")
+	}
+}
+
+func TestSchedule_SemaphoreLimit(t *testing.T) {
+	cfg := &config.Config{
+		Settings: config.Settings{
+			Parallelism:  8,
+			WeightBudget: 8,
+			Semaphores:   map[string]int{"e2e-browser": 2},
+		},
+		Jobs: map[string]config.Job{
+			"e2e-chrome":  lockedJob("e2e-browser"),
+			"e2e-firefox": lockedJob("e2e-browser"),
+			"e2e-safari":  lockedJob("e2e-browser"),
+			"e2e-edge":    lockedJob("e2e-browser"),
+		},
+	}
+
+	peak, _, err := runPipeline(t, cfg)
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if peak != 2 {
+		t.Errorf("Expected at most 2 jobs in the 'e2e-browser' pool at once, peak was %v", peak)
+	}
+}
+
+func TestTimings_Report(t *testing.T) {
+	graph, _ := BuildDag(&config.Config{Jobs: map[string]config.Job{
+		"first":  {},
+		"second": {},
+		"never":  {},
+	}})
+	start := time.Now()
+	times := timings{}
+	times.ready("first", start)
+	times.ready("second", start)
+	times.started("first", start)
+	times.blocked("second", "db-port", start)
+	times.finished("first", StatusSuccess, start.Add(2*time.Second))
+	times.started("second", start.Add(2*time.Second))
+	times.finished("second", StatusFailed, start.Add(3*time.Second))
+
+	lines := times.report(graph)
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "JOB") {
+		t.Fatalf("Expected a header and 3 rows, got %q", lines)
+	}
+	for i, want := range []string{"first", "second", "never"} {
+		if !strings.HasPrefix(lines[i+1], want+" ") {
+			t.Errorf("Expected row %d to be '%s', got %q", i+1, want, lines[i+1])
+		}
+	}
+	if !strings.Contains(lines[2], "2s (db-port)") || !strings.Contains(lines[2], "failed") {
+		t.Errorf("Expected 'second' to show its lock wait, got %q", lines[2])
+	}
+	if !strings.Contains(lines[3], "skipped") {
+		t.Errorf("Expected 'never' to be reported as skipped, got %q", lines[3])
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/Purpose-Dev/flowcraft/internal/tracing"
+)
+
+func TestRun_Tracing(t *testing.T) {
+	receiver := &tracing.Receiver{}
+	ts := httptest.NewServer(receiver)
+	defer ts.Close()
+	exporter, err := tracing.NewOTLPExporter(ts.URL, "flowcraft")
+	if err != nil {
+		t.Fatalf("NewOTLPExporter() returned an unexpected error: %v", err)
+	}
+	tracer := tracing.NewTracer(exporter)
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{
+			"build": {Steps: []config.Step{{Name: "Compile", Cmd: `echo "$TRACEPARENT" > traceparent`}}},
+			"test": {
+				DependsOn: []string{"build"},
+				Steps:     []config.Step{{Name: "Unit", Cmd: "exit 3"}},
+			},
+		},
+	}
+	graph, err := BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+
+	_ = Run(context.Background(), cfg, graph, logger, WithWorkdir(dir), WithTracer(tracer, tracing.String("flowcraft.run.id", "r1")))
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() returned an unexpected error: %v", err)
+	}
+
+	spans := make(map[string]tracing.SpanData)
+	for _, span := range receiver.Spans() {
+		spans[span.Name] = span
+	}
+	run, job, step := spans["run"], spans["job build"], spans["step Compile"]
+	if run.Attribute("flowcraft.run.id") != "r1" || !run.Failed {
+		t.Errorf("Expected a failed run span with its attributes, got %+v", run)
+	}
+	if job.ParentSpanID != run.SpanID || step.TraceID != run.TraceID {
+		t.Errorf("Expected 'job build' under the run, got %+v", job)
+	}
+	if step.Attribute("process.exit.code") != int64(0) || step.Failed {
+		t.Errorf("Unexpected step span %+v", step)
+	}
+	if failed := spans["step Unit"]; !failed.Failed || failed.Attribute("process.exit.code") != int64(3) {
+		t.Errorf("Expected 'step Unit' to fail with exit code 3, got %+v", failed)
+	}
+
+	// Both jobs have an 'attempt 1': the step's parent is one of them.
+	var parentFound bool
+	for _, span := range receiver.Spans() {
+		if span.Name == "attempt 1" && span.SpanID == step.ParentSpanID {
+			parentFound = span.ParentSpanID == job.SpanID
+		}
+	}
+	if !parentFound {
+		t.Errorf("Expected 'step Compile' under the attempt of 'build', got %+v", step)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "traceparent"))
+	if err != nil {
+		t.Fatalf("Failed to read the step's TRACEPARENT: %v", err)
+	}
+	want := tracing.SpanContext{TraceID: step.TraceID, SpanID: step.SpanID}.TraceParent()
+	if got := strings.TrimSpace(string(data)); got != want {
+		t.Errorf("Expected TRACEPARENT=%s in the step, got %q", want, got)
+	}
+}
@@ -0,0 +1,228 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ghactions
+
+import (
+	"strconv"
+	"strings"
+)
+
+// An if condition becomes a bash test that ends the commands of a step
+// early when it is false. Comparisons with == and != joined by && and ||
+// are translated; the comparisons of matrix values are decided when the
+// workflow is converted, as the combination of every job is known then.
+
+// guard is the translation of an if condition.
+type guard struct {
+	// test is the bash test of the condition, "" when it always holds.
+	test string
+	// never is set when the condition never holds.
+	never bool
+}
+
+// line returns the command ending a script when the condition is false.
+func (g guard) line() string {
+	return g.test + " || exit 0"
+}
+
+// operand is a side of a comparison: a value known when converting, or
+// a variable read when the step runs.
+type operand struct {
+	value    string
+	variable string
+	// ref is set for github.ref, which is only compared to branches.
+	ref bool
+}
+
+// condition translates an if condition. ok is false when it cannot be
+// translated.
+func (c *converter) condition(s scope, cond string) (g guard, ok bool) {
+	cond = condText(cond)
+	if cond == "" || cond == "success()" {
+		return guard{}, true
+	}
+	return c.translate(s, cond)
+}
+
+func (c *converter) translate(s scope, cond string) (guard, bool) {
+	tokens, ok := tokenize(cond)
+	if !ok {
+		return guard{}, false
+	}
+	var terms []string
+	for _, term := range split(tokens, "||") {
+		var tests []string
+		holds := true
+		for _, cmp := range split(term, "&&") {
+			if len(cmp) != 3 || (cmp[1] != "==" && cmp[1] != "!=") {
+				return guard{}, false
+			}
+			left, ok := c.operand(s, cmp[0])
+			if !ok {
+				return guard{}, false
+			}
+			right, ok := c.operand(s, cmp[2])
+			if !ok {
+				return guard{}, false
+			}
+			test, known, equal, ok := compare(left, cmp[1], right)
+			if !ok {
+				return guard{}, false
+			}
+			switch {
+			case !known:
+				tests = append(tests, test)
+			case equal != (cmp[1] == "=="):
+				holds = false
+			}
+		}
+		switch {
+		case !holds:
+		case len(tests) == 0:
+			return guard{}, true
+		default:
+			terms = append(terms, strings.Join(tests, " && "))
+		}
+	}
+	if len(terms) == 0 {
+		return guard{never: true}, true
+	}
+	return guard{test: "[[ " + strings.Join(terms, " || ") + " ]]"}, true
+}
+
+// compare compares two operands at conversion time when both are
+// known, else returns the bash test comparing them with op.
+func compare(left operand, op string, right operand) (test string, known, equal, ok bool) {
+	if left.ref && right.ref {
+		return "", false, false, false
+	}
+	if left.ref || right.ref {
+		if right.ref {
+			left, right = right, left
+		}
+		branch, isBranch := strings.CutPrefix(right.value, "refs/heads/")
+		if right.variable != "" || !isBranch {
+			return "", false, false, false
+		}
+		left, right = operand{variable: "CI_BRANCH"}, operand{value: branch}
+	}
+	if left.variable == "" && right.variable == "" {
+		// GitHub compares strings ignoring case.
+		return "", true, strings.EqualFold(left.value, right.value), true
+	}
+	return left.bash() + " " + op + " " + right.bash(), false, false, true
+}
+
+func (o operand) bash() string {
+	if o.variable != "" {
+		return `"${` + o.variable + `}"`
+	}
+	return "'" + strings.ReplaceAll(o.value, "'", `'\''`) + "'"
+}
+
+func (c *converter) operand(s scope, token string) (operand, bool) {
+	if strings.HasPrefix(token, "'") {
+		return operand{value: strings.ReplaceAll(token[1:len(token)-1], "''", "'")}, true
+	}
+	if token == "github.ref" {
+		return operand{ref: true}, true
+	}
+	if name, ok := githubContext[token]; ok {
+		return operand{variable: name}, true
+	}
+	ref := contextRef.FindStringSubmatch(token)
+	if ref == nil {
+		if _, err := strconv.ParseFloat(token, 64); err == nil || token == "true" || token == "false" {
+			return operand{value: token}, true
+		}
+		return operand{}, false
+	}
+	switch ref[1] {
+	case "matrix":
+		return operand{value: s.matrix[ref[2]]}, true
+	case "env":
+		return operand{variable: ref[2]}, true
+	case "vars":
+		c.todo(s, "vars.%s is read from the host variable %s", ref[2], ref[2])
+		c.vars[ref[2]] = true
+		return operand{variable: ref[2]}, true
+	}
+	// GitHub does not allow secrets in if conditions.
+	return operand{}, false
+}
+
+// tokenize splits a condition into operators, quoted strings and
+// context references. ok is false for anything else, such as function
+// calls, negations and parentheses.
+func tokenize(cond string) (tokens []string, ok bool) {
+	for i := 0; i < len(cond); {
+		switch ch := cond[i]; {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == '\'':
+			end := i + 1
+			for {
+				next := strings.IndexByte(cond[end:], '\'')
+				if next < 0 {
+					return nil, false
+				}
+				end += next + 1
+				if end >= len(cond) || cond[end] != '\'' {
+					break
+				}
+				end++
+			}
+			tokens = append(tokens, cond[i:end])
+			i = end
+		case i+1 < len(cond) && isOperator(cond[i:i+2]):
+			tokens = append(tokens, cond[i:i+2])
+			i += 2
+		default:
+			end := i
+			for end < len(cond) && isIdentByte(cond[end]) {
+				end++
+			}
+			if end == i {
+				return nil, false
+			}
+			tokens = append(tokens, cond[i:end])
+			i = end
+		}
+	}
+	return tokens, len(tokens) > 0
+}
+
+func isOperator(s string) bool {
+	return s == "==" || s == "!=" || s == "&&" || s == "||"
+}
+
+func isIdentByte(b byte) bool {
+	return b == '.' || b == '_' || b == '-' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// split splits tokens at every op.
+func split(tokens []string, op string) [][]string {
+	var parts [][]string
+	start := 0
+	for i, token := range tokens {
+		if token == op {
+			parts = append(parts, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, tokens[start:])
+}
@@ -0,0 +1,884 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ghactions converts GitHub Actions workflows to flow.toml.
+package ghactions
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/tomlfmt"
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
+)
+
+// Issue is a part of a workflow that could not be converted. It is also
+// left as a TODO comment in the generated file.
+type Issue struct {
+	// Job is the id of the workflow job, or "" for the workflow itself.
+	Job string
+	// Step is the name of the step, or "" for the whole job.
+	Step    string
+	Message string
+}
+
+func (i Issue) String() string {
+	switch {
+	case i.Job == "":
+		return i.Message
+	case i.Step == "":
+		return fmt.Sprintf("job '%s': %s", i.Job, i.Message)
+	}
+	return fmt.Sprintf("job '%s', step '%s': %s", i.Job, i.Step, i.Message)
+}
+
+// Result is the translation of a workflow.
+type Result struct {
+	// Config is the generated flow.toml.
+	Config []byte
+	// Issues lists what could not be converted, in the order of the
+	// workflow.
+	Issues []Issue
+}
+
+// Events with an equivalent in the triggers of a flow.toml.
+var triggerEvents = map[string]string{
+	"push":         config.TriggerPush,
+	"pull_request": config.TriggerPullRequest,
+}
+
+// Workflow and job keys dropped on purpose: they only make sense on
+// GitHub's runners.
+var (
+	ignoredWorkflowKeys = []string{"name", "run-name", "permissions", "concurrency"}
+	ignoredJobKeys      = []string{"name", "runs-on", "permissions", "concurrency"}
+)
+
+// githubContext maps the github.* expressions to the CI_* variables set
+// for the runs of a flowcraft-server.
+var githubContext = map[string]string{
+	"github.sha":                       "CI_SHA",
+	"github.ref_name":                  "CI_BRANCH",
+	"github.repository":                "CI_REPOSITORY",
+	"github.event_name":                "CI_EVENT",
+	"github.base_ref":                  "CI_BASE_BRANCH",
+	"github.event.pull_request.number": "CI_PULL_REQUEST",
+}
+
+var (
+	expression = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+	contextRef = regexp.MustCompile(`^(env|vars|secrets|matrix)\.([A-Za-z0-9_.-]+)$`)
+	invalidKey = regexp.MustCompile(`[^a-z0-9_-]+`)
+)
+
+type converter struct {
+	workflow *node
+	env      []variable
+	// secrets maps the declared secrets to the host variables read by
+	// the "env" provider.
+	secrets     map[string]string
+	secretOrder []string
+	// globalSecrets are the secrets of the workflow's env, needed by
+	// every job.
+	globalSecrets []string
+	// vars are the host variables read for vars.* expressions.
+	vars     map[string]bool
+	triggers config.Triggers
+	jobs     []*job
+	issues   []Issue
+	seen     map[Issue]bool
+	// needed holds the ids of the jobs other jobs need.
+	needed map[string]bool
+}
+
+type variable struct {
+	key, value string
+}
+
+type job struct {
+	id       string
+	name     string
+	comment  string
+	todos    []string
+	needs    []string
+	env      []variable
+	secrets  []string
+	steps    []*step
+	disabled []string
+	timeout  string
+	// guard is the if condition of the job, run before every step.
+	guard guard
+	cond  string
+}
+
+type step struct {
+	name  string
+	note  string
+	todos []string
+	cfg   *config.Step
+	guard guard
+	// timeout is the timeout of the step, set once cfg is.
+	timeout string
+	// disabled holds the TOML lines of a step that could not be
+	// converted, generated as comments.
+	disabled []string
+}
+
+// scope is what the expressions of a job expand to.
+type scope struct {
+	job    *job
+	matrix map[string]string
+	step   string
+}
+
+// Convert translates the GitHub Actions workflow in data, read from
+// source, to a flow.toml. Jobs become jobs, needs their dependencies and
+// run steps their steps; matrices are expanded to a job per combination.
+// What has no equivalent is reported and left as TODO comments.
+func Convert(data []byte, source string) (*Result, error) {
+	root, err := parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("error during parsing yaml of %s: %w", source, err)
+	}
+	if root.kind != mappingNode {
+		return nil, fmt.Errorf("%s is not a workflow", source)
+	}
+	jobs := root.get("jobs")
+	if jobs == nil || jobs.kind != mappingNode || len(jobs.pairs) == 0 {
+		return nil, fmt.Errorf("%s has no jobs", source)
+	}
+
+	c := &converter{workflow: root, secrets: make(map[string]string), vars: make(map[string]bool), seen: make(map[Issue]bool), needed: make(map[string]bool)}
+	for _, p := range root.pairs {
+		switch p.key {
+		case "on":
+			c.convertTriggers(p.value)
+		case "env":
+			global := &job{}
+			c.env = c.convertEnv(p.value, scope{job: global})
+			c.globalSecrets = global.secrets
+		case "defaults", "jobs":
+		default:
+			if !slices.Contains(ignoredWorkflowKeys, p.key) {
+				c.report(Issue{Message: fmt.Sprintf("'%s' is not converted", p.key)})
+			}
+		}
+	}
+
+	// Name the jobs of every matrix combination first, so that needs
+	// can be resolved in any order.
+	type expansion struct {
+		id     string
+		node   *node
+		matrix *matrix
+		names  []string
+	}
+	var expansions []*expansion
+	names := make(map[string][]string)
+	taken := make(map[string]bool)
+	for _, p := range jobs.pairs {
+		e := &expansion{id: p.key, node: p.value}
+		e.matrix = c.expandMatrix(p.key, p.value.get("strategy").get("matrix"))
+		for _, combo := range e.matrix.combos {
+			name := p.key
+			if suffix := e.matrix.suffix(combo); suffix != "" {
+				name += "-" + suffix
+			}
+			for base, n := name, 2; taken[name]; n++ {
+				name = fmt.Sprintf("%s-%d", base, n)
+			}
+			taken[name] = true
+			e.names = append(e.names, name)
+		}
+		names[p.key] = e.names
+		expansions = append(expansions, e)
+		for _, need := range p.value.get("needs").list() {
+			c.needed[need] = true
+		}
+	}
+
+	for _, e := range expansions {
+		for i, combo := range e.matrix.combos {
+			j := c.convertJob(e.id, e.names[i], e.node, combo, names)
+			if len(combo) > 0 {
+				j.comment += ", matrix " + e.matrix.describe(combo)
+			}
+			c.jobs = append(c.jobs, j)
+		}
+	}
+	return &Result{Config: c.generate(source), Issues: c.issues}, nil
+}
+
+// report records an issue once, however many matrix jobs it affects.
+func (c *converter) report(issue Issue) {
+	if !c.seen[issue] {
+		c.seen[issue] = true
+		c.issues = append(c.issues, issue)
+	}
+}
+
+// todo reports an issue of the scope and returns its message.
+func (c *converter) todo(s scope, format string, args ...any) string {
+	message := fmt.Sprintf(format, args...)
+	c.report(Issue{Job: s.job.id, Step: s.step, Message: message})
+	return message
+}
+
+func (c *converter) convertTriggers(on *node) {
+	var events []string
+	filters := make(map[string]*node)
+	switch on.kind {
+	case scalarNode, sequenceNode:
+		events = on.list()
+	case mappingNode:
+		for _, p := range on.pairs {
+			events = append(events, p.key)
+			filters[p.key] = p.value
+		}
+	}
+
+	var branches, paths [][]string
+	for _, event := range events {
+		name, ok := triggerEvents[event]
+		if !ok {
+			c.report(Issue{Message: fmt.Sprintf("the '%s' event is not converted", event)})
+			continue
+		}
+		c.triggers.Events = append(c.triggers.Events, name)
+		filter := filters[event]
+		for _, p := range filter.entries() {
+			switch p.key {
+			case "branches":
+				branches = append(branches, c.globs(event, p.key, p.value.list()))
+			case "paths":
+				paths = append(paths, c.globs(event, p.key, p.value.list()))
+			default:
+				c.report(Issue{Message: fmt.Sprintf("'on.%s.%s' is not converted", event, p.key)})
+			}
+		}
+	}
+	c.triggers.Branches = c.mergeFilters("branches", branches)
+	c.triggers.Paths = c.mergeFilters("paths", paths)
+}
+
+// globs returns the patterns that are valid globs.
+func (c *converter) globs(event, key string, patterns []string) []string {
+	var valid []string
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			c.report(Issue{Message: fmt.Sprintf("the negated pattern '%s' of 'on.%s.%s' is not converted", pattern, event, key)})
+			continue
+		}
+		if _, err := workspace.CompileGlob(pattern); err != nil {
+			c.report(Issue{Message: fmt.Sprintf("the pattern '%s' of 'on.%s.%s' is not converted: %v", pattern, event, key, err)})
+			continue
+		}
+		valid = append(valid, pattern)
+	}
+	if valid == nil {
+		valid = []string{}
+	}
+	return valid
+}
+
+// mergeFilters merges the filters of the push and pull_request events,
+// which share the triggers of a flow.toml.
+func (c *converter) mergeFilters(key string, filters [][]string) []string {
+	var merged []string
+	for _, filter := range filters {
+		for _, pattern := range filter {
+			if !slices.Contains(merged, pattern) {
+				merged = append(merged, pattern)
+			}
+		}
+	}
+	if (len(filters) > 0 && len(filters) < len(c.triggers.Events)) || (len(filters) == 2 && !slices.Equal(filters[0], filters[1])) {
+		c.report(Issue{Message: fmt.Sprintf("the %s of the events are merged: flowcraft applies them to every event", key)})
+	}
+	return merged
+}
+
+// matrix is the expansion of a strategy.matrix.
+type matrix struct {
+	combos []map[string]string
+	// keys are the matrix keys in the order of the workflow, and base
+	// the ones defined before include.
+	keys, base []string
+}
+
+// expandMatrix returns the combinations of a strategy.matrix, or a
+// single empty combination.
+func (c *converter) expandMatrix(id string, n *node) *matrix {
+	m := &matrix{combos: []map[string]string{{}}}
+	if n == nil {
+		return m
+	}
+	if n.kind != mappingNode {
+		c.report(Issue{Job: id, Message: fmt.Sprintf("the matrix '%s' is not converted", n.str())})
+		return m
+	}
+
+	for _, p := range n.pairs {
+		if p.key == "include" || p.key == "exclude" {
+			continue
+		}
+		if p.value.kind != sequenceNode || len(p.value.items) == 0 {
+			c.report(Issue{Job: id, Message: fmt.Sprintf("the matrix values of '%s' are not converted", p.key)})
+			continue
+		}
+		var next []map[string]string
+		for _, combo := range m.combos {
+			for _, item := range p.value.items {
+				values := maps.Clone(combo)
+				m.addKeys(flatten(values, p.key, item))
+				next = append(next, values)
+			}
+		}
+		m.combos = next
+	}
+	m.base = slices.Clone(m.keys)
+	if len(m.base) == 0 {
+		m.combos = nil
+	}
+
+	for _, entry := range n.get("exclude").elements() {
+		exclude := make(map[string]string)
+		flatten(exclude, "", entry)
+		m.combos = slices.DeleteFunc(m.combos, func(combo map[string]string) bool {
+			for k, v := range exclude {
+				if combo[k] != v {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	for _, entry := range n.get("include").elements() {
+		include := make(map[string]string)
+		m.addKeys(flatten(include, "", entry))
+		matched := false
+		for _, combo := range m.combos {
+			if m.extends(combo, include) {
+				maps.Copy(combo, include)
+				matched = true
+			}
+		}
+		if !matched {
+			m.combos = append(m.combos, include)
+		}
+	}
+	if len(m.combos) == 0 {
+		m.combos = []map[string]string{{}}
+	}
+	return m
+}
+
+func (m *matrix) addKeys(keys []string) {
+	for _, key := range keys {
+		if !slices.Contains(m.keys, key) {
+			m.keys = append(m.keys, key)
+		}
+	}
+}
+
+// extends reports whether an include entry can be added to a combination
+// without overwriting its original values.
+func (m *matrix) extends(combo, include map[string]string) bool {
+	for k, v := range include {
+		if original, ok := combo[k]; ok && slices.Contains(m.base, k) && original != v {
+			return false
+		}
+	}
+	return true
+}
+
+// suffix names the job of a combination after the values of the matrix
+// keys, or of its own keys when it was only added by include.
+func (m *matrix) suffix(combo map[string]string) string {
+	keys := m.base
+	if !slices.ContainsFunc(keys, func(k string) bool { _, ok := combo[k]; return ok }) {
+		keys = m.keys
+	}
+	var parts []string
+	for _, k := range keys {
+		if v, ok := combo[k]; ok {
+			if part := strings.Trim(invalidKey.ReplaceAllString(strings.ToLower(v), "-"), "-"); part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// describe lists the values of a combination.
+func (m *matrix) describe(combo map[string]string) string {
+	var values []string
+	for _, k := range m.keys {
+		if v, ok := combo[k]; ok {
+			values = append(values, k+"="+v)
+		}
+	}
+	return strings.Join(values, " ")
+}
+
+// flatten sets the values of a matrix entry, with dotted keys for the
+// fields of objects, and returns their keys.
+func flatten(values map[string]string, key string, n *node) []string {
+	switch n.kind {
+	case mappingNode:
+		var keys []string
+		for _, p := range n.pairs {
+			keys = append(keys, flatten(values, strings.TrimPrefix(key+"."+p.key, "."), p.value)...)
+		}
+		return keys
+	case scalarNode:
+		values[key] = n.value
+		return []string{key}
+	}
+	return nil
+}
+
+func (c *converter) convertJob(id, name string, n *node, combo map[string]string, names map[string][]string) *job {
+	j := &job{id: id, name: name, secrets: slices.Clone(c.globalSecrets)}
+	s := scope{job: j, matrix: combo}
+
+	j.comment = fmt.Sprintf("Job '%s'", id)
+	if title := n.get("name").str(); title != "" {
+		j.comment = fmt.Sprintf("%s (job '%s')", display(title, combo), id)
+	}
+	if n.kind != mappingNode {
+		j.todos = append(j.todos, c.todo(s, "the job is not a mapping"))
+		return j
+	}
+
+	for _, need := range n.get("needs").list() {
+		deps, ok := names[need]
+		if !ok {
+			j.todos = append(j.todos, c.todo(s, "needs the unknown job '%s'", need))
+			continue
+		}
+		j.needs = append(j.needs, deps...)
+	}
+
+	for _, p := range n.pairs {
+		switch p.key {
+		case "needs", "steps", "defaults", "strategy", "with", "secrets":
+		case "env":
+			j.env = append(j.env, c.convertEnv(p.value, s)...)
+		case "if":
+			if g, ok := c.condition(s, p.value.str()); ok {
+				j.guard, j.cond = g, p.value.str()
+				if g.test != "" && c.needed[id] {
+					j.todos = append(j.todos, c.todo(s, "the jobs that need this job still run when if: %s is false", condText(j.cond)))
+				}
+			} else {
+				j.todos = append(j.todos, c.todo(s, "if: %s is not converted: the job always runs", condText(p.value.str())))
+			}
+		case "timeout-minutes":
+			if j.timeout = timeout(p.value.str()); j.timeout == "" {
+				j.todos = append(j.todos, c.todo(s, "timeout-minutes: %s is not converted", p.value.str()))
+			}
+		case "continue-on-error":
+			j.todos = append(j.todos, c.todo(s, "continue-on-error is not converted: the pipeline fails with the job"))
+		case "environment":
+			env := p.value.str()
+			if env == "" {
+				env = p.value.get("name").str()
+			}
+			j.todos = append(j.todos, c.todo(s, "the environment '%s' is not converted: set approve = true if its deployments need a review", env))
+		case "uses":
+			j.todos = append(j.todos, c.todo(s, "the reusable workflow '%s' is not converted", p.value.str()))
+			j.disabled = append(j.disabled, "uses = "+tomlfmt.String(p.value.str()))
+		default:
+			if !slices.Contains(ignoredJobKeys, p.key) {
+				j.todos = append(j.todos, c.todo(s, "'%s' is not converted", p.key))
+			}
+		}
+	}
+
+	if j.guard.never {
+		j.todos = append(j.todos, c.todo(s, "if: %s never holds here: the job has no steps, but the jobs that need it still run", condText(j.cond)))
+		return j
+	}
+	for i, item := range n.get("steps").elements() {
+		st := c.convertStep(s, n, item, i)
+		if j.guard.test != "" {
+			c.applyGuard(s, st, j.guard)
+		}
+		j.steps = append(j.steps, st)
+	}
+	return j
+}
+
+// applyGuard makes the commands of a step end early when the if
+// condition of the step or of its job is false.
+func (c *converter) applyGuard(s scope, st *step, g guard) {
+	switch {
+	case st.cfg == nil:
+	case st.cfg.Checkout != nil:
+		st.todos = append(st.todos, c.todo(s, "the if condition is not applied to the checkout: it always runs"))
+	default:
+		st.cfg.Cmd = g.line() + "\n" + st.cfg.Cmd
+	}
+}
+
+// condText returns an if condition without the ${{ }} around it.
+func condText(cond string) string {
+	cond = strings.TrimSpace(cond)
+	if m := expression.FindStringSubmatch(cond); m != nil && m[0] == cond {
+		return m[1]
+	}
+	return cond
+}
+
+// timeout converts timeout-minutes to a flowcraft duration, or returns
+// "" when it is not a positive number of minutes.
+func timeout(minutes string) string {
+	m, err := strconv.ParseFloat(minutes, 64)
+	switch {
+	case err != nil || m <= 0:
+		return ""
+	case m == float64(int(m)):
+		return fmt.Sprintf("%dm", int(m))
+	}
+	return time.Duration(m * float64(time.Minute)).String()
+}
+
+// runDefault returns a defaults.run setting of the job, else of the
+// workflow.
+func (c *converter) runDefault(jobNode *node, key string) string {
+	if v := jobNode.get("defaults").get("run").get(key).str(); v != "" {
+		return v
+	}
+	return c.workflow.get("defaults").get("run").get(key).str()
+}
+
+func (c *converter) convertStep(s scope, jobNode, n *node, index int) *step {
+	st := &step{}
+	run, uses := n.get("run").str(), n.get("uses").str()
+	// Issues name the step as the workflow does, whatever the matrix.
+	s.step = n.get("name").str()
+	st.name = display(s.step, s.matrix)
+	switch {
+	case st.name != "":
+	case run != "":
+		first, _, _ := strings.Cut(strings.TrimSpace(display(run, s.matrix)), "\n")
+		if len(first) > 60 {
+			first = first[:57] + "..."
+		}
+		st.name = "Run " + first
+	case uses != "":
+		st.name = uses
+	default:
+		st.name = fmt.Sprintf("Step %d", index+1)
+	}
+	if s.step == "" {
+		s.step = st.name
+	}
+
+	if n.kind != mappingNode {
+		st.todos = append(st.todos, c.todo(s, "the step is not a mapping"))
+		return st
+	}
+	for _, p := range n.pairs {
+		switch p.key {
+		case "id", "name", "run", "uses", "with", "env", "working-directory", "shell":
+		case "if":
+			if g, ok := c.condition(s, p.value.str()); !ok {
+				st.todos = append(st.todos, c.todo(s, "if: %s is not converted: the step always runs", condText(p.value.str())))
+			} else if g.never {
+				st.note = fmt.Sprintf("Step '%s' is skipped: if: %s never holds here.", st.name, condText(p.value.str()))
+				return st
+			} else {
+				st.guard = g
+			}
+		case "timeout-minutes":
+			if st.timeout = timeout(p.value.str()); st.timeout == "" {
+				st.todos = append(st.todos, c.todo(s, "timeout-minutes: %s is not converted", p.value.str()))
+			}
+		case "continue-on-error":
+			st.todos = append(st.todos, c.todo(s, "continue-on-error is not converted: the job fails with the step"))
+		default:
+			st.todos = append(st.todos, c.todo(s, "'%s' is not converted", p.key))
+		}
+	}
+
+	c.mergeStepEnv(s, n.get("env"))
+	switch {
+	case uses != "":
+		c.convertAction(s, st, uses, n.get("with"))
+	case run != "":
+		c.convertRun(s, st, jobNode, n, run)
+	default:
+		st.todos = append(st.todos, c.todo(s, "the step has neither run nor uses"))
+	}
+	if st.cfg != nil {
+		st.cfg.Timeout = st.timeout
+	}
+	if st.guard.test != "" {
+		c.applyGuard(s, st, st.guard)
+	}
+	return st
+}
+
+// mergeStepEnv adds the env of a step to its job: steps have no
+// environment of their own.
+func (c *converter) mergeStepEnv(s scope, n *node) {
+	for _, v := range c.convertEnv(n, s) {
+		i := slices.IndexFunc(s.job.env, func(e variable) bool { return e.key == v.key })
+		switch {
+		case i < 0:
+			s.job.env = append(s.job.env, v)
+		case s.job.env[i].value != v.value:
+			c.todo(s, "%s is set to '%s' for the whole job, not '%s'", v.key, s.job.env[i].value, v.value)
+		}
+	}
+}
+
+func (c *converter) convertRun(s scope, st *step, jobNode, n *node, run string) {
+	shell := n.get("shell").str()
+	if shell == "" {
+		shell = c.runDefault(jobNode, "shell")
+	}
+	if shell != "" && shell != "bash" && shell != "sh" {
+		st.todos = append(st.todos, c.todo(s, "the shell '%s' is not converted: flowcraft runs steps with bash", shell))
+		st.disabled = []string{"name = " + tomlfmt.String(st.name), "cmd = " + tomlfmt.String(strings.TrimRight(run, "\n"))}
+		return
+	}
+
+	script := c.expand(s, strings.TrimRight(run, "\n"))
+	if strings.Contains(script, "\n") {
+		// GitHub runs scripts with 'bash -e': stop at the first error.
+		script = "set -e\n" + script
+	}
+	dir := n.get("working-directory").str()
+	if dir == "" {
+		dir = c.runDefault(jobNode, "working-directory")
+	}
+	st.cfg = &config.Step{Name: st.name, Cmd: script, Dir: c.expand(s, dir)}
+
+	if vars := c.hostVariables(s, st.cfg); len(vars) > 0 {
+		st.todos = append(st.todos, c.todo(s, "%s are expanded by flowcraft from the host environment before bash runs", strings.Join(vars, ", ")))
+	}
+}
+
+// hostVariables returns the variables of a step that flowcraft expands
+// from the host environment: shell variables, and the variables set by
+// GitHub's runners.
+func (c *converter) hostVariables(s scope, st *config.Step) []string {
+	declared := make(map[string]bool)
+	for _, v := range slices.Concat(c.env, s.job.env) {
+		declared[v.key] = true
+	}
+	for _, name := range s.job.secrets {
+		declared[name] = true
+	}
+	for _, name := range githubContext {
+		declared[name] = true
+	}
+	for name := range c.vars {
+		declared[name] = true
+	}
+	var vars []string
+	collect := func(name string) string {
+		if !declared[name] && !slices.Contains(vars, "$"+name) {
+			vars = append(vars, "$"+name)
+		}
+		return ""
+	}
+	// Expressions left as they are were reported already.
+	os.Expand(expression.ReplaceAllString(st.Cmd, ""), collect)
+	os.Expand(expression.ReplaceAllString(st.Dir, ""), collect)
+	return vars
+}
+
+func (c *converter) convertAction(s scope, st *step, uses string, with *node) {
+	action, _, _ := strings.Cut(uses, "@")
+	if action == "actions/checkout" {
+		if with.get("repository").str() == "" {
+			st.note = "actions/checkout is not needed: steps run in the working directory."
+			return
+		}
+		st.cfg = c.checkout(s, st.name, with)
+		return
+	}
+
+	hint := "replace it with the commands it runs"
+	if strings.HasPrefix(action, "actions/setup-") {
+		hint = "install the tool on the host instead"
+	}
+	st.todos = append(st.todos, c.todo(s, "the action '%s' is not converted: %s", uses, hint))
+	st.disabled = []string{"name = " + tomlfmt.String(st.name), "uses = " + tomlfmt.String(uses)}
+	if len(with.entries()) > 0 {
+		fields := make([]string, 0, len(with.entries()))
+		for _, p := range with.entries() {
+			fields = append(fields, tomlfmt.Key(p.key)+" = "+tomlfmt.String(c.substitute(p.value.str(), s.matrix)))
+		}
+		st.disabled = append(st.disabled, "with = { "+strings.Join(fields, ", ")+" }")
+	}
+}
+
+// checkout converts actions/checkout of another repository to a
+// checkout step.
+func (c *converter) checkout(s scope, name string, with *node) *config.Step {
+	co := &config.Checkout{
+		Repository: "https://github.com/" + c.expand(s, with.get("repository").str()) + ".git",
+		Ref:        c.expand(s, with.get("ref").str()),
+		Path:       c.expand(s, with.get("path").str()),
+		Depth:      1,
+	}
+	if depth := with.get("fetch-depth").str(); depth != "" {
+		d, err := strconv.Atoi(depth)
+		if err != nil || d < 0 {
+			c.todo(s, "fetch-depth: %s is not converted", depth)
+		} else {
+			co.Depth = d
+		}
+	}
+	switch with.get("submodules").str() {
+	case "true", "recursive":
+		co.Submodules = true
+	}
+	co.Sparse = strings.Fields(with.get("sparse-checkout").str())
+	if token := with.get("token").str(); token != "" {
+		if key, ok := secretRef(token); ok {
+			co.Credentials = c.secret(s, key, key)
+		} else {
+			c.todo(s, "the token '%s' is not converted", token)
+		}
+	}
+	for _, p := range with.entries() {
+		switch p.key {
+		case "repository", "ref", "path", "fetch-depth", "submodules", "sparse-checkout", "token":
+		default:
+			c.todo(s, "the checkout option '%s' is not converted", p.key)
+		}
+	}
+	return &config.Step{Name: name, Checkout: co}
+}
+
+// secret declares a secret read by the "env" provider from a host
+// variable, and adds it to the job.
+func (c *converter) secret(s scope, name, key string) string {
+	if existing, ok := c.secrets[name]; !ok {
+		c.secrets[name] = key
+		c.secretOrder = append(c.secretOrder, name)
+	} else if existing != key {
+		c.todo(s, "the secret %s is read from %s for every job, not %s", name, existing, key)
+	}
+	if !slices.Contains(s.job.secrets, name) {
+		s.job.secrets = append(s.job.secrets, name)
+	}
+	return name
+}
+
+// convertEnv converts an env mapping. A variable set to a secret becomes
+// a secret of the job, exposed under the variable's name.
+func (c *converter) convertEnv(n *node, s scope) []variable {
+	var vars []variable
+	for _, p := range n.entries() {
+		value := p.value.str()
+		if key, ok := secretRef(value); ok {
+			c.secret(s, p.key, key)
+			continue
+		}
+		value = c.substitute(value, s.matrix)
+		for _, m := range expression.FindAllString(value, -1) {
+			c.todo(s, "%s: the expression '%s' is not expanded in env values", p.key, m)
+		}
+		vars = append(vars, variable{key: p.key, value: value})
+	}
+	return vars
+}
+
+// secretRef returns the name of the secret when value is only a
+// ${{ secrets.NAME }} expression.
+func secretRef(value string) (string, bool) {
+	m := expression.FindStringSubmatch(value)
+	if m == nil || m[0] != strings.TrimSpace(value) {
+		return "", false
+	}
+	ref := contextRef.FindStringSubmatch(m[1])
+	if ref == nil || ref[1] != "secrets" {
+		return "", false
+	}
+	return ref[2], true
+}
+
+// substitute replaces the matrix values in s. Like on GitHub, the
+// values missing from the combination are empty.
+func (c *converter) substitute(s string, matrix map[string]string) string {
+	return expression.ReplaceAllStringFunc(s, func(expr string) string {
+		ref := contextRef.FindStringSubmatch(expression.FindStringSubmatch(expr)[1])
+		if ref != nil && ref[1] == "matrix" {
+			return matrix[ref[2]]
+		}
+		return expr
+	})
+}
+
+// display replaces the expressions of a name with the matrix values
+// and the variables they read: flowcraft does not expand names.
+func display(text string, matrix map[string]string) string {
+	return expression.ReplaceAllStringFunc(text, func(expr string) string {
+		inner := expression.FindStringSubmatch(expr)[1]
+		if name, ok := githubContext[inner]; ok {
+			return "$" + name
+		}
+		ref := contextRef.FindStringSubmatch(inner)
+		switch {
+		case ref == nil:
+			return inner
+		case ref[1] == "matrix":
+			return matrix[ref[2]]
+		}
+		return "$" + ref[2]
+	})
+}
+
+// expand converts the expressions of a command or a directory to the
+// variables flowcraft expands.
+func (c *converter) expand(s scope, text string) string {
+	return expression.ReplaceAllStringFunc(text, func(expr string) string {
+		inner := expression.FindStringSubmatch(expr)[1]
+		if name, ok := githubContext[inner]; ok {
+			return "${" + name + "}"
+		}
+		ref := contextRef.FindStringSubmatch(inner)
+		if ref == nil {
+			c.todo(s, "the expression '%s' is not converted", expr)
+			return expr
+		}
+		switch ref[1] {
+		case "matrix":
+			return s.matrix[ref[2]]
+		case "env":
+			return "${" + ref[2] + "}"
+		case "vars":
+			c.todo(s, "vars.%s is read from the host variable %s", ref[2], ref[2])
+			c.vars[ref[2]] = true
+			return "${" + ref[2] + "}"
+		}
+		return "${" + c.secret(s, ref[2], ref[2]) + "}"
+	})
+}
@@ -0,0 +1,318 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ghactions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+)
+
+// convert converts a workflow and parses the generated file as
+// 'flowcraft validate' does.
+func convert(t *testing.T, workflow string) (*config.Config, *Result) {
+	t.Helper()
+	result, err := Convert([]byte(workflow), "ci.yml")
+	if err != nil {
+		t.Fatalf("Convert() returned an unexpected error: %v", err)
+	}
+	cfg, err := config.Parse(result.Config)
+	if err != nil {
+		t.Fatalf("Parse() of the converted file returned an unexpected error: %v\n%s", err, result.Config)
+	}
+	if _, err := engine.BuildDag(cfg); err != nil {
+		t.Fatalf("BuildDag() of the converted file returned an unexpected error: %v\n%s", err, result.Config)
+	}
+	return cfg, result
+}
+
+func issues(result *Result) []string {
+	var lines []string
+	for _, issue := range result.Issues {
+		lines = append(lines, issue.String())
+	}
+	return lines
+}
+
+func TestConvert_Jobs(t *testing.T) {
+	cfg, result := convert(t, `
+name: CI
+on:
+  push:
+    branches: [main]
+  pull_request:
+    branches: [main]
+env:
+  GOFLAGS: -mod=mod
+  NPM_TOKEN: ${{ secrets.NPM_TOKEN }}
+defaults:
+  run:
+    working-directory: api
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - name: Vet
+        run: go vet ./...
+  test:
+    needs: lint
+    env:
+      CGO_ENABLED: "0"
+    steps:
+      - name: Test
+        working-directory: ./cmd
+        env:
+          API_KEY: ${{ secrets.API_TOKEN }}
+        run: |
+          go test ./...
+          echo "${{ github.sha }} ${{ env.GOFLAGS }} ${{ secrets.DEPLOY }}"
+`)
+
+	if want := (config.Triggers{Events: []string{"push", "pull_request"}, Branches: []string{"main"}}); !reflect.DeepEqual(cfg.Triggers, want) {
+		t.Errorf("Triggers = %+v, want %+v", cfg.Triggers, want)
+	}
+	if want := map[string]string{"GOFLAGS": "-mod=mod"}; !reflect.DeepEqual(cfg.Env, want) {
+		t.Errorf("Env = %v, want %v", cfg.Env, want)
+	}
+	wantSecrets := map[string]config.Secret{
+		"NPM_TOKEN": {Provider: "env", Key: "NPM_TOKEN"},
+		"API_KEY":   {Provider: "env", Key: "API_TOKEN"},
+		"DEPLOY":    {Provider: "env", Key: "DEPLOY"},
+	}
+	if !reflect.DeepEqual(cfg.Secrets, wantSecrets) {
+		t.Errorf("Secrets = %v, want %v", cfg.Secrets, wantSecrets)
+	}
+
+	lint := cfg.Jobs["lint"]
+	if want := []config.Step{{Name: "Vet", Cmd: "go vet ./...", Dir: "api"}}; !reflect.DeepEqual(lint.Steps, want) {
+		t.Errorf("lint steps = %+v, want %+v", lint.Steps, want)
+	}
+	test := cfg.Jobs["test"]
+	if want := []string{"lint"}; !reflect.DeepEqual(test.DependsOn, want) {
+		t.Errorf("test depends on %v, want %v", test.DependsOn, want)
+	}
+	if want := []string{"NPM_TOKEN", "API_KEY", "DEPLOY"}; !reflect.DeepEqual(test.Secrets, want) {
+		t.Errorf("test secrets = %v, want %v", test.Secrets, want)
+	}
+	if want := map[string]string{"CGO_ENABLED": "0"}; !reflect.DeepEqual(test.Env, want) {
+		t.Errorf("test env = %v, want %v", test.Env, want)
+	}
+	wantStep := config.Step{
+		Name: "Test",
+		Cmd:  "set -e\ngo test ./...\necho \"${CI_SHA} ${GOFLAGS} ${DEPLOY}\"",
+		Dir:  "./cmd",
+	}
+	if len(test.Steps) != 1 || !reflect.DeepEqual(test.Steps[0], wantStep) {
+		t.Errorf("test steps = %+v, want [%+v]", test.Steps, wantStep)
+	}
+	if len(result.Issues) != 0 {
+		t.Errorf("Issues = %v, want none", issues(result))
+	}
+}
+
+func TestConvert_Matrix(t *testing.T) {
+	cfg, result := convert(t, `
+on: push
+jobs:
+  test:
+    strategy:
+      matrix:
+        go: ["1.21", "1.22"]
+        os: [linux, darwin]
+        exclude:
+          - go: "1.21"
+            os: darwin
+        include:
+          - go: "1.22"
+            os: linux
+            coverage: "-cover"
+          - go: tip
+    steps:
+      - run: go test ${{ matrix.coverage }} ./...
+        env:
+          GOOS: ${{ matrix.os }}
+  publish:
+    needs: test
+    steps:
+      - run: ./publish.sh
+`)
+
+	wantCmds := map[string]string{
+		"test-1-21-linux":  "go test  ./...",
+		"test-1-22-linux":  "go test -cover ./...",
+		"test-1-22-darwin": "go test  ./...",
+		"test-tip":         "go test  ./...",
+	}
+	for name, cmd := range wantCmds {
+		job, ok := cfg.Jobs[name]
+		if !ok || len(job.Steps) != 1 || job.Steps[0].Cmd != cmd {
+			t.Errorf("job %s = %+v, want a step running %q", name, job, cmd)
+		}
+	}
+	if got := cfg.Jobs["test-1-22-darwin"].Env["GOOS"]; got != "darwin" {
+		t.Errorf("GOOS of test-1-22-darwin = %q, want darwin", got)
+	}
+	want := []string{"test-1-21-linux", "test-1-22-linux", "test-1-22-darwin", "test-tip"}
+	if got := cfg.Jobs["publish"].DependsOn; !reflect.DeepEqual(got, want) {
+		t.Errorf("publish depends on %v, want %v", got, want)
+	}
+	if len(cfg.Jobs) != 5 {
+		t.Errorf("got %d jobs, want 5", len(cfg.Jobs))
+	}
+	// The values missing from a combination are empty, as on GitHub.
+	if len(result.Issues) != 0 {
+		t.Errorf("Issues = %v, want none", issues(result))
+	}
+}
+
+func TestConvert_Report(t *testing.T) {
+	cfg, result := convert(t, `
+on:
+  push:
+    tags: ["v*"]
+  schedule:
+    - cron: "0 0 * * *"
+jobs:
+  build:
+    if: always()
+    timeout-minutes: ${{ inputs.timeout }}
+    environment: production
+    steps:
+      - uses: actions/setup-go@v5
+        with:
+          go-version: "1.22"
+      - name: Build
+        if: success()
+        run: for f in *.go; do echo $f; done
+      - name: Windows
+        shell: pwsh
+        run: Write-Host hi
+      - uses: actions/checkout@v4
+        with:
+          repository: acme/tools
+          path: tools
+          token: ${{ secrets.TOOLS_TOKEN }}
+`)
+
+	want := []string{
+		"'on.push.tags' is not converted",
+		"the 'schedule' event is not converted",
+		"job 'build': if: always() is not converted: the job always runs",
+		"job 'build': timeout-minutes: ${{ inputs.timeout }} is not converted",
+		"job 'build': the environment 'production' is not converted: set approve = true if its deployments need a review",
+		"job 'build', step 'actions/setup-go@v5': the action 'actions/setup-go@v5' is not converted: install the tool on the host instead",
+		"job 'build', step 'Build': $f are expanded by flowcraft from the host environment before bash runs",
+		"job 'build', step 'Windows': the shell 'pwsh' is not converted: flowcraft runs steps with bash",
+	}
+	if got := issues(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("Issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	for _, todo := range []string{
+		"# TODO: the action 'actions/setup-go@v5' is not converted",
+		"# [[jobs.build.steps]]\n# name = \"actions/setup-go@v5\"\n# uses = \"actions/setup-go@v5\"\n# with = { go-version = \"1.22\" }\n",
+		"# TODO: timeout-minutes: ${{ inputs.timeout }} is not converted\n",
+	} {
+		if !strings.Contains(string(result.Config), todo) {
+			t.Errorf("the converted file does not contain %q:\n%s", todo, result.Config)
+		}
+	}
+
+	steps := cfg.Jobs["build"].Steps
+	wantCheckout := &config.Checkout{Repository: "https://github.com/acme/tools.git", Path: "tools", Depth: 1, Credentials: "TOOLS_TOKEN"}
+	if len(steps) != 2 || steps[0].Name != "Build" || !reflect.DeepEqual(steps[1].Checkout, wantCheckout) {
+		t.Errorf("build steps = %+v, want Build and a checkout of %+v", steps, wantCheckout)
+	}
+	if len(cfg.Triggers.Events) != 1 || cfg.Triggers.Events[0] != "push" {
+		t.Errorf("Triggers.Events = %v, want [push]", cfg.Triggers.Events)
+	}
+}
+
+func TestConvert_ConditionsAndTimeouts(t *testing.T) {
+	cfg, result := convert(t, `
+on: push
+jobs:
+  build:
+    strategy:
+      matrix:
+        os: [linux, darwin]
+    timeout-minutes: 30
+    steps:
+      - run: make
+        timeout-minutes: 1.5
+      - name: Package for ${{ matrix.os }}
+        if: matrix.os == 'Linux' && github.event_name == 'push'
+        run: make package
+      - name: Never
+        if: ${{ matrix.os != 'linux' && matrix.os != 'darwin' }}
+        run: make never
+  deploy:
+    needs: build
+    if: github.ref == 'refs/heads/main' || env.FORCE != ''
+    steps:
+      - run: echo "deploy ${{ github.ref_name }}"
+`)
+
+	linux := cfg.Jobs["build-linux"]
+	if linux.Timeout != "30m" || len(linux.Steps) != 2 || linux.Steps[0].Timeout != "1m30s" {
+		t.Fatalf("build-linux = %+v, want a 30m job with 2 steps, the first with a 1m30s timeout", linux)
+	}
+	wantPackage := config.Step{
+		Name: "Package for linux",
+		Cmd:  "[[ \"${CI_EVENT}\" == 'push' ]] || exit 0\nmake package",
+	}
+	if !reflect.DeepEqual(linux.Steps[1], wantPackage) {
+		t.Errorf("package step = %+v, want %+v", linux.Steps[1], wantPackage)
+	}
+	if steps := cfg.Jobs["build-darwin"].Steps; len(steps) != 1 {
+		t.Errorf("build-darwin steps = %+v, want the package step to be skipped", steps)
+	}
+	if !strings.Contains(string(result.Config), "# Step 'Never' is skipped: if: matrix.os != 'linux' && matrix.os != 'darwin' never holds here.") {
+		t.Errorf("the converted file does not note the skipped step:\n%s", result.Config)
+	}
+
+	wantDeploy := config.Step{
+		Name: `Run echo "deploy $CI_BRANCH"`,
+		Cmd:  "[[ \"${CI_BRANCH}\" == 'main' || \"${FORCE}\" != '' ]] || exit 0\necho \"deploy ${CI_BRANCH}\"",
+	}
+	if steps := cfg.Jobs["deploy"].Steps; len(steps) != 1 || !reflect.DeepEqual(steps[0], wantDeploy) {
+		t.Errorf("deploy steps = %+v, want [%+v]", steps, wantDeploy)
+	}
+	if got := issues(result); len(got) != 0 {
+		t.Errorf("Issues = %v, want none", got)
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"- a\n", "ci.yml is not a workflow"},
+		{"on: push\n", "ci.yml has no jobs"},
+		{"jobs:\n  a: [\n", "error during parsing yaml of ci.yml: yaml: line 2: did not find expected node content"},
+	}
+	for _, tt := range tests {
+		if _, err := Convert([]byte(tt.in), "ci.yml"); err == nil || err.Error() != tt.want {
+			t.Errorf("Convert(%q) error = %v, want %q", tt.in, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ghactions
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/tomlfmt"
+)
+
+func (c *converter) generate(source string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Imported from %s by 'flowcraft import github-actions'.\n", comment(source))
+	if len(c.issues) > 0 {
+		buf.WriteString("# Resolve the TODO comments, check the file with 'flowcraft validate', then\n# run the pipeline with 'flowcraft run'.\n")
+	} else {
+		buf.WriteString("# Check the file with 'flowcraft validate', then run the pipeline with\n# 'flowcraft run'.\n")
+	}
+	if name := c.workflow.get("name").str(); name != "" {
+		fmt.Fprintf(&buf, "# Workflow: %s\n", comment(name))
+	}
+	for _, issue := range c.issues {
+		if issue.Job == "" {
+			fmt.Fprintf(&buf, "# TODO: %s\n", comment(issue.Message))
+		}
+	}
+
+	if t := c.triggers; len(t.Events) > 0 {
+		buf.WriteString("\n[triggers]\n")
+		fmt.Fprintf(&buf, "events = %s\n", tomlfmt.Array(t.Events))
+		if len(t.Branches) > 0 {
+			fmt.Fprintf(&buf, "branches = %s\n", tomlfmt.Array(t.Branches))
+		}
+		if len(t.Paths) > 0 {
+			fmt.Fprintf(&buf, "paths = %s\n", tomlfmt.Array(t.Paths))
+		}
+	}
+	if len(c.env) > 0 {
+		buf.WriteString("\n[env]\n")
+		writeVariables(&buf, c.env)
+	}
+	for _, name := range c.secretOrder {
+		fmt.Fprintf(&buf, "\n[secrets.%s]\n", tomlfmt.Key(name))
+		buf.WriteString("provider = \"env\"\n")
+		fmt.Fprintf(&buf, "key = %s\n", tomlfmt.String(c.secrets[name]))
+	}
+
+	for _, j := range c.jobs {
+		table := "jobs." + tomlfmt.Key(j.name)
+		fmt.Fprintf(&buf, "\n# %s\n[%s]\n", comment(j.comment), table)
+		if len(j.needs) > 0 {
+			fmt.Fprintf(&buf, "depends_on = %s\n", tomlfmt.Array(j.needs))
+		}
+		if len(j.secrets) > 0 {
+			fmt.Fprintf(&buf, "secrets = %s\n", tomlfmt.Array(j.secrets))
+		}
+		if j.timeout != "" {
+			fmt.Fprintf(&buf, "timeout = %s\n", tomlfmt.String(j.timeout))
+		}
+		writeTodos(&buf, j.todos)
+		for _, line := range j.disabled {
+			fmt.Fprintf(&buf, "# %s\n", line)
+		}
+		if len(j.env) > 0 {
+			fmt.Fprintf(&buf, "\n[%s.env]\n", table)
+			writeVariables(&buf, j.env)
+		}
+
+		for _, st := range j.steps {
+			buf.WriteString("\n")
+			if st.note != "" {
+				fmt.Fprintf(&buf, "# %s\n", comment(st.note))
+			}
+			writeTodos(&buf, st.todos)
+			switch {
+			case st.cfg != nil:
+				fmt.Fprintf(&buf, "[[%s.steps]]\n", table)
+				writeStep(&buf, st.cfg)
+			case st.disabled != nil:
+				fmt.Fprintf(&buf, "# [[%s.steps]]\n", table)
+				for _, line := range st.disabled {
+					fmt.Fprintf(&buf, "# %s\n", line)
+				}
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func writeStep(buf *bytes.Buffer, st *config.Step) {
+	fmt.Fprintf(buf, "name = %s\n", tomlfmt.String(st.Name))
+	if st.Cmd != "" {
+		fmt.Fprintf(buf, "cmd = %s\n", tomlfmt.Text(st.Cmd))
+	}
+	if st.Dir != "" {
+		fmt.Fprintf(buf, "dir = %s\n", tomlfmt.String(st.Dir))
+	}
+	if st.Timeout != "" {
+		fmt.Fprintf(buf, "timeout = %s\n", tomlfmt.String(st.Timeout))
+	}
+	if co := st.Checkout; co != nil {
+		fields := []string{"repository = " + tomlfmt.String(co.Repository)}
+		if co.Ref != "" {
+			fields = append(fields, "ref = "+tomlfmt.String(co.Ref))
+		}
+		if co.Path != "" {
+			fields = append(fields, "path = "+tomlfmt.String(co.Path))
+		}
+		if co.Depth > 0 {
+			fields = append(fields, "depth = "+strconv.Itoa(co.Depth))
+		}
+		if co.Submodules {
+			fields = append(fields, "submodules = true")
+		}
+		if len(co.Sparse) > 0 {
+			fields = append(fields, "sparse = "+tomlfmt.Array(co.Sparse))
+		}
+		if co.Credentials != "" {
+			fields = append(fields, "credentials = "+tomlfmt.String(co.Credentials))
+		}
+		fmt.Fprintf(buf, "checkout = { %s }\n", strings.Join(fields, ", "))
+	}
+}
+
+func writeVariables(buf *bytes.Buffer, vars []variable) {
+	for _, v := range vars {
+		fmt.Fprintf(buf, "%s = %s\n", tomlfmt.Key(v.key), tomlfmt.String(v.value))
+	}
+}
+
+func writeTodos(buf *bytes.Buffer, todos []string) {
+	for _, todo := range todos {
+		fmt.Fprintf(buf, "# TODO: %s\n", comment(todo))
+	}
+}
+
+// comment keeps text on a single comment line.
+func comment(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
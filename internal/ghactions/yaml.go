@@ -0,0 +1,195 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ghactions
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The workflow is parsed with yaml.v3 and turned into the tree below,
+// with the aliases and merge keys resolved. Every scalar is kept as a
+// string.
+
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	mappingNode
+	sequenceNode
+)
+
+type node struct {
+	kind  nodeKind
+	value string
+	pairs []pair
+	items []*node
+}
+
+type pair struct {
+	key   string
+	value *node
+}
+
+// get returns the value of key in a mapping, or nil.
+func (n *node) get(key string) *node {
+	if n == nil || n.kind != mappingNode {
+		return nil
+	}
+	for _, p := range n.pairs {
+		if p.key == key {
+			return p.value
+		}
+	}
+	return nil
+}
+
+// entries returns the pairs of a mapping.
+func (n *node) entries() []pair {
+	if n == nil || n.kind != mappingNode {
+		return nil
+	}
+	return n.pairs
+}
+
+// elements returns the items of a sequence.
+func (n *node) elements() []*node {
+	if n == nil || n.kind != sequenceNode {
+		return nil
+	}
+	return n.items
+}
+
+// str returns the value of a scalar, or "".
+func (n *node) str() string {
+	if n == nil || n.kind != scalarNode {
+		return ""
+	}
+	return n.value
+}
+
+// list returns the scalars of a sequence, or the scalar itself when it
+// is not empty.
+func (n *node) list() []string {
+	if n == nil {
+		return nil
+	}
+	switch n.kind {
+	case scalarNode:
+		if n.value != "" {
+			return []string{n.value}
+		}
+	case sequenceNode:
+		values := make([]string, 0, len(n.items))
+		for _, item := range n.items {
+			if item.kind == scalarNode {
+				values = append(values, item.value)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// parseYAML parses the first YAML document of data.
+func parseYAML(data []byte) (*node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	t := &tree{anchors: make(map[*yaml.Node]*node), open: make(map[*yaml.Node]bool)}
+	return t.convert(&doc)
+}
+
+// tree converts yaml.v3 nodes. An anchored node is converted once and
+// shared by its aliases, so that nested aliases cannot blow up.
+type tree struct {
+	anchors map[*yaml.Node]*node
+	// open holds the anchored nodes being converted.
+	open map[*yaml.Node]bool
+}
+
+func (t *tree) convert(y *yaml.Node) (*node, error) {
+	if n, ok := t.anchors[y]; ok {
+		return n, nil
+	}
+	if y.Anchor == "" {
+		return t.build(y)
+	}
+	if t.open[y] {
+		return nil, fmt.Errorf("line %d: the anchor '%s' contains itself", y.Line, y.Anchor)
+	}
+	t.open[y] = true
+	n, err := t.build(y)
+	if err != nil {
+		return nil, err
+	}
+	t.anchors[y] = n
+	return n, nil
+}
+
+func (t *tree) build(y *yaml.Node) (*node, error) {
+	switch y.Kind {
+	case yaml.DocumentNode:
+		if len(y.Content) == 0 {
+			return &node{kind: scalarNode}, nil
+		}
+		return t.convert(y.Content[0])
+	case yaml.AliasNode:
+		return t.convert(y.Alias)
+	case yaml.SequenceNode:
+		n := &node{kind: sequenceNode, items: make([]*node, 0, len(y.Content))}
+		for _, item := range y.Content {
+			v, err := t.convert(item)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, v)
+		}
+		return n, nil
+	case yaml.MappingNode:
+		n := &node{kind: mappingNode}
+		var merged []pair
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			v, err := t.convert(y.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			key := y.Content[i]
+			if key.Tag == "!!merge" {
+				// The keys of the mapping win over the merged ones.
+				for _, m := range append([]*node{v}, v.elements()...) {
+					merged = append(merged, m.entries()...)
+				}
+				continue
+			}
+			n.pairs = append(n.pairs, pair{key: key.Value, value: v})
+		}
+		for _, p := range merged {
+			if n.get(p.key) == nil {
+				n.pairs = append(n.pairs, p)
+			}
+		}
+		return n, nil
+	}
+	if y.Tag == "!!null" && y.Value != "" && y.Style == 0 {
+		// "~" and "null" are empty, like an absent value.
+		return &node{kind: scalarNode}, nil
+	}
+	return &node{kind: scalarNode, value: y.Value}, nil
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gittest creates the git repositories used by tests.
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Git runs a git command for a test fixture and returns its output.
+func Git(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Flowcraft", "GIT_AUTHOR_EMAIL=ci@example.com",
+		"GIT_COMMITTER_NAME=Flowcraft", "GIT_COMMITTER_EMAIL=ci@example.com",
+		"GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=protocol.file.allow", "GIT_CONFIG_VALUE_0=always")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Commit writes files in the work tree dir, commits them and returns the commit.
+func Commit(t testing.TB, dir, message string, files map[string]string) string {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	Git(t, dir, "add", "-A")
+	Git(t, dir, "commit", "-q", "-m", message)
+	return Git(t, dir, "rev-parse", "HEAD")
+}
+
+// BareRepo creates a bare repository whose main branch holds two
+// commits, and returns its file:// URL and the commits, HEAD last.
+func BareRepo(t testing.TB) (string, []string) {
+	t.Helper()
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	bare := filepath.Join(root, "app.git")
+	Git(t, root, "init", "-q", "-b", "main", work)
+	first := Commit(t, work, "Initial commit", map[string]string{"src/main.go": "package main", "docs/README.md": "# App"})
+	second := Commit(t, work, "Add the changelog\n\nWith a body.", map[string]string{"CHANGELOG.md": "v1"})
+	Git(t, root, "clone", "-q", "--bare", work, bare)
+	return "file://" + bare, []string{first, second}
+}
@@ -0,0 +1,213 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package junit reads and writes JUnit XML test reports, the format
+// most test runners can produce and most CI systems display.
+package junit
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Testsuites is the root element of a report.
+type Testsuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr,omitempty"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     Seconds     `xml:"time,attr"`
+	Suites   []Testsuite `xml:"testsuite"`
+}
+
+// Testsuite groups test cases. Some runners nest suites.
+type Testsuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      Seconds     `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr,omitempty"`
+	Cases     []Testcase  `xml:"testcase"`
+	Suites    []Testsuite `xml:"testsuite"`
+}
+
+// Testcase is a single test. It failed when Failure or Error is set.
+type Testcase struct {
+	Name      string  `xml:"name,attr"`
+	Classname string  `xml:"classname,attr,omitempty"`
+	Time      Seconds `xml:"time,attr"`
+	Failure   *Result `xml:"failure"`
+	Error     *Result `xml:"error"`
+	Skipped   *Result `xml:"skipped"`
+	SystemOut string  `xml:"system-out,omitempty"`
+}
+
+// Result details why a test case failed or was skipped.
+type Result struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// Seconds is a duration attribute. Malformed values read as zero, as
+// reports are produced by many tools and only the outcomes matter.
+type Seconds float64
+
+func (s Seconds) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: strconv.FormatFloat(float64(s), 'f', 3, 64)}, nil
+}
+
+func (s *Seconds) UnmarshalXMLAttr(attr xml.Attr) error {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(attr.Value, ",", ""), 64)
+	if err == nil {
+		*s = Seconds(v)
+	}
+	return nil
+}
+
+// FullName identifies the test case in summaries, e.g.
+// "pkg.TestParse".
+func (c Testcase) FullName() string {
+	if c.Classname == "" {
+		return c.Name
+	}
+	return c.Classname + "." + c.Name
+}
+
+// Failed reports whether the test case failed or errored.
+func (c Testcase) Failed() bool {
+	return c.Failure != nil || c.Error != nil
+}
+
+// Parse reads a report whose root element is either <testsuites> or a
+// single <testsuite>.
+func Parse(r io.Reader) (*Testsuites, error) {
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, errors.New("no <testsuites> or <testsuite> element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "testsuites":
+			var suites Testsuites
+			if err := dec.DecodeElement(&suites, &start); err != nil {
+				return nil, err
+			}
+			return &suites, nil
+		case "testsuite":
+			var suite Testsuite
+			if err := dec.DecodeElement(&suite, &start); err != nil {
+				return nil, err
+			}
+			return &Testsuites{Suites: []Testsuite{suite}}, nil
+		default:
+			return nil, fmt.Errorf("unexpected root element <%s>", start.Name.Local)
+		}
+	}
+}
+
+// ParseFile reads the report at path.
+func ParseFile(path string) (*Testsuites, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	suites, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return suites, nil
+}
+
+// Write writes the report, filling in the counts of every suite from
+// its test cases.
+func Write(w io.Writer, suites Testsuites) error {
+	suites.Tests, suites.Failures, suites.Errors, suites.Skipped = 0, 0, 0, 0
+	for i := range suites.Suites {
+		s := &suites.Suites[i]
+		count(s)
+		suites.Tests += s.Tests
+		suites.Failures += s.Failures
+		suites.Errors += s.Errors
+		suites.Skipped += s.Skipped
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteFile writes the report to path.
+func WriteFile(path string, suites Testsuites) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, suites); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func count(s *Testsuite) {
+	s.Tests, s.Failures, s.Errors, s.Skipped = 0, 0, 0, 0
+	for i := range s.Suites {
+		child := &s.Suites[i]
+		count(child)
+		s.Tests += child.Tests
+		s.Failures += child.Failures
+		s.Errors += child.Errors
+		s.Skipped += child.Skipped
+	}
+	for _, c := range s.Cases {
+		s.Tests++
+		switch {
+		case c.Failure != nil:
+			s.Failures++
+		case c.Error != nil:
+			s.Errors++
+		case c.Skipped != nil:
+			s.Skipped++
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package junit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const goReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="4" failures="1" errors="1">
+	<testsuite name="pkg/a" tests="2" failures="1" time="0.5">
+		<testcase classname="pkg/a" name="TestOK" time="0.1"></testcase>
+		<testcase classname="pkg/a" name="TestBroken" time="0.4">
+			<failure message="Failed" type="">want 1, got 2</failure>
+		</testcase>
+	</testsuite>
+	<testsuite name="pkg/b" tests="2" errors="1" time="1,200.5">
+		<testcase classname="pkg/b" name="TestPanic"><error message="panic"></error></testcase>
+		<testcase classname="pkg/b" name="TestLater"><skipped message="TODO"/></testcase>
+	</testsuite>
+</testsuites>`
+
+func TestParse(t *testing.T) {
+	suites, err := Parse(strings.NewReader(goReport))
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	if len(suites.Suites) != 2 || len(suites.Suites[0].Cases) != 2 {
+		t.Fatalf("Parse() = %+v, want 2 suites of 2 cases", suites)
+	}
+	if got := suites.Suites[1].Time; got != 1200.5 {
+		t.Errorf("time = %v, want 1200.5", got)
+	}
+	if got := suites.Suites[0].Cases[1].Failure.Text; got != "want 1, got 2" {
+		t.Errorf("failure text = %q", got)
+	}
+
+	got := Summarize(suites)
+	want := Summary{
+		Reports:  1,
+		Tests:    4,
+		Passed:   1,
+		Failed:   2,
+		Skipped:  1,
+		Failures: []string{"pkg/a.TestBroken", "pkg/b.TestPanic"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+	if s := got.String(); s != "1 passed, 2 failed, 1 skipped" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestParse_SingleSuite(t *testing.T) {
+	report := `<testsuite name="jest"><testsuite name="nested"><testcase name="renders"/></testsuite><testcase name="loads"/></testsuite>`
+	suites, err := Parse(strings.NewReader(report))
+	if err != nil {
+		t.Fatalf("Parse() returned an unexpected error: %v", err)
+	}
+	if got := Summarize(suites); got.Tests != 2 || got.Passed != 2 {
+		t.Errorf("Summarize() = %+v, want 2 passed tests", got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, report := range []string{"", "<html></html>", "<testsuites><testsuite"} {
+		if _, err := Parse(strings.NewReader(report)); err == nil {
+			t.Errorf("Parse(%q) returned no error", report)
+		}
+	}
+}
+
+func TestSummary_AddCapsFailures(t *testing.T) {
+	var total Summary
+	for i := 0; i < 3; i++ {
+		part := Summary{Reports: 1, Tests: MaxFailures, Failed: MaxFailures}
+		for j := 0; j < MaxFailures; j++ {
+			part.Failures = append(part.Failures, "t")
+		}
+		total.Add(part)
+	}
+	if total.Failed != 3*MaxFailures || len(total.Failures) != MaxFailures || total.Reports != 3 {
+		t.Errorf("Add() = %d failed, %d names, %d reports", total.Failed, len(total.Failures), total.Reports)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("junit.xml", goReport)
+	write("web/reports/junit.xml", `<testsuite name="web"><testcase name="ok"/></testsuite>`)
+	write("web/other.xml", `<testsuite name="other"><testcase name="ignored"/></testsuite>`)
+	write(".git/junit.xml", `<testsuite name="git"><testcase name="ignored"/></testsuite>`)
+	write("broken/junit.xml", `not xml`)
+
+	got, err := Collect(dir, []string{"**/junit.xml"})
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Collect() error = %v, want the broken report", err)
+	}
+	if got.Reports != 2 || got.Tests != 5 || got.Passed != 2 || got.Failed != 2 {
+		t.Errorf("Collect() = %+v, want 2 reports with 5 tests", got)
+	}
+
+	if _, err := Collect(dir, []string{"[z-a]"}); err == nil {
+		t.Error("Collect() with an invalid pattern returned no error")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	suites := Testsuites{
+		Name: "flowcraft",
+		Suites: []Testsuite{{
+			Name: "build",
+			Time: 1.5,
+			Cases: []Testcase{
+				{Name: "compile", Classname: "build", Time: 1},
+				{Name: "test", Classname: "build", Time: 0.5, Failure: &Result{Message: "exit status 1"}},
+			},
+		}, {
+			Name:  "deploy",
+			Cases: []Testcase{{Name: "deploy", Classname: "deploy", Skipped: &Result{Message: "skipped"}}},
+		}},
+	}
+
+	var b strings.Builder
+	if err := Write(&b, suites); err != nil {
+		t.Fatalf("Write() returned an unexpected error: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<testsuites name="flowcraft" tests="3" failures="1" errors="0" skipped="1" time="0.000">`,
+		`<testsuite name="build" tests="2" failures="1" errors="0" skipped="0" time="1.500">`,
+		`<failure message="exit status 1"></failure>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Write() output lacks %s:\n%s", want, out)
+		}
+	}
+
+	parsed, err := Parse(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("Parse() of the written report returned an error: %v", err)
+	}
+	if got := Summarize(parsed); got.Passed != 1 || got.Failed != 1 || got.Skipped != 1 {
+		t.Errorf("Summarize() of the written report = %+v", got)
+	}
+}
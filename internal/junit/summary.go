@@ -0,0 +1,143 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package junit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
+)
+
+// MaxFailures bounds the failed test names kept in a Summary.
+const MaxFailures = 50
+
+// Summary counts the outcomes of the test cases of one or more reports.
+type Summary struct {
+	// Reports is the number of report files read.
+	Reports int `json:"reports"`
+	Tests   int `json:"tests"`
+	Passed  int `json:"passed"`
+	// Failed counts the test cases with a failure or an error.
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	// Failures names the failed test cases, at most MaxFailures of them.
+	Failures []string `json:"failures,omitempty"`
+}
+
+// Summarize counts the test cases of a report.
+func Summarize(suites *Testsuites) Summary {
+	s := Summary{Reports: 1}
+	for _, suite := range suites.Suites {
+		s.addSuite(suite)
+	}
+	return s
+}
+
+func (s *Summary) addSuite(suite Testsuite) {
+	for _, child := range suite.Suites {
+		s.addSuite(child)
+	}
+	for _, c := range suite.Cases {
+		s.Tests++
+		switch {
+		case c.Failed():
+			s.Failed++
+			if len(s.Failures) < MaxFailures {
+				s.Failures = append(s.Failures, c.FullName())
+			}
+		case c.Skipped != nil:
+			s.Skipped++
+		default:
+			s.Passed++
+		}
+	}
+}
+
+// Add merges the counts of other into s.
+func (s *Summary) Add(other Summary) {
+	s.Reports += other.Reports
+	s.Tests += other.Tests
+	s.Passed += other.Passed
+	s.Failed += other.Failed
+	s.Skipped += other.Skipped
+	for _, name := range other.Failures {
+		if len(s.Failures) == MaxFailures {
+			break
+		}
+		s.Failures = append(s.Failures, name)
+	}
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d passed, %d failed, %d skipped", s.Passed, s.Failed, s.Skipped)
+}
+
+// Collect reads the reports under dir whose slash-separated relative
+// path matches one of patterns. The reports that cannot be read are
+// left out of the summary and reported in the error.
+func Collect(dir string, patterns []string) (Summary, error) {
+	globs := make([]*workspace.Glob, 0, len(patterns))
+	for _, pattern := range patterns {
+		g, err := workspace.CompileGlob(pattern)
+		if err != nil {
+			return Summary{}, err
+		}
+		globs = append(globs, g)
+	}
+	if dir == "" {
+		dir = "."
+	}
+
+	var summary Summary
+	var errs []error
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, g := range globs {
+			if !g.Match(rel) {
+				continue
+			}
+			suites, err := ParseFile(path)
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				summary.Add(Summarize(suites))
+			}
+			break
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return summary, errors.Join(errs...)
+}
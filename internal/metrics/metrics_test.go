@@ -0,0 +1,123 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func text(t *testing.T, reg *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if err := reg.WriteText(&b); err != nil {
+		t.Fatalf("WriteText() returned an unexpected error: %v", err)
+	}
+	return b.String()
+}
+
+func TestWriteText(t *testing.T) {
+	reg := NewRegistry()
+	runs := reg.Counter("runs_total", "Runs by status.", "status")
+	workers := reg.Gauge("workers", "Busy workers.")
+	duration := reg.Histogram("duration_seconds", "Durations.", []float64{1, 5}, "job")
+
+	runs.Inc("success")
+	runs.Add(2, "failed")
+	workers.Add(3)
+	workers.Add(-1)
+	duration.Observe(0.5, `say "hi"`)
+	duration.Observe(1, `say "hi"`)
+	duration.Observe(7, `say "hi"`)
+
+	want := `# HELP runs_total Runs by status.
+# TYPE runs_total counter
+runs_total{status="failed"} 2
+runs_total{status="success"} 1
+# HELP workers Busy workers.
+# TYPE workers gauge
+workers 2
+# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{job="say \"hi\"",le="1"} 2
+duration_seconds_bucket{job="say \"hi\"",le="5"} 2
+duration_seconds_bucket{job="say \"hi\"",le="+Inf"} 3
+duration_seconds_sum{job="say \"hi\""} 8.5
+duration_seconds_count{job="say \"hi\""} 3
+`
+	if got := text(t, reg); got != want {
+		t.Errorf("Unexpected exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistry_Panics(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.Counter("jobs_total", "Jobs.", "job")
+	for name, fn := range map[string]func(){
+		"duplicate":      func() { reg.Gauge("jobs_total", "Again.") },
+		"label count":    func() { c.Inc() },
+		"negative":       func() { c.Add(-1, "build") },
+		"unsorted bound": func() { reg.Histogram("h", "H.", []float64{5, 1}) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected a panic on %s", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestHandler(t *testing.T) {
+	reg := NewRegistry()
+	reg.Counter("hits_total", "Hits.").Inc()
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "hits_total 1\n") {
+		t.Errorf("Expected the counter in the body, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	reg := NewRegistry()
+	reg.Gauge("up", "Up.").Set(1)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flowcraft.prom")
+	if err := reg.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile() returned an unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the metrics file: %v", err)
+	}
+	if !strings.Contains(string(data), "up 1\n") {
+		t.Errorf("Unexpected metrics file:\n%s", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected the temporary file to be renamed, found %d entries", len(entries))
+	}
+}
@@ -0,0 +1,284 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+// Outputs exported by a checkout step.
+const (
+	OutputGitSHA     = "git.sha"
+	OutputGitBranch  = "git.branch"
+	OutputGitMessage = "git.message"
+)
+
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// checkout runs a checkout step: it clones the repository into the
+// destination, or fetches into the clone already there, and checks out
+// the ref. The commit is exported as git.sha, git.branch and
+// git.message.
+func checkout(ctx context.Context, step config.Step, env *environment, opts Options, logger *Logger) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := runCheckout(ctx, *step.Checkout, env, opts, logger); err != nil {
+		if ctx.Err() == context.Canceled {
+			return context.Canceled
+		}
+		wrappedError := fmt.Errorf("step '%s' failed: %w", step.Name, err)
+		logger.Error(wrappedError.Error())
+		return wrappedError
+	}
+	logger.Success(fmt.Sprintf("Step '%s' completed successfully", step.Name))
+	return nil
+}
+
+func runCheckout(ctx context.Context, c config.Checkout, vars *environment, opts Options, logger *Logger) error {
+	repo := vars.expand(c.Repository)
+	if repo == "" {
+		repo, _ = vars.lookup("CI_REPOSITORY_URL")
+	}
+	if repo == "" {
+		return errors.New("no repository to check out: set checkout.repository, or start the run from a webhook")
+	}
+	ref, branch := vars.expand(c.Ref), ""
+	if ref == "" {
+		ref, _ = vars.lookup("CI_SHA")
+		branch, _ = vars.lookup("CI_BRANCH")
+	}
+	dest := vars.expand(c.Path)
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(opts.Workdir, dest)
+	}
+
+	var settings [][2]string
+	if c.Credentials != "" {
+		token, _ := vars.lookup(c.Credentials)
+		if token == "" {
+			return fmt.Errorf("the credentials secret '%s' is empty or not available", c.Credentials)
+		}
+		header, err := authHeader(repo, token)
+		if err != nil {
+			return err
+		}
+		logger.SetSecretsToMask([]string{header[1]})
+		settings = append(settings, header)
+	}
+	if c.Submodules && strings.HasPrefix(repo, "file://") {
+		// Git refuses local submodules by default; a local superproject
+		// may use them.
+		settings = append(settings, [2]string{"protocol.file.allow", "always"})
+	}
+	env := append(slices.Clone(vars.env), "GIT_TERMINAL_PROMPT=0")
+	env = append(env, gitConfigEnv(settings)...)
+
+	g := &gitCmd{ctx: ctx, dir: dest, env: env, logger: logger}
+	target := repo
+	if ref != "" {
+		target += "@" + ref
+	}
+	logger.Info(fmt.Sprintf("Checking out %s into %s", target, dest))
+
+	if _, err := os.Stat(filepath.Join(dest, ".git")); err == nil {
+		// Never clobber work in progress, e.g. when a local run checks
+		// out into the project being worked on.
+		if origin, _ := g.run("remote", "get-url", "origin"); origin != repo {
+			return fmt.Errorf("%s already holds a clone of '%s'", dest, origin)
+		}
+		status, err := g.run("status", "--porcelain", "--untracked-files=no")
+		if err != nil {
+			return err
+		}
+		if status != "" {
+			return fmt.Errorf("refusing to check out over the uncommitted changes in %s", dest)
+		}
+	} else {
+		if err := os.MkdirAll(dest, 0o755); err != nil {
+			return err
+		}
+		if _, err := g.run("init", "-q"); err != nil {
+			return err
+		}
+		if _, err := g.run("remote", "add", "origin", repo); err != nil {
+			return err
+		}
+	}
+	if len(c.Sparse) > 0 {
+		if _, err := g.run(append([]string{"sparse-checkout", "set", "--"}, c.Sparse...)...); err != nil {
+			return err
+		}
+	}
+
+	if ref == "" {
+		head, err := g.run("ls-remote", "--symref", "origin", "HEAD")
+		if err != nil {
+			return err
+		}
+		if branch = defaultBranch(head); branch == "" {
+			return fmt.Errorf("cannot find the default branch of %s", repo)
+		}
+		ref = branch
+	}
+
+	fetch := []string{"fetch", "--no-tags"}
+	if c.Depth > 0 {
+		fetch = append(fetch, "--depth", strconv.Itoa(c.Depth))
+	}
+	if _, err := g.run(append(fetch, "origin", ref)...); err != nil {
+		return err
+	}
+	if branch == "" && !shaPattern.MatchString(ref) {
+		branch = fetchedBranch(filepath.Join(dest, ".git", "FETCH_HEAD"))
+	}
+
+	if branch != "" {
+		_, err := g.run("checkout", "-q", "--force", "-B", branch, "FETCH_HEAD")
+		if err != nil {
+			return err
+		}
+	} else if _, err := g.run("checkout", "-q", "--force", "--detach", "FETCH_HEAD"); err != nil {
+		return err
+	}
+
+	if c.Submodules {
+		update := []string{"submodule", "update", "--init", "--recursive", "--force"}
+		if c.Depth > 0 {
+			update = append(update, "--depth", strconv.Itoa(c.Depth))
+		}
+		if _, err := g.run(update...); err != nil {
+			return err
+		}
+	}
+
+	sha, err := g.run("rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	message, err := g.run("log", "-1", "--format=%B")
+	if err != nil {
+		return err
+	}
+	opts.Outputs.Set(OutputGitSHA, sha)
+	opts.Outputs.Set(OutputGitBranch, branch)
+	opts.Outputs.Set(OutputGitMessage, message)
+
+	subject, _, _ := strings.Cut(message, "\n")
+	logger.Info(fmt.Sprintf("Checked out %s: %s", sha[:min(len(sha), 12)], subject))
+	return nil
+}
+
+// gitCmd runs git commands in a directory, logging what they print on
+// stderr.
+type gitCmd struct {
+	ctx    context.Context
+	dir    string
+	env    []string
+	logger *Logger
+}
+
+// run returns the trimmed standard output of a git command.
+func (g *gitCmd) run(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(g.ctx, "git", args...)
+	cmd.Dir = g.dir
+	cmd.Env = g.env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			g.logger.Info(line)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// authHeader returns the setting sending token to the host of repo,
+// and to that host only, so that submodules hosted elsewhere never
+// receive it. A token without a "user:" prefix is sent as the
+// x-access-token user, as GitHub and GitLab expect.
+func authHeader(repo, token string) ([2]string, error) {
+	u, err := url.Parse(repo)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return [2]string{}, fmt.Errorf("credentials can only be sent to http(s) repositories, not to '%s'", repo)
+	}
+	if !strings.Contains(token, ":") {
+		token = "x-access-token:" + token
+	}
+	key := fmt.Sprintf("http.%s://%s/.extraHeader", u.Scheme, u.Host)
+	return [2]string{key, "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(token))}, nil
+}
+
+// gitConfigEnv passes settings to git through GIT_CONFIG_* variables,
+// which keeps them off the command line and out of the repository.
+func gitConfigEnv(settings [][2]string) []string {
+	if len(settings) == 0 {
+		return nil
+	}
+	env := []string{fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(settings))}
+	for i, s := range settings {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, s[0]), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, s[1]))
+	}
+	return env
+}
+
+// defaultBranch parses the output of 'git ls-remote --symref origin HEAD'.
+func defaultBranch(lsRemote string) string {
+	for _, line := range strings.Split(lsRemote, "\n") {
+		if ref, ok := strings.CutPrefix(line, "ref: refs/heads/"); ok {
+			name, _, _ := strings.Cut(ref, "\t")
+			return name
+		}
+	}
+	return ""
+}
+
+// fetchedBranch returns the branch recorded in FETCH_HEAD, or "" when
+// the fetched ref was not a branch.
+func fetchedBranch(fetchHead string) string {
+	data, err := os.ReadFile(fetchHead)
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	_, rest, ok := strings.Cut(line, "\tbranch '")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, "' of ")
+	return name
+}
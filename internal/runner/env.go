@@ -0,0 +1,185 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+// EnvPolicy controls which host variables are visible to a step,
+// both in the process environment and in the command expander.
+type EnvPolicy struct {
+	// Inherit passes the whole host environment to the step.
+	Inherit bool
+	// Pass lists host variables passed to the step when Inherit is false.
+	Pass []string
+	// WarnUndeclared reports host variables referenced by the step
+	// that are not declared in the config.
+	WarnUndeclared bool
+}
+
+// DefaultEnvPolicy inherits the host environment, as flowcraft always did.
+func DefaultEnvPolicy() EnvPolicy {
+	return EnvPolicy{Inherit: true}
+}
+
+func (p EnvPolicy) allows(key string) bool {
+	return p.Inherit || slices.Contains(p.Pass, key)
+}
+
+// hostEnv returns the host variables allowed by the policy, in KEY=VALUE form.
+func (p EnvPolicy) hostEnv() []string {
+	if p.Inherit {
+		return os.Environ()
+	}
+	var env []string
+	for _, key := range p.Pass {
+		if val, ok := os.LookupEnv(key); ok {
+			env = append(env, fmt.Sprintf("%s=%s", key, val))
+		}
+	}
+	return env
+}
+
+// runtimeVar is a variable only set in the environment of the process.
+type runtimeVar struct {
+	// env is its name in the environment.
+	env   string
+	value string
+}
+
+// environment holds the variables of a step.
+type environment struct {
+	custom map[string]string
+	// runtime holds the variables of Options.ProcessEnv and the
+	// outputs, by the name commands reference them with.
+	runtime map[string]runtimeVar
+	policy  EnvPolicy
+	// env is the environment of the process, in KEY=VALUE form.
+	env []string
+}
+
+func buildEnvironment(customEnvs map[string]string, opts Options) *environment {
+	e := &environment{custom: customEnvs, runtime: make(map[string]runtimeVar), policy: opts.Env}
+	for name, value := range opts.Outputs.Values() {
+		env := OutputEnvName(name)
+		_, declared := customEnvs[env]
+		_, process := opts.ProcessEnv[env]
+		if declared || process {
+			// The declared variable takes precedence; ${git.sha}
+			// still refers to the output.
+			env = "FLOWCRAFT_OUTPUT_" + env
+		} else {
+			e.runtime[env] = runtimeVar{env: env, value: value}
+		}
+		e.runtime[name] = runtimeVar{env: env, value: value}
+	}
+	for name, value := range opts.ProcessEnv {
+		e.runtime[name] = runtimeVar{env: name, value: value}
+	}
+
+	e.env = opts.Env.hostEnv()
+	for k, v := range customEnvs {
+		if _, ok := opts.ProcessEnv[k]; !ok {
+			e.env = append(e.env, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+	exported := make(map[string]bool)
+	for _, v := range e.runtime {
+		if !exported[v.env] {
+			exported[v.env] = true
+			e.env = append(e.env, fmt.Sprintf("%s=%s", v.env, v.value))
+		}
+	}
+	return e
+}
+
+// lookup returns the value of a variable of the config or the run.
+func (e *environment) lookup(key string) (string, bool) {
+	if v, ok := e.runtime[key]; ok {
+		return v.value, true
+	}
+	val, ok := e.custom[key]
+	return val, ok
+}
+
+// expand expands the variables of a value that no shell reads, such as
+// a directory or a repository URL.
+func (e *environment) expand(s string) string {
+	return os.Expand(s, func(key string) string {
+		if val, ok := e.lookup(key); ok {
+			return val
+		}
+		if !e.policy.allows(key) {
+			return ""
+		}
+		return os.Getenv(key)
+	})
+}
+
+// expandCommand expands the variables of a command, except the ones only
+// set in the process environment: their references are left to bash, so
+// that values coming from outside, such as a commit message, are never
+// read as shell code.
+func (e *environment) expandCommand(s string) string {
+	return os.Expand(s, func(key string) string {
+		if v, ok := e.runtime[key]; ok {
+			return "${" + v.env + "}"
+		}
+		if val, ok := e.custom[key]; ok {
+			return val
+		}
+		if !e.policy.allows(key) {
+			return ""
+		}
+		return os.Getenv(key)
+	})
+}
+
+// undeclaredHostVars returns the host variables referenced by the step's
+// command or directory that are neither declared nor allow-listed.
+// Only references visible to the expander are detected; variables read
+// by the spawned programs themselves cannot be observed.
+func (e *environment) undeclaredHostVars(step config.Step) []string {
+	seen := make(map[string]bool)
+	collect := func(key string) string {
+		if _, declared := e.lookup(key); declared {
+			return ""
+		}
+		if slices.Contains(e.policy.Pass, key) {
+			return ""
+		}
+		if _, onHost := os.LookupEnv(key); onHost {
+			seen[key] = true
+		}
+		return ""
+	}
+	os.Expand(step.Cmd, collect)
+	os.Expand(step.Dir, collect)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
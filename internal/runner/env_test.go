@@ -0,0 +1,131 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+func TestBuildEnvironment_Hermetic(t *testing.T) {
+	os.Setenv("FLOWCRAFT_TEST_PASSED", "passed")
+	os.Setenv("FLOWCRAFT_TEST_LEAKED", "leaked")
+	defer os.Unsetenv("FLOWCRAFT_TEST_PASSED")
+	defer os.Unsetenv("FLOWCRAFT_TEST_LEAKED")
+
+	policy := EnvPolicy{Inherit: false, Pass: []string{"FLOWCRAFT_TEST_PASSED"}}
+	env := buildEnvironment(map[string]string{"DECLARED": "declared"}, Options{Env: policy})
+
+	got := env.expand("$DECLARED $FLOWCRAFT_TEST_PASSED [$FLOWCRAFT_TEST_LEAKED]")
+	if got != "declared passed []" {
+		t.Errorf("Expected expander to hide undeclared host variables, got '%s'", got)
+	}
+
+	expected := []string{"FLOWCRAFT_TEST_PASSED=passed", "DECLARED=declared"}
+	if !reflect.DeepEqual(env.env, expected) {
+		t.Errorf("Expected environment %v, got %v", expected, env)
+	}
+}
+
+func TestBuildEnvironment_Inherit(t *testing.T) {
+	os.Setenv("FLOWCRAFT_TEST_LEAKED", "leaked")
+	defer os.Unsetenv("FLOWCRAFT_TEST_LEAKED")
+
+	env := buildEnvironment(nil, Options{Env: DefaultEnvPolicy()})
+	if got := env.expand("$FLOWCRAFT_TEST_LEAKED"); got != "leaked" {
+		t.Errorf("Expected 'leaked', got '%s'", got)
+	}
+	if !slices.Contains(env.env, "FLOWCRAFT_TEST_LEAKED=leaked") {
+		t.Error("Expected inherited environment to contain FLOWCRAFT_TEST_LEAKED")
+	}
+}
+
+func TestUndeclaredHostVars(t *testing.T) {
+	os.Setenv("FLOWCRAFT_TEST_HOST", "host")
+	os.Setenv("FLOWCRAFT_TEST_PASSED", "passed")
+	defer os.Unsetenv("FLOWCRAFT_TEST_HOST")
+	defer os.Unsetenv("FLOWCRAFT_TEST_PASSED")
+
+	step := config.Step{
+		Cmd: "echo $DECLARED ${FLOWCRAFT_TEST_HOST} $FLOWCRAFT_TEST_PASSED $NOT_ON_HOST",
+		Dir: "$FLOWCRAFT_TEST_HOST",
+	}
+	policy := EnvPolicy{Pass: []string{"FLOWCRAFT_TEST_PASSED"}, WarnUndeclared: true}
+
+	got := buildEnvironment(map[string]string{"DECLARED": "x"}, Options{Env: policy}).undeclaredHostVars(step)
+	expected := []string{"FLOWCRAFT_TEST_HOST"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected undeclared variables %v, got %v", expected, got)
+	}
+}
+
+func TestExpandCommand_RuntimeVariables(t *testing.T) {
+	opts := Options{
+		Env:        DefaultEnvPolicy(),
+		Outputs:    NewOutputs(map[string]string{"git.message": "msg", "git.sha": "abc"}),
+		ProcessEnv: map[string]string{"CI_BRANCH": "main"},
+	}
+	env := buildEnvironment(map[string]string{"GIT_SHA": "declared", "NAME": "app"}, opts)
+
+	got := env.expandCommand(`echo "$NAME ${git.message} $CI_BRANCH ${git.sha} $GIT_SHA"`)
+	want := `echo "app ${GIT_MESSAGE} ${CI_BRANCH} ${FLOWCRAFT_OUTPUT_GIT_SHA} declared"`
+	if got != want {
+		t.Errorf("expandCommand() = %s, want %s", got, want)
+	}
+	if got := env.expand("${git.sha}/$CI_BRANCH"); got != "abc/main" {
+		t.Errorf("expand() = %s, want abc/main", got)
+	}
+	for _, v := range []string{"GIT_MESSAGE=msg", "CI_BRANCH=main", "FLOWCRAFT_OUTPUT_GIT_SHA=abc", "GIT_SHA=declared"} {
+		if !slices.Contains(env.env, v) {
+			t.Errorf("Expected the environment to contain %s, got %v", v, env.env)
+		}
+	}
+}
+
+func TestExecute_ProcessEnvIsNotShellCode(t *testing.T) {
+	dir := t.TempDir()
+	pwned := filepath.Join(dir, "pwned")
+	message := `fix"; touch ` + pwned + `; echo "`
+	logger := NewLogger()
+	var out bytes.Buffer
+	logger.SetOutput(&out)
+	opts := Options{
+		Env:        DefaultEnvPolicy(),
+		Workdir:    dir,
+		Outputs:    NewOutputs(map[string]string{"git.message": message}),
+		ProcessEnv: map[string]string{"CI_COMMIT_MESSAGE": message},
+	}
+
+	step := config.Step{Name: "Print", Cmd: `echo "$CI_COMMIT_MESSAGE" && echo "${git.message}"`}
+	if err := Execute(context.Background(), step, nil, opts, logger); err != nil {
+		t.Fatalf("Execute() returned an unexpected error: %v\n%s", err, out.String())
+	}
+	if _, err := os.Stat(pwned); err == nil {
+		t.Fatal("The commit message was run as a shell command")
+	}
+	if strings.Count(out.String(), message) != 2 {
+		t.Errorf("Expected the message to be printed twice, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,335 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Log formats, selected with --log-format.
+const (
+	FormatAuto      = "auto"
+	FormatText      = "text"
+	FormatPlain     = "plain"
+	FormatGitHub    = "github"
+	FormatGitLab    = "gitlab"
+	FormatBuildkite = "buildkite"
+	FormatAzure     = "azure"
+	FormatTeamCity  = "teamcity"
+)
+
+// LogFormats lists the accepted log formats.
+var LogFormats = []string{FormatAuto, FormatText, FormatPlain, FormatGitHub, FormatGitLab, FormatBuildkite, FormatAzure, FormatTeamCity}
+
+// Formatter writes the entries of a Logger in the syntax of the system
+// displaying them, such as a terminal or the log viewer of a CI
+// provider. It is called with the output lock held, one entry at a time.
+type Formatter interface {
+	Format(w io.Writer, e Entry)
+	// Mask registers a secret with the CI provider, so that it also
+	// hides it from the output flowcraft does not write. Formatters of
+	// providers without masking commands ignore it.
+	Mask(w io.Writer, secret string)
+}
+
+// DetectFormat returns the log format of the CI provider running
+// flowcraft, or "text" outside of CI ("plain" when $NO_COLOR is set).
+func DetectFormat() string {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return FormatGitHub
+	case os.Getenv("GITLAB_CI") == "true":
+		return FormatGitLab
+	case os.Getenv("BUILDKITE") == "true":
+		return FormatBuildkite
+	case strings.EqualFold(os.Getenv("TF_BUILD"), "true"):
+		return FormatAzure
+	case os.Getenv("TEAMCITY_VERSION") != "":
+		return FormatTeamCity
+	case os.Getenv("NO_COLOR") != "":
+		return FormatPlain
+	}
+	return FormatText
+}
+
+// NewFormatter returns the formatter of a log format. "auto" and ""
+// detect it from the environment.
+func NewFormatter(format string) (Formatter, error) {
+	if format == "" || format == FormatAuto {
+		format = DetectFormat()
+	}
+	switch format {
+	case FormatText:
+		return textFormatter{color: true}, nil
+	case FormatPlain:
+		return textFormatter{}, nil
+	case FormatGitHub:
+		return &githubFormatter{}, nil
+	case FormatGitLab:
+		return &gitlabFormatter{sections: newSections()}, nil
+	case FormatBuildkite:
+		return buildkiteFormatter{}, nil
+	case FormatAzure:
+		return &azureFormatter{}, nil
+	case FormatTeamCity:
+		return &teamcityFormatter{sections: newSections()}, nil
+	}
+	return nil, fmt.Errorf("unknown log format '%s' (expected one of: %s)", format, strings.Join(LogFormats, ", "))
+}
+
+// textFormatter writes tagged lines, in color for terminals.
+type textFormatter struct {
+	color bool
+}
+
+func (f textFormatter) Format(w io.Writer, e Entry) {
+	paint := func(color, text string) string {
+		if !f.color {
+			return text
+		}
+		return color + text + ColorReset
+	}
+
+	switch e.Level {
+	case LevelError:
+		_, _ = fmt.Fprintln(w, paint(ColorRed, "[ERROR] "+e.Message))
+	case LevelWarn:
+		_, _ = fmt.Fprintln(w, paint(ColorYellow, "[WARN] "+e.Message))
+	case LevelSuccess:
+		_, _ = fmt.Fprintln(w, paint(ColorGreen, "[SUCCESS] "+e.Message))
+	case LevelGroup:
+		_, _ = fmt.Fprintf(w, "\n%s\n", paint(ColorYellow, "▶ "+e.Message))
+		_, _ = fmt.Fprintln(w, "------------------------------------------------")
+	case LevelEndGroup:
+		_, _ = fmt.Fprintln(w, "------------------------------------------------")
+	default:
+		_, _ = fmt.Fprintln(w, paint(ColorCyan, "[INFO] "+e.Message))
+	}
+}
+
+func (textFormatter) Mask(io.Writer, string) {}
+
+// githubFormatter writes GitHub Actions workflow commands: errors and
+// warnings become annotations and groups are foldable.
+type githubFormatter struct {
+	groups nesting
+}
+
+func (f *githubFormatter) Format(w io.Writer, e Entry) {
+	switch e.Level {
+	case LevelError:
+		_, _ = fmt.Fprintf(w, "::error::%s\n", githubEscape(e.Message))
+	case LevelWarn:
+		_, _ = fmt.Fprintf(w, "::warning::%s\n", githubEscape(e.Message))
+	case LevelGroup:
+		if f.groups.open() {
+			_, _ = fmt.Fprintf(w, "::group::%s\n", githubEscape(e.Message))
+		} else {
+			_, _ = fmt.Fprintf(w, "▶ %s\n", e.Message)
+		}
+	case LevelEndGroup:
+		if f.groups.close() {
+			_, _ = fmt.Fprintln(w, "::endgroup::")
+		}
+	default:
+		_, _ = fmt.Fprintln(w, e.Message)
+	}
+}
+
+// Mask writes ::add-mask::. Multi-line secrets are masked line by line
+// through the variants registered by SetSecretsToMask.
+func (*githubFormatter) Mask(w io.Writer, secret string) {
+	if strings.ContainsAny(secret, "\r\n") {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "::add-mask::%s\n", githubEscape(secret))
+}
+
+func githubEscape(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// nesting tracks the depth of the groups for providers whose groups
+// cannot nest: only the outermost group is a real one.
+type nesting struct {
+	depth int
+}
+
+// open reports whether a group opened now is an outermost one.
+func (n *nesting) open() bool {
+	n.depth++
+	return n.depth == 1
+}
+
+// close reports whether the group closed now is an outermost one.
+func (n *nesting) close() bool {
+	if n.depth == 0 {
+		return false
+	}
+	n.depth--
+	return n.depth == 0
+}
+
+// sections tracks the groups opened by each job, so that formatters
+// whose groups are closed by name know which one an end closes.
+type sections struct {
+	open map[string][]string
+}
+
+func newSections() sections {
+	return sections{open: make(map[string][]string)}
+}
+
+func (s *sections) push(job, name string) {
+	s.open[job] = append(s.open[job], name)
+}
+
+func (s *sections) pop(job string) (string, bool) {
+	stack := s.open[job]
+	if len(stack) == 0 {
+		return "", false
+	}
+	name := stack[len(stack)-1]
+	s.open[job] = stack[:len(stack)-1]
+	return name, true
+}
+
+// gitlabFormatter writes GitLab CI collapsible sections around groups
+// and colored lines otherwise.
+type gitlabFormatter struct {
+	sections sections
+	next     int
+}
+
+var sectionChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+func (f *gitlabFormatter) Format(w io.Writer, e Entry) {
+	switch e.Level {
+	case LevelGroup:
+		// Section names are unique and limited to [a-z0-9_.-].
+		f.next++
+		name := fmt.Sprintf("flowcraft_%d_%s", f.next, strings.Trim(sectionChars.ReplaceAllString(strings.ToLower(e.Message), "_"), "_"))
+		f.sections.push(e.Job, name)
+		_, _ = fmt.Fprintf(w, "\033[0Ksection_start:%d:%s\r\033[0K%s\n", timestamp(e), name, ColorYellow+e.Message+ColorReset)
+	case LevelEndGroup:
+		if name, ok := f.sections.pop(e.Job); ok {
+			_, _ = fmt.Fprintf(w, "\033[0Ksection_end:%d:%s\r\033[0K\n", timestamp(e), name)
+		}
+	default:
+		textFormatter{color: true}.Format(w, e)
+	}
+}
+
+func (*gitlabFormatter) Mask(io.Writer, string) {}
+
+// buildkiteFormatter writes Buildkite collapsed groups. A group has no
+// end: the next one starts after it. An error expands the current group.
+type buildkiteFormatter struct{}
+
+func (buildkiteFormatter) Format(w io.Writer, e Entry) {
+	switch e.Level {
+	case LevelGroup:
+		_, _ = fmt.Fprintf(w, "--- %s\n", e.Message)
+	case LevelEndGroup:
+	case LevelError:
+		_, _ = fmt.Fprintln(w, "^^^ +++")
+		textFormatter{color: true}.Format(w, e)
+	default:
+		textFormatter{color: true}.Format(w, e)
+	}
+}
+
+func (buildkiteFormatter) Mask(io.Writer, string) {}
+
+// azureFormatter writes Azure Pipelines formatting commands.
+type azureFormatter struct {
+	groups nesting
+}
+
+func (f *azureFormatter) Format(w io.Writer, e Entry) {
+	switch e.Level {
+	case LevelError:
+		_, _ = fmt.Fprintf(w, "##[error]%s\n", e.Message)
+	case LevelWarn:
+		_, _ = fmt.Fprintf(w, "##[warning]%s\n", e.Message)
+	case LevelSuccess:
+		_, _ = fmt.Fprintf(w, "##[section]%s\n", e.Message)
+	case LevelGroup:
+		if f.groups.open() {
+			_, _ = fmt.Fprintf(w, "##[group]%s\n", e.Message)
+		} else {
+			_, _ = fmt.Fprintf(w, "▶ %s\n", e.Message)
+		}
+	case LevelEndGroup:
+		if f.groups.close() {
+			_, _ = fmt.Fprintln(w, "##[endgroup]")
+		}
+	default:
+		_, _ = fmt.Fprintln(w, e.Message)
+	}
+}
+
+// Mask writes ##vso[task.setsecret].
+func (*azureFormatter) Mask(w io.Writer, secret string) {
+	if strings.ContainsAny(secret, "\r\n") {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "##vso[task.setsecret]%s\n", strings.NewReplacer("%", "%AZP25", ";", "%3B", "]", "%5D").Replace(secret))
+}
+
+// teamcityFormatter writes TeamCity service messages: groups become
+// blocks, errors and warnings messages with a status.
+type teamcityFormatter struct {
+	sections sections
+}
+
+func (f *teamcityFormatter) Format(w io.Writer, e Entry) {
+	switch e.Level {
+	case LevelError:
+		_, _ = fmt.Fprintf(w, "##teamcity[message text='%s' status='ERROR']\n", teamcityEscape(e.Message))
+	case LevelWarn:
+		_, _ = fmt.Fprintf(w, "##teamcity[message text='%s' status='WARNING']\n", teamcityEscape(e.Message))
+	case LevelGroup:
+		f.sections.push(e.Job, e.Message)
+		_, _ = fmt.Fprintf(w, "##teamcity[blockOpened name='%s']\n", teamcityEscape(e.Message))
+	case LevelEndGroup:
+		// Blocks are closed by the name they were opened with.
+		if name, ok := f.sections.pop(e.Job); ok {
+			_, _ = fmt.Fprintf(w, "##teamcity[blockClosed name='%s']\n", teamcityEscape(name))
+		}
+	default:
+		_, _ = fmt.Fprintln(w, e.Message)
+	}
+}
+
+func (*teamcityFormatter) Mask(io.Writer, string) {}
+
+func teamcityEscape(s string) string {
+	return strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace(s)
+}
+
+func timestamp(e Entry) int64 {
+	if e.Time.IsZero() {
+		return time.Now().Unix()
+	}
+	return e.Time.Unix()
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// formatted logs the same entries with each format.
+func formatted(t *testing.T, format string) string {
+	t.Helper()
+	f, err := NewFormatter(format)
+	if err != nil {
+		t.Fatalf("NewFormatter(%q) returned an unexpected error: %v", format, err)
+	}
+	var out bytes.Buffer
+	logger := NewLogger()
+	logger.SetOutput(&out)
+	logger.SetFormatter(f)
+
+	job := logger.WithJob("build")
+	job.StartGroup("Job: build")
+	job.StartGroup("Step: compile")
+	job.Info("compiling")
+	job.EndGroup()
+	job.Warn("slow [disk]")
+	job.Error("it's broken\nbadly")
+	job.Success("done")
+	job.EndGroup()
+	return out.String()
+}
+
+func TestFormatters(t *testing.T) {
+	tests := map[string][]string{
+		FormatPlain: {
+			"\n▶ Job: build\n------------------------------------------------\n",
+			"[INFO] compiling\n",
+			"[WARN] slow [disk]\n",
+			"[SUCCESS] done\n",
+		},
+		FormatGitHub: {
+			"::group::Job: build\n▶ Step: compile\ncompiling\n",
+			"::warning::slow [disk]\n",
+			"::error::it's broken%0Abadly\n",
+			"done\n::endgroup::\n",
+		},
+		FormatGitLab: {
+			":flowcraft_1_job_build\r\033[0K",
+			"\033[0Ksection_end:",
+			":flowcraft_1_job_build\r\033[0K\n",
+		},
+		FormatBuildkite: {
+			"--- Job: build\n",
+			"^^^ +++\n",
+		},
+		FormatAzure: {
+			"##[group]Job: build\n",
+			"##[warning]slow [disk]\n",
+			"##[group]Job: build\n▶ Step: compile\n",
+			"##[section]done\n##[endgroup]\n",
+		},
+		FormatTeamCity: {
+			"##teamcity[blockOpened name='Job: build']\n",
+			"##teamcity[message text='slow |[disk|]' status='WARNING']\n",
+			"##teamcity[message text='it|'s broken|nbadly' status='ERROR']\n",
+			"##teamcity[blockClosed name='Job: build']\n",
+		},
+	}
+	for format, wants := range tests {
+		out := formatted(t, format)
+		for _, want := range wants {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected %q in the %s output, got:\n%s", want, format, out)
+			}
+		}
+		if format != FormatGitLab && format != FormatBuildkite && strings.Contains(out, "\033[") {
+			t.Errorf("Expected no color in the %s output, got:\n%q", format, out)
+		}
+	}
+
+	if out := formatted(t, FormatGitHub); strings.Contains(out, "::success::") {
+		t.Errorf("Expected no ::success:: command, got:\n%s", out)
+	}
+	if _, err := NewFormatter("jenkins"); err == nil || !strings.Contains(err.Error(), "unknown log format 'jenkins'") {
+		t.Errorf("Expected an unknown log format error, got: %v", err)
+	}
+}
+
+func TestFormatters_GroupsPerJob(t *testing.T) {
+	f, _ := NewFormatter(FormatTeamCity)
+	var out bytes.Buffer
+	for _, e := range []Entry{
+		{Level: LevelGroup, Job: "a", Message: "Job: a"},
+		{Level: LevelGroup, Job: "b", Message: "Job: b"},
+		{Level: LevelEndGroup, Job: "a"},
+		{Level: LevelEndGroup, Job: "b"},
+		{Level: LevelEndGroup, Job: "b", Time: time.Now()},
+	} {
+		f.Format(&out, e)
+	}
+	want := "##teamcity[blockOpened name='Job: a']\n##teamcity[blockOpened name='Job: b']\n" +
+		"##teamcity[blockClosed name='Job: a']\n##teamcity[blockClosed name='Job: b']\n"
+	if out.String() != want {
+		t.Errorf("Expected the blocks to close per job, got:\n%s", out.String())
+	}
+}
+
+func TestFormatters_Mask(t *testing.T) {
+	for format, want := range map[string]string{
+		FormatGitHub: "::add-mask::50%25-off\n",
+		FormatAzure:  "##vso[task.setsecret]50%AZP25-off\n",
+	} {
+		f, _ := NewFormatter(format)
+		var out bytes.Buffer
+		logger := NewLogger()
+		logger.SetOutput(&out)
+		logger.SetFormatter(f)
+		logger.SetSecretsToMask([]string{"50%-off", "line one\nline two is long"})
+		logger.SetSecretsToMask([]string{"50%-off"})
+		logger.Info("code 50%-off")
+
+		if strings.Count(out.String(), want) != 1 {
+			t.Errorf("Expected %q once in the %s output, got:\n%s", want, format, out.String())
+		}
+		if strings.Contains(out.String(), "line one\nline two") || !strings.Contains(out.String(), "line two is long\n") {
+			t.Errorf("Expected the lines of a multi-line secret to be masked one by one, got:\n%s", out.String())
+		}
+		if strings.Contains(out.String(), "code 50%-off") {
+			t.Errorf("Expected the secret to be scrubbed, got:\n%s", out.String())
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	vars := []string{"GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "TF_BUILD", "TEAMCITY_VERSION", "NO_COLOR"}
+	tests := []struct {
+		env, value, want string
+	}{
+		{"", "", FormatText},
+		{"GITHUB_ACTIONS", "true", FormatGitHub},
+		{"GITLAB_CI", "true", FormatGitLab},
+		{"BUILDKITE", "true", FormatBuildkite},
+		{"TF_BUILD", "True", FormatAzure},
+		{"TEAMCITY_VERSION", "2024.12", FormatTeamCity},
+		{"NO_COLOR", "1", FormatPlain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			for _, name := range vars {
+				t.Setenv(name, "")
+			}
+			if tt.env != "" {
+				t.Setenv(tt.env, tt.value)
+			}
+			if got := DetectFormat(); got != tt.want {
+				t.Errorf("DetectFormat() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
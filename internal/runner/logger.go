@@ -17,9 +17,12 @@
 package runner
 
 import (
-	"fmt"
+	"io"
 	"os"
-	"strings"
+	"slices"
+	"sort"
+	"sync"
+	"time"
 )
 
 const (
@@ -30,75 +33,181 @@ const (
 	ColorReset  = "\033[0m"
 )
 
-type Logger struct {
-	isCI          bool
+// Level identifies the kind of line written by a Logger.
+type Level string
+
+const (
+	LevelInfo     Level = "info"
+	LevelError    Level = "error"
+	LevelWarn     Level = "warn"
+	LevelSuccess  Level = "success"
+	LevelGroup    Level = "group"
+	LevelEndGroup Level = "endgroup"
+)
+
+// Entry is a single line written by a Logger, after masking.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Job     string
+	Message string
+}
+
+// Sink receives every entry written by a Logger and its job loggers.
+type Sink func(Entry)
+
+// loggerCore is the state shared by a Logger and the job loggers
+// derived from it.
+type loggerCore struct {
+	mu sync.RWMutex
+	// secretsToMask holds every masked form of the registered secrets,
+	// sorted by decreasing length so that the longest match wins.
 	secretsToMask []string
+	sinks         []Sink
+
+	outMu     sync.Mutex
+	out       io.Writer
+	formatter Formatter
+}
+
+type Logger struct {
+	*loggerCore
+	job string
 }
 
+// NewLogger returns a logger writing to stdout in the format of the CI
+// provider detected from the environment, see DetectFormat.
 func NewLogger() *Logger {
-	isCI := os.Getenv("GITHUB_ACTIONS") == "true"
-	return &Logger{isCI: isCI}
+	formatter, _ := NewFormatter(FormatAuto)
+	return &Logger{loggerCore: &loggerCore{out: os.Stdout, formatter: formatter}}
 }
 
+// WithJob returns a logger attributing its entries to the given job.
+// It shares the output, sinks and masked secrets of l.
+func (l *Logger) WithJob(job string) *Logger {
+	return &Logger{loggerCore: l.loggerCore, job: job}
+}
+
+// Job returns the job the logger is attributed to, if any.
+func (l *Logger) Job() string {
+	return l.job
+}
+
+// SetOutput redirects the formatted output, e.g. to io.Discard when
+// only sinks are of interest.
+func (l *Logger) SetOutput(w io.Writer) {
+	l.outMu.Lock()
+	defer l.outMu.Unlock()
+	l.out = w
+}
+
+// SetFormatter changes the format of the output.
+func (l *Logger) SetFormatter(f Formatter) {
+	l.outMu.Lock()
+	defer l.outMu.Unlock()
+	l.formatter = f
+}
+
+// AddSink registers a function called with every entry.
+func (l *Logger) AddSink(sink Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(slices.Clone(l.sinks), sink)
+}
+
+// SetSecretsToMask registers secrets to scrub from all output, along with
+// their common encodings, and with the CI provider when it can mask
+// them too. It is safe to call while steps are running.
 func (l *Logger) SetSecretsToMask(secrets []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Build a new slice so that readers holding the previous one are unaffected.
+	masks := slices.Clone(l.secretsToMask)
+	var added []string
 	for _, s := range secrets {
-		if s != "" {
-			l.secretsToMask = append(l.secretsToMask, s)
+		for _, v := range maskVariants(s) {
+			if !slices.Contains(masks, v) {
+				masks = append(masks, v)
+				added = append(added, v)
+			}
 		}
 	}
+	if len(added) > 0 {
+		l.outMu.Lock()
+		for _, v := range added {
+			l.formatter.Mask(l.out, v)
+		}
+		l.outMu.Unlock()
+	}
+	sort.SliceStable(masks, func(i, j int) bool {
+		return len(masks[i]) > len(masks[j])
+	})
+	l.secretsToMask = masks
+}
+
+func (l *Logger) masks() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.secretsToMask
 }
 
 func (l *Logger) scrub(msg string) string {
-	if len(l.secretsToMask) == 0 {
+	masks := l.masks()
+	if len(masks) == 0 {
 		return msg
 	}
-	for _, secret := range l.secretsToMask {
-		msg = strings.ReplaceAll(msg, secret, "[SECRET]")
-	}
-	return msg
+	masked, _ := maskBytes([]byte(msg), masks, len(msg))
+	return string(masked)
 }
 
 func (l *Logger) Info(msg string) {
-	msg = l.scrub(msg)
-	if l.isCI {
-		fmt.Println(msg)
-	} else {
-		fmt.Printf("%s[INFO] %s%s\n", ColorCyan, msg, ColorReset)
-	}
+	l.write(LevelInfo, msg)
 }
 
 func (l *Logger) Error(msg string) {
-	msg = l.scrub(msg)
-	if l.isCI {
-		fmt.Printf("::error::%s\n", msg)
-	} else {
-		fmt.Printf("%s[ERROR] %s%s\n", ColorRed, msg, ColorReset)
-	}
+	l.write(LevelError, msg)
+}
+
+func (l *Logger) Warn(msg string) {
+	l.write(LevelWarn, msg)
 }
 
 func (l *Logger) Success(msg string) {
-	msg = l.scrub(msg)
-	if l.isCI {
-		fmt.Printf("::success::%s\n", msg)
-	} else {
-		fmt.Printf("%s[SUCCESS] %s%s\n", ColorGreen, msg, ColorReset)
-	}
+	l.write(LevelSuccess, msg)
 }
 
 func (l *Logger) StartGroup(title string) {
-	title = l.scrub(title)
-	if l.isCI {
-		fmt.Printf("::group::%s\n", title)
-	} else {
-		fmt.Printf("\n%s▶ %s%s\n", ColorYellow, title, ColorReset)
-		fmt.Println("------------------------------------------------")
-	}
+	l.write(LevelGroup, title)
 }
 
 func (l *Logger) EndGroup() {
-	if l.isCI {
-		fmt.Println("::end_group::")
-	} else {
-		fmt.Println("------------------------------------------------")
+	l.write(LevelEndGroup, "")
+}
+
+// Replay writes an entry produced elsewhere, such as by a remote run,
+// with this logger's formatting. The entry is not sent to the sinks.
+func (l *Logger) Replay(e Entry) {
+	e.Message = l.scrub(e.Message)
+	l.print(e)
+}
+
+func (l *Logger) write(level Level, msg string) {
+	msg = l.scrub(msg)
+
+	l.mu.RLock()
+	sinks := l.sinks
+	l.mu.RUnlock()
+	entry := Entry{Time: time.Now(), Level: level, Job: l.job, Message: msg}
+	for _, sink := range sinks {
+		sink(entry)
 	}
+
+	l.print(entry)
+}
+
+func (l *Logger) print(e Entry) {
+	l.outMu.Lock()
+	defer l.outMu.Unlock()
+	l.formatter.Format(l.out, e)
 }
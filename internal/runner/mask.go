@@ -0,0 +1,200 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// MaskPlaceholder replaces every occurrence of a registered secret.
+const MaskPlaceholder = "[SECRET]"
+
+// AddMaskDirective lets a step register a value generated at runtime,
+// e.g. `echo "::add-mask::$TOKEN"`. The directive line is not printed.
+const AddMaskDirective = "::add-mask::"
+
+// minLineMaskLen is the shortest line of a multi-line secret that is
+// masked on its own. Shorter lines (braces, blank padding) would
+// otherwise blank out unrelated output.
+const minLineMaskLen = 4
+
+// maskVariants returns the forms under which a secret commonly leaks:
+// as-is, base64 and URL encoded, JSON-escaped, and line by line for
+// multi-line values such as private keys.
+func maskVariants(secret string) []string {
+	if secret == "" {
+		return nil
+	}
+
+	variants := []string{
+		secret,
+		base64.StdEncoding.EncodeToString([]byte(secret)),
+		base64.RawStdEncoding.EncodeToString([]byte(secret)),
+		base64.URLEncoding.EncodeToString([]byte(secret)),
+		base64.RawURLEncoding.EncodeToString([]byte(secret)),
+		url.QueryEscape(secret),
+		url.PathEscape(secret),
+	}
+
+	for _, escapeHTML := range []bool{true, false} {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(escapeHTML)
+		if err := enc.Encode(secret); err == nil {
+			quoted := strings.TrimSpace(buf.String())
+			variants = append(variants, quoted[1:len(quoted)-1])
+		}
+	}
+
+	if strings.ContainsAny(secret, "\r\n") {
+		for _, line := range strings.FieldsFunc(secret, func(r rune) bool { return r == '\n' || r == '\r' }) {
+			if line = strings.TrimSpace(line); len(line) >= minLineMaskLen {
+				variants = append(variants, line)
+			}
+		}
+	}
+
+	return variants
+}
+
+// maskBytes replaces the masks found in data, scanning left to right and
+// preferring the longest mask at each position. Masks must be sorted by
+// decreasing length. It stops at limit, except that a match starting
+// before limit is always consumed whole, and returns the masked output
+// along with the number of input bytes consumed.
+func maskBytes(data []byte, masks []string, limit int) ([]byte, int) {
+	out := make([]byte, 0, len(data))
+	i := 0
+	for i < limit {
+		matched := false
+		for _, m := range masks {
+			if bytes.HasPrefix(data[i:], []byte(m)) {
+				out = append(out, MaskPlaceholder...)
+				i += len(m)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			out = append(out, data[i])
+			i++
+		}
+	}
+	return out, i
+}
+
+// partialMatchStart returns the index of the earliest suffix of data
+// that is a strict prefix of a mask, or len(data) if there is none.
+// Such a suffix may complete into a secret with the next read.
+func partialMatchStart(data []byte, masks []string) int {
+	longest := 0
+	for _, m := range masks {
+		longest = max(longest, len(m))
+	}
+
+	for start := max(0, len(data)-longest+1); start < len(data); start++ {
+		suffix := data[start:]
+		for _, m := range masks {
+			if len(suffix) < len(m) && strings.HasPrefix(m, string(suffix)) {
+				return start
+			}
+		}
+	}
+	return len(data)
+}
+
+// maskingReader masks secrets in a process output stream before it is
+// split into lines, so that multi-line secrets and secrets spanning
+// two reads are caught. Only a possible secret prefix is held back, so
+// ordinary output is not delayed. The add-mask directives are read from
+// the raw output, before masking, and are dropped from it.
+type maskingReader struct {
+	src     io.Reader
+	logger  *Logger
+	buf     []byte
+	pending []byte
+	out     []byte
+	err     error
+	// lineStart is set when pending starts a line.
+	lineStart bool
+}
+
+func newMaskingReader(src io.Reader, logger *Logger) *maskingReader {
+	return &maskingReader{src: src, logger: logger, buf: make([]byte, 32*1024), lineStart: true}
+}
+
+func (r *maskingReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		holding := r.takeDirectives()
+		if r.err != nil && len(r.pending) == 0 {
+			return 0, r.err
+		}
+
+		masks := r.logger.masks()
+		limit := len(r.pending)
+		switch {
+		case holding:
+			limit = 0
+		case r.err == nil:
+			limit = partialMatchStart(r.pending, masks)
+		}
+		// Stop at the end of the line: the next one may be a directive.
+		if end := bytes.IndexByte(r.pending, '\n'); end >= 0 {
+			limit = min(limit, end+1)
+		}
+
+		if limit == 0 {
+			n, err := r.src.Read(r.buf)
+			r.pending = append(r.pending, r.buf[:n]...)
+			r.err = err
+			continue
+		}
+		masked, consumed := maskBytes(r.pending, masks, limit)
+		r.out = masked
+		r.lineStart = r.pending[consumed-1] == '\n'
+		r.pending = append(r.pending[:0], r.pending[consumed:]...)
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// takeDirectives registers the values of the add-mask directives at the
+// start of the pending output and drops their lines. It returns true
+// while the pending output may be an incomplete directive.
+func (r *maskingReader) takeDirectives() bool {
+	for r.lineStart && len(r.pending) > 0 {
+		if !bytes.HasPrefix(r.pending, []byte(AddMaskDirective)) {
+			return r.err == nil && strings.HasPrefix(AddMaskDirective, string(r.pending))
+		}
+		line, rest, found := bytes.Cut(r.pending, []byte("\n"))
+		if !found && r.err == nil {
+			return true
+		}
+		if value := strings.TrimSpace(string(line[len(AddMaskDirective):])); value != "" {
+			r.logger.SetSecretsToMask([]string{value})
+		}
+		r.pending = append(r.pending[:0], rest...)
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"encoding/base64"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestScrub_Encodings(t *testing.T) {
+	secret := "p@ss/w0rd+\"quoted\""
+	logger := NewLogger()
+	logger.SetSecretsToMask([]string{secret})
+
+	leaks := map[string]string{
+		"plain":       secret,
+		"base64":      base64.StdEncoding.EncodeToString([]byte(secret)),
+		"base64url":   base64.RawURLEncoding.EncodeToString([]byte(secret)),
+		"urlencoded":  url.QueryEscape(secret),
+		"jsonescaped": `p@ss/w0rd+\"quoted\"`,
+	}
+	for name, leak := range leaks {
+		got := logger.scrub("value=" + leak + ";")
+		if got != "value=[SECRET];" {
+			t.Errorf("Expected %s form to be masked, got '%s'", name, got)
+		}
+	}
+}
+
+func TestScrub_MultiLineSecret(t *testing.T) {
+	secret := "-----BEGIN KEY-----\nMIIEowIBAAKCAQEA\n-----END KEY-----"
+	logger := NewLogger()
+	logger.SetSecretsToMask([]string{secret})
+
+	if got := logger.scrub("line: MIIEowIBAAKCAQEA"); got != "line: [SECRET]" {
+		t.Errorf("Expected a single line of a multi-line secret to be masked, got '%s'", got)
+	}
+}
+
+func TestMaskingReader_SplitAcrossReads(t *testing.T) {
+	logger := NewLogger()
+	logger.SetSecretsToMask([]string{"first-half\nsecond-half"})
+
+	input := "before first-half\nsecond-half after\n"
+	got, err := io.ReadAll(newMaskingReader(iotest.OneByteReader(strings.NewReader(input)), logger))
+	if err != nil {
+		t.Fatalf("ReadAll returned an unexpected error: %v", err)
+	}
+	if string(got) != "before [SECRET] after\n" {
+		t.Errorf("Expected the secret to be masked before line splitting, got %q", got)
+	}
+}
+
+func TestMaskingReader_DoesNotHoldOrdinaryOutput(t *testing.T) {
+	logger := NewLogger()
+	logger.SetSecretsToMask([]string{"topsecret"})
+
+	r := newMaskingReader(strings.NewReader("hello top"), logger)
+	buf := make([]byte, 64)
+	n, _ := r.Read(buf)
+	if string(buf[:n]) != "hello " {
+		t.Errorf("Expected only the possible secret prefix to be held back, got %q", buf[:n])
+	}
+}
+
+func TestMaskingReader_AddMask(t *testing.T) {
+	logger := NewLogger()
+	logger.SetSecretsToMask([]string{"known-secret"})
+
+	// The runtime token contains a secret that is already masked: it
+	// must be registered as it is, not as its masked output.
+	input := "start\n::add-mask::tok-known-secret-42\r\nuse tok-known-secret-42\n::add-mask::\nend"
+	got, err := io.ReadAll(newMaskingReader(iotest.OneByteReader(strings.NewReader(input)), logger))
+	if err != nil {
+		t.Fatalf("ReadAll returned an unexpected error: %v", err)
+	}
+	if string(got) != "start\nuse [SECRET]\nend" {
+		t.Errorf("Expected the directives to be dropped and the token masked whole, got %q", got)
+	}
+	if got := logger.scrub("token is tok-known-secret-42"); got != "token is [SECRET]" {
+		t.Errorf("Expected a value registered with add-mask to be masked, got '%s'", got)
+	}
+}
+
+func TestMaskingReader_DirectiveOnlyAtLineStart(t *testing.T) {
+	logger := NewLogger()
+	input := "echo ::add-mask::value\n"
+	got, _ := io.ReadAll(newMaskingReader(strings.NewReader(input), logger))
+	if string(got) != input {
+		t.Errorf("Expected a directive in the middle of a line to be printed, got %q", got)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runner
+
+import (
+	"maps"
+	"strings"
+	"sync"
+)
+
+// Outputs collects the values exported by steps, such as the git.sha
+// of a checkout. The later steps of the job see them as ${git.sha} in
+// their commands and as GIT_SHA in their environment, and the engine
+// hands them on to the jobs starting afterwards. A nil *Outputs holds
+// nothing and drops what is set.
+type Outputs struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// NewOutputs returns Outputs holding a copy of initial.
+func NewOutputs(initial map[string]string) *Outputs {
+	return &Outputs{values: maps.Clone(initial)}
+}
+
+// Set exports a value.
+func (o *Outputs) Set(name, value string) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.values == nil {
+		o.values = make(map[string]string)
+	}
+	o.values[name] = value
+}
+
+// Values returns a copy of the exported values.
+func (o *Outputs) Values() map[string]string {
+	if o == nil {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return maps.Clone(o.values)
+}
+
+// OutputEnvName returns the environment variable holding an output:
+// git.sha is exported as GIT_SHA.
+func OutputEnvName(name string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+}
@@ -20,14 +20,30 @@ import (
 	"bufio"
 	"context"
 	"fmt"
-	"os"
 	"os/exec"
+	"path/filepath"
 	"sync"
 
 	"github.com/Purpose-Dev/flowcraft/internal/config"
 )
 
-func Execute(ctx context.Context, step config.Step, envVars map[string]string, logger *Logger) error {
+// Options controls how step processes are spawned.
+type Options struct {
+	Env EnvPolicy
+	// Workdir is the directory the steps run in, and the base of
+	// relative step directories. Empty means the current directory.
+	Workdir string
+	// Outputs collects the values exported by the steps.
+	Outputs *Outputs
+	// ProcessEnv holds variables only set in the environment of the
+	// steps, such as the CI_* variables of a webhook. Like outputs, they
+	// are never expanded into the text of a command: bash expands them
+	// when the step runs, so that a commit message cannot inject shell
+	// code.
+	ProcessEnv map[string]string
+}
+
+func Execute(ctx context.Context, step config.Step, envVars map[string]string, opts Options, logger *Logger) error {
 	logger.StartGroup(fmt.Sprintf("Step: %s", step.Name))
 	defer logger.EndGroup()
 
@@ -35,18 +51,33 @@ func Execute(ctx context.Context, step config.Step, envVars map[string]string, l
 		return err
 	}
 
-	expander, finalEnv := buildEnvironment(envVars)
-	cmdStr := expander(step.Cmd)
-	cmdDir := expander(step.Dir)
+	env := buildEnvironment(envVars, opts)
+	if step.Checkout != nil {
+		return checkout(ctx, step, env, opts, logger)
+	}
+
+	cmdStr := env.expandCommand(step.Cmd)
+	cmdDir := env.expand(step.Dir)
+
+	if opts.Env.WarnUndeclared {
+		for _, name := range env.undeclaredHostVars(step) {
+			logger.Warn(fmt.Sprintf("Step '%s' reads host variable '%s' which is not declared in the config", step.Name, name))
+		}
+	}
 
 	logger.Info(fmt.Sprintf("Executing command: %s", cmdStr))
 	cmd := exec.CommandContext(ctx, "bash", "-c", cmdStr)
 	if cmdDir != "" {
-		cmd.Dir = cmdDir
 		logger.Info(fmt.Sprintf("Working directory: %s", cmdDir))
+		if !filepath.IsAbs(cmdDir) {
+			cmdDir = filepath.Join(opts.Workdir, cmdDir)
+		}
+		cmd.Dir = cmdDir
+	} else {
+		cmd.Dir = opts.Workdir
 	}
 
-	cmd.Env = finalEnv
+	cmd.Env = env.env
 
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
@@ -66,7 +97,7 @@ func Execute(ctx context.Context, step config.Step, envVars map[string]string, l
 
 	go func() {
 		defer wg.Done()
-		scanner := bufio.NewScanner(stdoutPipe)
+		scanner := bufio.NewScanner(newMaskingReader(stdoutPipe, logger))
 		for scanner.Scan() {
 			logger.Info(scanner.Text())
 		}
@@ -77,7 +108,7 @@ func Execute(ctx context.Context, step config.Step, envVars map[string]string, l
 
 	go func() {
 		defer wg.Done()
-		scanner := bufio.NewScanner(stderrPipe)
+		scanner := bufio.NewScanner(newMaskingReader(stderrPipe, logger))
 		for scanner.Scan() {
 			logger.Info(scanner.Text())
 		}
@@ -100,26 +131,3 @@ func Execute(ctx context.Context, step config.Step, envVars map[string]string, l
 	logger.Success(fmt.Sprintf("Step '%s' completed successfully", step.Name))
 	return nil
 }
-
-func buildEnvironment(customEnvs map[string]string) (func(string) string, []string) {
-	envMap := make(map[string]string)
-	for k, v := range customEnvs {
-		envMap[k] = v
-	}
-
-	finalEnv := os.Environ()
-	for k, v := range customEnvs {
-		finalEnv = append(finalEnv, fmt.Sprintf("%s=%s", k, v))
-	}
-
-	expander := func(s string) string {
-		return os.Expand(s, func(key string) string {
-			if val, ok := envMap[key]; ok {
-				return val
-			}
-			return os.Getenv(key)
-		})
-	}
-
-	return expander, finalEnv
-}
@@ -0,0 +1,432 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package scaffold generates a starter flow.toml for the projects found
+// in a directory.
+package scaffold
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
+)
+
+// Kinds of projects.
+const (
+	KindGo     = "go"
+	KindNode   = "node"
+	KindRust   = "rust"
+	KindPython = "python"
+	KindMake   = "make"
+	KindDocker = "docker"
+)
+
+// Names of the tasks of a project, in the order they are generated.
+const (
+	TaskInstall = "install"
+	TaskLint    = "lint"
+	TaskTest    = "test"
+	TaskBuild   = "build"
+)
+
+// Project is a project found in a directory of the tree.
+type Project struct {
+	Kind string
+	// Dir is the slash-separated directory of the project, relative to
+	// the root, or "" for the root itself.
+	Dir string
+	// File is the file the project was detected from, e.g. "api/go.mod".
+	File string
+	// Description names the project in the generated comments.
+	Description string
+	Tasks       []Task
+}
+
+// Task becomes a job of the generated pipeline.
+type Task struct {
+	Name  string
+	Steps []Step
+	// DependsOn names tasks of the same project.
+	DependsOn []string
+}
+
+type Step struct {
+	Name string
+	Cmd  string
+}
+
+// skipDirs are never searched for projects.
+var skipDirs = []string{"node_modules", "vendor", "target", "dist", "build", "testdata"}
+
+// Detect finds the projects at the root of the tree and in the
+// directories right under it, for monorepos. Hidden directories and
+// those ignored by .gitignore are left out. In a directory holding
+// another project, a Makefile target named after a task replaces the
+// commands of that task; a Makefile alone is a project of its own.
+func Detect(root string) ([]Project, error) {
+	// The image of a Dockerfile at the root is named after it.
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	ignore := workspace.NewIgnore(root)
+	if err := ignore.Load("."); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{""}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || slices.Contains(skipDirs, name) || ignore.Match(name, true) {
+			continue
+		}
+		dirs = append(dirs, name)
+	}
+	sort.Strings(dirs[1:])
+
+	var projects []Project
+	for _, dir := range dirs {
+		found, err := detectDir(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, found...)
+	}
+	return projects, nil
+}
+
+// detectDir returns the projects of a directory.
+func detectDir(root, dir string) ([]Project, error) {
+	abs := filepath.Join(root, filepath.FromSlash(dir))
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(abs, name))
+		return err == nil
+	}
+	detectors := []struct {
+		file   string
+		detect func(abs string, exists func(string) bool) (Project, error)
+	}{
+		{"go.mod", detectGo},
+		{"package.json", detectNode},
+		{"Cargo.toml", detectRust},
+		{"pyproject.toml", detectPython},
+	}
+
+	var projects []Project
+	for _, d := range detectors {
+		if !exists(d.file) {
+			continue
+		}
+		p, err := d.detect(abs, exists)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path.Join(dir, d.file), err)
+		}
+		p.Dir, p.File = dir, path.Join(dir, d.file)
+		projects = append(projects, p)
+	}
+
+	if exists("Makefile") {
+		targets, err := makeTargets(filepath.Join(abs, "Makefile"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path.Join(dir, "Makefile"), err)
+		}
+		if len(projects) > 0 {
+			for i := range projects {
+				useMakeTargets(&projects[i], targets)
+			}
+		} else if p, ok := makeProject(targets); ok {
+			p.Dir, p.File = dir, path.Join(dir, "Makefile")
+			projects = append(projects, p)
+		}
+	}
+
+	if exists("Dockerfile") {
+		// The image is built once the code it holds passed its tests.
+		var after []string
+		for _, p := range projects {
+			if p.task(TaskTest) != nil {
+				after = append(after, jobRef{p.Kind, TaskTest}.String())
+			}
+		}
+		image := path.Base(filepath.ToSlash(abs))
+		if dir != "" {
+			image = dir
+		}
+		projects = append(projects, Project{
+			Kind:        KindDocker,
+			Dir:         dir,
+			File:        path.Join(dir, "Dockerfile"),
+			Description: "Docker image",
+			Tasks: []Task{{
+				Name:      TaskBuild,
+				Steps:     []Step{{Name: "Build image", Cmd: fmt.Sprintf("docker build -t %s:dev .", imageName(image))}},
+				DependsOn: after,
+			}},
+		})
+	}
+	return projects, nil
+}
+
+func (p *Project) task(name string) *Task {
+	for i := range p.Tasks {
+		if p.Tasks[i].Name == name {
+			return &p.Tasks[i]
+		}
+	}
+	return nil
+}
+
+// jobRef refers to the task of another project of the same directory,
+// in Task.DependsOn.
+type jobRef struct {
+	kind, task string
+}
+
+func (r jobRef) String() string {
+	return r.kind + ":" + r.task
+}
+
+func detectGo(abs string, exists func(string) bool) (Project, error) {
+	lint := []Step{
+		{Name: "Check formatting", Cmd: `test -z "$(gofmt -l .)"`},
+		{Name: "Vet", Cmd: "go vet ./..."},
+	}
+	if exists(".golangci.yml") || exists(".golangci.yaml") || exists(".golangci.toml") {
+		lint = append(lint, Step{Name: "Lint", Cmd: "golangci-lint run"})
+	}
+	return Project{
+		Kind:        KindGo,
+		Description: "Go module",
+		Tasks: []Task{
+			{Name: TaskLint, Steps: lint},
+			{Name: TaskTest, Steps: []Step{{Name: "Test", Cmd: "go test ./..."}}},
+			{Name: TaskBuild, Steps: []Step{{Name: "Build", Cmd: "go build ./..."}}, DependsOn: []string{TaskLint, TaskTest}},
+		},
+	}, nil
+}
+
+// npmPlaceholder is the test script of a new npm package, which fails.
+const npmPlaceholder = "no test specified"
+
+func detectNode(abs string, exists func(string) bool) (Project, error) {
+	data, err := os.ReadFile(filepath.Join(abs, "package.json"))
+	if err != nil {
+		return Project{}, err
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return Project{}, err
+	}
+
+	install, run := "npm install", "npm run"
+	switch {
+	case exists("pnpm-lock.yaml"):
+		install, run = "pnpm install --frozen-lockfile", "pnpm run"
+	case exists("yarn.lock"):
+		install, run = "yarn install --frozen-lockfile", "yarn run"
+	case exists("bun.lock") || exists("bun.lockb"):
+		install, run = "bun install --frozen-lockfile", "bun run"
+	case exists("package-lock.json"):
+		install = "npm ci"
+	}
+
+	p := Project{
+		Kind:        KindNode,
+		Description: "Node.js package",
+		Tasks:       []Task{{Name: TaskInstall, Steps: []Step{{Name: "Install dependencies", Cmd: install}}}},
+	}
+	var checks []string
+	for _, name := range []string{TaskLint, TaskTest, TaskBuild} {
+		script, ok := pkg.Scripts[name]
+		if !ok || (name == TaskTest && strings.Contains(script, npmPlaceholder)) {
+			continue
+		}
+		deps := []string{TaskInstall}
+		if name == TaskBuild {
+			deps = append(deps, checks...)
+		} else {
+			checks = append(checks, name)
+		}
+		p.Tasks = append(p.Tasks, Task{
+			Name:      name,
+			Steps:     []Step{{Name: titleCase(name), Cmd: run + " " + name}},
+			DependsOn: deps,
+		})
+	}
+	return p, nil
+}
+
+func detectRust(abs string, exists func(string) bool) (Project, error) {
+	return Project{
+		Kind:        KindRust,
+		Description: "Rust crate",
+		Tasks: []Task{
+			{Name: TaskLint, Steps: []Step{
+				{Name: "Check formatting", Cmd: "cargo fmt --check"},
+				{Name: "Clippy", Cmd: "cargo clippy --all-targets -- -D warnings"},
+			}},
+			{Name: TaskTest, Steps: []Step{{Name: "Test", Cmd: "cargo test"}}},
+			{Name: TaskBuild, Steps: []Step{{Name: "Build", Cmd: "cargo build --release"}}, DependsOn: []string{TaskLint, TaskTest}},
+		},
+	}, nil
+}
+
+func detectPython(abs string, exists func(string) bool) (Project, error) {
+	data, err := os.ReadFile(filepath.Join(abs, "pyproject.toml"))
+	if err != nil {
+		return Project{}, err
+	}
+	var pyproject struct {
+		Tool map[string]any `toml:"tool"`
+	}
+	if err := toml.Unmarshal(data, &pyproject); err != nil {
+		return Project{}, err
+	}
+	// Tools are found by name, in the dependencies or their settings.
+	mentions := func(tool string) bool {
+		_, configured := pyproject.Tool[tool]
+		return configured || regexp.MustCompile(`["'\s]`+tool+`\b`).Match(data)
+	}
+
+	install, prefix, build := "python -m pip install -e .", "", "python -m pip wheel --no-deps -w dist ."
+	switch {
+	case exists("uv.lock"):
+		install, prefix, build = "uv sync", "uv run ", "uv build"
+	case pyproject.Tool["poetry"] != nil:
+		install, prefix, build = "poetry install", "poetry run ", "poetry build"
+	}
+
+	p := Project{
+		Kind:        KindPython,
+		Description: "Python project",
+		Tasks:       []Task{{Name: TaskInstall, Steps: []Step{{Name: "Install dependencies", Cmd: install}}}},
+	}
+	checks := []string{TaskTest}
+	switch {
+	case mentions("ruff"):
+		checks = append(checks, TaskLint)
+		p.Tasks = append(p.Tasks, Task{Name: TaskLint, Steps: []Step{{Name: "Lint", Cmd: prefix + "ruff check ."}}, DependsOn: []string{TaskInstall}})
+	case mentions("flake8"):
+		checks = append(checks, TaskLint)
+		p.Tasks = append(p.Tasks, Task{Name: TaskLint, Steps: []Step{{Name: "Lint", Cmd: prefix + "flake8"}}, DependsOn: []string{TaskInstall}})
+	}
+	test := prefix + "python -m unittest discover"
+	if mentions("pytest") {
+		test = prefix + "pytest"
+	}
+	p.Tasks = append(p.Tasks,
+		Task{Name: TaskTest, Steps: []Step{{Name: "Test", Cmd: test}}, DependsOn: []string{TaskInstall}},
+		Task{Name: TaskBuild, Steps: []Step{{Name: "Build", Cmd: build}}, DependsOn: append([]string{TaskInstall}, checks...)},
+	)
+	return p, nil
+}
+
+// makeTasks maps Makefile targets to the tasks they run.
+var makeTasks = map[string][]string{
+	TaskLint:  {"lint", "check"},
+	TaskTest:  {"test"},
+	TaskBuild: {"build"},
+}
+
+var targetLine = regexp.MustCompile(`^([A-Za-z0-9_.-]+)\s*:([^=]|$)`)
+
+// makeTargets returns the targets defined by a Makefile.
+func makeTargets(file string) (map[string]bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	targets := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := targetLine.FindStringSubmatch(scanner.Text()); m != nil {
+			targets[m[1]] = true
+		}
+	}
+	return targets, scanner.Err()
+}
+
+// makeTarget returns the Makefile target running a task, if any.
+func makeTarget(targets map[string]bool, task string) (string, bool) {
+	for _, target := range makeTasks[task] {
+		if targets[target] {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// useMakeTargets replaces the commands of the tasks of p that have a
+// Makefile target.
+func useMakeTargets(p *Project, targets map[string]bool) {
+	for i, task := range p.Tasks {
+		if target, ok := makeTarget(targets, task.Name); ok {
+			p.Tasks[i].Steps = []Step{{Name: titleCase(task.Name), Cmd: "make " + target}}
+		}
+	}
+}
+
+// makeProject returns the project of a Makefile alone, if it has a
+// target for a task.
+func makeProject(targets map[string]bool) (Project, bool) {
+	p := Project{Kind: KindMake, Description: "Makefile"}
+	var checks []string
+	for _, name := range []string{TaskLint, TaskTest, TaskBuild} {
+		target, ok := makeTarget(targets, name)
+		if !ok {
+			continue
+		}
+		task := Task{Name: name, Steps: []Step{{Name: titleCase(name), Cmd: "make " + target}}}
+		if name == TaskBuild {
+			task.DependsOn = checks
+		} else {
+			checks = append(checks, name)
+		}
+		p.Tasks = append(p.Tasks, task)
+	}
+	return p, len(p.Tasks) > 0
+}
+
+var invalidImage = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// imageName turns a directory name into a valid image name.
+func imageName(name string) string {
+	name = strings.Trim(invalidImage.ReplaceAllString(strings.ToLower(name), "-"), "-._")
+	if name == "" {
+		return "app"
+	}
+	return name
+}
+
+func titleCase(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
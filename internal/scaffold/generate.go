@@ -0,0 +1,130 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Purpose-Dev/flowcraft/internal/tomlfmt"
+)
+
+const header = `# Generated by 'flowcraft init'. Review the commands, check the file with
+# 'flowcraft validate', then run the pipeline with 'flowcraft run'.
+`
+
+// starter is generated when no project was found.
+const starter = `# No project was detected: replace this job with your own.
+[jobs.build]
+
+[[jobs.build.steps]]
+name = "Build"
+cmd = "echo 'Add your build commands here'"
+`
+
+// Generate returns a flow.toml with a job per task of the projects. The
+// jobs of a project in a subdirectory run there, and their paths are
+// set to it for 'flowcraft run --changed-since'.
+func Generate(projects []Project) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	if len(projects) == 0 {
+		buf.WriteString("\n" + starter)
+		return buf.Bytes()
+	}
+
+	names := jobNames(projects)
+	for i, p := range projects {
+		fmt.Fprintf(&buf, "\n# %s (%s)\n", p.Description, p.File)
+		for _, task := range p.Tasks {
+			name := names[i][task.Name]
+			fmt.Fprintf(&buf, "\n[jobs.%s]\n", name)
+			if len(task.DependsOn) > 0 {
+				deps := make([]string, 0, len(task.DependsOn))
+				for _, dep := range task.DependsOn {
+					deps = append(deps, names.resolve(projects, i, dep))
+				}
+				fmt.Fprintf(&buf, "depends_on = %s\n", tomlfmt.Array(deps))
+			}
+			if p.Dir != "" {
+				fmt.Fprintf(&buf, "paths = %s\n", tomlfmt.Array([]string{p.Dir + "/**"}))
+			}
+			for _, step := range task.Steps {
+				fmt.Fprintf(&buf, "\n[[jobs.%s.steps]]\n", name)
+				fmt.Fprintf(&buf, "name = %s\n", tomlfmt.String(step.Name))
+				fmt.Fprintf(&buf, "cmd = %s\n", tomlfmt.String(step.Cmd))
+				if p.Dir != "" {
+					fmt.Fprintf(&buf, "dir = %s\n", tomlfmt.String(p.Dir))
+				}
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// names holds the job name of each task, per project.
+type names []map[string]string
+
+var invalidKey = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// jobNames names the jobs "<kind>-<task>" at the root and "<dir>-<task>"
+// in subdirectories, or "<dir>-<kind>-<task>" when the directory holds
+// several projects.
+func jobNames(projects []Project) names {
+	kinds := make(map[string]int)
+	for _, p := range projects {
+		kinds[p.Dir]++
+	}
+	taken := make(map[string]bool)
+	result := make(names, len(projects))
+	for i, p := range projects {
+		prefix := p.Kind
+		if p.Dir != "" {
+			prefix = strings.Trim(invalidKey.ReplaceAllString(strings.ToLower(p.Dir), "-"), "-")
+			if prefix == "" || kinds[p.Dir] > 1 {
+				prefix = strings.TrimPrefix(prefix+"-"+p.Kind, "-")
+			}
+		}
+		result[i] = make(map[string]string)
+		for _, task := range p.Tasks {
+			name := prefix + "-" + task.Name
+			for n := 2; taken[name]; n++ {
+				name = fmt.Sprintf("%s-%s-%d", prefix, task.Name, n)
+			}
+			taken[name] = true
+			result[i][task.Name] = name
+		}
+	}
+	return result
+}
+
+// resolve returns the job name of dep, a task of project i or a jobRef
+// to a project of the same directory.
+func (n names) resolve(projects []Project, i int, dep string) string {
+	kind, task, ok := strings.Cut(dep, ":")
+	if !ok {
+		return n[i][dep]
+	}
+	for j, p := range projects {
+		if p.Dir == projects[i].Dir && p.Kind == kind {
+			return n[j][task]
+		}
+	}
+	return dep
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// generate detects the projects of root and parses the generated file
+// as 'flowcraft validate' does.
+func generate(t *testing.T, root string) *config.Config {
+	t.Helper()
+	projects, err := Detect(root)
+	if err != nil {
+		t.Fatalf("Detect() returned an unexpected error: %v", err)
+	}
+	data := Generate(projects)
+	cfg, err := config.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() of the generated file returned an unexpected error: %v\n%s", err, data)
+	}
+	if _, err := engine.BuildDag(cfg); err != nil {
+		t.Fatalf("BuildDag() of the generated file returned an unexpected error: %v\n%s", err, data)
+	}
+	return cfg
+}
+
+func commands(job config.Job) []string {
+	var cmds []string
+	for _, step := range job.Steps {
+		cmds = append(cmds, step.Cmd)
+	}
+	return cmds
+}
+
+func TestGenerate_Monorepo(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                      "module example.com/app\n",
+		"Makefile":                    "GO ?= go\ntest: deps\n\t$(GO) test ./...\n.PHONY: test\n",
+		"Dockerfile":                  "FROM scratch\n",
+		".gitignore":                  "generated/\n",
+		"web/package.json":            `{"scripts": {"lint": "eslint .", "test": "vitest", "build": "vite build"}}`,
+		"web/pnpm-lock.yaml":          "",
+		"engine/Cargo.toml":           "[package]\nname = \"engine\"\n",
+		"ml/pyproject.toml":           "[project]\ndependencies = [\"numpy\"]\n\n[dependency-groups]\ndev = [\"pytest>=8\"]\n\n[tool.ruff]\nline-length = 100\n",
+		"ml/uv.lock":                  "",
+		"tools/Makefile":              "build:\n\techo build\nlint:\n\techo lint\n",
+		"node_modules/x/package.json": "{}",
+		"generated/go.mod":            "module example.com/generated\n",
+		"docs/README.md":              "",
+	})
+	cfg := generate(t, root)
+
+	var names []string
+	for name := range cfg.Jobs {
+		names = append(names, name)
+	}
+	want := []string{
+		"docker-build", "engine-build", "engine-lint", "engine-test", "go-build", "go-lint", "go-test",
+		"ml-build", "ml-install", "ml-lint", "ml-test", "tools-build", "tools-lint",
+		"web-build", "web-install", "web-lint", "web-test",
+	}
+	if len(names) != len(want) {
+		t.Fatalf("Expected the jobs %v, got %v", want, names)
+	}
+	for _, name := range want {
+		if _, ok := cfg.Jobs[name]; !ok {
+			t.Errorf("Expected a job '%s', got %v", name, names)
+		}
+	}
+
+	if cmds := commands(cfg.Jobs["go-test"]); !reflect.DeepEqual(cmds, []string{"make test"}) {
+		t.Errorf("Expected go-test to use the Makefile target, got %v", cmds)
+	}
+	if cmds := commands(cfg.Jobs["go-lint"]); !reflect.DeepEqual(cmds, []string{`test -z "$(gofmt -l .)"`, "go vet ./..."}) {
+		t.Errorf("Expected go-lint to check formatting and vet, got %v", cmds)
+	}
+	if deps := cfg.Jobs["docker-build"].DependsOn; !reflect.DeepEqual(deps, []string{"go-test"}) {
+		t.Errorf("Expected the image to be built after the tests, got %v", deps)
+	}
+	if cmds := commands(cfg.Jobs["docker-build"]); cmds[0] != "docker build -t "+imageName(filepath.Base(root))+":dev ." {
+		t.Errorf("Expected the image to be named after the directory, got %v", cmds)
+	}
+
+	web := cfg.Jobs["web-build"]
+	if !reflect.DeepEqual(web.DependsOn, []string{"web-install", "web-lint", "web-test"}) || !reflect.DeepEqual(web.Paths, []string{"web/**"}) {
+		t.Errorf("Expected web-build to depend on the checks and watch web/, got %+v", web)
+	}
+	if step := web.Steps[0]; step.Cmd != "pnpm run build" || step.Dir != "web" {
+		t.Errorf("Expected web-build to run with pnpm in web/, got %+v", step)
+	}
+	if cmds := commands(cfg.Jobs["web-install"]); cmds[0] != "pnpm install --frozen-lockfile" {
+		t.Errorf("Expected pnpm to install the dependencies, got %v", cmds)
+	}
+	if cmds := commands(cfg.Jobs["ml-test"]); cmds[0] != "uv run pytest" {
+		t.Errorf("Expected the tests to run with pytest under uv, got %v", cmds)
+	}
+	if cmds := commands(cfg.Jobs["ml-lint"]); cmds[0] != "uv run ruff check ." {
+		t.Errorf("Expected ruff to lint, got %v", cmds)
+	}
+	if deps := cfg.Jobs["tools-build"].DependsOn; !reflect.DeepEqual(deps, []string{"tools-lint"}) {
+		t.Errorf("Expected the Makefile build to run after its lint, got %v", deps)
+	}
+}
+
+func TestGenerate_NodeWithoutScripts(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"package.json":      `{"scripts": {"test": "echo \"Error: no test specified\" && exit 1"}}`,
+		"package-lock.json": "{}",
+	})
+	cfg := generate(t, root)
+	if len(cfg.Jobs) != 1 || !reflect.DeepEqual(commands(cfg.Jobs["node-install"]), []string{"npm ci"}) {
+		t.Errorf("Expected a single install job, got %+v", cfg.Jobs)
+	}
+}
+
+func TestGenerate_Empty(t *testing.T) {
+	cfg := generate(t, t.TempDir())
+	if _, ok := cfg.Jobs["build"]; !ok || len(cfg.Jobs) != 1 {
+		t.Errorf("Expected the starter job, got %+v", cfg.Jobs)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secrets
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+// DefaultDotenvPath is read when a dotenv secret has no 'path'.
+const DefaultDotenvPath = ".env"
+
+// dotenvProvider reads KEY=VALUE files. Each file is parsed once per run.
+type dotenvProvider struct {
+	mu    sync.Mutex
+	files map[string]map[string]string
+}
+
+func newDotenvProvider() *dotenvProvider {
+	return &dotenvProvider{files: make(map[string]map[string]string)}
+}
+
+func (p *dotenvProvider) Resolve(_ context.Context, _ string, secret config.Secret) (string, error) {
+	if secret.Key == "" {
+		return "", errors.New("'key' in config cannot be empty")
+	}
+	path := secret.Path
+	if path == "" {
+		path = DefaultDotenvPath
+	}
+
+	values, err := p.load(expandHome(path))
+	if err != nil {
+		return "", err
+	}
+	val, ok := values[secret.Key]
+	if !ok {
+		return "", fmt.Errorf("key '%s' not found in %s", secret.Key, path)
+	}
+	return val, nil
+}
+
+func (p *dotenvProvider) load(path string) (map[string]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if values, ok := p.files[path]; ok {
+		return values, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dotenv file: %w", err)
+	}
+	values, err := parseDotenv(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	p.files[path] = values
+	return values, nil
+}
+
+// parseDotenv parses the common subset of the dotenv format: comments,
+// an optional 'export' prefix, and single- or double-quoted values.
+// Double-quoted values support \n, \t, \" and \\ escapes.
+func parseDotenv(data string) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: empty key", lineNum)
+		}
+
+		parsed, err := parseDotenvValue(strings.TrimSpace(val))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		values[key] = parsed
+	}
+
+	return values, scanner.Err()
+}
+
+func parseDotenvValue(val string) (string, error) {
+	if val == "" {
+		return "", nil
+	}
+
+	switch val[0] {
+	case '\'':
+		end := strings.IndexByte(val[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated single-quoted value")
+		}
+		return val[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(val); i++ {
+			c := val[i]
+			switch {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(val):
+				i++
+				switch val[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(val[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated double-quoted value")
+	}
+
+	// Unquoted values end at an inline comment.
+	if idx := strings.Index(val, " #"); idx >= 0 {
+		val = val[:idx]
+	}
+	return strings.TrimSpace(val), nil
+}
@@ -0,0 +1,261 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/BurntSushi/toml"
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+const (
+	// DefaultIdentityEnv holds the age identity used to decrypt files.
+	DefaultIdentityEnv = "FLOWCRAFT_AGE_KEY"
+	// DefaultIdentityFileEnv points to a file holding age identities.
+	DefaultIdentityFileEnv = "FLOWCRAFT_AGE_KEY_FILE"
+)
+
+// encryptedFileProvider reads secrets from age-encrypted TOML or dotenv
+// files. Plaintext is only ever held in memory.
+type encryptedFileProvider struct {
+	settings config.EncryptedFileSettings
+
+	mu    sync.Mutex
+	files map[string]map[string]string
+}
+
+func newEncryptedFileProvider(settings config.EncryptedFileSettings) *encryptedFileProvider {
+	return &encryptedFileProvider{
+		settings: settings,
+		files:    make(map[string]map[string]string),
+	}
+}
+
+func (p *encryptedFileProvider) Resolve(_ context.Context, _ string, secret config.Secret) (string, error) {
+	if secret.Path == "" {
+		return "", errors.New("'path' in config cannot be empty")
+	}
+	if secret.Key == "" {
+		return "", errors.New("'key' in config cannot be empty")
+	}
+
+	values, err := p.load(expandHome(secret.Path))
+	if err != nil {
+		return "", err
+	}
+	val, ok := values[secret.Key]
+	if !ok {
+		return "", fmt.Errorf("key '%s' not found in %s", secret.Key, secret.Path)
+	}
+	return val, nil
+}
+
+func (p *encryptedFileProvider) load(path string) (map[string]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if values, ok := p.files[path]; ok {
+		return values, nil
+	}
+
+	identities, err := LoadIdentities(p.settings)
+	if err != nil {
+		return nil, err
+	}
+	values, err := DecryptFile(path, identities)
+	if err != nil {
+		return nil, err
+	}
+	p.files[path] = values
+	return values, nil
+}
+
+// LoadIdentities returns the age identities configured through the
+// environment or the identity file.
+func LoadIdentities(settings config.EncryptedFileSettings) ([]age.Identity, error) {
+	envName := settings.IdentityEnv
+	if envName == "" {
+		envName = DefaultIdentityEnv
+	}
+	if key := os.Getenv(envName); key != "" {
+		identities, err := age.ParseIdentities(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identity in $%s: %w", envName, err)
+		}
+		return identities, nil
+	}
+
+	path := os.Getenv(DefaultIdentityFileEnv)
+	if path == "" {
+		path = settings.IdentityFile
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no age identity found: set $%s or $%s, or settings.encrypted_file.identity_file", envName, DefaultIdentityFileEnv)
+	}
+
+	f, err := os.Open(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open age identity file: %w", err)
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid age identity file %s: %w", path, err)
+	}
+	return identities, nil
+}
+
+// LoadRecipients returns the recipients to encrypt to: the explicit
+// list if any, otherwise the configured ones, otherwise the recipients
+// matching the loaded X25519 identities.
+func LoadRecipients(settings config.EncryptedFileSettings, explicit []string) ([]age.Recipient, error) {
+	names := explicit
+	if len(names) == 0 {
+		names = settings.Recipients
+	}
+	if len(names) > 0 {
+		recipients, err := age.ParseRecipients(strings.NewReader(strings.Join(names, "\n")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient: %w", err)
+		}
+		return recipients, nil
+	}
+
+	identities, err := LoadIdentities(settings)
+	if err != nil {
+		return nil, err
+	}
+	var recipients []age.Recipient
+	for _, id := range identities {
+		if x, ok := id.(*age.X25519Identity); ok {
+			recipients = append(recipients, x.Recipient())
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipient could be derived from the identity; pass one explicitly")
+	}
+	return recipients, nil
+}
+
+// Decrypt decrypts an armored or binary age payload in memory.
+func Decrypt(data []byte, identities []age.Identity) ([]byte, error) {
+	var src io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(bytes.TrimSpace(data)))
+	}
+
+	r, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// Encrypt encrypts plaintext to the recipients as an armored payload,
+// which keeps encrypted files readable in diffs and reviews.
+func Encrypt(plaintext []byte, recipients []age.Recipient) ([]byte, error) {
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := aw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecryptFile decrypts an encrypted secrets file and parses it.
+func DecryptFile(path string, identities []age.Identity) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encrypted file: %w", err)
+	}
+	plaintext, err := Decrypt(data, identities)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	values, err := ParseSecretsFile(path, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// ParseSecretsFile parses decrypted content. Files named *.toml or
+// *.toml.age are TOML, with nested tables flattened to dotted keys;
+// anything else is read as dotenv.
+func ParseSecretsFile(path string, plaintext []byte) (map[string]string, error) {
+	name := strings.TrimSuffix(path, ".age")
+	if !strings.HasSuffix(name, ".toml") {
+		return parseDotenv(string(plaintext))
+	}
+
+	var doc map[string]any
+	if err := toml.Unmarshal(plaintext, &doc); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	flattenTOML("", doc, values)
+	return values, nil
+}
+
+func flattenTOML(prefix string, doc map[string]any, out map[string]string) {
+	for k, v := range doc {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			flattenTOML(key, val, out)
+		case string:
+			out[key] = val
+		default:
+			out[key] = fmt.Sprint(val)
+		}
+	}
+}
+
+// SortedKeys returns the keys of a decrypted file, for listing.
+func SortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+func writeEncrypted(t *testing.T, name, plaintext string, identity *age.X25519Identity) string {
+	t.Helper()
+	ciphertext, err := Encrypt([]byte(plaintext), []age.Recipient{identity.Recipient()})
+	if err != nil {
+		t.Fatalf("Encrypt() returned an unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, ciphertext, 0o644); err != nil {
+		t.Fatalf("Failed to write encrypted file: %v", err)
+	}
+	return path
+}
+
+func TestEncryptedFileProvider(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+	t.Setenv(DefaultIdentityEnv, identity.String())
+
+	tomlPath := writeEncrypted(t, "secrets.toml.age", "npm_token = \"npm-123\"\n[db]\npassword = \"db-pass\"\n", identity)
+	envPath := writeEncrypted(t, "secrets.env.age", "API_KEY=api-456\nUNUSED=unused-789\n", identity)
+
+	r, err := NewResolver(config.Settings{})
+	if err != nil {
+		t.Fatalf("NewResolver() returned an unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		path, key, expected string
+	}{
+		{tomlPath, "npm_token", "npm-123"},
+		{tomlPath, "db.password", "db-pass"},
+		{envPath, "API_KEY", "api-456"},
+	}
+	for _, c := range cases {
+		val, err := r.Resolve(context.Background(), c.key, config.Secret{Provider: "encrypted_file", Path: c.path, Key: c.key})
+		if err != nil {
+			t.Fatalf("Resolve(%s) returned an unexpected error: %v", c.key, err)
+		}
+		if val != c.expected {
+			t.Errorf("Expected %s to be '%s', got '%s'", c.key, c.expected, val)
+		}
+	}
+}
+
+func TestEncryptedFileProvider_WrongKey(t *testing.T) {
+	owner, _ := age.GenerateX25519Identity()
+	other, _ := age.GenerateX25519Identity()
+	path := writeEncrypted(t, "secrets.env.age", "API_KEY=api-456\n", owner)
+	t.Setenv(DefaultIdentityEnv, other.String())
+
+	r, _ := NewResolver(config.Settings{})
+	if _, err := r.Resolve(context.Background(), "k", config.Secret{Provider: "encrypted_file", Path: path, Key: "API_KEY"}); err == nil {
+		t.Error("Expected decryption with the wrong identity to fail, got nil")
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+func resolveEnv(_ context.Context, _ string, secret config.Secret) (string, error) {
+	if secret.Key == "" {
+		return "", errors.New("'key' in config cannot be empty")
+	}
+	val, ok := os.LookupEnv(secret.Key)
+	if !ok {
+		return "", fmt.Errorf("environment variable '%s' is not set", secret.Key)
+	}
+	return val, nil
+}
+
+func resolveFile(_ context.Context, _ string, secret config.Secret) (string, error) {
+	if secret.Path == "" {
+		return "", errors.New("'path' in config cannot be empty")
+	}
+	data, err := os.ReadFile(expandHome(secret.Path))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return trimNewline(string(data)), nil
+}
+
+func resolveCommand(ctx context.Context, _ string, secret config.Secret) (string, error) {
+	if secret.Command == "" {
+		return "", errors.New("'command' in config cannot be empty")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "bash", "-c", secret.Command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait on grandchildren that keep the pipes open after a timeout.
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return "", fmt.Errorf("command '%s' failed: %w", secret.Command, err)
+		}
+		return "", fmt.Errorf("command '%s' failed: %w: %s", secret.Command, err, msg)
+	}
+	return trimNewline(stdout.String()), nil
+}
+
+// trimNewline removes a single trailing newline, as written by editors
+// and by most password helpers.
+func trimNewline(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	return strings.TrimSuffix(s, "\r")
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package secrets resolves the secrets declared in flow.toml through
+// pluggable providers.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+// DefaultTimeout bounds a single resolution when the provider has no
+// timeout configured under [settings.secret_providers].
+const DefaultTimeout = 10 * time.Second
+
+// Provider resolves a secret declared in the config to its value.
+type Provider interface {
+	Resolve(ctx context.Context, name string, secret config.Secret) (string, error)
+}
+
+// ProviderFunc adapts an ordinary function to the Provider interface.
+type ProviderFunc func(ctx context.Context, name string, secret config.Secret) (string, error)
+
+func (f ProviderFunc) Resolve(ctx context.Context, name string, secret config.Secret) (string, error) {
+	return f(ctx, name, secret)
+}
+
+// Resolver dispatches secrets to their provider and applies the
+// per-provider timeouts. A Resolver is meant to live for a single run,
+// so providers may cache what they fetch.
+type Resolver struct {
+	providers map[string]Provider
+	timeouts  map[string]time.Duration
+}
+
+// NewResolver returns a Resolver with the built-in providers registered
+// and the timeouts taken from the config settings.
+func NewResolver(settings config.Settings) (*Resolver, error) {
+	r := &Resolver{
+		providers: make(map[string]Provider),
+		timeouts:  make(map[string]time.Duration),
+	}
+
+	r.Register("env", ProviderFunc(resolveEnv))
+	r.Register("file", ProviderFunc(resolveFile))
+	r.Register("command", ProviderFunc(resolveCommand))
+	r.Register("dotenv", newDotenvProvider())
+	r.Register("vault", newVaultProvider(settings.Vault))
+	r.Register("encrypted_file", newEncryptedFileProvider(settings.EncryptedFile))
+
+	for name, opts := range settings.SecretProviders {
+		if opts.Timeout == "" {
+			continue
+		}
+		timeout, err := time.ParseDuration(opts.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("secret provider '%s': invalid timeout '%s': %w", name, opts.Timeout, err)
+		}
+		r.timeouts[name] = timeout
+	}
+
+	return r, nil
+}
+
+// Register adds or replaces the provider used for the given name.
+func (r *Resolver) Register(name string, p Provider) {
+	r.providers[name] = p
+}
+
+// Providers returns the names of the registered providers, sorted.
+func (r *Resolver) Providers() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the value of a single secret.
+func (r *Resolver) Resolve(ctx context.Context, name string, secret config.Secret) (string, error) {
+	provider, ok := r.providers[secret.Provider]
+	if !ok {
+		return "", fmt.Errorf("secret '%s': provider '%s' is not supported (available: %v)", name, secret.Provider, r.Providers())
+	}
+
+	timeout, ok := r.timeouts[secret.Provider]
+	if !ok {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	val, err := provider.Resolve(ctx, name, secret)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("secret '%s': provider '%s' timed out after %s", name, secret.Provider, timeout)
+		}
+		return "", fmt.Errorf("secret '%s': %w", name, err)
+	}
+	return val, nil
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+func newTestResolver(t *testing.T, settings config.Settings) *Resolver {
+	t.Helper()
+	r, err := NewResolver(settings)
+	if err != nil {
+		t.Fatalf("NewResolver() returned an unexpected error: %v", err)
+	}
+	return r
+}
+
+func TestResolve_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write secret file: %v", err)
+	}
+
+	r := newTestResolver(t, config.Settings{})
+	val, err := r.Resolve(context.Background(), "token", config.Secret{Provider: "file", Path: path})
+	if err != nil {
+		t.Fatalf("Resolve() returned an unexpected error: %v", err)
+	}
+	if val != "file-secret" {
+		t.Errorf("Expected 'file-secret', got '%s'", val)
+	}
+}
+
+func TestResolve_Command(t *testing.T) {
+	r := newTestResolver(t, config.Settings{})
+	val, err := r.Resolve(context.Background(), "token", config.Secret{Provider: "command", Command: "printf 'cmd-secret\\n'"})
+	if err != nil {
+		t.Fatalf("Resolve() returned an unexpected error: %v", err)
+	}
+	if val != "cmd-secret" {
+		t.Errorf("Expected 'cmd-secret', got '%s'", val)
+	}
+
+	_, err = r.Resolve(context.Background(), "token", config.Secret{Provider: "command", Command: "echo denied >&2; exit 3"})
+	if err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("Expected the helper's stderr in the error, got: %v", err)
+	}
+}
+
+func TestResolve_Timeout(t *testing.T) {
+	settings := config.Settings{
+		SecretProviders: map[string]config.SecretProviderSettings{
+			"command": {Timeout: "100ms"},
+		},
+	}
+	r := newTestResolver(t, settings)
+
+	_, err := r.Resolve(context.Background(), "slow", config.Secret{Provider: "command", Command: "sleep 5"})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a timeout error, got: %v", err)
+	}
+}
+
+func TestResolve_Dotenv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "# comment\nexport DB_PASS='s3cr3t'\nAPI_KEY=\"line1\\nline2\"\nPLAIN=value # trailing\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write dotenv file: %v", err)
+	}
+
+	r := newTestResolver(t, config.Settings{})
+	got := make(map[string]string)
+	for _, key := range []string{"DB_PASS", "API_KEY", "PLAIN"} {
+		val, err := r.Resolve(context.Background(), key, config.Secret{Provider: "dotenv", Path: path, Key: key})
+		if err != nil {
+			t.Fatalf("Resolve(%s) returned an unexpected error: %v", key, err)
+		}
+		got[key] = val
+	}
+
+	expected := map[string]string{"DB_PASS": "s3cr3t", "API_KEY": "line1\nline2", "PLAIN": "value"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if _, err := r.Resolve(context.Background(), "x", config.Secret{Provider: "dotenv", Path: path, Key: "MISSING"}); err == nil {
+		t.Error("Expected an error for a missing dotenv key, got nil")
+	}
+}
+
+func TestResolve_UnknownProvider(t *testing.T) {
+	r := newTestResolver(t, config.Settings{})
+	_, err := r.Resolve(context.Background(), "x", config.Secret{Provider: "nope"})
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("Expected an unsupported provider error, got: %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+// VaultLease is the lease metadata returned alongside a KV v2 read.
+type VaultLease struct {
+	ID        string
+	Duration  int
+	Renewable bool
+	Version   int
+}
+
+type vaultEntry struct {
+	data  map[string]any
+	lease VaultLease
+}
+
+// vaultProvider reads secrets from a HashiCorp Vault KV v2 engine.
+// Each path is fetched once per run and the client token is obtained
+// lazily on the first read.
+type vaultProvider struct {
+	settings config.VaultSettings
+	client   *http.Client
+
+	mu      sync.Mutex
+	token   string
+	entries map[string]*vaultEntry
+}
+
+func newVaultProvider(settings config.VaultSettings) *vaultProvider {
+	if settings.Address == "" {
+		settings.Address = os.Getenv("VAULT_ADDR")
+	}
+	if settings.Namespace == "" {
+		settings.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if settings.Mount == "" {
+		settings.Mount = "secret"
+	}
+	if settings.Auth == "" {
+		settings.Auth = "token"
+	}
+	if settings.SecretIDEnv == "" {
+		settings.SecretIDEnv = "VAULT_SECRET_ID"
+	}
+	if settings.AppRoleMount == "" {
+		settings.AppRoleMount = "approle"
+	}
+
+	return &vaultProvider{
+		settings: settings,
+		client:   &http.Client{},
+		entries:  make(map[string]*vaultEntry),
+	}
+}
+
+func (p *vaultProvider) Resolve(ctx context.Context, _ string, secret config.Secret) (string, error) {
+	if secret.Path == "" {
+		return "", errors.New("'path' in config cannot be empty")
+	}
+	if secret.Key == "" {
+		return "", errors.New("'key' in config cannot be empty")
+	}
+	mount := secret.Mount
+	if mount == "" {
+		mount = p.settings.Mount
+	}
+
+	entry, err := p.read(ctx, mount, secret.Path)
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := entry.data[secret.Key]
+	if !ok {
+		return "", fmt.Errorf("key '%s' not found at vault path '%s/%s'", secret.Key, mount, secret.Path)
+	}
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode key '%s': %w", secret.Key, err)
+		}
+		return string(encoded), nil
+	}
+}
+
+// Lease returns the lease metadata cached for a path, if it was read.
+func (p *vaultProvider) Lease(mount, path string) (VaultLease, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.entries[mount+"/"+strings.Trim(path, "/")]
+	if !ok {
+		return VaultLease{}, false
+	}
+	return entry.lease, true
+}
+
+func (p *vaultProvider) read(ctx context.Context, mount, path string) (*vaultEntry, error) {
+	// The lock is held for the whole request so that concurrent
+	// resolutions of the same path only hit Vault once.
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	path = strings.Trim(path, "/")
+	cacheKey := mount + "/" + path
+	if entry, ok := p.entries[cacheKey]; ok {
+		return entry, nil
+	}
+
+	if p.settings.Address == "" {
+		return nil, errors.New("vault address is not configured (set settings.vault.address or VAULT_ADDR)")
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		LeaseID       string `json:"lease_id"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+		Data          struct {
+			Data     map[string]any `json:"data"`
+			Metadata struct {
+				Version int `json:"version"`
+			} `json:"metadata"`
+		} `json:"data"`
+	}
+	endpoint := fmt.Sprintf("/v1/%s/data/%s", strings.Trim(mount, "/"), path)
+	if err := p.do(ctx, http.MethodGet, endpoint, nil, &resp); err != nil {
+		return nil, fmt.Errorf("vault read '%s' failed: %w", cacheKey, err)
+	}
+	if resp.Data.Data == nil {
+		return nil, fmt.Errorf("vault path '%s' has no data (is it a KV v2 mount?)", cacheKey)
+	}
+
+	entry := &vaultEntry{
+		data: resp.Data.Data,
+		lease: VaultLease{
+			ID:        resp.LeaseID,
+			Duration:  resp.LeaseDuration,
+			Renewable: resp.Renewable,
+			Version:   resp.Data.Metadata.Version,
+		},
+	}
+	p.entries[cacheKey] = entry
+	return entry, nil
+}
+
+// authenticate obtains a client token. It must be called with p.mu held.
+func (p *vaultProvider) authenticate(ctx context.Context) error {
+	if p.token != "" {
+		return nil
+	}
+
+	switch p.settings.Auth {
+	case "token":
+		token := os.Getenv("VAULT_TOKEN")
+		if token == "" {
+			return errors.New("vault token auth: VAULT_TOKEN is not set")
+		}
+		p.token = token
+		return nil
+	case "approle":
+		secretID := os.Getenv(p.settings.SecretIDEnv)
+		if p.settings.RoleID == "" || secretID == "" {
+			return fmt.Errorf("vault approle auth: 'role_id' and $%s are required", p.settings.SecretIDEnv)
+		}
+		body := map[string]string{"role_id": p.settings.RoleID, "secret_id": secretID}
+		var resp struct {
+			Auth struct {
+				ClientToken string `json:"client_token"`
+			} `json:"auth"`
+		}
+		endpoint := fmt.Sprintf("/v1/auth/%s/login", strings.Trim(p.settings.AppRoleMount, "/"))
+		if err := p.do(ctx, http.MethodPost, endpoint, body, &resp); err != nil {
+			return fmt.Errorf("vault approle login failed: %w", err)
+		}
+		if resp.Auth.ClientToken == "" {
+			return errors.New("vault approle login returned no client token")
+		}
+		p.token = resp.Auth.ClientToken
+		return nil
+	default:
+		return fmt.Errorf("vault auth method '%s' is not supported (use 'token' or 'approle')", p.settings.Auth)
+	}
+}
+
+func (p *vaultProvider) do(ctx context.Context, method, endpoint string, body, out any) error {
+	u, err := url.JoinPath(p.settings.Address, endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid vault address: %w", err)
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	if p.token != "" {
+		req.Header.Set("X-Vault-Token", p.token)
+	}
+	if p.settings.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.settings.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		if len(errResp.Errors) > 0 {
+			return fmt.Errorf("status %d: %s", resp.StatusCode, strings.Join(errResp.Errors, "; "))
+		}
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
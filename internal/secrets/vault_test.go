@@ -0,0 +1,151 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+// newVaultStub mimics the KV v2 read and AppRole login endpoints.
+func newVaultStub(t *testing.T, reads *atomic.Int32) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role-1" || body["secret_id"] != "secret-id-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth":{"client_token":"approle-token"}}`))
+	})
+
+	mux.HandleFunc("GET /v1/kv/data/app/db", func(w http.ResponseWriter, r *http.Request) {
+		reads.Add(1)
+		token := r.Header.Get("X-Vault-Token")
+		if token != "root-token" && token != "approle-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.Header.Get("X-Vault-Namespace") != "team-a" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"lease_id": "",
+			"lease_duration": 3600,
+			"renewable": false,
+			"data": {
+				"data": {"user": "app", "password": "vault-pass", "port": 5432},
+				"metadata": {"version": 4}
+			}
+		}`))
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestVaultProvider_TokenAuth(t *testing.T) {
+	var reads atomic.Int32
+	srv := newVaultStub(t, &reads)
+	defer srv.Close()
+	t.Setenv("VAULT_TOKEN", "root-token")
+
+	p := newVaultProvider(config.VaultSettings{Address: srv.URL, Namespace: "team-a", Mount: "kv"})
+	ctx := context.Background()
+
+	for key, expected := range map[string]string{"password": "vault-pass", "user": "app", "port": "5432"} {
+		val, err := p.Resolve(ctx, key, config.Secret{Provider: "vault", Path: "app/db", Key: key})
+		if err != nil {
+			t.Fatalf("Resolve(%s) returned an unexpected error: %v", key, err)
+		}
+		if val != expected {
+			t.Errorf("Expected %s to be '%s', got '%s'", key, expected, val)
+		}
+	}
+
+	if n := reads.Load(); n != 1 {
+		t.Errorf("Expected the path to be fetched once per run, got %d reads", n)
+	}
+
+	lease, ok := p.Lease("kv", "app/db")
+	if !ok {
+		t.Fatal("Expected lease metadata to be cached")
+	}
+	if lease.Duration != 3600 || lease.Version != 4 {
+		t.Errorf("Unexpected lease metadata: %+v", lease)
+	}
+
+	_, err := p.Resolve(ctx, "x", config.Secret{Provider: "vault", Path: "app/db", Key: "missing"})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a missing key error, got: %v", err)
+	}
+}
+
+func TestVaultProvider_AppRole(t *testing.T) {
+	var reads atomic.Int32
+	srv := newVaultStub(t, &reads)
+	defer srv.Close()
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("CI_VAULT_SECRET_ID", "secret-id-1")
+
+	settings := config.VaultSettings{
+		Address:     srv.URL,
+		Namespace:   "team-a",
+		Mount:       "kv",
+		Auth:        "approle",
+		RoleID:      "role-1",
+		SecretIDEnv: "CI_VAULT_SECRET_ID",
+	}
+	r, err := NewResolver(config.Settings{Vault: settings})
+	if err != nil {
+		t.Fatalf("NewResolver() returned an unexpected error: %v", err)
+	}
+
+	val, err := r.Resolve(context.Background(), "db", config.Secret{Provider: "vault", Path: "app/db", Key: "password"})
+	if err != nil {
+		t.Fatalf("Resolve() returned an unexpected error: %v", err)
+	}
+	if val != "vault-pass" {
+		t.Errorf("Expected 'vault-pass', got '%s'", val)
+	}
+}
+
+func TestVaultProvider_PermissionDenied(t *testing.T) {
+	var reads atomic.Int32
+	srv := newVaultStub(t, &reads)
+	defer srv.Close()
+	t.Setenv("VAULT_TOKEN", "wrong-token")
+
+	p := newVaultProvider(config.VaultSettings{Address: srv.URL, Namespace: "team-a", Mount: "kv"})
+	_, err := p.Resolve(context.Background(), "db", config.Secret{Provider: "vault", Path: "app/db", Key: "password"})
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Expected Vault's error message to be surfaced, got: %v", err)
+	}
+}
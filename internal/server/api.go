@@ -0,0 +1,410 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+)
+
+// Handler returns the HTTP API of the server:
+//
+//	POST   /api/v1/runs                          submit a pipeline (multipart: config, workspace)
+//	GET    /api/v1/runs                          list runs
+//	GET    /api/v1/runs/{id}                     inspect a run
+//	POST   /api/v1/runs/{id}/cancel              cancel a run
+//	GET    /api/v1/runs/{id}/events              stream events (Server-Sent Events)
+//	POST   /api/v1/runs/{id}/jobs/{job}/approve  let a job waiting for approval run
+//	POST   /api/v1/runs/{id}/jobs/{job}/reject   reject a job waiting for approval
+//	POST   /api/v1/runs/{id}/jobs/{job}/cancel   cancel a job, failing the run
+//	POST   /api/v1/webhooks/{provider}           start a run from a GitHub or GitLab delivery
+//
+// the endpoints managing authentication:
+//
+//	GET    /api/v1/whoami                   describe the caller's token
+//	POST   /api/v1/tokens                   create a token
+//	GET    /api/v1/tokens                   list tokens
+//	DELETE /api/v1/tokens/{id}              revoke a token
+//	POST   /api/v1/login/device             start a device login
+//	POST   /api/v1/login/device/token       poll for the token of a device login
+//	GET    /api/v1/login/device/{code}      describe a device login
+//	POST   /api/v1/login/device/approve     approve or deny a device login
+//	POST   /api/v1/session                  store the caller's token in a cookie
+//	DELETE /api/v1/session                  clear that cookie
+//
+// and the endpoints used by agents:
+//
+//	POST   /api/v1/agents                    register an agent
+//	GET    /api/v1/agents                    list agents and their leases
+//	POST   /api/v1/agents/{id}/lease?wait=   wait for a job to run
+//	GET    /api/v1/leases/{id}/workspace     download the run's workspace
+//	POST   /api/v1/leases/{id}/heartbeat     keep a lease alive
+//	POST   /api/v1/leases/{id}/events        report step events and logs
+//	POST   /api/v1/leases/{id}/complete      report the job's result
+//
+// With Options.Auth, each endpoint requires a token with a scope: read
+// to inspect, submit to start and act on runs, admin to manage tokens
+// and agent-register (join tokens) to register agents. The lease
+// endpoints require the token an agent received when it registered.
+// The web dashboard is served under /ui/, and the Prometheus metrics
+// at /metrics (read scope).
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /ui/", uiHandler())
+	mux.Handle("GET /{$}", http.RedirectHandler("/ui/", http.StatusFound))
+	mux.HandleFunc("GET /metrics", s.require(api.ScopeRead, s.handleMetrics))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("POST /api/v1/runs", s.require(api.ScopeSubmit, s.handleSubmit))
+	mux.HandleFunc("GET /api/v1/runs", s.require(api.ScopeRead, s.handleList))
+	mux.HandleFunc("GET /api/v1/runs/{id}", s.require(api.ScopeRead, s.handleGet))
+	mux.HandleFunc("POST /api/v1/runs/{id}/cancel", s.require(api.ScopeSubmit, s.handleCancel))
+	mux.HandleFunc("GET /api/v1/runs/{id}/events", s.require(api.ScopeRead, s.handleEvents))
+	mux.HandleFunc("POST /api/v1/runs/{id}/jobs/{job}/approve", s.require(api.ScopeSubmit, s.handleApprove(true)))
+	mux.HandleFunc("POST /api/v1/runs/{id}/jobs/{job}/reject", s.require(api.ScopeSubmit, s.handleApprove(false)))
+	mux.HandleFunc("POST /api/v1/runs/{id}/jobs/{job}/cancel", s.require(api.ScopeSubmit, s.handleCancelJob))
+	mux.HandleFunc("POST /api/v1/webhooks/{provider}", s.handleWebhook)
+	mux.HandleFunc("GET /api/v1/whoami", s.require("", s.handleWhoami))
+	mux.HandleFunc("POST /api/v1/tokens", s.require(api.ScopeAdmin, s.handleCreateToken))
+	mux.HandleFunc("GET /api/v1/tokens", s.require(api.ScopeAdmin, s.handleTokens))
+	mux.HandleFunc("DELETE /api/v1/tokens/{id}", s.require(api.ScopeAdmin, s.handleRevokeToken))
+	mux.HandleFunc("POST /api/v1/login/device", s.handleStartDevice)
+	mux.HandleFunc("POST /api/v1/login/device/token", s.handleDeviceToken)
+	mux.HandleFunc("GET /api/v1/login/device/{code}", s.require(api.ScopeRead, s.handleDevice))
+	mux.HandleFunc("POST /api/v1/login/device/approve", s.require(api.ScopeRead, s.handleApproveDevice))
+	mux.HandleFunc("POST /api/v1/session", s.require("", s.handleSession))
+	mux.HandleFunc("DELETE /api/v1/session", s.handleEndSession)
+	mux.HandleFunc("POST /api/v1/agents", s.require(api.ScopeAgentRegister, s.handleRegister))
+	mux.HandleFunc("GET /api/v1/agents", s.require(api.ScopeRead, s.handleAgents))
+	mux.HandleFunc("POST /api/v1/agents/{id}/lease", s.requireAgent(s.handleLease))
+	mux.HandleFunc("GET /api/v1/leases/{id}/workspace", s.requireLease(s.handleWorkspace))
+	mux.HandleFunc("POST /api/v1/leases/{id}/heartbeat", s.requireLease(s.handleHeartbeat))
+	mux.HandleFunc("POST /api/v1/leases/{id}/events", s.requireLease(s.handleLeaseEvents))
+	mux.HandleFunc("POST /api/v1/leases/{id}/complete", s.requireLease(s.handleComplete))
+	return mux
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxUploadBytes)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("expected a multipart body: %w", err))
+		return
+	}
+
+	var configData []byte
+	var run *api.Run
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		switch part.FormName() {
+		case "config":
+			if configData, err = io.ReadAll(part); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		case "workspace":
+			// The workspace is streamed to disk, so the config must come first.
+			if configData == nil {
+				writeError(w, http.StatusBadRequest, errors.New("the 'config' part must precede the 'workspace' part"))
+				return
+			}
+			if run, err = s.Submit(configData, part); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		part.Close()
+	}
+
+	if run == nil {
+		if configData == nil {
+			writeError(w, http.StatusBadRequest, errors.New("missing 'config' part"))
+			return
+		}
+		if run, err = s.Submit(configData, nil); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	log.Printf("Run %s submitted with %d job(s).", run.ID, len(run.Jobs))
+	writeJSON(w, http.StatusCreated, run)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	runs, err := s.Runs()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if runs == nil {
+		runs = []*api.Run{}
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	run, err := s.Run(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	run, err := s.Cancel(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+func (s *Server) handleApprove(approved bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		run, err := s.Approve(r.PathValue("id"), r.PathValue("job"), approved)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, run)
+	}
+}
+
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	run, err := s.CancelJob(r.PathValue("id"), r.PathValue("job"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+// handleEvents streams the events of a run as Server-Sent Events until
+// the run finishes. Clients resume with ?after=<seq> or Last-Event-ID.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	after, err := parseAfter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := s.Run(id); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		events, wait, done, err := s.Events(id, after)
+		if err != nil {
+			return
+		}
+		for _, e := range events {
+			data, _ := json.Marshal(e)
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data); err != nil {
+				return
+			}
+			after = e.Seq
+		}
+		flusher.Flush()
+
+		if done {
+			_, _ = fmt.Fprint(w, "event: end\ndata: {}\n\n")
+			flusher.Flush()
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-wait:
+		}
+	}
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var reg api.AgentRegistration
+	if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid registration: %w", err))
+		return
+	}
+	agent, err := s.Register(r.Context(), reg)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, agent)
+}
+
+func (s *Server) handleAgents(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Agents())
+}
+
+// handleLease long-polls for a job. It answers 204 when none became
+// available within ?wait= (a Go duration, 20s by default).
+func (s *Server) handleLease(w http.ResponseWriter, r *http.Request) {
+	wait := 20 * time.Second
+	if value := r.URL.Query().Get("wait"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid wait '%s'", value))
+			return
+		}
+		wait = d
+	}
+
+	lease, err := s.Lease(r.Context(), r.PathValue("id"), wait)
+	if err != nil {
+		writeAgentError(w, err)
+		return
+	}
+	if lease == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, lease)
+}
+
+// handleWorkspace streams the workspace tarball, or answers 204 when
+// the run was submitted without one.
+func (s *Server) handleWorkspace(w http.ResponseWriter, r *http.Request) {
+	rc, err := s.Workspace(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeAgentError(w, err)
+		return
+	}
+	if rc == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", "application/gzip")
+	_, _ = io.Copy(w, rc)
+}
+
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.Heartbeat(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeAgentError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleLeaseEvents(w http.ResponseWriter, r *http.Request) {
+	var events []api.Event
+	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid events: %w", err))
+		return
+	}
+	if err := s.SendEvents(r.Context(), r.PathValue("id"), events); err != nil {
+		writeAgentError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleComplete(w http.ResponseWriter, r *http.Request) {
+	var result api.LeaseResult
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid result: %w", err))
+		return
+	}
+	if err := s.Complete(r.Context(), r.PathValue("id"), result); err != nil {
+		writeAgentError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseAfter(r *http.Request) (uint64, error) {
+	value := r.URL.Query().Get("after")
+	if value == "" {
+		value = r.Header.Get("Last-Event-ID")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	after, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid event position '%s'", value)
+	}
+	return after, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, api.ErrorResponse{Error: err.Error()})
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, errors.New("run not found"))
+	case errors.Is(err, ErrNotAwaitingApproval), errors.Is(err, ErrJobNotActive):
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// writeAgentError maps the agent protocol errors to the status codes
+// the client translates back: 404 for an unknown agent, 410 for a lost lease.
+func writeAgentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, api.ErrUnknownAgent):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, api.ErrLeaseLost):
+		writeError(w, http.StatusGone, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+)
+
+var (
+	// ErrNotAwaitingApproval is returned when approving or rejecting a
+	// job that is not held at its approval gate.
+	ErrNotAwaitingApproval = errors.New("job is not waiting for approval")
+	// ErrJobNotActive is returned when cancelling a job that is neither
+	// waiting for an agent, running, nor waiting for approval.
+	ErrJobNotActive = errors.New("job is not waiting or running")
+)
+
+// approve is the engine.Approver of a run: it holds the job until
+// Approve is called for it or the gate closes.
+func (l *liveRun) approve(ctx context.Context, req engine.ApprovalRequest) (bool, error) {
+	decision := make(chan bool, 1)
+	l.mu.Lock()
+	l.gates[req.Job] = decision
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.gates, req.Job)
+		l.mu.Unlock()
+	}()
+
+	select {
+	case approved := <-decision:
+		return approved, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// decide answers the approval gate of job. Only the first answer counts.
+func (l *liveRun) decide(job string, approved bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	decision, ok := l.gates[job]
+	if !ok {
+		return ErrNotAwaitingApproval
+	}
+	delete(l.gates, job)
+	decision <- approved
+	return nil
+}
+
+// Approve lets a job held at its approval gate run, or rejects it, which
+// fails the run.
+func (s *Server) Approve(id, job string, approved bool) (*api.Run, error) {
+	live := s.liveRun(id)
+	if live == nil {
+		if _, err := s.store.GetRun(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotAwaitingApproval
+	}
+	if err := live.decide(job, approved); err != nil {
+		return nil, err
+	}
+
+	verdict := "approved"
+	if !approved {
+		verdict = "rejected"
+	}
+	log.Printf("Run %s: job '%s' %s.", id, job, verdict)
+	return live.snapshot(), nil
+}
+
+// CancelJob stops one job of a run: a job waiting for approval is
+// rejected, a job waiting for an agent is withdrawn, and the agent
+// running it is told to stop. Either way the run then fails.
+func (s *Server) CancelJob(id, job string) (*api.Run, error) {
+	live := s.liveRun(id)
+	if live == nil {
+		if _, err := s.store.GetRun(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrJobNotActive
+	}
+	if err := live.decide(job, false); err == nil {
+		log.Printf("Run %s: job '%s' rejected by cancellation.", id, job)
+		return live.snapshot(), nil
+	}
+	if !s.d.cancelJob(id, job) {
+		return nil, ErrJobNotActive
+	}
+	log.Printf("Run %s: job '%s' cancelled.", id, job)
+	return live.snapshot(), nil
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+)
+
+const gatedConfig = `
+[jobs.build]
+[[jobs.build.steps]]
+name = "Build"
+cmd = "echo built"
+
+[jobs.deploy]
+depends_on = ["build"]
+approve = true
+[[jobs.deploy.steps]]
+name = "Deploy"
+cmd = "echo deployed"
+`
+
+func post(t *testing.T, ts *httptest.Server, path string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(ts.URL+path, "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", path, err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+// waitForJob polls a run until job reaches status.
+func waitForJob(t *testing.T, ts *httptest.Server, id, job, status string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for getRun(t, ts, id).Jobs[job].Status != status {
+		if time.Now().After(deadline) {
+			t.Fatalf("Job '%s' never became %s", job, status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestApprove_ServerRun(t *testing.T) {
+	_, ts := newTestServer(t)
+	_, run := submit(t, ts, gatedConfig, nil)
+
+	waitForJob(t, ts, run.ID, "deploy", api.JobAwaitingApproval)
+	if resp := post(t, ts, "/api/v1/runs/"+run.ID+"/jobs/build/approve"); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 when approving a job without a gate, got %d", resp.StatusCode)
+	}
+	if resp := post(t, ts, "/api/v1/runs/"+run.ID+"/jobs/deploy/approve"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	events := streamEvents(t, ts, run.ID)
+	final := getRun(t, ts, run.ID)
+	if final.Status != api.RunSucceeded || final.Jobs["deploy"].Status != api.JobSuccess {
+		t.Fatalf("Expected the approved run to succeed, got %s (%s)", final.Status, final.Error)
+	}
+	var resolved bool
+	for _, e := range events {
+		if e.Type == "approval_resolved" && e.Job == "deploy" && e.Status == "approved" {
+			resolved = true
+		}
+	}
+	if !resolved {
+		t.Error("Expected an approval_resolved event for 'deploy'")
+	}
+}
+
+func TestApprove_RejectFailsRun(t *testing.T) {
+	_, ts := newTestServer(t)
+	_, run := submit(t, ts, gatedConfig, nil)
+
+	waitForJob(t, ts, run.ID, "deploy", api.JobAwaitingApproval)
+	post(t, ts, "/api/v1/runs/"+run.ID+"/jobs/deploy/reject")
+
+	streamEvents(t, ts, run.ID)
+	final := getRun(t, ts, run.ID)
+	if final.Status != api.RunFailed || !strings.Contains(final.Error, "rejected") {
+		t.Errorf("Expected the run to fail on the rejection, got %s (%s)", final.Status, final.Error)
+	}
+	if status := final.Jobs["deploy"].Status; status != api.JobFailed {
+		t.Errorf("Expected 'deploy' to be failed, got %s", status)
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	cfg := `
+[jobs.slow]
+retry = 2
+[[jobs.slow.steps]]
+name = "Sleep"
+cmd = "sleep 30"
+
+[jobs.after]
+depends_on = ["slow"]
+[[jobs.after.steps]]
+name = "After"
+cmd = "true"
+`
+	_, run := submit(t, ts, cfg, nil)
+	waitForJob(t, ts, run.ID, "slow", api.JobRunning)
+
+	resp, err := http.Post(ts.URL+"/api/v1/runs/"+run.ID+"/jobs/slow/cancel", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Cancel request failed: %v", err)
+	}
+	var snapshot api.Run
+	_ = json.NewDecoder(resp.Body).Decode(&snapshot)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || snapshot.ID != run.ID {
+		t.Fatalf("Expected status 200 and the run, got %d", resp.StatusCode)
+	}
+
+	streamEvents(t, ts, run.ID)
+	final := getRun(t, ts, run.ID)
+	if final.Status != api.RunFailed || !strings.Contains(final.Error, "job 'slow' was cancelled") {
+		t.Errorf("Expected the run to fail on the cancelled job, got %s (%s)", final.Status, final.Error)
+	}
+	slow := final.Jobs["slow"]
+	if slow.Status != api.JobCancelled || slow.Attempt != 1 {
+		t.Errorf("Expected 'slow' to be cancelled without a retry, got %+v", slow)
+	}
+	if status := final.Jobs["after"].Status; status != api.JobSkipped {
+		t.Errorf("Expected 'after' to be skipped, got %s", status)
+	}
+
+	if resp := post(t, ts, "/api/v1/runs/"+run.ID+"/jobs/slow/cancel"); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 for a finished run, got %d", resp.StatusCode)
+	}
+	if resp := post(t, ts, "/api/v1/runs/missing/jobs/slow/cancel"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown run, got %d", resp.StatusCode)
+	}
+}
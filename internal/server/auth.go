@@ -0,0 +1,605 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+)
+
+const (
+	// apiTokenPrefix and agentTokenPrefix make the tokens recognisable,
+	// e.g. by secret scanners.
+	apiTokenPrefix   = "fct_"
+	agentTokenPrefix = "fca_"
+	// sessionCookie carries the token of the dashboard, as EventSource
+	// cannot send an Authorization header.
+	sessionCookie = "flowcraft_token"
+	// deviceLoginTTL is how long a device login may wait for approval.
+	deviceLoginTTL = 10 * time.Minute
+	// devicePollInterval is how often, in seconds, the CLI polls for the
+	// token of a device login.
+	devicePollInterval = 2
+	// maxDeviceLogins bounds the device logins waiting for approval.
+	maxDeviceLogins = 64
+	// userCodeAlphabet has no vowels, so that codes spell no words, and
+	// no look-alike characters.
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+)
+
+var (
+	// ErrTokenNotFound is returned when revoking an unknown token.
+	ErrTokenNotFound = errors.New("token not found")
+	// ErrAuthDisabled is returned by the login endpoints of a server
+	// that does not require tokens.
+	ErrAuthDisabled = errors.New("authentication is disabled on this server")
+)
+
+// deviceLogin is a device login waiting for its approval, or for the
+// CLI to collect its token.
+type deviceLogin struct {
+	api.DeviceLogin
+	deviceCode string
+	// answered is set as soon as someone approves or denies the login.
+	answered bool
+	token    *api.NewToken
+	denied   bool
+}
+
+// authenticator holds the API tokens, indexed by the hash of their
+// secret, and the device logins in progress.
+type authenticator struct {
+	store *Store
+
+	mu      sync.Mutex
+	tokens  map[string]api.Token
+	devices map[string]*deviceLogin
+}
+
+func newAuthenticator(store *Store) (*authenticator, error) {
+	stored, err := store.Tokens()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokens: %w", err)
+	}
+	a := &authenticator{
+		store:   store,
+		tokens:  make(map[string]api.Token, len(stored)),
+		devices: make(map[string]*deviceLogin),
+	}
+	for _, token := range stored {
+		a.tokens[token.Hash] = token.Token
+	}
+	return a, nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSecret(prefix string) string {
+	b := make([]byte, 20)
+	_, _ = rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+// lookup returns the token whose secret is given.
+func (a *authenticator) lookup(secret string) (api.Token, bool) {
+	if secret == "" {
+		return api.Token{}, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	token, ok := a.tokens[hashToken(secret)]
+	return token, ok
+}
+
+// create issues a token. Join tokens, with the agent-register scope,
+// cannot have any other scope.
+func (a *authenticator) create(req api.TokenRequest) (*api.NewToken, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, errors.New("a token needs a name")
+	}
+	if len(req.Scopes) == 0 {
+		return nil, errors.New("a token needs at least one scope")
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(api.Scopes, scope) {
+			return nil, fmt.Errorf("unknown scope '%s' (expected one of %s)", scope, strings.Join(api.Scopes, ", "))
+		}
+	}
+	if slices.Contains(req.Scopes, api.ScopeAgentRegister) && len(req.Scopes) > 1 {
+		return nil, fmt.Errorf("the '%s' scope is reserved to join tokens and cannot be combined", api.ScopeAgentRegister)
+	}
+
+	token := api.NewToken{
+		Token: api.Token{
+			ID:        newID("token"),
+			Name:      req.Name,
+			Scopes:    slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+			CreatedAt: time.Now(),
+		},
+		Secret: newSecret(apiTokenPrefix),
+	}
+	hash := hashToken(token.Secret)
+	if err := a.store.SaveToken(storedToken{Token: token.Token, Hash: hash}); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.tokens[hash] = token.Token
+	a.mu.Unlock()
+	return &token, nil
+}
+
+func (a *authenticator) list() []api.Token {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	tokens := make([]api.Token, 0, len(a.tokens))
+	for _, token := range a.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.Before(tokens[j].CreatedAt) })
+	return tokens
+}
+
+func (a *authenticator) revoke(id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for hash, token := range a.tokens {
+		if token.ID == id {
+			if err := a.store.DeleteToken(id); err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+			delete(a.tokens, hash)
+			return nil
+		}
+	}
+	return ErrTokenNotFound
+}
+
+// bootstrap creates an admin token when there is none yet, so that a
+// new server can be administered at all.
+func (a *authenticator) bootstrap() error {
+	a.mu.Lock()
+	empty := len(a.tokens) == 0
+	a.mu.Unlock()
+	if !empty {
+		return nil
+	}
+	token, err := a.create(api.TokenRequest{Name: "admin", Scopes: []string{api.ScopeAdmin}})
+	if err != nil {
+		return fmt.Errorf("failed to create the admin token: %w", err)
+	}
+	log.Printf("No API token exists yet, created the admin token %s. It is not shown again: "+
+		"log in with 'flowcraft login <server> --with-token' and create narrower tokens with 'flowcraft token create'.", token.Secret)
+	return nil
+}
+
+// startDevice begins a device login for the named client.
+func (a *authenticator) startDevice(name string) *deviceLogin {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	for code, login := range a.devices {
+		if now.After(login.ExpiresAt) {
+			delete(a.devices, code)
+		}
+	}
+	if len(a.devices) >= maxDeviceLogins {
+		// The endpoint is open to anyone: evicting the oldest login
+		// keeps a flood of requests from locking everybody out.
+		a.evictOldestDevice()
+	}
+
+	login := &deviceLogin{
+		DeviceLogin: api.DeviceLogin{UserCode: newUserCode(), Name: name, ExpiresAt: now.Add(deviceLoginTTL)},
+		deviceCode:  newSecret(""),
+	}
+	a.devices[login.deviceCode] = login
+	return login
+}
+
+// evictOldestDevice drops the unanswered device login started first,
+// or the oldest login if all of them were answered. It must be called
+// with a.mu held.
+func (a *authenticator) evictOldestDevice() {
+	var oldest *deviceLogin
+	for _, login := range a.devices {
+		switch {
+		case oldest == nil,
+			oldest.answered && !login.answered,
+			oldest.answered == login.answered && login.ExpiresAt.Before(oldest.ExpiresAt):
+			oldest = login
+		}
+	}
+	if oldest != nil {
+		delete(a.devices, oldest.deviceCode)
+	}
+}
+
+// device returns the device login waiting for userCode. It must be
+// called with a.mu held.
+func (a *authenticator) device(userCode string) (*deviceLogin, error) {
+	userCode = strings.ToUpper(strings.TrimSpace(userCode))
+	for _, login := range a.devices {
+		if login.UserCode == userCode && !login.answered && time.Now().Before(login.ExpiresAt) {
+			return login, nil
+		}
+	}
+	return nil, api.ErrExpiredToken
+}
+
+// approveDevice answers a device login. An approved login receives a
+// new token with the scopes of the approver.
+func (a *authenticator) approveDevice(approver api.Token, userCode string, approved bool) error {
+	a.mu.Lock()
+	login, err := a.device(userCode)
+	if err != nil {
+		a.mu.Unlock()
+		return err
+	}
+	login.answered = true
+	login.denied = !approved
+	name := login.Name
+	a.mu.Unlock()
+	if !approved {
+		return nil
+	}
+
+	token, err := a.create(api.TokenRequest{
+		Name:   fmt.Sprintf("%s (approved by %s)", name, approver.Name),
+		Scopes: approver.Scopes,
+	})
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		login.denied = true
+		return err
+	}
+	login.token = token
+	return nil
+}
+
+// deviceToken hands the token of an approved device login out, once.
+func (a *authenticator) deviceToken(deviceCode string) (*api.NewToken, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	login, ok := a.devices[deviceCode]
+	switch {
+	case !ok:
+		return nil, api.ErrExpiredToken
+	case login.token != nil:
+		delete(a.devices, deviceCode)
+		return login.token, nil
+	case login.denied:
+		delete(a.devices, deviceCode)
+		return nil, api.ErrAccessDenied
+	case time.Now().After(login.ExpiresAt):
+		delete(a.devices, deviceCode)
+		return nil, api.ErrExpiredToken
+	default:
+		return nil, api.ErrAuthorizationPending
+	}
+}
+
+func newUserCode() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	code := make([]byte, 0, 9)
+	for i, v := range b {
+		if i == 4 {
+			code = append(code, '-')
+		}
+		code = append(code, userCodeAlphabet[int(v)%len(userCodeAlphabet)])
+	}
+	return string(code)
+}
+
+// bearer returns the token of a request: the Authorization header, else
+// the dashboard's cookie.
+func bearer(r *http.Request) string {
+	if value, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(value)
+	}
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+type tokenKey struct{}
+
+// caller returns the token that authenticated r.
+func caller(r *http.Request) (api.Token, bool) {
+	token, ok := r.Context().Value(tokenKey{}).(api.Token)
+	return token, ok
+}
+
+func writeUnauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="flowcraft"`)
+	writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token: run 'flowcraft login <server>'"))
+}
+
+// require wraps h to demand a token granting scope, or any valid token
+// when scope is empty. It returns h as is when authentication is off.
+func (s *Server) require(scope string, h http.HandlerFunc) http.HandlerFunc {
+	if s.auth == nil {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := s.auth.lookup(bearer(r))
+		if !ok {
+			writeUnauthorized(w)
+			return
+		}
+		if scope != "" && !token.Allows(scope) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("token '%s' lacks the '%s' scope", token.Name, scope))
+			return
+		}
+		h(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, token)))
+	}
+}
+
+// requireAgent wraps the lease request of an agent to demand the token
+// the agent received when it registered.
+func (s *Server) requireAgent(h http.HandlerFunc) http.HandlerFunc {
+	if s.auth == nil {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret := bearer(r)
+		if secret == "" {
+			writeUnauthorized(w)
+			return
+		}
+		agentID, ok := s.d.agentByToken(hashToken(secret))
+		if !ok {
+			// The server forgot the agent, e.g. across a restart: it
+			// must register again with its join token.
+			writeAgentError(w, api.ErrUnknownAgent)
+			return
+		}
+		if agentID != r.PathValue("id") {
+			writeError(w, http.StatusForbidden, errors.New("the token belongs to another agent"))
+			return
+		}
+		h(w, r)
+	}
+}
+
+// requireLease wraps the requests about a lease to demand the token of
+// the agent holding it.
+func (s *Server) requireLease(h http.HandlerFunc) http.HandlerFunc {
+	if s.auth == nil {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret := bearer(r)
+		if secret == "" {
+			writeUnauthorized(w)
+			return
+		}
+		agentID, ok := s.d.agentByToken(hashToken(secret))
+		if !ok {
+			writeAgentError(w, api.ErrLeaseLost)
+			return
+		}
+		if owner, ok := s.d.leaseOwner(r.PathValue("id")); ok && owner != agentID {
+			writeError(w, http.StatusForbidden, errors.New("the lease belongs to another agent"))
+			return
+		}
+		h(w, r)
+	}
+}
+
+// CreateToken issues an API token.
+func (s *Server) CreateToken(req api.TokenRequest) (*api.NewToken, error) {
+	if s.auth == nil {
+		return nil, ErrAuthDisabled
+	}
+	token, err := s.auth.create(req)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Token %s ('%s') created with scopes %v.", token.ID, token.Name, token.Scopes)
+	return token, nil
+}
+
+// Tokens lists the API tokens, oldest first.
+func (s *Server) Tokens() []api.Token {
+	if s.auth == nil {
+		return []api.Token{}
+	}
+	return s.auth.list()
+}
+
+// RevokeToken deletes an API token. Agents that registered with a
+// revoked join token keep running until they need to register again.
+func (s *Server) RevokeToken(id string) error {
+	if s.auth == nil {
+		return ErrTokenNotFound
+	}
+	if err := s.auth.revoke(id); err != nil {
+		return err
+	}
+	log.Printf("Token %s revoked.", id)
+	return nil
+}
+
+func (s *Server) handleCreateToken(w http.ResponseWriter, r *http.Request) {
+	var req api.TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid token request: %w", err))
+		return
+	}
+	token, err := s.CreateToken(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, token)
+}
+
+func (s *Server) handleTokens(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Tokens())
+}
+
+func (s *Server) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	if err := s.RevokeToken(r.PathValue("id")); err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleWhoami describes the caller's token. Without authentication,
+// everyone is an anonymous admin.
+func (s *Server) handleWhoami(w http.ResponseWriter, r *http.Request) {
+	token, ok := caller(r)
+	if !ok {
+		token = api.Token{Name: "anonymous", Scopes: []string{api.ScopeAdmin}}
+	}
+	writeJSON(w, http.StatusOK, token)
+}
+
+// handleSession stores the caller's token in the dashboard's cookie.
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    bearer(r),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	s.handleWhoami(w, r)
+}
+
+func (s *Server) handleEndSession(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleStartDevice(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		writeError(w, http.StatusNotFound, ErrAuthDisabled)
+		return
+	}
+	var req api.DeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid device request: %w", err))
+		return
+	}
+	if req.Name == "" {
+		req.Name = "flowcraft"
+	}
+	login := s.auth.startDevice(req.Name)
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	log.Printf("Device login %s started by '%s'.", login.UserCode, login.Name)
+	writeJSON(w, http.StatusCreated, api.DeviceCode{
+		DeviceCode:      login.deviceCode,
+		UserCode:        login.UserCode,
+		VerificationURL: fmt.Sprintf("%s://%s/ui/#/device/%s", scheme, r.Host, login.UserCode),
+		ExpiresIn:       int(deviceLoginTTL.Seconds()),
+		Interval:        devicePollInterval,
+	})
+}
+
+func (s *Server) handleDeviceToken(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		writeError(w, http.StatusNotFound, ErrAuthDisabled)
+		return
+	}
+	var req api.DeviceTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid token request: %w", err))
+		return
+	}
+	token, err := s.auth.deviceToken(req.DeviceCode)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, token)
+}
+
+func (s *Server) handleDevice(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		writeError(w, http.StatusNotFound, ErrAuthDisabled)
+		return
+	}
+	s.auth.mu.Lock()
+	login, err := s.auth.device(r.PathValue("code"))
+	var info api.DeviceLogin
+	if err == nil {
+		info = login.DeviceLogin
+	}
+	s.auth.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("no device login is waiting for this code"))
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) handleApproveDevice(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		writeError(w, http.StatusNotFound, ErrAuthDisabled)
+		return
+	}
+	var req api.DeviceApproval
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid approval: %w", err))
+		return
+	}
+	approver, _ := caller(r)
+	if err := s.auth.approveDevice(approver, req.UserCode, req.Approved); err != nil {
+		writeError(w, http.StatusNotFound, errors.New("no device login is waiting for this code"))
+		return
+	}
+	verdict := "approved"
+	if !req.Approved {
+		verdict = "denied"
+	}
+	log.Printf("Device login %s %s by '%s'.", strings.ToUpper(req.UserCode), verdict, approver.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,296 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/agent"
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/client"
+)
+
+func newToken(t *testing.T, srv *Server, scopes ...string) string {
+	t.Helper()
+	token, err := srv.CreateToken(api.TokenRequest{Name: "test", Scopes: scopes})
+	if err != nil {
+		t.Fatalf("CreateToken() returned an unexpected error: %v", err)
+	}
+	return token.Secret
+}
+
+// call sends an empty request with token and returns its status.
+func call(t *testing.T, ts *httptest.Server, method, path, token string) int {
+	t.Helper()
+	req, _ := http.NewRequest(method, ts.URL+path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestAuth_Scopes(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Auth: true})
+	if tokens := srv.Tokens(); len(tokens) != 1 || !tokens[0].Allows(api.ScopeAdmin) {
+		t.Fatalf("Expected a bootstrap admin token, got %+v", tokens)
+	}
+	read := newToken(t, srv, api.ScopeRead)
+	submitter := newToken(t, srv, api.ScopeSubmit)
+	admin := newToken(t, srv, api.ScopeAdmin)
+	join := newToken(t, srv, api.ScopeAgentRegister)
+
+	tests := []struct {
+		method, path, token string
+		want                int
+	}{
+		{"GET", "/api/v1/runs", "", http.StatusUnauthorized},
+		{"GET", "/api/v1/runs", "fct_unknown", http.StatusUnauthorized},
+		{"GET", "/api/v1/runs", read, http.StatusOK},
+		{"GET", "/api/v1/runs", submitter, http.StatusForbidden},
+		{"GET", "/api/v1/runs", admin, http.StatusOK},
+		{"GET", "/api/v1/runs", join, http.StatusForbidden},
+		{"POST", "/api/v1/runs/run-x/cancel", read, http.StatusForbidden},
+		{"POST", "/api/v1/runs/run-x/cancel", submitter, http.StatusNotFound},
+		{"GET", "/api/v1/tokens", read, http.StatusForbidden},
+		{"GET", "/api/v1/tokens", admin, http.StatusOK},
+		{"GET", "/api/v1/whoami", join, http.StatusOK},
+		{"GET", "/healthz", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := call(t, ts, tt.method, tt.path, tt.token); got != tt.want {
+			t.Errorf("%s %s with token %q: expected status %d, got %d", tt.method, tt.path, tt.token, tt.want, got)
+		}
+	}
+}
+
+func TestAuth_Disabled(t *testing.T) {
+	_, ts := newTestServer(t)
+	if got := call(t, ts, "GET", "/api/v1/runs", ""); got != http.StatusOK {
+		t.Errorf("Expected status 200 without authentication, got %d", got)
+	}
+	if got := call(t, ts, "POST", "/api/v1/login/device", ""); got != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a device login, got %d", got)
+	}
+}
+
+func TestCreateToken_Validation(t *testing.T) {
+	srv, _ := startTestServer(t, Options{Auth: true})
+	invalid := []api.TokenRequest{
+		{Scopes: []string{api.ScopeRead}},
+		{Name: "none"},
+		{Name: "unknown", Scopes: []string{"write"}},
+		{Name: "mixed", Scopes: []string{api.ScopeAgentRegister, api.ScopeRead}},
+	}
+	for _, req := range invalid {
+		if _, err := srv.CreateToken(req); err == nil {
+			t.Errorf("Expected CreateToken(%+v) to fail", req)
+		}
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Auth: true})
+	secret := newToken(t, srv, api.ScopeRead)
+	token, _ := srv.auth.lookup(secret)
+
+	if err := srv.RevokeToken(token.ID); err != nil {
+		t.Fatalf("RevokeToken() returned an unexpected error: %v", err)
+	}
+	if got := call(t, ts, "GET", "/api/v1/runs", secret); got != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 with a revoked token, got %d", got)
+	}
+	if err := srv.RevokeToken(token.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Expected ErrTokenNotFound, got %v", err)
+	}
+}
+
+func TestAuth_AgentTokens(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Auth: true})
+	ctx := context.Background()
+
+	join := client.New(ts.URL)
+	join.SetToken(newToken(t, srv, api.ScopeAgentRegister))
+	first, err := join.Register(ctx, api.AgentRegistration{Name: "first", Capacity: 1})
+	if err != nil {
+		t.Fatalf("Register() returned an unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(first.Token, agentTokenPrefix) {
+		t.Errorf("Expected an agent token, got %q", first.Token)
+	}
+
+	other := client.New(ts.URL)
+	other.SetToken(newToken(t, srv, api.ScopeAgentRegister))
+	second, err := other.Register(ctx, api.AgentRegistration{Name: "second", Capacity: 1})
+	if err != nil {
+		t.Fatalf("Register() returned an unexpected error: %v", err)
+	}
+	// An agent token only leases for its own agent.
+	if got := call(t, ts, "POST", "/api/v1/agents/"+first.ID+"/lease", second.Token); got != http.StatusForbidden {
+		t.Errorf("Expected status 403 when leasing for another agent, got %d", got)
+	}
+	// The agent token is not an API token.
+	if got := call(t, ts, "GET", "/api/v1/runs", first.Token); got != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for an agent token on the API, got %d", got)
+	}
+	for _, a := range srv.Agents() {
+		if a.Token != "" {
+			t.Errorf("Expected the agent listing to hide tokens, got %q", a.Token)
+		}
+	}
+}
+
+func TestAuth_RunsOnAgents(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Auth: true, Workers: 2}, agent.Options{Name: "agent-1", Capacity: 2})
+	c := client.New(ts.URL)
+	c.SetToken(newToken(t, srv, api.ScopeSubmit, api.ScopeRead))
+
+	ctx := context.Background()
+	run, err := c.Submit(ctx, []byte(gatedConfig), nil)
+	if err != nil {
+		t.Fatalf("Submit() returned an unexpected error: %v", err)
+	}
+	waitForStatus := func(status string) {
+		t.Helper()
+		for range 200 {
+			current, err := c.Run(ctx, run.ID)
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if current.Jobs["deploy"].Status == status {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("Job 'deploy' never became %s", status)
+	}
+	waitForStatus(api.JobAwaitingApproval)
+	if _, err := srv.Approve(run.ID, "deploy", true); err != nil {
+		t.Fatalf("Approve() returned an unexpected error: %v", err)
+	}
+	waitForStatus(api.JobSuccess)
+}
+
+func TestAuth_DeviceLogin(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Auth: true})
+	ctx := context.Background()
+	c := client.New(ts.URL)
+
+	login, err := c.StartDeviceLogin(ctx, "laptop")
+	if err != nil {
+		t.Fatalf("StartDeviceLogin() returned an unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(login.VerificationURL, "/ui/#/device/"+login.UserCode) {
+		t.Errorf("Unexpected verification URL %q", login.VerificationURL)
+	}
+	if _, err := c.DeviceToken(ctx, login.DeviceCode); !errors.Is(err, api.ErrAuthorizationPending) {
+		t.Fatalf("Expected ErrAuthorizationPending, got %v", err)
+	}
+
+	approver := newToken(t, srv, api.ScopeRead, api.ScopeSubmit)
+	body := strings.NewReader(`{"user_code":"` + strings.ToLower(login.UserCode) + `","approved":true}`)
+	req, _ := http.NewRequest("POST", ts.URL+"/api/v1/login/device/approve", body)
+	req.Header.Set("Authorization", "Bearer "+approver)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Approve request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", resp.StatusCode)
+	}
+
+	token, err := c.DeviceToken(ctx, login.DeviceCode)
+	if err != nil {
+		t.Fatalf("DeviceToken() returned an unexpected error: %v", err)
+	}
+	if !token.Allows(api.ScopeSubmit) || token.Allows(api.ScopeAdmin) {
+		t.Errorf("Expected the approver's scopes, got %v", token.Scopes)
+	}
+	c.SetToken(token.Secret)
+	if _, err := c.Whoami(ctx); err != nil {
+		t.Errorf("Whoami() with the device token returned an unexpected error: %v", err)
+	}
+	// The token is handed out once.
+	if _, err := c.DeviceToken(ctx, login.DeviceCode); !errors.Is(err, api.ErrExpiredToken) {
+		t.Errorf("Expected ErrExpiredToken on a second poll, got %v", err)
+	}
+}
+
+func TestAuth_DeviceLoginDenied(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Auth: true})
+	ctx := context.Background()
+	c := client.New(ts.URL)
+	login, err := c.StartDeviceLogin(ctx, "laptop")
+	if err != nil {
+		t.Fatalf("StartDeviceLogin() returned an unexpected error: %v", err)
+	}
+
+	if err := srv.auth.approveDevice(api.Token{Name: "admin"}, login.UserCode, false); err != nil {
+		t.Fatalf("approveDevice() returned an unexpected error: %v", err)
+	}
+	if _, err := c.DeviceToken(ctx, login.DeviceCode); !errors.Is(err, api.ErrAccessDenied) {
+		t.Errorf("Expected ErrAccessDenied, got %v", err)
+	}
+	if err := srv.auth.approveDevice(api.Token{Name: "admin"}, "BCDF-GHJK", true); err == nil {
+		t.Error("Expected approving an unknown code to fail")
+	}
+}
+
+func TestAuth_DeviceLoginFloodEvictsOldest(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Auth: true})
+	ctx := context.Background()
+	c := client.New(ts.URL)
+
+	approved, err := c.StartDeviceLogin(ctx, "laptop")
+	if err != nil {
+		t.Fatalf("StartDeviceLogin() returned an unexpected error: %v", err)
+	}
+	if err := srv.auth.approveDevice(api.Token{Name: "admin", Scopes: []string{api.ScopeRead}}, approved.UserCode, true); err != nil {
+		t.Fatalf("approveDevice() returned an unexpected error: %v", err)
+	}
+	first, err := c.StartDeviceLogin(ctx, "flood")
+	if err != nil {
+		t.Fatalf("StartDeviceLogin() returned an unexpected error: %v", err)
+	}
+	var last *api.DeviceCode
+	for i := 0; i < maxDeviceLogins; i++ {
+		if last, err = c.StartDeviceLogin(ctx, "flood"); err != nil {
+			t.Fatalf("Expected device login %d to start, got %v", i, err)
+		}
+	}
+
+	if _, err := c.DeviceToken(ctx, first.DeviceCode); !errors.Is(err, api.ErrExpiredToken) {
+		t.Errorf("Expected the oldest pending login to be evicted, got %v", err)
+	}
+	if _, err := c.DeviceToken(ctx, last.DeviceCode); !errors.Is(err, api.ErrAuthorizationPending) {
+		t.Errorf("Expected the newest login to be pending, got %v", err)
+	}
+	if _, err := c.DeviceToken(ctx, approved.DeviceCode); err != nil {
+		t.Errorf("Expected the approved login to be kept, got %v", err)
+	}
+}
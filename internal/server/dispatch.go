@@ -0,0 +1,494 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/agent"
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/Purpose-Dev/flowcraft/internal/tracing"
+)
+
+var _ agent.Coordinator = (*Server)(nil)
+
+// pendingJob is a job attempt waiting for an agent, or leased to one.
+type pendingJob struct {
+	runID        string
+	spec         engine.JobSpec
+	live         *liveRun
+	logger       *runner.Logger
+	hasWorkspace bool
+	result       chan api.LeaseResult
+	cancelled    bool
+	// span is the span of the attempt, nil when the run is not traced.
+	span *tracing.Span
+}
+
+type activeLease struct {
+	lease   api.Lease
+	job     *pendingJob
+	expires time.Time
+}
+
+type agentState struct {
+	info     api.Agent
+	lastSeen time.Time
+	leases   map[string]bool
+	// tokenHash identifies the token given to the agent on registration.
+	tokenHash string
+}
+
+// dispatcher hands job attempts out to agents as leases. A lease must
+// be kept alive with heartbeats; when it expires, the job goes back to
+// the front of the queue for another agent.
+type dispatcher struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	queue   []*pendingJob
+	leases  map[string]*activeLease
+	agents  map[string]*agentState
+	changed chan struct{}
+}
+
+func newDispatcher(ttl time.Duration) *dispatcher {
+	return &dispatcher{
+		ttl:     ttl,
+		leases:  make(map[string]*activeLease),
+		agents:  make(map[string]*agentState),
+		changed: make(chan struct{}),
+	}
+}
+
+// notify wakes up the agents waiting for a lease. It must be called
+// with d.mu held.
+func (d *dispatcher) notify() {
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+func (d *dispatcher) enqueue(job *pendingJob, front bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if front {
+		d.queue = append([]*pendingJob{job}, d.queue...)
+	} else {
+		d.queue = append(d.queue, job)
+	}
+	d.notify()
+}
+
+// cancel withdraws a job: queued jobs are dropped right away, leased
+// jobs are flagged so that the next heartbeat tells the agent to stop.
+// It reports whether the job was still queued.
+func (d *dispatcher) cancel(job *pendingJob) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	job.cancelled = true
+	if i := slices.Index(d.queue, job); i >= 0 {
+		d.queue = slices.Delete(d.queue, i, i+1)
+		return true
+	}
+	return false
+}
+
+// cancelJob withdraws the attempt of job being dispatched for run, as
+// cancel does. A job still queued is answered right away. It reports
+// whether such an attempt was found.
+func (d *dispatcher) cancelJob(runID, job string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i, pending := range d.queue {
+		if pending.runID == runID && pending.spec.Name == job {
+			pending.cancelled = true
+			d.queue = slices.Delete(d.queue, i, i+1)
+			pending.result <- api.LeaseResult{Status: engine.StatusCancelled, Error: "cancelled before it was leased"}
+			return true
+		}
+	}
+	for _, l := range d.leases {
+		if l.job.runID == runID && l.job.spec.Name == job && !l.job.cancelled {
+			l.job.cancelled = true
+			return true
+		}
+	}
+	return false
+}
+
+// agentByToken returns the ID of the agent holding the token with hash.
+func (d *dispatcher) agentByToken(hash string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, agent := range d.agents {
+		if agent.tokenHash == hash {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// leaseOwner returns the ID of the agent holding a lease.
+func (d *dispatcher) leaseOwner(leaseID string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	l, ok := d.leases[leaseID]
+	if !ok {
+		return "", false
+	}
+	return l.lease.AgentID, true
+}
+
+// Register adds an agent and returns its identity, with the token
+// authenticating its lease requests.
+func (s *Server) Register(_ context.Context, reg api.AgentRegistration) (*api.Agent, error) {
+	if reg.Capacity <= 0 {
+		reg.Capacity = 1
+	}
+	info := api.Agent{
+		ID:              newID("agent"),
+		Name:            reg.Name,
+		Tags:            reg.Tags,
+		Capacity:        reg.Capacity,
+		CPU:             reg.CPU,
+		Memory:          reg.Memory,
+		LastSeen:        time.Now(),
+		LeaseTTLSeconds: int(s.d.ttl.Seconds()),
+	}
+
+	token := newSecret(agentTokenPrefix)
+	s.d.mu.Lock()
+	s.d.agents[info.ID] = &agentState{info: info, lastSeen: info.LastSeen, leases: make(map[string]bool), tokenHash: hashToken(token)}
+	s.d.mu.Unlock()
+
+	log.Printf("Agent %s (%s) registered with capacity %d, %s and tags %v.",
+		info.ID, info.Name, info.Capacity, formatResources(info.CPU, info.Memory), info.Tags)
+	info.Token = token
+	return &info, nil
+}
+
+// Agents returns the registered agents and their leases.
+func (s *Server) Agents() []api.Agent {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	agents := make([]api.Agent, 0, len(s.d.agents))
+	for _, a := range s.d.agents {
+		info := a.info
+		info.LastSeen = a.lastSeen
+		info.Leases = make([]string, 0, len(a.leases))
+		for id := range a.leases {
+			info.Leases = append(info.Leases, id)
+		}
+		sort.Strings(info.Leases)
+		info.Jobs = make([]api.LeasedJob, 0, len(info.Leases))
+		for _, id := range info.Leases {
+			if l, ok := s.d.leases[id]; ok {
+				info.Jobs = append(info.Jobs, api.LeasedJob{
+					Lease:     id,
+					RunID:     l.lease.RunID,
+					Job:       l.lease.Job,
+					Attempt:   l.lease.Attempt,
+					ExpiresAt: l.expires,
+				})
+			}
+		}
+		agents = append(agents, info)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+	return agents
+}
+
+// Lease waits up to wait for a job the agent can take: the first queued
+// job whose tags and resources fit the agent. It returns nil when none
+// became available in time.
+func (s *Server) Lease(ctx context.Context, agentID string, wait time.Duration) (*api.Lease, error) {
+	d := s.d
+	wait = min(wait, d.ttl)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		d.mu.Lock()
+		agent, ok := d.agents[agentID]
+		if !ok {
+			d.mu.Unlock()
+			return nil, api.ErrUnknownAgent
+		}
+		agent.lastSeen = time.Now()
+
+		if i := slices.IndexFunc(d.queue, func(job *pendingJob) bool { return agent.fits(job.spec.Job) }); i >= 0 {
+			job := d.queue[i]
+			d.queue = slices.Delete(d.queue, i, i+1)
+			lease := d.grant(agent, job)
+			d.mu.Unlock()
+
+			job.logger.Info(fmt.Sprintf("Job '%s' (attempt %d) leased to agent '%s'.", job.spec.Name, job.spec.Attempt, agent.info.Name))
+			return &lease, nil
+		}
+		changed := d.changed
+		d.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return nil, nil
+		case <-changed:
+		}
+	}
+}
+
+// grant creates a lease of job for agent. It must be called with d.mu held.
+func (d *dispatcher) grant(agent *agentState, job *pendingJob) api.Lease {
+	now := time.Now()
+	opts := job.spec.Options
+	lease := api.Lease{
+		ID:                newID("lease"),
+		RunID:             job.runID,
+		Job:               job.spec.Name,
+		Attempt:           job.spec.Attempt,
+		Spec:              job.spec.Job,
+		Env:               job.spec.Env,
+		Secrets:           job.spec.SecretRefs,
+		SecretSettings:    job.spec.SecretSettings,
+		InheritEnv:        opts.Env.Inherit,
+		PassEnv:           opts.Env.Pass,
+		WarnUndeclaredEnv: opts.Env.WarnUndeclared,
+		Outputs:           opts.Outputs.Values(),
+		ProcessEnv:        opts.ProcessEnv,
+		HasWorkspace:      job.hasWorkspace,
+		LeaseTTLSeconds:   int(d.ttl.Seconds()),
+		ExpiresAt:         now.Add(d.ttl),
+		AgentID:           agent.info.ID,
+		TraceParent:       job.span.Context().TraceParent(),
+	}
+	job.span.SetAttributes(tracing.String("flowcraft.agent", agent.info.Name), tracing.String("flowcraft.agent.id", agent.info.ID))
+	d.leases[lease.ID] = &activeLease{lease: lease, job: job, expires: lease.ExpiresAt}
+	agent.leases[lease.ID] = true
+	agent.reserve(job.spec.Job, 1)
+	return lease
+}
+
+// lookup returns an active lease and extends it. It must be called with d.mu held.
+func (d *dispatcher) lookup(leaseID string) (*activeLease, error) {
+	l, ok := d.leases[leaseID]
+	if !ok {
+		return nil, api.ErrLeaseLost
+	}
+	now := time.Now()
+	l.expires = now.Add(d.ttl)
+	if agent, ok := d.agents[l.lease.AgentID]; ok {
+		agent.lastSeen = now
+	}
+	return l, nil
+}
+
+// Heartbeat keeps a lease alive and tells the agent whether the job was cancelled.
+func (s *Server) Heartbeat(_ context.Context, leaseID string) (*api.HeartbeatResponse, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	l, err := s.d.lookup(leaseID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.HeartbeatResponse{Cancelled: l.job.cancelled}, nil
+}
+
+// SendEvents appends the step events and log lines reported by an
+// agent to the run's event stream.
+func (s *Server) SendEvents(_ context.Context, leaseID string, events []api.Event) error {
+	s.d.mu.Lock()
+	l, err := s.d.lookup(leaseID)
+	s.d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.metrics.observeRemote(l.lease.Job, events)
+	s.traceRemote(l.lease, events)
+	l.job.live.appendRemote(l.lease.Job, events)
+	return nil
+}
+
+// Complete releases a lease with the result of the job attempt.
+func (s *Server) Complete(_ context.Context, leaseID string, result api.LeaseResult) error {
+	d := s.d
+	d.mu.Lock()
+	l, err := d.lookup(leaseID)
+	if err != nil {
+		d.mu.Unlock()
+		return err
+	}
+	d.release(l)
+	d.notify()
+	d.mu.Unlock()
+
+	l.job.result <- result
+	return nil
+}
+
+// Workspace returns the workspace tarball of the leased job's run, or
+// nil when the run was submitted without one.
+func (s *Server) Workspace(_ context.Context, leaseID string) (io.ReadCloser, error) {
+	s.d.mu.Lock()
+	l, err := s.d.lookup(leaseID)
+	s.d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if !l.job.hasWorkspace {
+		return nil, nil
+	}
+	return os.Open(workspacePath(s.opts.DataDir, l.lease.RunID))
+}
+
+// release forgets a lease. It must be called with d.mu held.
+func (d *dispatcher) release(l *activeLease) {
+	delete(d.leases, l.lease.ID)
+	if agent, ok := d.agents[l.lease.AgentID]; ok {
+		delete(agent.leases, l.lease.ID)
+		agent.reserve(l.job.spec.Job, -1)
+	}
+}
+
+// reap requeues the jobs of expired leases and forgets agents that
+// stopped polling.
+func (d *dispatcher) reap(now time.Time) {
+	var requeued, dropped []*activeLease
+
+	d.mu.Lock()
+	for _, l := range d.leases {
+		if !now.After(l.expires) {
+			continue
+		}
+		d.release(l)
+		if l.job.cancelled {
+			dropped = append(dropped, l)
+		} else {
+			requeued = append(requeued, l)
+			d.queue = append([]*pendingJob{l.job}, d.queue...)
+		}
+	}
+	if len(requeued) > 0 || len(dropped) > 0 {
+		d.notify()
+	}
+	for id, agent := range d.agents {
+		if len(agent.leases) == 0 && now.Sub(agent.lastSeen) > 2*d.ttl {
+			log.Printf("Agent %s (%s) stopped polling and was removed.", id, agent.info.Name)
+			delete(d.agents, id)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, l := range requeued {
+		l.job.logger.Warn(fmt.Sprintf("Lease of job '%s' on agent %s expired, requeuing the job.", l.lease.Job, l.lease.AgentID))
+	}
+	for _, l := range dropped {
+		l.job.result <- api.LeaseResult{Status: engine.StatusCancelled, Error: "lease expired after cancellation"}
+	}
+}
+
+func (d *dispatcher) reaper(ctx context.Context) {
+	ticker := time.NewTicker(max(d.ttl/4, 50*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.reap(now)
+		}
+	}
+}
+
+// dispatch returns the executor used by the runs of the server: each
+// job attempt becomes a lease and the call blocks until an agent
+// reports its result.
+func (s *Server) dispatch(runID string, live *liveRun, hasWorkspace bool) engine.JobExecutor {
+	return func(ctx context.Context, spec engine.JobSpec, logger *runner.Logger) error {
+		job := &pendingJob{
+			runID:        runID,
+			spec:         spec,
+			live:         live,
+			logger:       logger,
+			hasWorkspace: hasWorkspace,
+			result:       make(chan api.LeaseResult, 1),
+			span:         tracing.SpanFromContext(ctx),
+		}
+		if len(spec.Job.RunsOn) > 0 {
+			logger.Info(fmt.Sprintf("Job '%s' queued for an agent tagged [%s].", spec.Name, strings.Join(spec.Job.RunsOn, ", ")))
+		} else {
+			logger.Info(fmt.Sprintf("Job '%s' queued for an agent.", spec.Name))
+		}
+		s.d.enqueue(job, false)
+
+		select {
+		case res := <-job.result:
+			if res.Tests != nil && spec.Report != nil {
+				spec.Report(*res.Tests)
+			}
+			switch res.Status {
+			case engine.StatusSuccess:
+				for name, value := range res.Outputs {
+					spec.Options.Outputs.Set(name, value)
+				}
+				return nil
+			case engine.StatusCancelled:
+				return fmt.Errorf("job '%s' was cancelled: %w", spec.Name, context.Canceled)
+			default:
+				return errors.New(res.Error)
+			}
+		case <-ctx.Done():
+			live.mu.Lock()
+			byUser := live.cancelled
+			live.mu.Unlock()
+			if !s.d.cancel(job) && byUser {
+				// Give the agent a chance to stop the job before the
+				// run is reported as cancelled.
+				select {
+				case <-job.result:
+				case <-time.After(s.d.ttl + time.Second):
+				}
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+func newID(prefix string) string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return prefix + "-" + hex.EncodeToString(b)
+}
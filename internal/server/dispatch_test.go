@@ -0,0 +1,376 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/agent"
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/gittest"
+)
+
+func TestDispatch_SpreadsJobsAcrossAgents(t *testing.T) {
+	_, ts := startTestServer(t, Options{Workers: 1},
+		agent.Options{Name: "alpha", Capacity: 1},
+		agent.Options{Name: "beta", Capacity: 1},
+	)
+
+	cfg := `
+[jobs.one]
+[[jobs.one.steps]]
+name = "One"
+cmd = "sleep 0.5"
+
+[jobs.two]
+[[jobs.two.steps]]
+name = "Two"
+cmd = "sleep 0.5"
+`
+	_, run := submit(t, ts, cfg, nil)
+	events := streamEvents(t, ts, run.ID)
+
+	leasedTo := make(map[string]bool)
+	for _, e := range events {
+		if e.Type != api.EventLog || !strings.Contains(e.Message, "leased to agent") {
+			continue
+		}
+		for _, name := range []string{"alpha", "beta"} {
+			if strings.Contains(e.Message, "'"+name+"'") {
+				leasedTo[name] = true
+			}
+		}
+	}
+	if !leasedTo["alpha"] || !leasedTo["beta"] {
+		t.Errorf("Expected both agents to get a job, got %v", leasedTo)
+	}
+	if status := getRun(t, ts, run.ID).Status; status != api.RunSucceeded {
+		t.Errorf("Expected run to succeed, got %s", status)
+	}
+}
+
+func TestDispatch_RequeuesExpiredLease(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Workers: 1, LeaseTTL: time.Second})
+
+	cfg := "[jobs.work]\n[[jobs.work.steps]]\nname = \"Work\"\ncmd = \"echo finally done\"\n"
+	_, run := submit(t, ts, cfg, nil)
+
+	// An agent that takes the job and disappears without heartbeats.
+	ctx := context.Background()
+	ghost, err := srv.Register(ctx, api.AgentRegistration{Name: "ghost"})
+	if err != nil {
+		t.Fatalf("Register() returned an unexpected error: %v", err)
+	}
+	lease, err := srv.Lease(ctx, ghost.ID, 5*time.Second)
+	if err != nil || lease == nil {
+		t.Fatalf("Expected the ghost agent to get a lease, got %v, %v", lease, err)
+	}
+
+	// The job only completes once another agent picks it up.
+	healthy := agent.New(srv, agent.Options{Name: "healthy", WorkDir: t.TempDir(), PollWait: time.Second})
+	agentCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		_ = healthy.Run(agentCtx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	events := streamEvents(t, ts, run.ID)
+
+	var expired, output bool
+	for _, e := range events {
+		if e.Type != api.EventLog {
+			continue
+		}
+		expired = expired || strings.Contains(e.Message, "expired, requeuing")
+		output = output || strings.Contains(e.Message, "finally done")
+	}
+	if !expired {
+		t.Error("Expected a warning about the expired lease")
+	}
+	if !output {
+		t.Error("Expected the job to run on the healthy agent")
+	}
+	if status := getRun(t, ts, run.ID).Status; status != api.RunSucceeded {
+		t.Errorf("Expected run to succeed, got %s", status)
+	}
+
+	if _, err := srv.Heartbeat(ctx, lease.ID); err != api.ErrLeaseLost {
+		t.Errorf("Expected the ghost's lease to be lost, got %v", err)
+	}
+	if err := srv.Complete(ctx, lease.ID, api.LeaseResult{Status: "success"}); err != api.ErrLeaseLost {
+		t.Errorf("Expected a late completion to be rejected, got %v", err)
+	}
+}
+
+func TestDispatch_AgentResolvesSecrets(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Workers: 1, LeaseTTL: time.Second})
+
+	marker := filepath.Join(t.TempDir(), "resolved")
+	cfg := `
+[secrets]
+token = { provider = "command", command = "touch ` + marker + ` && echo agent-secret-value" }
+
+[jobs.use]
+secrets = ["token"]
+[[jobs.use.steps]]
+name = "Use"
+cmd = "test \"$token\" = agent-secret-value && echo \"token is $token\""
+`
+	_, run := submit(t, ts, cfg, nil)
+
+	// The lease only carries the declaration of the secret.
+	ctx := context.Background()
+	ghost, err := srv.Register(ctx, api.AgentRegistration{Name: "ghost"})
+	if err != nil {
+		t.Fatalf("Register() returned an unexpected error: %v", err)
+	}
+	lease, err := srv.Lease(ctx, ghost.ID, 5*time.Second)
+	if err != nil || lease == nil {
+		t.Fatalf("Expected the ghost agent to get a lease, got %v, %v", lease, err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("Expected the server not to run the secret's helper")
+	}
+	if _, ok := lease.Env["token"]; ok {
+		t.Error("Expected the lease not to carry the secret's value")
+	}
+	if lease.Secrets["token"].Provider != "command" {
+		t.Errorf("Expected the lease to carry the secret's declaration, got %+v", lease.Secrets)
+	}
+
+	// Once the lease expires, a real agent resolves the secret itself.
+	healthy := agent.New(srv, agent.Options{Name: "healthy", WorkDir: t.TempDir(), PollWait: time.Second})
+	agentCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		_ = healthy.Run(agentCtx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	events := streamEvents(t, ts, run.ID)
+	if status := getRun(t, ts, run.ID).Status; status != api.RunSucceeded {
+		t.Fatalf("Expected run to succeed, got %s", status)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Error("Expected the agent to run the secret's helper")
+	}
+	for _, e := range events {
+		if strings.Contains(e.Message, "agent-secret-value") {
+			t.Errorf("Expected the secret to be masked, got %q", e.Message)
+		}
+	}
+}
+
+// waitForAgents waits until n agents are registered.
+func waitForAgents(t *testing.T, srv *Server, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(srv.Agents()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d agent(s) to register", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDispatch_MatchesRunsOnTags(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Workers: 1},
+		agent.Options{Name: "linux-box", Tags: []string{"linux"}},
+		agent.Options{Name: "mac-box", Tags: []string{"macos", "m1"}},
+	)
+	waitForAgents(t, srv, 2)
+
+	cfg := "[jobs.ios]\nruns_on = [\"macos\"]\n[[jobs.ios.steps]]\nname = \"Build\"\ncmd = \"echo ok\"\n"
+	_, run := submit(t, ts, cfg, nil)
+
+	var leasedTo string
+	for _, e := range streamEvents(t, ts, run.ID) {
+		if e.Type == api.EventLog && strings.Contains(e.Message, "leased to agent") {
+			leasedTo = e.Message
+		}
+	}
+	if !strings.Contains(leasedTo, "'mac-box'") {
+		t.Errorf("Expected the job to be leased to mac-box, got %q", leasedTo)
+	}
+}
+
+func TestDispatch_ReservesResources(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Workers: 1},
+		agent.Options{Name: "small", Capacity: 2, CPU: 2},
+	)
+	waitForAgents(t, srv, 1)
+
+	// Both jobs fit the agent's slots, but not its CPUs at the same time.
+	cfg := `
+[jobs.one]
+resources = { cpu = 2 }
+[[jobs.one.steps]]
+name = "One"
+cmd = "sleep 0.3"
+
+[jobs.two]
+resources = { cpu = 1.5 }
+[[jobs.two.steps]]
+name = "Two"
+cmd = "sleep 0.3"
+`
+	_, run := submit(t, ts, cfg, nil)
+
+	started := make(map[string]time.Time)
+	finished := make(map[string]time.Time)
+	for _, e := range streamEvents(t, ts, run.ID) {
+		switch e.Type {
+		case "step_started":
+			started[e.Job] = e.Time
+		case "step_finished":
+			finished[e.Job] = e.Time
+		}
+	}
+	if len(started) != 2 || len(finished) != 2 {
+		t.Fatalf("Expected both jobs to run, got starts %v and finishes %v", started, finished)
+	}
+	if started["one"].Before(finished["two"]) && started["two"].Before(finished["one"]) {
+		t.Error("Expected the jobs not to overlap on a 2-CPU agent")
+	}
+}
+
+func TestSubmit_RejectsUnplaceableJobs(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Workers: 1},
+		agent.Options{Name: "builder", Tags: []string{"linux"}, CPU: 4, Memory: 8 << 30},
+	)
+	waitForAgents(t, srv, 1)
+
+	tests := []struct {
+		name   string
+		config string
+		reason string
+	}{
+		{"unknown tag", "[jobs.gpu]\nruns_on = [\"linux\", \"gpu\"]\n", "no registered agent has all of these tags"},
+		{"too many CPUs", "[jobs.big]\nresources = { cpu = 16 }\n", "requests 16 CPU(s)"},
+		{"too much memory", "[jobs.big]\nresources = { mem = \"32Gi\" }\n", "offers 4 CPU(s) and 8Gi of memory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := srv.Submit([]byte(tt.config), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("Expected a rejection containing %q, got %v (%v)", tt.reason, err, resp)
+			}
+		})
+	}
+
+	if resp, _ := submit(t, ts, "[jobs.ok]\nruns_on = [\"linux\"]\nresources = { cpu = 4, mem = \"8Gi\" }\n", nil); resp.StatusCode != 201 {
+		t.Errorf("Expected a job fitting the agent to be accepted, got status %d", resp.StatusCode)
+	}
+}
+
+func TestDispatch_CarriesOutputs(t *testing.T) {
+	_, ts := startTestServer(t, Options{Workers: 1},
+		agent.Options{Name: "alpha", Capacity: 1},
+		agent.Options{Name: "beta", Capacity: 1},
+	)
+	repo, commits := gittest.BareRepo(t)
+	sha := commits[1]
+
+	cfg := `
+[jobs.checkout]
+[[jobs.checkout.steps]]
+name = "Checkout"
+checkout = { repository = "` + repo + `", depth = 1 }
+
+[jobs.build]
+depends_on = ["checkout"]
+[[jobs.build.steps]]
+name = "Build"
+cmd = "echo building $GIT_SHA"
+`
+	_, run := submit(t, ts, cfg, nil)
+	var sawSHA bool
+	for _, e := range streamEvents(t, ts, run.ID) {
+		if e.Type == api.EventLog && e.Job == "build" && strings.Contains(e.Message, "building "+sha) {
+			sawSHA = true
+		}
+	}
+	if !sawSHA {
+		t.Error("Expected 'build' to see the git.sha exported by 'checkout' on another lease")
+	}
+	if final := getRun(t, ts, run.ID); final.Status != api.RunSucceeded {
+		t.Errorf("Expected the run to succeed, got %s (%s)", final.Status, final.Error)
+	}
+}
+
+func TestDispatch_CarriesTestReports(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	cfg := `
+[jobs.test]
+reports = ["**/junit.xml"]
+[[jobs.test.steps]]
+name = "Unit"
+cmd = "true"
+`
+	report := `<testsuite name="unit"><testcase classname="calc" name="TestAdd"/><testcase classname="calc" name="TestDiv"><failure/></testcase></testsuite>`
+	_, run := submit(t, ts, cfg, map[string]string{"junit.xml": report})
+
+	var reports []api.Event
+	for _, e := range streamEvents(t, ts, run.ID) {
+		if e.Type == "test_report" {
+			reports = append(reports, e)
+		}
+	}
+	if len(reports) != 1 || reports[0].Job != "test" || reports[0].Tests == nil || reports[0].Tests.Failed != 1 {
+		t.Fatalf("Expected a single test_report event of 'test', got %+v", reports)
+	}
+
+	final := getRun(t, ts, run.ID)
+	tests := final.Jobs["test"].Tests
+	if tests == nil || tests.Passed != 1 || tests.Failed != 1 || len(tests.Failures) != 1 || tests.Failures[0] != "calc.TestDiv" {
+		t.Errorf("Expected the test report on the job state, got %+v", tests)
+	}
+}
+
+func TestDispatch_ListsLeasedJobs(t *testing.T) {
+	srv, ts := newTestServer(t)
+
+	cfg := "[jobs.slow]\n[[jobs.slow.steps]]\nname = \"Sleep\"\ncmd = \"sleep 30\"\n"
+	_, run := submit(t, ts, cfg, nil)
+	waitForJob(t, ts, run.ID, "slow", api.JobRunning)
+
+	agents := srv.Agents()
+	if len(agents) != 1 || len(agents[0].Jobs) != 1 {
+		t.Fatalf("Expected one agent holding one job, got %+v", agents)
+	}
+	job := agents[0].Jobs[0]
+	if job.RunID != run.ID || job.Job != "slow" || job.Attempt != 1 || job.Lease != agents[0].Leases[0] {
+		t.Errorf("Unexpected leased job %+v", job)
+	}
+	post(t, ts, "/api/v1/runs/"+run.ID+"/cancel")
+	streamEvents(t, ts, run.ID)
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+// liveRun is the in-memory state of a run being executed. Events and
+// job states are persisted by periodic flushes rather than with one
+// transaction per log line.
+type liveRun struct {
+	mu        sync.Mutex
+	run       *api.Run
+	events    []api.Event
+	flushed   int
+	notify    chan struct{}
+	cancel    context.CancelFunc
+	cancelled bool
+	done      bool
+	// gates holds the decision channel of each job waiting for approval.
+	gates map[string]chan bool
+}
+
+func newLiveRun(run *api.Run, cancel context.CancelFunc) *liveRun {
+	return &liveRun{run: run, cancel: cancel, notify: make(chan struct{}), gates: make(map[string]chan bool)}
+}
+
+// append assigns the next sequence number to an event and wakes up
+// the subscribers. It must be called with l.mu held.
+func (l *liveRun) append(e api.Event) {
+	e.Seq = uint64(len(l.events)) + 1
+	l.events = append(l.events, e)
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+// appendLog records a line written by the run's logger.
+func (l *liveRun) appendLog(e runner.Entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.append(api.Event{
+		Type:    api.EventLog,
+		Time:    e.Time,
+		Job:     e.Job,
+		Level:   string(e.Level),
+		Message: e.Message,
+	})
+}
+
+// apply records an engine event and updates the job states accordingly.
+func (l *liveRun) apply(e engine.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if job, ok := l.run.Jobs[e.Job]; ok {
+		t := e.Time
+		switch e.Type {
+		case engine.EventJobStarted:
+			job.Status = api.JobRunning
+			job.Attempt = e.Attempt
+			job.Error = ""
+			if job.StartedAt == nil {
+				job.StartedAt = &t
+			}
+		case engine.EventApprovalRequested:
+			job.Status = api.JobAwaitingApproval
+		case engine.EventApprovalResolved:
+			if e.Status == engine.StatusApproved {
+				job.Status = api.JobPending
+			}
+		case engine.EventJobFinished, engine.EventJobSkipped:
+			job.Status = e.Status
+			job.Error = e.Error
+			job.FinishedAt = &t
+		case engine.EventTestReport:
+			job.Tests = e.Tests
+		}
+	}
+
+	l.append(api.Event{
+		Type:       string(e.Type),
+		Time:       e.Time,
+		Job:        e.Job,
+		Step:       e.Step,
+		Attempt:    e.Attempt,
+		Status:     e.Status,
+		Error:      e.Error,
+		DurationMs: e.Duration.Milliseconds(),
+		Message:    e.Message,
+		ExitCode:   e.ExitCode,
+		SpanID:     e.SpanID,
+		Tests:      e.Tests,
+	})
+}
+
+// appendRemote records the step events and log lines reported by the
+// agent running job. Lifecycle events of jobs and runs are owned by the
+// server's scheduler and are dropped.
+func (l *liveRun) appendRemote(job string, events []api.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, e := range events {
+		switch e.Type {
+		case api.EventLog, string(engine.EventStepStarted), string(engine.EventStepFinished):
+			e.Job = job
+			l.append(e)
+		}
+	}
+}
+
+// finish marks the run as done with its final status.
+func (l *liveRun) finish(status api.RunStatus, errMsg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.run.Status = status
+	l.run.Error = errMsg
+	l.run.FinishedAt = &now
+	l.done = true
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+// snapshot returns a copy of the run state.
+func (l *liveRun) snapshot() *api.Run {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return cloneRun(l.run)
+}
+
+// eventsAfter returns the events after seq, a channel closed on the
+// next change, and whether the run is finished.
+func (l *liveRun) eventsAfter(after uint64) ([]api.Event, <-chan struct{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var events []api.Event
+	if after < uint64(len(l.events)) {
+		events = append(events, l.events[after:]...)
+	}
+	return events, l.notify, l.done
+}
+
+// unflushed returns the events and run state to persist.
+func (l *liveRun) unflushed() ([]api.Event, *api.Run) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	events := append([]api.Event(nil), l.events[l.flushed:]...)
+	l.flushed = len(l.events)
+	return events, cloneRun(l.run)
+}
+
+func cloneRun(run *api.Run) *api.Run {
+	c := *run
+	c.Jobs = make(map[string]*api.JobState, len(run.Jobs))
+	for name, job := range run.Jobs {
+		j := *job
+		c.Jobs[name] = &j
+	}
+	return &c
+}
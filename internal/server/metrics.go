@@ -0,0 +1,73 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/metrics"
+)
+
+// serverMetrics are the engine's metrics over every run of the server,
+// plus gauges of the dispatcher sampled at each scrape.
+type serverMetrics struct {
+	reg        *metrics.Registry
+	engine     *engine.Metrics
+	queuedJobs *metrics.Gauge
+	leasedJobs *metrics.Gauge
+	agents     *metrics.Gauge
+}
+
+func newServerMetrics() *serverMetrics {
+	reg := metrics.NewRegistry()
+	return &serverMetrics{
+		reg:        reg,
+		engine:     engine.NewMetrics(reg),
+		queuedJobs: reg.Gauge("flowcraft_server_queued_jobs", "Job attempts waiting for an agent."),
+		leasedJobs: reg.Gauge("flowcraft_server_leased_jobs", "Job attempts leased to an agent."),
+		agents:     reg.Gauge("flowcraft_server_agents", "Registered agents."),
+	}
+}
+
+// observeRemote records the steps finished by an agent running job.
+func (m *serverMetrics) observeRemote(job string, events []api.Event) {
+	for _, e := range events {
+		if e.Type == string(engine.EventStepFinished) {
+			m.engine.Observe(engine.Event{
+				Type:     engine.EventStepFinished,
+				Job:      job,
+				Step:     e.Step,
+				Status:   e.Status,
+				Duration: time.Duration(e.DurationMs) * time.Millisecond,
+			})
+		}
+	}
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.d.mu.Lock()
+	queued, leased, agents := len(s.d.queue), len(s.d.leases), len(s.d.agents)
+	s.d.mu.Unlock()
+
+	s.metrics.queuedJobs.Set(float64(queued))
+	s.metrics.leasedJobs.Set(float64(leased))
+	s.metrics.agents.Set(float64(agents))
+	s.metrics.reg.Handler().ServeHTTP(w, r)
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+)
+
+func TestMetrics_Served(t *testing.T) {
+	_, ts := newTestServer(t)
+	_, run := submit(t, ts, `
+[jobs.build]
+[[jobs.build.steps]]
+name = "Compile"
+cmd = "echo compiled"
+`, nil)
+	streamEvents(t, ts, run.ID)
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, want := range []string{
+		`flowcraft_runs_total{status="success"} 1`,
+		`flowcraft_job_duration_seconds_count{job="build",status="success"} 1`,
+		// Steps run on the agent and are reported over the API.
+		`flowcraft_step_duration_seconds_count{job="build",step="Compile",status="success"} 1`,
+		`flowcraft_server_agents 1`,
+		`flowcraft_server_queued_jobs 0`,
+	} {
+		if !strings.Contains(string(body), want+"\n") {
+			t.Errorf("Expected %q in the metrics, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetrics_RequiresReadScope(t *testing.T) {
+	srv, ts := startTestServer(t, Options{Auth: true})
+	if got := call(t, ts, "GET", "/metrics", ""); got != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without a token, got %d", got)
+	}
+	if got := call(t, ts, "GET", "/metrics", newToken(t, srv, api.ScopeRead)); got != http.StatusOK {
+		t.Errorf("Expected status 200 with a read token, got %d", got)
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+// hasTags reports whether an agent has all the tags a job runs on.
+func (a *agentState) hasTags(job config.Job) bool {
+	for _, tag := range job.RunsOn {
+		if !slices.Contains(a.info.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// canHold reports whether the job's resources fit in the given free
+// CPU and memory of the agent. Zero totals are unlimited.
+func (a *agentState) canHold(job config.Job, freeCPU float64, freeMem int64) bool {
+	if a.info.CPU > 0 && job.Resources.CPU > freeCPU {
+		return false
+	}
+	if a.info.Memory > 0 && job.Resources.MemBytes() > freeMem {
+		return false
+	}
+	return true
+}
+
+// fits reports whether the agent can take the job right now. It must
+// be called with d.mu held.
+func (a *agentState) fits(job config.Job) bool {
+	if len(a.leases) >= a.info.Capacity || !a.hasTags(job) {
+		return false
+	}
+	return a.canHold(job, a.info.CPU-a.info.UsedCPU, a.info.Memory-a.info.UsedMemory)
+}
+
+// reserve books, or with sign -1 releases, the resources of a job.
+func (a *agentState) reserve(job config.Job, sign int) {
+	a.info.UsedCPU += float64(sign) * job.Resources.CPU
+	a.info.UsedMemory += int64(sign) * job.Resources.MemBytes()
+}
+
+// checkPlacement rejects pipelines holding a job that none of the
+// registered agents could run, even idle. Everything is accepted while
+// no agent is registered, since agents may connect later.
+func (d *dispatcher) checkPlacement(cfg *config.Config) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.agents) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(cfg.Jobs))
+	for name := range cfg.Jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		job := cfg.Jobs[name]
+
+		var tagged []*agentState
+		for _, agent := range d.agents {
+			if agent.hasTags(job) {
+				tagged = append(tagged, agent)
+			}
+		}
+		if len(tagged) == 0 {
+			return fmt.Errorf("job '%s' runs on [%s] but no registered agent has all of these tags", name, strings.Join(job.RunsOn, ", "))
+		}
+
+		var maxCPU float64
+		var maxMem int64
+		placeable := false
+		for _, agent := range tagged {
+			if agent.canHold(job, agent.info.CPU, agent.info.Memory) {
+				placeable = true
+				break
+			}
+			maxCPU = max(maxCPU, agent.info.CPU)
+			maxMem = max(maxMem, agent.info.Memory)
+		}
+		if !placeable {
+			return fmt.Errorf("job '%s' requests %s but the largest matching agent offers %s",
+				name, formatRequest(job.Resources), formatResources(maxCPU, maxMem))
+		}
+	}
+	return nil
+}
+
+func formatResources(cpu float64, mem int64) string {
+	cpus := "unlimited CPUs"
+	if cpu > 0 {
+		cpus = strconv.FormatFloat(cpu, 'f', -1, 64) + " CPU(s)"
+	}
+	memory := "unlimited memory"
+	if mem > 0 {
+		memory = config.FormatQuantity(mem) + " of memory"
+	}
+	return cpus + " and " + memory
+}
+
+func formatRequest(r config.Resources) string {
+	var parts []string
+	if r.CPU > 0 {
+		parts = append(parts, strconv.FormatFloat(r.CPU, 'f', -1, 64)+" CPU(s)")
+	}
+	if mem := r.MemBytes(); mem > 0 {
+		parts = append(parts, config.FormatQuantity(mem)+" of memory")
+	}
+	return strings.Join(parts, " and ")
+}
@@ -0,0 +1,471 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package server implements flowcraft-server: a persistent run queue
+// and an HTTP/JSON API scheduling pipelines with the same engine as
+// 'flowcraft run'. The jobs themselves are leased to agents.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/Purpose-Dev/flowcraft/internal/tracing"
+	"github.com/Purpose-Dev/flowcraft/internal/webhook"
+)
+
+// flushInterval is how often the events of running pipelines are persisted.
+const flushInterval = 500 * time.Millisecond
+
+// Options configures a Server.
+type Options struct {
+	// DataDir holds the database and the submitted workspaces.
+	DataDir string
+	// Workers is the number of runs executed concurrently.
+	Workers int
+	// MaxUploadBytes limits the size of a submission. Defaults to 256 MiB.
+	MaxUploadBytes int64
+	// LeaseTTL is how long an agent may go without a heartbeat before
+	// its job is given to another agent. Defaults to 30 seconds.
+	LeaseTTL time.Duration
+	// WebhookConfig is the flow.toml run by webhook deliveries. It is
+	// read again for each delivery. Webhooks are disabled when empty.
+	WebhookConfig string
+	// WebhookSecret verifies the signature of GitHub deliveries and the
+	// token of GitLab ones.
+	WebhookSecret string
+	// Auth requires an API token on every request but the webhooks, and
+	// agents to register with a join token. An admin token is created
+	// and logged when there is no token yet.
+	Auth bool
+	// Tracer, if not nil, records each run as a trace, exported when the
+	// run ends.
+	Tracer *tracing.Tracer
+}
+
+// Server owns the run queue and executes the queued runs.
+type Server struct {
+	opts  Options
+	store *Store
+	wake  chan struct{}
+	d     *dispatcher
+	// auth is nil when authentication is disabled.
+	auth    *authenticator
+	metrics *serverMetrics
+
+	mu   sync.Mutex
+	live map[string]*liveRun
+}
+
+// New opens the store in opts.DataDir and requeues the runs that were
+// interrupted by a previous shutdown.
+func New(opts Options) (*Server, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.MaxUploadBytes <= 0 {
+		opts.MaxUploadBytes = 256 << 20
+	}
+	if opts.LeaseTTL <= 0 {
+		opts.LeaseTTL = 30 * time.Second
+	}
+	for _, dir := range []string{opts.DataDir, workspacesDir(opts.DataDir)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+	}
+
+	store, err := OpenStore(filepath.Join(opts.DataDir, "flowcraft.db"))
+	if err != nil {
+		return nil, err
+	}
+	recovered, err := store.Recover()
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to recover interrupted runs: %w", err)
+	}
+	for _, id := range recovered {
+		log.Printf("Run %s was interrupted and has been requeued.", id)
+	}
+
+	var auth *authenticator
+	if opts.Auth {
+		if auth, err = newAuthenticator(store); err == nil {
+			err = auth.bootstrap()
+		}
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+	}
+
+	return &Server{
+		opts:    opts,
+		store:   store,
+		wake:    make(chan struct{}, 1),
+		d:       newDispatcher(opts.LeaseTTL),
+		auth:    auth,
+		metrics: newServerMetrics(),
+		live:    make(map[string]*liveRun),
+	}, nil
+}
+
+// Close releases the store. Start's context must be cancelled first.
+func (s *Server) Close() error {
+	return s.store.Close()
+}
+
+// Start runs the workers until ctx is cancelled. Runs interrupted by
+// the cancellation stay 'running' in the store and are requeued by
+// the next New.
+func (s *Server) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.d.reaper(ctx)
+	}()
+	for i := 0; i < s.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.worker(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Server) worker(ctx context.Context) {
+	for {
+		id, ok, err := s.store.Dequeue()
+		if err != nil {
+			log.Printf("Failed to dequeue run: %v", err)
+		}
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.wake:
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		s.execute(ctx, id)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// Submit validates a pipeline and queues it. The workspace tarball may
+// be nil for pipelines that don't need any files.
+func (s *Server) Submit(configData []byte, workspaceTarball io.Reader) (*api.Run, error) {
+	return s.submit(configData, workspaceTarball, nil)
+}
+
+func (s *Server) submit(configData []byte, workspaceTarball io.Reader, trigger *api.Trigger) (*api.Run, error) {
+	cfg, err := config.Parse(configData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	graph, err := engine.BuildDag(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.d.checkPlacement(cfg); err != nil {
+		return nil, err
+	}
+
+	run := &api.Run{
+		ID:        newRunID(),
+		Status:    api.RunQueued,
+		CreatedAt: time.Now(),
+		Jobs:      make(map[string]*api.JobState, len(graph.Nodes)),
+		Trigger:   trigger,
+	}
+	for name, node := range graph.Nodes {
+		run.Jobs[name] = &api.JobState{Status: api.JobPending, DependsOn: node.Job.DependsOn}
+	}
+
+	if workspaceTarball != nil {
+		f, err := os.Create(workspacePath(s.opts.DataDir, run.ID))
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(f, workspaceTarball)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return nil, fmt.Errorf("failed to store workspace: %w", err)
+		}
+	}
+
+	if err := s.store.CreateRun(run, configData); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return run, nil
+}
+
+// Run returns the current state of a run.
+func (s *Server) Run(id string) (*api.Run, error) {
+	if live := s.liveRun(id); live != nil {
+		return live.snapshot(), nil
+	}
+	return s.store.GetRun(id)
+}
+
+// Runs returns all runs, most recent first.
+func (s *Server) Runs() ([]*api.Run, error) {
+	runs, err := s.store.ListRuns()
+	if err != nil {
+		return nil, err
+	}
+	for i, run := range runs {
+		if live := s.liveRun(run.ID); live != nil {
+			runs[i] = live.snapshot()
+		}
+	}
+	return runs, nil
+}
+
+// Cancel stops a running run or removes a queued one from the queue.
+func (s *Server) Cancel(id string) (*api.Run, error) {
+	if live := s.liveRun(id); live != nil {
+		live.mu.Lock()
+		live.cancelled = true
+		live.mu.Unlock()
+		live.cancel()
+		return live.snapshot(), nil
+	}
+
+	run, err := s.store.GetRun(id)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != api.RunQueued {
+		return run, nil
+	}
+	if err := s.store.RemoveFromQueue(id); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	run.Status = api.RunCancelled
+	run.FinishedAt = &now
+	for _, job := range run.Jobs {
+		job.Status = api.JobSkipped
+	}
+	if err := s.store.SaveRun(run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// Events returns the events of a run after the given sequence number,
+// a channel closed when more may be available (nil once the run is
+// finished), and whether the run is finished.
+func (s *Server) Events(id string, after uint64) ([]api.Event, <-chan struct{}, bool, error) {
+	if live := s.liveRun(id); live != nil {
+		events, wait, done := live.eventsAfter(after)
+		return events, wait, done, nil
+	}
+
+	run, err := s.store.GetRun(id)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	events, err := s.store.Events(id, after)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if run.Status.Done() {
+		return events, nil, true, nil
+	}
+
+	// Queued: poll until a worker picks the run up.
+	wait := make(chan struct{})
+	time.AfterFunc(500*time.Millisecond, func() { close(wait) })
+	return events, wait, false, nil
+}
+
+func (s *Server) liveRun(id string) *liveRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.live[id]
+}
+
+func (s *Server) execute(ctx context.Context, id string) {
+	run, err := s.store.GetRun(id)
+	if err != nil {
+		log.Printf("Run %s: %v", id, err)
+		return
+	}
+	if run.Status != api.RunQueued {
+		return
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	now := time.Now()
+	run.Status = api.RunRunning
+	run.StartedAt = &now
+	live := newLiveRun(run, cancel)
+
+	if err := s.store.ClearEvents(id); err != nil {
+		log.Printf("Run %s: failed to clear events: %v", id, err)
+	}
+	if err := s.store.SaveRun(run); err != nil {
+		log.Printf("Run %s: failed to save run: %v", id, err)
+		return
+	}
+	s.mu.Lock()
+	s.live[id] = live
+	s.mu.Unlock()
+
+	flushDone := make(chan struct{})
+	stopFlush := make(chan struct{})
+	go func() {
+		defer close(flushDone)
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopFlush:
+				return
+			case <-ticker.C:
+				s.flush(id, live)
+			}
+		}
+	}()
+
+	log.Printf("Run %s started.", id)
+	runErr := s.runPipeline(runCtx, id, live)
+	s.flushTraces(id)
+
+	close(stopFlush)
+	<-flushDone
+
+	status, errMsg := api.RunSucceeded, ""
+	switch {
+	case runErr == nil:
+	case live.cancelled:
+		status, errMsg = api.RunCancelled, "cancelled by user"
+	case ctx.Err() != nil:
+		// Server shutdown: leave the run 'running' so that it is requeued.
+		s.flush(id, live)
+		s.dropLive(id)
+		log.Printf("Run %s interrupted by shutdown.", id)
+		return
+	default:
+		status, errMsg = api.RunFailed, runErr.Error()
+	}
+
+	live.finish(status, errMsg)
+	s.flush(id, live)
+	s.dropLive(id)
+	_ = os.Remove(workspacePath(s.opts.DataDir, id))
+	log.Printf("Run %s finished: %s.", id, status)
+}
+
+// runPipeline schedules the pipeline exactly like 'flowcraft run'
+// would, except that each job attempt is leased to an agent and that
+// approval gates are answered through the API.
+func (s *Server) runPipeline(ctx context.Context, id string, live *liveRun) error {
+	configData, err := s.store.Config(id)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Parse(configData)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	graph, err := engine.BuildDag(cfg)
+	if err != nil {
+		return err
+	}
+	var processEnv map[string]string
+	if trigger := live.snapshot().Trigger; trigger != nil {
+		// The CI_* variables come from the push: a commit message must
+		// not be expanded into the commands.
+		processEnv = webhook.Env(*trigger)
+	}
+
+	_, err = os.Stat(workspacePath(s.opts.DataDir, id))
+	hasWorkspace := err == nil
+
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+	logger.AddSink(live.appendLog)
+
+	return engine.Run(ctx, cfg, graph, logger,
+		engine.WithListener(live.apply),
+		engine.WithExecutor(s.dispatch(id, live, hasWorkspace)),
+		engine.WithApprover(live.approve),
+		engine.WithMetrics(s.metrics.engine),
+		engine.WithProcessEnv(processEnv),
+		engine.WithDeferredSecrets(),
+		engine.WithTracer(s.opts.Tracer, runAttributes(live.snapshot())...),
+	)
+}
+
+func (s *Server) flush(id string, live *liveRun) {
+	events, run := live.unflushed()
+	if err := s.store.AppendEvents(id, events); err != nil {
+		log.Printf("Run %s: failed to persist events: %v", id, err)
+	}
+	if err := s.store.SaveRun(run); err != nil {
+		log.Printf("Run %s: failed to persist state: %v", id, err)
+	}
+}
+
+func (s *Server) dropLive(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.live, id)
+}
+
+func newRunID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102-150405"), hex.EncodeToString(b))
+}
+
+func workspacesDir(dataDir string) string {
+	return filepath.Join(dataDir, "workspaces")
+}
+
+func workspacePath(dataDir, id string) string {
+	return filepath.Join(workspacesDir(dataDir), id+".tar.gz")
+}
@@ -0,0 +1,311 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/agent"
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/client"
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
+)
+
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	return startTestServer(t, Options{Workers: 2}, agent.Options{Name: "agent-1", Capacity: 2})
+}
+
+// startTestServer starts a server and agents talking to it over HTTP.
+func startTestServer(t *testing.T, opts Options, agents ...agent.Options) (*Server, *httptest.Server) {
+	t.Helper()
+	opts.DataDir = t.TempDir()
+	if opts.LeaseTTL == 0 {
+		opts.LeaseTTL = 3 * time.Second
+	}
+	srv, err := New(opts)
+	if err != nil {
+		t.Fatalf("New() returned an unexpected error: %v", err)
+	}
+	ts := httptest.NewServer(srv.Handler())
+
+	// With authentication, the agents join with a shared join token.
+	var joinToken string
+	if opts.Auth && len(agents) > 0 {
+		token, err := srv.CreateToken(api.TokenRequest{Name: "agents", Scopes: []string{api.ScopeAgentRegister}})
+		if err != nil {
+			t.Fatalf("CreateToken() returned an unexpected error: %v", err)
+		}
+		joinToken = token.Secret
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		srv.Start(ctx)
+	}()
+	for _, agentOpts := range agents {
+		agentOpts.WorkDir = t.TempDir()
+		agentOpts.PollWait = time.Second
+		c := client.New(ts.URL)
+		c.SetToken(joinToken)
+		a := agent.New(c, agentOpts)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := a.Run(ctx); err != nil {
+				t.Errorf("Agent '%s' failed: %v", agentOpts.Name, err)
+			}
+		}()
+	}
+
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+		ts.Close()
+		srv.Close()
+	})
+	return srv, ts
+}
+
+// submit posts a config and, if files is not nil, a workspace holding them.
+func submit(t *testing.T, ts *httptest.Server, configData string, files map[string]string) (*http.Response, *api.Run) {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("config", "flow.toml")
+	_, _ = part.Write([]byte(configData))
+
+	if files != nil {
+		dir := t.TempDir()
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatalf("Failed to write workspace file: %v", err)
+			}
+		}
+		part, _ = mw.CreateFormFile("workspace", "workspace.tar.gz")
+		if err := workspace.Pack(dir, part); err != nil {
+			t.Fatalf("Pack() returned an unexpected error: %v", err)
+		}
+	}
+	mw.Close()
+
+	resp, err := http.Post(ts.URL+"/api/v1/runs", mw.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("Submit request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var run api.Run
+	if resp.StatusCode == http.StatusCreated {
+		if err := json.NewDecoder(resp.Body).Decode(&run); err != nil {
+			t.Fatalf("Failed to decode run: %v", err)
+		}
+	}
+	return resp, &run
+}
+
+// streamEvents reads the SSE stream of a run until the end event.
+func streamEvents(t *testing.T, ts *httptest.Server, id string) []api.Event {
+	t.Helper()
+	resp, err := http.Get(ts.URL + "/api/v1/runs/" + id + "/events")
+	if err != nil {
+		t.Fatalf("Events request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var events []api.Event
+	var eventType string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if eventType == "end" {
+				return events
+			}
+			var e api.Event
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e); err != nil {
+				t.Fatalf("Failed to decode event: %v", err)
+			}
+			events = append(events, e)
+		}
+	}
+	t.Fatal("Event stream ended without an end event")
+	return nil
+}
+
+func getRun(t *testing.T, ts *httptest.Server, id string) *api.Run {
+	t.Helper()
+	resp, err := http.Get(ts.URL + "/api/v1/runs/" + id)
+	if err != nil {
+		t.Fatalf("Get request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var run api.Run
+	if err := json.NewDecoder(resp.Body).Decode(&run); err != nil {
+		t.Fatalf("Failed to decode run: %v", err)
+	}
+	return &run
+}
+
+func TestSubmitAndRun(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	cfg := `
+[jobs.read]
+[[jobs.read.steps]]
+name = "Read workspace"
+cmd = "cat input.txt"
+
+[jobs.after]
+depends_on = ["read"]
+[[jobs.after.steps]]
+name = "After"
+cmd = "echo done"
+`
+	resp, run := submit(t, ts, cfg, map[string]string{"input.txt": "hello from the workspace"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+	}
+
+	events := streamEvents(t, ts, run.ID)
+
+	var sawOutput, sawFinished bool
+	for i, e := range events {
+		if e.Seq != uint64(i+1) {
+			t.Fatalf("Expected contiguous sequence numbers, got %d at index %d", e.Seq, i)
+		}
+		if e.Type == api.EventLog && e.Job == "read" && strings.Contains(e.Message, "hello from the workspace") {
+			sawOutput = true
+		}
+		if e.Type == "run_finished" && e.Status == "success" {
+			sawFinished = true
+		}
+	}
+	if !sawOutput {
+		t.Error("Expected the step output to be streamed as a log event of job 'read'")
+	}
+	if !sawFinished {
+		t.Error("Expected a successful run_finished event")
+	}
+
+	final := getRun(t, ts, run.ID)
+	if final.Status != api.RunSucceeded {
+		t.Errorf("Expected run to succeed, got %s (%s)", final.Status, final.Error)
+	}
+	for name, job := range final.Jobs {
+		if job.Status != api.JobSuccess {
+			t.Errorf("Expected job '%s' to succeed, got %s", name, job.Status)
+		}
+	}
+}
+
+func TestSubmit_InvalidConfig(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	resp, _ := submit(t, ts, "[jobs.a]\ndepends_on = [\"missing\"]\n", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid DAG, got %d", resp.StatusCode)
+	}
+}
+
+func TestCancelRun(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	cfg := "[jobs.slow]\n[[jobs.slow.steps]]\nname = \"Sleep\"\ncmd = \"sleep 30\"\n"
+	_, run := submit(t, ts, cfg, nil)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for getRun(t, ts, run.ID).Jobs["slow"].Status != api.JobRunning {
+		if time.Now().After(deadline) {
+			t.Fatal("Job never started")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	resp, err := http.Post(ts.URL+"/api/v1/runs/"+run.ID+"/cancel", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Cancel request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	streamEvents(t, ts, run.ID)
+	if status := getRun(t, ts, run.ID).Status; status != api.RunCancelled {
+		t.Errorf("Expected run to be cancelled, got %s", status)
+	}
+}
+
+func TestRecoverInterruptedRuns(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenStore(filepath.Join(dir, "flowcraft.db"))
+	if err != nil {
+		t.Fatalf("OpenStore() returned an unexpected error: %v", err)
+	}
+
+	older := &api.Run{ID: "older", Status: api.RunQueued, CreatedAt: time.Now().Add(-time.Minute), Jobs: map[string]*api.JobState{}}
+	interrupted := &api.Run{ID: "interrupted", Status: api.RunQueued, CreatedAt: time.Now(), Jobs: map[string]*api.JobState{
+		"a": {Status: api.JobRunning, Attempt: 1},
+	}}
+	for _, run := range []*api.Run{older, interrupted} {
+		if err := store.CreateRun(run, []byte("")); err != nil {
+			t.Fatalf("CreateRun() returned an unexpected error: %v", err)
+		}
+	}
+
+	// Simulate a server stopping while 'interrupted' was running.
+	if id, _, _ := store.Dequeue(); id != "older" {
+		t.Fatalf("Expected FIFO order, dequeued '%s'", id)
+	}
+	_, _, _ = store.Dequeue()
+	interrupted.Status = api.RunRunning
+	_ = store.SaveRun(interrupted)
+	_ = store.CreateRun(&api.Run{ID: "newer", Status: api.RunQueued, CreatedAt: time.Now(), Jobs: map[string]*api.JobState{}}, nil)
+
+	recovered, err := store.Recover()
+	if err != nil {
+		t.Fatalf("Recover() returned an unexpected error: %v", err)
+	}
+	if len(recovered) != 1 || recovered[0] != "interrupted" {
+		t.Errorf("Expected 'interrupted' to be recovered, got %v", recovered)
+	}
+
+	if id, _, _ := store.Dequeue(); id != "interrupted" {
+		t.Errorf("Expected the interrupted run to be requeued first, got '%s'", id)
+	}
+	run, _ := store.GetRun("interrupted")
+	if run.Status != api.RunQueued || run.Jobs["a"].Status != api.JobPending {
+		t.Errorf("Expected the interrupted run to be reset, got %+v", run)
+	}
+	store.Close()
+}
@@ -0,0 +1,358 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketRuns    = []byte("runs")
+	bucketConfigs = []byte("configs")
+	bucketQueue   = []byte("queue")
+	bucketEvents  = []byte("events")
+	bucketTokens  = []byte("tokens")
+)
+
+// ErrNotFound is returned when a run does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Store persists runs, their configs, their events, the run queue and
+// the API tokens in an embedded bbolt database.
+type Store struct {
+	db *bolt.DB
+}
+
+// OpenStore opens or creates the database at path.
+func OpenStore(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketRuns, bucketConfigs, bucketQueue, bucketEvents, bucketTokens} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize store: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// CreateRun saves a new run with its config and appends it to the queue.
+func (s *Store) CreateRun(run *api.Run, config []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := putJSON(tx.Bucket(bucketRuns), run.ID, run); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketConfigs).Put([]byte(run.ID), config); err != nil {
+			return err
+		}
+		return enqueue(tx, run.ID)
+	})
+}
+
+// GetRun returns a run by ID, or ErrNotFound.
+func (s *Store) GetRun(id string) (*api.Run, error) {
+	var run api.Run
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketRuns).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, &run)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRuns returns all runs, most recent first.
+func (s *Store) ListRuns() ([]*api.Run, error) {
+	var runs []*api.Run
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRuns).ForEach(func(_, v []byte) error {
+			var run api.Run
+			if err := json.Unmarshal(v, &run); err != nil {
+				return err
+			}
+			runs = append(runs, &run)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+	return runs, nil
+}
+
+// SaveRun overwrites the stored state of a run.
+func (s *Store) SaveRun(run *api.Run) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(bucketRuns), run.ID, run)
+	})
+}
+
+// Config returns the flow.toml submitted with a run.
+func (s *Store) Config(id string) ([]byte, error) {
+	var config []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketConfigs).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		config = append([]byte(nil), data...)
+		return nil
+	})
+	return config, err
+}
+
+// Dequeue pops the oldest queued run ID. ok is false when the queue is empty.
+func (s *Store) Dequeue() (id string, ok bool, err error) {
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketQueue).Cursor()
+		k, v := c.First()
+		if k == nil {
+			return nil
+		}
+		id, ok = string(v), true
+		return c.Delete()
+	})
+	return id, ok, err
+}
+
+// RemoveFromQueue drops a run from the queue, e.g. when it is cancelled
+// before it starts.
+func (s *Store) RemoveFromQueue(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketQueue).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if string(v) == id {
+				return c.Delete()
+			}
+		}
+		return nil
+	})
+}
+
+// Recover puts runs that were running when the server stopped back at
+// the front of the queue, ahead of runs submitted later.
+func (s *Store) Recover() ([]string, error) {
+	var recovered []string
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		runs := tx.Bucket(bucketRuns)
+		var interrupted []*api.Run
+		err := runs.ForEach(func(_, v []byte) error {
+			var run api.Run
+			if err := json.Unmarshal(v, &run); err != nil {
+				return err
+			}
+			if run.Status == api.RunRunning {
+				interrupted = append(interrupted, &run)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(interrupted, func(i, j int) bool {
+			return interrupted[i].CreatedAt.Before(interrupted[j].CreatedAt)
+		})
+
+		// Rebuild the queue with the interrupted runs first.
+		queue := tx.Bucket(bucketQueue)
+		var pending []string
+		if err := queue.ForEach(func(_, v []byte) error {
+			pending = append(pending, string(v))
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := tx.DeleteBucket(bucketQueue); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucket(bucketQueue); err != nil {
+			return err
+		}
+
+		for _, run := range interrupted {
+			run.Status = api.RunQueued
+			run.StartedAt = nil
+			for _, job := range run.Jobs {
+				*job = api.JobState{Status: api.JobPending, DependsOn: job.DependsOn}
+			}
+			if err := putJSON(runs, run.ID, run); err != nil {
+				return err
+			}
+			if err := enqueue(tx, run.ID); err != nil {
+				return err
+			}
+			recovered = append(recovered, run.ID)
+		}
+		for _, id := range pending {
+			if err := enqueue(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return recovered, err
+}
+
+// AppendEvents persists events whose sequence numbers were already assigned.
+func (s *Store) AppendEvents(runID string, events []api.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(bucketEvents).CreateBucketIfNotExists([]byte(runID))
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(itob(e.Seq), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Events returns the events of a run with a sequence number above after.
+func (s *Store) Events(runID string, after uint64) ([]api.Event, error) {
+	var events []api.Event
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketEvents).Bucket([]byte(runID))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Seek(itob(after + 1)); k != nil; k, v = c.Next() {
+			var e api.Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			events = append(events, e)
+		}
+		return nil
+	})
+	return events, err
+}
+
+// ClearEvents drops the events of a run, before it is executed again.
+func (s *Store) ClearEvents(runID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(bucketEvents).DeleteBucket([]byte(runID))
+		if errors.Is(err, bolt.ErrBucketNotFound) {
+			return nil
+		}
+		return err
+	})
+}
+
+// storedToken is an API token as persisted: only a hash of its secret
+// is kept.
+type storedToken struct {
+	api.Token
+	Hash string `json:"hash"`
+}
+
+// SaveToken persists a token under its ID.
+func (s *Store) SaveToken(token storedToken) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(bucketTokens), token.ID, token)
+	})
+}
+
+// DeleteToken removes a token, or returns ErrNotFound.
+func (s *Store) DeleteToken(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketTokens)
+		if b.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+// Tokens returns all tokens.
+func (s *Store) Tokens() ([]storedToken, error) {
+	var tokens []storedToken
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketTokens).ForEach(func(_, v []byte) error {
+			var token storedToken
+			if err := json.Unmarshal(v, &token); err != nil {
+				return err
+			}
+			tokens = append(tokens, token)
+			return nil
+		})
+	})
+	return tokens, err
+}
+
+func enqueue(tx *bolt.Tx, id string) error {
+	queue := tx.Bucket(bucketQueue)
+	seq, err := queue.NextSequence()
+	if err != nil {
+		return err
+	}
+	return queue.Put(itob(seq), []byte(id))
+}
+
+func putJSON(b *bolt.Bucket, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), data)
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
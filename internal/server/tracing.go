@@ -0,0 +1,94 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/tracing"
+)
+
+// runAttributes describes a run on the span of its trace.
+func runAttributes(run *api.Run) []tracing.Attribute {
+	attrs := []tracing.Attribute{tracing.String("flowcraft.run.id", run.ID)}
+	if t := run.Trigger; t != nil {
+		attrs = append(attrs,
+			tracing.String("flowcraft.trigger.provider", t.Provider),
+			tracing.String("flowcraft.trigger.event", t.Event),
+			tracing.String("vcs.repository.name", t.Repository),
+			tracing.String("vcs.ref.head.name", t.Branch),
+			tracing.String("vcs.ref.head.revision", t.SHA))
+	}
+	return attrs
+}
+
+// traceRemote records the spans of the steps an agent ran for lease.
+// The agent gave them their IDs, passed to the steps in $TRACEPARENT,
+// and reports them in its step_finished events.
+func (s *Server) traceRemote(lease api.Lease, events []api.Event) {
+	if s.opts.Tracer == nil {
+		return
+	}
+	parent, err := tracing.ParseTraceParent(lease.TraceParent)
+	if err != nil {
+		return
+	}
+	for _, e := range events {
+		if e.Type != string(engine.EventStepFinished) || e.SpanID == "" {
+			continue
+		}
+		id, err := tracing.ParseSpanID(e.SpanID)
+		if err != nil {
+			continue
+		}
+		span := tracing.SpanData{
+			Name:         "step " + e.Step,
+			TraceID:      parent.TraceID,
+			SpanID:       id,
+			ParentSpanID: parent.SpanID,
+			Start:        e.Time.Add(-time.Duration(e.DurationMs) * time.Millisecond),
+			End:          e.Time,
+			Attributes: []tracing.Attribute{
+				tracing.String("flowcraft.job", lease.Job),
+				tracing.String("flowcraft.step", e.Step),
+				tracing.String("flowcraft.agent.id", lease.AgentID),
+			},
+			Failed: e.Status != engine.StatusSuccess,
+			Error:  e.Error,
+		}
+		if e.Status == engine.StatusSuccess || e.ExitCode != 0 {
+			span.Attributes = append(span.Attributes, tracing.Int("process.exit.code", e.ExitCode))
+		}
+		s.opts.Tracer.Record(span)
+	}
+}
+
+// flushTraces exports the spans of a run that just ended.
+func (s *Server) flushTraces(id string) {
+	if s.opts.Tracer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.opts.Tracer.Flush(ctx); err != nil {
+		log.Printf("Run %s: %v", id, err)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/agent"
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/tracing"
+)
+
+func TestTracing_RemoteSteps(t *testing.T) {
+	receiver := &tracing.Receiver{}
+	collector := httptest.NewServer(receiver)
+	defer collector.Close()
+	exporter, err := tracing.NewOTLPExporter(collector.URL, "flowcraft-server")
+	if err != nil {
+		t.Fatalf("NewOTLPExporter() returned an unexpected error: %v", err)
+	}
+
+	_, ts := startTestServer(t, Options{Workers: 1, Tracer: tracing.NewTracer(exporter)},
+		agent.Options{Name: "agent-1", Capacity: 1})
+	_, run := submit(t, ts, `
+[jobs.build]
+[[jobs.build.steps]]
+name = "Compile"
+cmd = "echo traceparent=$TRACEPARENT"
+`, nil)
+	events := streamEvents(t, ts, run.ID)
+
+	// The spans are exported once the run has ended.
+	spans := make(map[string]tracing.SpanData)
+	for deadline := time.Now().Add(5 * time.Second); len(spans) < 4 && time.Now().Before(deadline); {
+		time.Sleep(20 * time.Millisecond)
+		for _, span := range receiver.Spans() {
+			spans[span.Name] = span
+		}
+	}
+	root, attempt, step := spans["run"], spans["attempt 1"], spans["step Compile"]
+	if root.Attribute("flowcraft.run.id") != run.ID {
+		t.Fatalf("Expected a run span for %s, got %+v", run.ID, spans)
+	}
+	if attempt.Attribute("flowcraft.agent") != "agent-1" {
+		t.Errorf("Expected the attempt span to name its agent, got %+v", attempt)
+	}
+	if step.ParentSpanID != attempt.SpanID || step.TraceID != root.TraceID {
+		t.Errorf("Expected the agent's step under the attempt, got %+v", step)
+	}
+	if step.Attribute("process.exit.code") != int64(0) || step.End.Before(step.Start) {
+		t.Errorf("Unexpected step span %+v", step)
+	}
+
+	want := "traceparent=" + tracing.SpanContext{TraceID: step.TraceID, SpanID: step.SpanID}.TraceParent()
+	var found bool
+	for _, e := range events {
+		if e.Type == api.EventLog && strings.Contains(e.Message, want) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the step to run with %s", want)
+	}
+}
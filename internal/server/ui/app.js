@@ -0,0 +1,524 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// The flowcraft dashboard. It only reads the public JSON API and the
+// Server-Sent Events of runs, like 'flowcraft run --remote' does.
+"use strict";
+
+const API = "/api/v1";
+const POLL_MS = 3000;
+
+// Event types sent on the stream of a run (see engine/events.go).
+const EVENT_TYPES = [
+	"run_started", "run_finished", "job_waiting", "approval_requested", "approval_resolved",
+	"job_started", "job_finished", "job_skipped", "step_started", "step_finished", "log",
+];
+
+// The view being shown. stop() releases its timers and streams.
+let current = {stop() {}};
+
+function el(tag, attrs, ...children) {
+	const node = document.createElement(tag);
+	for (const [name, value] of Object.entries(attrs || {})) {
+		if (name.startsWith("on")) {
+			node.addEventListener(name.slice(2), value);
+		} else if (value !== undefined && value !== null && value !== false) {
+			node.setAttribute(name, value);
+		}
+	}
+	for (const child of children.flat()) {
+		if (child !== undefined && child !== null) {
+			node.append(child instanceof Node ? child : String(child));
+		}
+	}
+	return node;
+}
+
+function svg(tag, attrs, ...children) {
+	const node = document.createElementNS("http://www.w3.org/2000/svg", tag);
+	for (const [name, value] of Object.entries(attrs || {})) {
+		if (name.startsWith("on")) {
+			node.addEventListener(name.slice(2), value);
+		} else {
+			node.setAttribute(name, value);
+		}
+	}
+	node.append(...children);
+	return node;
+}
+
+function status(value) {
+	return el("span", {class: "status " + value}, value.replace("_", " "));
+}
+
+function showError(err) {
+	const box = document.getElementById("error");
+	box.textContent = err.message || String(err);
+	box.hidden = false;
+	clearTimeout(showError.timer);
+	showError.timer = setTimeout(() => { box.hidden = true; }, 6000);
+}
+
+// request calls the API with the session cookie. A 401 means the server
+// requires a token: the login form replaces the current view.
+async function request(method, path, payload, headers) {
+	const init = {method, headers: {...headers}};
+	if (payload !== undefined) {
+		init.headers["Content-Type"] = "application/json";
+		init.body = JSON.stringify(payload);
+	}
+	const resp = await fetch(API + path, init);
+	const body = await resp.json().catch(() => ({}));
+	if (resp.status === 401 && path !== "/session") {
+		showLogin();
+	}
+	if (!resp.ok) {
+		throw new Error(body.error || `${method} ${path}: ${resp.status}`);
+	}
+	return body;
+}
+
+// action runs a POST from a button and reports its failure.
+function action(path, confirmation) {
+	return async () => {
+		if (confirmation && !confirm(confirmation)) {
+			return;
+		}
+		try {
+			await request("POST", path);
+		} catch (err) {
+			showError(err);
+		}
+	};
+}
+
+function formatTime(value) {
+	return value ? new Date(value).toLocaleString() : "";
+}
+
+function formatDuration(start, end) {
+	if (!start) {
+		return "";
+	}
+	const seconds = Math.round(((end ? new Date(end) : new Date()) - new Date(start)) / 1000);
+	if (seconds < 60) {
+		return seconds + "s";
+	}
+	const minutes = Math.floor(seconds / 60);
+	return minutes < 60 ? `${minutes}m${seconds % 60}s` : `${Math.floor(minutes / 60)}h${minutes % 60}m`;
+}
+
+function describeTrigger(trigger) {
+	if (!trigger) {
+		return "manual";
+	}
+	const what = trigger.pull_request ? `PR #${trigger.pull_request} → ${trigger.base_branch}` : trigger.branch;
+	return `${trigger.provider} ${trigger.repository} ${what} @ ${(trigger.sha || "").slice(0, 8)}`;
+}
+
+function describeTests(tests) {
+	return `${tests.passed} passed, ${tests.failed} failed, ${tests.skipped} skipped`;
+}
+
+// poll calls fn now and every POLL_MS until the view changes.
+function poll(fn) {
+	let timer;
+	let stopped = false;
+	const tick = async () => {
+		try {
+			await fn();
+		} catch (err) {
+			showError(err);
+		}
+		if (!stopped) {
+			timer = setTimeout(tick, POLL_MS);
+		}
+	};
+	tick();
+	return () => {
+		stopped = true;
+		clearTimeout(timer);
+	};
+}
+
+function runsView(root) {
+	const body = el("tbody");
+	root.append(
+		el("h1", {}, "Runs"),
+		el("table", {},
+			el("thead", {}, el("tr", {},
+				["Run", "Status", "Trigger", "Jobs", "Created", "Duration"].map(h => el("th", {}, h)))),
+			body));
+
+	const stop = poll(async () => {
+		const runs = await request("GET", "/runs");
+		body.replaceChildren(...runs.map(run => {
+			const counts = {};
+			for (const job of Object.values(run.jobs)) {
+				counts[job.status] = (counts[job.status] || 0) + 1;
+			}
+			return el("tr", {},
+				el("td", {}, el("a", {href: "#/runs/" + encodeURIComponent(run.id), class: "mono"}, run.id)),
+				el("td", {}, status(run.status)),
+				el("td", {}, describeTrigger(run.trigger)),
+				el("td", {}, Object.entries(counts).sort().map(([s, n]) => `${n} ${s.replace("_", " ")}`).join(", ")),
+				el("td", {}, formatTime(run.created_at)),
+				el("td", {}, formatDuration(run.started_at, run.finished_at)));
+		}));
+		if (runs.length === 0) {
+			body.append(el("tr", {}, el("td", {colspan: 6, class: "muted"}, "No runs yet.")));
+		}
+	});
+	return {stop};
+}
+
+// layout places each job in the column after its deepest dependency.
+function layout(jobs) {
+	const depth = {};
+	const visit = name => {
+		if (depth[name] === undefined) {
+			depth[name] = 0;
+			for (const dep of jobs[name].depends_on || []) {
+				if (jobs[dep]) {
+					depth[name] = Math.max(depth[name], visit(dep) + 1);
+				}
+			}
+		}
+		return depth[name];
+	};
+	const columns = [];
+	for (const name of Object.keys(jobs).sort()) {
+		const d = visit(name);
+		(columns[d] = columns[d] || []).push(name);
+	}
+	return columns;
+}
+
+const NODE_W = 170, NODE_H = 46, GAP_X = 60, GAP_Y = 16, PAD = 16;
+
+function renderDag(run, selected, onSelect) {
+	const columns = layout(run.jobs);
+	const pos = {};
+	columns.forEach((names, x) => names.forEach((name, y) => {
+		pos[name] = {x: PAD + x * (NODE_W + GAP_X), y: PAD + y * (NODE_H + GAP_Y)};
+	}));
+	const rows = Math.max(1, ...columns.map(c => c.length));
+	const width = PAD * 2 + columns.length * NODE_W + Math.max(0, columns.length - 1) * GAP_X;
+	const height = PAD * 2 + rows * NODE_H + (rows - 1) * GAP_Y;
+
+	const edges = [];
+	const nodes = [];
+	for (const [name, job] of Object.entries(run.jobs)) {
+		for (const dep of job.depends_on || []) {
+			if (!pos[dep]) {
+				continue;
+			}
+			const x1 = pos[dep].x + NODE_W, y1 = pos[dep].y + NODE_H / 2;
+			const x2 = pos[name].x, y2 = pos[name].y + NODE_H / 2;
+			const mid = (x1 + x2) / 2;
+			edges.push(svg("path", {class: "edge", d: `M${x1},${y1} C${mid},${y1} ${mid},${y2} ${x2},${y2}`}));
+		}
+		const label = name.length > 22 ? name.slice(0, 21) + "…" : name;
+		let state = job.status.replace("_", " ");
+		if (job.attempt > 1) {
+			state += ` (attempt ${job.attempt})`;
+		}
+		if (job.started_at) {
+			state += " · " + formatDuration(job.started_at, job.finished_at);
+		}
+		nodes.push(svg("g", {
+			class: `node ${job.status}${name === selected ? " selected" : ""}`,
+			transform: `translate(${pos[name].x},${pos[name].y})`,
+			onclick: () => onSelect(name),
+		},
+		svg("title", {}, name),
+		svg("rect", {width: NODE_W, height: NODE_H}),
+		svg("text", {x: 10, y: 19}, label),
+		svg("text", {x: 10, y: 36, class: "state"}, state)));
+	}
+	return svg("svg", {width, height, viewBox: `0 0 ${width} ${height}`}, ...edges, ...nodes);
+}
+
+function runView(root, id) {
+	const path = "/runs/" + encodeURIComponent(id);
+	const header = el("div");
+	const dag = el("div", {class: "dag"});
+	const jobPanel = el("div");
+	const logs = el("pre", {class: "logs"});
+	root.append(header, el("h2", {}, "Jobs"), dag, jobPanel, logs);
+
+	let run = null;
+	let selected = null;
+	const entries = [];
+
+	const render = () => {
+		if (!run) {
+			return;
+		}
+		const running = !["succeeded", "failed", "cancelled"].includes(run.status);
+		header.replaceChildren(
+			el("h1", {class: "mono"}, run.id),
+			el("div", {class: "toolbar"},
+				status(run.status),
+				el("span", {class: "muted"}, describeTrigger(run.trigger)),
+				el("span", {class: "muted"}, formatDuration(run.started_at, run.finished_at)),
+				running ? el("button", {class: "danger", onclick: action(path + "/cancel", "Cancel this run?")}, "Cancel run") : null),
+			run.error ? el("p", {class: "mono"}, run.error) : null);
+		dag.replaceChildren(renderDag(run, selected, name => {
+			selected = selected === name ? null : name;
+			render();
+			renderLogs();
+		}));
+
+		const job = selected && run.jobs[selected];
+		if (!job) {
+			jobPanel.replaceChildren(el("h2", {}, "Logs"), el("p", {class: "muted"}, "Select a job to filter its logs."));
+			return;
+		}
+		const jobPath = `${path}/jobs/${encodeURIComponent(selected)}`;
+		const buttons = [];
+		if (job.status === "awaiting_approval") {
+			buttons.push(
+				el("button", {class: "primary", onclick: action(jobPath + "/approve")}, "Approve"),
+				el("button", {class: "danger", onclick: action(jobPath + "/reject", `Reject job '${selected}'? The run will fail.`)}, "Reject"));
+		} else if (running && ["pending", "running"].includes(job.status)) {
+			buttons.push(el("button", {class: "danger", onclick: action(jobPath + "/cancel", `Cancel job '${selected}'? The run will fail.`)}, "Cancel job"));
+		}
+		jobPanel.replaceChildren(
+			el("h2", {}, `Logs of '${selected}'`),
+			el("div", {class: "toolbar"}, status(job.status), ...buttons,
+				job.tests ? el("span", {class: "muted"}, "Tests: " + describeTests(job.tests)) : null),
+			job.error ? el("p", {class: "mono"}, job.error) : null,
+			job.tests && job.tests.failures ? el("ul", {class: "mono"}, ...job.tests.failures.map(name => el("li", {}, name))) : null);
+	};
+
+	const formatEntry = e => {
+		const time = new Date(e.time).toLocaleTimeString();
+		const job = e.job && !selected ? `[${e.job}] ` : "";
+		if (e.type === "log") {
+			if (e.level === "endgroup") {
+				return el("div");
+			}
+			const text = e.level === "group" ? `▸ ${e.message}` : e.message;
+			return el("div", {class: e.level}, `${time} ${job}${text}`);
+		}
+		const what = [e.type, e.step, e.status, e.message, e.error, e.tests && describeTests(e.tests)].filter(Boolean).join(" ");
+		return el("div", {class: "event"}, `${time} ${job}— ${what}`);
+	};
+
+	const renderLogs = () => {
+		const shown = entries.filter(e => !selected || e.job === selected);
+		logs.replaceChildren(...shown.map(formatEntry));
+		logs.scrollTop = logs.scrollHeight;
+	};
+
+	const refresh = async () => {
+		try {
+			run = await request("GET", path);
+			render();
+		} catch (err) {
+			showError(err);
+		}
+	};
+	let pending = null;
+	const scheduleRefresh = () => {
+		if (!pending) {
+			pending = setTimeout(() => { pending = null; refresh(); }, 150);
+		}
+	};
+
+	// The stream resumes after the last event seen when it reconnects.
+	const source = new EventSource(API + path + "/events");
+	const onEvent = msg => {
+		const e = JSON.parse(msg.data);
+		entries.push(e);
+		if (!selected || e.job === selected) {
+			const atBottom = logs.scrollTop + logs.clientHeight >= logs.scrollHeight - 4;
+			logs.append(formatEntry(e));
+			if (atBottom) {
+				logs.scrollTop = logs.scrollHeight;
+			}
+		}
+		if (e.type !== "log") {
+			scheduleRefresh();
+		}
+	};
+	for (const type of EVENT_TYPES) {
+		source.addEventListener(type, onEvent);
+	}
+	source.addEventListener("end", () => {
+		source.close();
+		refresh();
+	});
+
+	refresh();
+	// Durations and approvals answered elsewhere still need a refresh.
+	const stopPoll = poll(async () => {
+		if (run && !["succeeded", "failed", "cancelled"].includes(run.status)) {
+			run = await request("GET", path);
+			render();
+		}
+	});
+	return {
+		stop() {
+			source.close();
+			stopPoll();
+			clearTimeout(pending);
+		},
+	};
+}
+
+function agentsView(root) {
+	const body = el("tbody");
+	root.append(
+		el("h1", {}, "Agents"),
+		el("table", {},
+			el("thead", {}, el("tr", {},
+				["Agent", "Tags", "Capacity", "Resources", "Last seen", "Leases"].map(h => el("th", {}, h)))),
+			body));
+
+	const stop = poll(async () => {
+		const agents = await request("GET", "/agents");
+		body.replaceChildren(...agents.map(agent => {
+			let resources = "";
+			if (agent.cpu) {
+				resources += `CPU ${agent.used_cpu}/${agent.cpu}`;
+			}
+			if (agent.memory) {
+				resources += ` mem ${Math.round(agent.used_memory / 1048576)}/${Math.round(agent.memory / 1048576)} MiB`;
+			}
+			return el("tr", {},
+				el("td", {}, agent.name, el("div", {class: "muted mono"}, agent.id)),
+				el("td", {}, (agent.tags || []).join(", ")),
+				el("td", {}, `${agent.leases.length}/${agent.capacity}`),
+				el("td", {}, resources || el("span", {class: "muted"}, "unlimited")),
+				el("td", {}, formatTime(agent.last_seen)),
+				el("td", {}, (agent.jobs || []).map(job => el("div", {},
+					el("a", {href: "#/runs/" + encodeURIComponent(job.run_id)}, `${job.job} #${job.attempt}`),
+					el("span", {class: "muted mono"}, ` ${job.run_id} (${job.lease})`)))));
+		}));
+		if (agents.length === 0) {
+			body.append(el("tr", {}, el("td", {colspan: 6, class: "muted"}, "No agent connected.")));
+		}
+	});
+	return {stop};
+}
+
+// loginView asks for an API token and stores it in the session cookie.
+function loginView(root) {
+	const input = el("input", {type: "password", class: "mono", placeholder: "fct_…", autocomplete: "off", required: true});
+	const submit = async event => {
+		event.preventDefault();
+		try {
+			await request("POST", "/session", undefined, {Authorization: "Bearer " + input.value.trim()});
+			await whoami();
+			route();
+		} catch (err) {
+			showError(err);
+		}
+	};
+	root.append(
+		el("h1", {}, "Log in"),
+		el("p", {class: "muted"}, "This server requires an API token with the read scope. ",
+			"Create one with ", el("code", {}, "flowcraft token create"), " or use the admin token printed by the server on its first start."),
+		el("form", {class: "toolbar", onsubmit: submit}, input, el("button", {class: "primary", type: "submit"}, "Log in")));
+	input.focus();
+	return {stop() {}, login: true};
+}
+
+function showLogin() {
+	if (current.login) {
+		return;
+	}
+	current.stop();
+	document.getElementById("user").replaceChildren();
+	const root = document.getElementById("view");
+	root.replaceChildren();
+	current = loginView(root);
+}
+
+// deviceView confirms a 'flowcraft login' started on another machine.
+function deviceView(root, code) {
+	const path = "/login/device/" + encodeURIComponent(code);
+	const answer = approved => async () => {
+		try {
+			await request("POST", "/login/device/approve", {user_code: code, approved});
+			root.replaceChildren(
+				el("h1", {}, approved ? "Device approved" : "Device denied"),
+				el("p", {class: "muted"}, approved ? "You can return to your terminal." : "The login was refused."));
+		} catch (err) {
+			showError(err);
+		}
+	};
+	request("GET", path).then(login => {
+		root.append(
+			el("h1", {}, "Device login"),
+			el("p", {}, `'${login.name}' asks for an API token with your scopes. Check that your terminal shows this code:`),
+			el("p", {class: "mono code"}, login.user_code),
+			el("p", {class: "muted"}, `Expires at ${formatTime(login.expires_at)}.`),
+			el("div", {class: "toolbar"},
+				el("button", {class: "primary", onclick: answer(true)}, "Approve"),
+				el("button", {class: "danger", onclick: answer(false)}, "Deny")));
+	}).catch(showError);
+	return {stop() {}};
+}
+
+// whoami shows the caller in the header, with a way to end the session.
+async function whoami() {
+	const user = document.getElementById("user");
+	const token = await request("GET", "/whoami");
+	if (!token.id) {
+		user.replaceChildren();
+		return;
+	}
+	const logout = async event => {
+		event.preventDefault();
+		await request("DELETE", "/session").catch(showError);
+		showLogin();
+	};
+	user.replaceChildren(
+		el("span", {class: "muted"}, token.name),
+		el("a", {href: "#", onclick: logout}, "Log out"));
+}
+
+function route() {
+	current.stop();
+	const root = document.getElementById("view");
+	root.replaceChildren();
+
+	const hash = location.hash.replace(/^#/, "") || "/";
+	let nav = "runs";
+	const match = hash.match(/^\/runs\/(.+)$/);
+	const device = hash.match(/^\/device\/(.+)$/);
+	if (match) {
+		current = runView(root, decodeURIComponent(match[1]));
+	} else if (device) {
+		nav = "";
+		current = deviceView(root, decodeURIComponent(device[1]));
+	} else if (hash === "/agents") {
+		nav = "agents";
+		current = agentsView(root);
+	} else {
+		current = runsView(root);
+	}
+	for (const link of document.querySelectorAll("[data-nav]")) {
+		link.classList.toggle("active", link.dataset.nav === nav);
+	}
+}
+
+window.addEventListener("hashchange", route);
+whoami().catch(() => {});
+route();
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUI_Served(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	for path, want := range map[string]string{
+		"/":           "<script src=\"app.js\">",
+		"/ui/":        "<script src=\"app.js\">",
+		"/ui/app.js":  "new EventSource(",
+		"/ui/missing": "404 page not found",
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected GET %s to contain %q, got %d: %.80s", path, want, resp.StatusCode, body)
+		}
+	}
+}
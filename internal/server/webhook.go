@@ -0,0 +1,109 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/webhook"
+)
+
+// maxWebhookBytes is the largest payload GitHub delivers.
+const maxWebhookBytes = 25 << 20
+
+// Trigger queues a run of the webhook pipeline for a delivery, unless
+// the pipeline's [triggers] filter it out. The run gets no workspace:
+// its jobs fetch the sources themselves, using the CI_* variables
+// describing the trigger.
+func (s *Server) Trigger(d *webhook.Delivery) (*api.WebhookResponse, error) {
+	if d.Skip != "" {
+		return &api.WebhookResponse{Status: api.WebhookIgnored, Reason: d.Skip}, nil
+	}
+
+	configData, err := os.ReadFile(s.opts.WebhookConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the webhook pipeline: %w", err)
+	}
+	cfg, err := config.Parse(configData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if ok, reason := webhook.Match(cfg.Triggers, d); !ok {
+		return &api.WebhookResponse{Status: api.WebhookIgnored, Reason: reason}, nil
+	}
+
+	trigger := d.Trigger
+	run, err := s.submit(configData, nil, &trigger)
+	if err != nil {
+		return nil, err
+	}
+	return &api.WebhookResponse{Status: api.WebhookTriggered, Run: run}, nil
+}
+
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.opts.WebhookConfig == "" {
+		writeError(w, http.StatusNotFound, errors.New("webhooks are not enabled on this server"))
+		return
+	}
+
+	var parse func(http.Header, []byte, string) (*webhook.Delivery, error)
+	switch provider := r.PathValue("provider"); provider {
+	case "github":
+		parse = webhook.ParseGitHub
+	case "gitlab":
+		parse = webhook.ParseGitLab
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown webhook provider '%s'", provider))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	d, err := parse(r.Header, body, s.opts.WebhookSecret)
+	if errors.Is(err, webhook.ErrSignature) {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := s.Trigger(d)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if resp.Run == nil {
+		log.Printf("Webhook delivery ignored: %s.", resp.Reason)
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	t := resp.Run.Trigger
+	log.Printf("Run %s triggered by a %s %s on %s (%s@%.7s).", resp.Run.ID, t.Provider, t.Event, t.Repository, t.Branch, t.SHA)
+	writeJSON(w, http.StatusCreated, resp)
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/agent"
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+)
+
+const webhookSecret = "s3cr3t"
+
+const webhookPipeline = `
+[triggers]
+branches = ["main"]
+
+[jobs.build]
+[[jobs.build.steps]]
+name = "Build"
+cmd = "test \"$CI_BRANCH\" = main && echo \"building $CI_REPOSITORY at $CI_SHA\""
+`
+
+func startWebhookServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "flow.toml")
+	if err := os.WriteFile(path, []byte(webhookPipeline), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, ts := startTestServer(t, Options{Workers: 1, WebhookConfig: path, WebhookSecret: webhookSecret},
+		agent.Options{Name: "agent-1", Capacity: 1})
+	return ts
+}
+
+// deliver posts a webhook fixture and decodes the response.
+func deliver(t *testing.T, ts *httptest.Server, provider, fixture string, header http.Header) (int, *api.WebhookResponse) {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("..", "webhook", "testdata", fixture))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	if header.Get("X-GitHub-Event") != "" && header.Get("X-Hub-Signature-256") == "" {
+		mac := hmac.New(sha256.New, []byte(webhookSecret))
+		mac.Write(body)
+		header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/webhooks/"+provider, strings.NewReader(string(body)))
+	req.Header = header
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Webhook request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out api.WebhookResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, &out
+}
+
+func TestWebhook_GitHubPushStartsRun(t *testing.T) {
+	ts := startWebhookServer(t)
+
+	status, resp := deliver(t, ts, "github", "github_push.json", http.Header{"X-Github-Event": {"push"}})
+	if status != http.StatusCreated || resp.Status != api.WebhookTriggered {
+		t.Fatalf("Expected the push to trigger a run, got %d %+v", status, resp)
+	}
+	if resp.Run.Trigger == nil || resp.Run.Trigger.Branch != "main" {
+		t.Fatalf("Expected the run to record its trigger, got %+v", resp.Run.Trigger)
+	}
+
+	var sawOutput bool
+	for _, e := range streamEvents(t, ts, resp.Run.ID) {
+		if e.Type == api.EventLog && strings.Contains(e.Message, "building Purpose-Dev/flowcraft at 0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c") {
+			sawOutput = true
+		}
+	}
+	if !sawOutput {
+		t.Error("Expected the job to see the CI_* variables of the push")
+	}
+	if run := getRun(t, ts, resp.Run.ID); run.Status != api.RunSucceeded {
+		t.Errorf("Expected the run to succeed, got %s (%s)", run.Status, run.Error)
+	}
+}
+
+func TestWebhook_FilteredByTriggers(t *testing.T) {
+	ts := startWebhookServer(t)
+
+	// The GitLab fixture pushes to release/1.2, which triggers.branches leaves out.
+	status, resp := deliver(t, ts, "gitlab", "gitlab_push.json",
+		http.Header{"X-Gitlab-Event": {"Push Hook"}, "X-Gitlab-Token": {webhookSecret}})
+	if status != http.StatusOK || resp.Status != api.WebhookIgnored || !strings.Contains(resp.Reason, "release/1.2") {
+		t.Errorf("Expected the push to be ignored because of its branch, got %d %+v", status, resp)
+	}
+
+	status, resp = deliver(t, ts, "github", "github_pull_request.json", http.Header{"X-Github-Event": {"pull_request"}})
+	if status != http.StatusOK || resp.Status != api.WebhookIgnored {
+		t.Errorf("Expected pull requests to be ignored by default, got %d %+v", status, resp)
+	}
+}
+
+func TestWebhook_RejectsBadSignature(t *testing.T) {
+	ts := startWebhookServer(t)
+
+	status, _ := deliver(t, ts, "github", "github_push.json",
+		http.Header{"X-Github-Event": {"push"}, "X-Hub-Signature-256": {"sha256=00"}})
+	if status != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a bad signature, got %d", status)
+	}
+	status, _ = deliver(t, ts, "gitlab", "gitlab_push.json",
+		http.Header{"X-Gitlab-Event": {"Push Hook"}, "X-Gitlab-Token": {"guess"}})
+	if status != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a bad token, got %d", status)
+	}
+}
+
+func TestWebhook_Disabled(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	status, _ := deliver(t, ts, "github", "github_push.json", http.Header{"X-Github-Event": {"push"}})
+	if status != http.StatusNotFound {
+		t.Errorf("Expected status 404 when webhooks are disabled, got %d", status)
+	}
+}
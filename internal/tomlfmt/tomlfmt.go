@@ -0,0 +1,97 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tomlfmt quotes the keys and values of the flow.toml files
+// generated by flowcraft.
+package tomlfmt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Key returns key bare when it can be, else quoted.
+func Key(key string) string {
+	if bareKey.MatchString(key) {
+		return key
+	}
+	return String(key)
+}
+
+// Array returns an inline array of the quoted values.
+func Array(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = String(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// String quotes s, as a literal string when it holds double quotes
+// or backslashes but no single quote, so that commands stay readable.
+func String(s string) string {
+	if strings.ContainsAny(s, `"\`) && literal(s, false) && !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	return `"` + escape(s, false) + `"`
+}
+
+// Text quotes s like String, as a multi-line string when it
+// holds several lines.
+func Text(s string) string {
+	if !strings.Contains(s, "\n") {
+		return String(s)
+	}
+	if literal(s, true) && !strings.Contains(s, "'''") && !strings.HasSuffix(s, "'") {
+		return "'''\n" + s + "'''"
+	}
+	return "\"\"\"\n" + escape(s, true) + `"""`
+}
+
+// literal reports whether s can be written without escapes.
+func literal(s string, multiline bool) bool {
+	for _, r := range s {
+		if (r < 0x20 && r != '\t' && !(multiline && r == '\n')) || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func escape(s string, multiline bool) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' && multiline:
+			b.WriteByte('\n')
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
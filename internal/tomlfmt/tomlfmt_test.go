@@ -0,0 +1,64 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tomlfmt
+
+import "testing"
+
+func TestString(t *testing.T) {
+	for s, want := range map[string]string{
+		`go test ./...`:         `"go test ./..."`,
+		`test -z "$(gofmt -l)"`: `'test -z "$(gofmt -l)"'`,
+		`echo "it's"`:           `"echo \"it's\""`,
+		`dir\sub`:               `'dir\sub'`,
+		"a\\b\n":                `"a\\b\n"`,
+	} {
+		if got := String(s); got != want {
+			t.Errorf("String(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`echo hi`, `"echo hi"`},
+		{`echo "hi"`, `'echo "hi"'`},
+		{`echo "it's"`, `"echo \"it's\""`},
+		{"a\nb \\", "'''\na\nb \\'''"},
+		{"a\necho 'b'", "\"\"\"\na\necho 'b'\"\"\""},
+		{"a\n\"b\\\"", "'''\na\n\"b\\\"'''"},
+	}
+	for _, tt := range tests {
+		if got := Text(tt.in); got != tt.want {
+			t.Errorf("Text(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestKeyAndArray(t *testing.T) {
+	if got := Key("go_build-1"); got != "go_build-1" {
+		t.Errorf("Expected a bare key, got %s", got)
+	}
+	if got := Key("a.b"); got != `"a.b"` {
+		t.Errorf("Expected a quoted key, got %s", got)
+	}
+	if got := Array([]string{"a", `b"c`}); got != `["a", 'b"c']` {
+		t.Errorf("Expected an inline array, got %s", got)
+	}
+}
@@ -0,0 +1,317 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLP/JSON encoding of an ExportTraceServiceRequest. IDs are hex
+// strings and 64-bit integers decimal strings.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Message string `json:"message,omitempty"`
+	Code    int    `json:"code,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+	statusOK         = 1
+	statusError      = 2
+)
+
+func encodeAttributes(attrs []Attribute) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		var v otlpAnyValue
+		switch value := attr.Value.(type) {
+		case string:
+			v.StringValue = &value
+		case int64:
+			s := strconv.FormatInt(value, 10)
+			v.IntValue = &s
+		case float64:
+			v.DoubleValue = &value
+		case bool:
+			v.BoolValue = &value
+		default:
+			s := fmt.Sprint(value)
+			v.StringValue = &s
+		}
+		kvs = append(kvs, otlpKeyValue{Key: attr.Key, Value: v})
+	}
+	return kvs
+}
+
+func decodeAttributes(kvs []otlpKeyValue) []Attribute {
+	attrs := make([]Attribute, 0, len(kvs))
+	for _, kv := range kvs {
+		attr := Attribute{Key: kv.Key}
+		switch v := kv.Value; {
+		case v.StringValue != nil:
+			attr.Value = *v.StringValue
+		case v.IntValue != nil:
+			attr.Value, _ = strconv.ParseInt(*v.IntValue, 10, 64)
+		case v.DoubleValue != nil:
+			attr.Value = *v.DoubleValue
+		case v.BoolValue != nil:
+			attr.Value = *v.BoolValue
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+func encodeSpans(service string, spans []SpanData) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           span.TraceID.String(),
+			SpanID:            span.SpanID.String(),
+			Name:              span.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        encodeAttributes(span.Attributes),
+			Status:            otlpStatus{Code: statusOK},
+		}
+		if span.ParentSpanID.IsValid() {
+			s.ParentSpanID = span.ParentSpanID.String()
+		}
+		if span.Failed {
+			s.Status = otlpStatus{Code: statusError, Message: span.Error}
+		}
+		encoded = append(encoded, s)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: encodeAttributes([]Attribute{String("service.name", service)})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "flowcraft"}, Spans: encoded}},
+	}}}
+}
+
+// DecodeOTLP decodes the spans of an OTLP/JSON export request.
+func DecodeOTLP(r io.Reader) ([]SpanData, error) {
+	var req otlpRequest
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid OTLP request: %w", err)
+	}
+	var spans []SpanData
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, s := range ss.Spans {
+				span := SpanData{
+					Name:       s.Name,
+					Start:      unixNano(s.StartTimeUnixNano),
+					End:        unixNano(s.EndTimeUnixNano),
+					Attributes: decodeAttributes(s.Attributes),
+					Failed:     s.Status.Code == statusError,
+					Error:      s.Status.Message,
+				}
+				decodeID(span.TraceID[:], s.TraceID)
+				decodeID(span.SpanID[:], s.SpanID)
+				decodeID(span.ParentSpanID[:], s.ParentSpanID)
+				spans = append(spans, span)
+			}
+		}
+	}
+	return spans, nil
+}
+
+func decodeID(dst []byte, s string) {
+	if b, err := hex.DecodeString(s); err == nil && len(b) == len(dst) {
+		copy(dst, b)
+	}
+}
+
+func unixNano(s string) time.Time {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return time.Unix(0, n)
+}
+
+// ErrNoEndpoint is returned by NewOTLPExporter without an endpoint.
+var ErrNoEndpoint = errors.New("no OTLP endpoint configured")
+
+// OTLPExporter posts spans to an OTLP/HTTP collector, JSON-encoded.
+type OTLPExporter struct {
+	url     string
+	service string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewOTLPExporter exports to endpoint, the base URL of a collector
+// (such as http://localhost:4318, /v1/traces is added) or the full URL
+// of its traces endpoint. The spans belong to the service.name service.
+// Extra headers, e.g. for authentication, are read from
+// $OTEL_EXPORTER_OTLP_HEADERS as key=value pairs separated by commas.
+func NewOTLPExporter(endpoint, service string) (*OTLPExporter, error) {
+	if endpoint == "" {
+		return nil, ErrNoEndpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint '%s': expected an http(s) URL", endpoint)
+	}
+	if !strings.HasSuffix(u.Path, "/v1/traces") {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/v1/traces"
+	}
+	return &OTLPExporter{
+		url:     u.String(),
+		service: service,
+		headers: parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// DefaultEndpoint returns the collector configured by the standard
+// OpenTelemetry variables, if any.
+func DefaultEndpoint() string {
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+}
+
+func parseHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if decoded, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		headers[strings.TrimSpace(key)] = value
+	}
+	return headers
+}
+
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(encodeSpans(e.service, spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Receiver is an in-process OTLP/HTTP collector accepting JSON exports
+// on any path. It keeps the spans it receives, for tests and local
+// debugging.
+type Receiver struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	spans, err := DecodeOTLP(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	r.spans = append(r.spans, spans...)
+	r.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte("{}"))
+}
+
+// Spans returns the spans received so far.
+func (r *Receiver) Spans() []SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]SpanData(nil), r.spans...)
+}
@@ -0,0 +1,348 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tracing records runs, jobs and steps as OpenTelemetry spans
+// and exports them over OTLP/HTTP. Spans travel in contexts, and
+// across processes as W3C traceparent strings.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceParentEnv is the variable holding the traceparent of a step, for
+// instrumented tools to attach their spans to it.
+const TraceParentEnv = "TRACEPARENT"
+
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// ParseSpanID parses the hex form of a span ID.
+func ParseSpanID(s string) (SpanID, error) {
+	var id SpanID
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		return id, fmt.Errorf("invalid span ID '%s'", s)
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+// SpanContext identifies a span within its trace.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// TraceParent formats sc as a sampled W3C traceparent, or returns ""
+// for an invalid context.
+func (sc SpanContext) TraceParent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", sc.TraceID, sc.SpanID)
+}
+
+// ParseTraceParent parses a W3C traceparent such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("invalid traceparent '%s'", s)
+	}
+	trace, err := hex.DecodeString(parts[1])
+	if err != nil || len(trace) != len(sc.TraceID) {
+		return sc, fmt.Errorf("invalid trace ID in traceparent '%s'", s)
+	}
+	span, err := hex.DecodeString(parts[2])
+	if err != nil || len(span) != len(sc.SpanID) {
+		return sc, fmt.Errorf("invalid span ID in traceparent '%s'", s)
+	}
+	copy(sc.TraceID[:], trace)
+	copy(sc.SpanID[:], span)
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("invalid traceparent '%s': all-zero ID", s)
+	}
+	return sc, nil
+}
+
+// Attribute is a key and a string, int64, float64 or bool value.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attribute { return Attribute{key, value} }
+
+func Int(key string, value int) Attribute { return Attribute{key, int64(value)} }
+
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// SpanData is a finished span, as exported.
+type SpanData struct {
+	Name         string
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Attributes   []Attribute
+	// Failed marks a span whose operation failed with Error.
+	Failed bool
+	Error  string
+}
+
+// Attribute returns the value of the attribute key, or nil.
+func (d SpanData) Attribute(key string) any {
+	for _, attr := range d.Attributes {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return nil
+}
+
+// Span is an operation being traced. The methods of a nil Span do
+// nothing, so that code can be traced whether or not tracing is on.
+// A span without a tracer still has a valid context to propagate, but
+// is not exported.
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// Context returns the span's identity, or an invalid one for nil.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return SpanContext{TraceID: s.data.TraceID, SpanID: s.data.SpanID}
+}
+
+// SetAttributes sets attributes, replacing those with the same key.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, attr := range attrs {
+		s.data.Attributes = setAttribute(s.data.Attributes, attr)
+	}
+}
+
+func setAttribute(attrs []Attribute, attr Attribute) []Attribute {
+	for i := range attrs {
+		if attrs[i].Key == attr.Key {
+			attrs[i] = attr
+			return attrs
+		}
+	}
+	return append(attrs, attr)
+}
+
+// SetError marks the span as failed with err. A nil err does nothing.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Failed = true
+	s.data.Error = err.Error()
+}
+
+// End finishes the span and queues it for export. Only the first call
+// counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if s.tracer != nil {
+		s.tracer.Record(data)
+	}
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// ContextWithSpan returns a copy of ctx in which span is the parent of
+// the spans started from it.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span of ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteParent returns a copy of ctx in which the spans
+// started continue the trace of sc, such as a traceparent received from
+// another process.
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Start starts a span child of the span in ctx, recorded by the same
+// tracer. Under a remote parent only, the span is not recorded but has
+// its own ID to propagate. Without any parent, it returns a nil span.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if parent := SpanFromContext(ctx); parent != nil {
+		return parent.tracer.start(ctx, name, parent.Context(), attrs)
+	}
+	if sc, ok := ctx.Value(remoteKey{}).(SpanContext); ok && sc.IsValid() {
+		var none *Tracer
+		return none.start(ctx, name, sc, attrs)
+	}
+	return ctx, nil
+}
+
+// Exporter sends finished spans to a tracing backend.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// maxPending is the number of finished spans that triggers an export
+// without waiting for Flush.
+const maxPending = 512
+
+// Tracer records the spans of runs and hands them to its exporter in
+// batches.
+type Tracer struct {
+	exporter Exporter
+
+	mu      sync.Mutex
+	pending []SpanData
+	// exporting serializes the exports.
+	exporting sync.Mutex
+}
+
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Start starts a span child of the span in ctx or, without one, the
+// root span of a new trace. A nil Tracer behaves like the package-level
+// Start.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return Start(ctx, name, attrs...)
+	}
+	if SpanFromContext(ctx) != nil {
+		return Start(ctx, name, attrs...)
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return t.start(ctx, name, sc, attrs)
+}
+
+func (t *Tracer) start(ctx context.Context, name string, parent SpanContext, attrs []Attribute) (context.Context, *Span) {
+	span := &Span{tracer: t, data: SpanData{
+		Name:         name,
+		TraceID:      parent.TraceID,
+		SpanID:       newSpanID(),
+		ParentSpanID: parent.SpanID,
+		Start:        time.Now(),
+	}}
+	if !span.data.TraceID.IsValid() {
+		span.data.TraceID = newTraceID()
+	}
+	span.SetAttributes(attrs...)
+	return ContextWithSpan(ctx, span), span
+}
+
+// Record queues a finished span, such as one reported by another
+// process, for export.
+func (t *Tracer) Record(span SpanData) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.pending = append(t.pending, span)
+	full := len(t.pending) >= maxPending
+	t.mu.Unlock()
+	if full {
+		go func() { _ = t.Flush(context.Background()) }()
+	}
+}
+
+// Flush exports the spans finished so far.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.exporting.Lock()
+	defer t.exporting.Unlock()
+
+	t.mu.Lock()
+	spans := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+	if err := t.exporter.Export(ctx, spans); err != nil {
+		return fmt.Errorf("failed to export %d span(s): %w", len(spans), err)
+	}
+	return nil
+}
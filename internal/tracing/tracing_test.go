@@ -0,0 +1,148 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceParent(valid)
+	if err != nil {
+		t.Fatalf("ParseTraceParent() returned an unexpected error: %v", err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+		t.Errorf("Unexpected span context %s/%s", sc.TraceID, sc.SpanID)
+	}
+	if sc.TraceParent() != valid {
+		t.Errorf("Expected %q back, got %q", valid, sc.TraceParent())
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-zzzzzzzzzzzzzzzz-01",
+	} {
+		if _, err := ParseTraceParent(invalid); err == nil {
+			t.Errorf("Expected ParseTraceParent(%q) to fail", invalid)
+		}
+	}
+}
+
+func TestTracer_ExportsTree(t *testing.T) {
+	receiver := &Receiver{}
+	ts := httptest.NewServer(receiver)
+	defer ts.Close()
+	exporter, err := NewOTLPExporter(ts.URL, "test")
+	if err != nil {
+		t.Fatalf("NewOTLPExporter() returned an unexpected error: %v", err)
+	}
+	tracer := NewTracer(exporter)
+
+	ctx, root := tracer.Start(context.Background(), "run", String("flowcraft.run.id", "r1"))
+	_, child := Start(ctx, "job build", Int("flowcraft.attempts", 2), Bool("cached", false))
+	child.SetAttributes(Int("flowcraft.attempts", 3))
+	child.SetError(errors.New("exit status 1"))
+	child.End()
+	child.End()
+	root.End()
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() returned an unexpected error: %v", err)
+	}
+	spans := receiver.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	job, run := spans[0], spans[1]
+	if run.Name != "run" || run.ParentSpanID.IsValid() || run.Failed {
+		t.Errorf("Unexpected root span %+v", run)
+	}
+	if job.TraceID != run.TraceID || job.ParentSpanID != run.SpanID {
+		t.Errorf("Expected 'job build' to be a child of 'run', got %+v", job)
+	}
+	if !job.Failed || job.Error != "exit status 1" {
+		t.Errorf("Expected 'job build' to be failed, got %+v", job)
+	}
+	if got := job.Attribute("flowcraft.attempts"); got != int64(3) {
+		t.Errorf("Expected the attribute to be replaced, got %v", got)
+	}
+	if got := run.Attribute("flowcraft.run.id"); got != "r1" {
+		t.Errorf("Unexpected run ID attribute %v", got)
+	}
+	if job.End.Before(job.Start) {
+		t.Errorf("Unexpected times %v - %v", job.Start, job.End)
+	}
+}
+
+func TestStart_RemoteParent(t *testing.T) {
+	parent, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemoteParent(context.Background(), parent)
+
+	ctx, span := Start(ctx, "step")
+	sc := span.Context()
+	if sc.TraceID != parent.TraceID || !sc.SpanID.IsValid() || sc.SpanID == parent.SpanID {
+		t.Errorf("Expected a new span in the remote trace, got %s", sc.TraceParent())
+	}
+	_, nested := Start(ctx, "nested")
+	if nested.data.ParentSpanID != sc.SpanID {
+		t.Error("Expected the nested span to be a child of the step")
+	}
+	span.End()
+
+	// Without any parent, spans are nil and their methods do nothing.
+	_, none := Start(context.Background(), "untraced")
+	none.SetAttributes(String("k", "v"))
+	none.SetError(errors.New("ignored"))
+	none.End()
+	if none.Context().IsValid() {
+		t.Error("Expected an invalid context for a nil span")
+	}
+}
+
+func TestNewOTLPExporter(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer%20abc, x-team = ci")
+	for endpoint, want := range map[string]string{
+		"http://localhost:4318":              "http://localhost:4318/v1/traces",
+		"http://localhost:4318/":             "http://localhost:4318/v1/traces",
+		"https://otel.example.com/v1/traces": "https://otel.example.com/v1/traces",
+	} {
+		exporter, err := NewOTLPExporter(endpoint, "test")
+		if err != nil {
+			t.Fatalf("NewOTLPExporter(%q) returned an unexpected error: %v", endpoint, err)
+		}
+		if exporter.url != want {
+			t.Errorf("Expected %q to export to %q, got %q", endpoint, want, exporter.url)
+		}
+		if exporter.headers["Authorization"] != "Bearer abc" || exporter.headers["x-team"] != "ci" {
+			t.Errorf("Unexpected headers %v", exporter.headers)
+		}
+	}
+	for _, endpoint := range []string{"", "localhost:4318", "ftp://collector"} {
+		if _, err := NewOTLPExporter(endpoint, "test"); err == nil {
+			t.Errorf("Expected NewOTLPExporter(%q) to fail", endpoint)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tui
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Names of the special keys returned by parseKeys. Other keys are
+// returned as the character they type.
+const (
+	keyUp       = "up"
+	keyDown     = "down"
+	keyPageUp   = "pgup"
+	keyPageDown = "pgdown"
+	keyHome     = "home"
+	keyEnd      = "end"
+	keyEscape   = "esc"
+	keyEnter    = "enter"
+	keyCtrlC    = "ctrl+c"
+)
+
+// sequences maps the escape sequences of the special keys, as sent by
+// terminals in raw mode, to their names.
+var sequences = map[string]string{
+	"\x1b[A": keyUp, "\x1bOA": keyUp,
+	"\x1b[B": keyDown, "\x1bOB": keyDown,
+	"\x1b[5~": keyPageUp, "\x1b[6~": keyPageDown,
+	"\x1b[H": keyHome, "\x1bOH": keyHome, "\x1b[1~": keyHome, "\x1b[7~": keyHome,
+	"\x1b[F": keyEnd, "\x1bOF": keyEnd, "\x1b[4~": keyEnd, "\x1b[8~": keyEnd,
+}
+
+// parseKeys splits what a terminal sent in raw mode into keys. Unknown
+// escape sequences are dropped.
+func parseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch b[0] {
+		case 0x1b:
+			n := sequenceLength(b)
+			if n == 1 {
+				keys = append(keys, keyEscape)
+			} else if name, ok := sequences[string(b[:n])]; ok {
+				keys = append(keys, name)
+			}
+			b = b[n:]
+			continue
+		case 0x03:
+			keys = append(keys, keyCtrlC)
+		case '\r', '\n':
+			keys = append(keys, keyEnter)
+		default:
+			r, n := utf8.DecodeRune(b)
+			if r >= ' ' && r != utf8.RuneError {
+				keys = append(keys, string(r))
+			}
+			b = b[n:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// sequenceLength returns the length of the escape sequence b starts with.
+func sequenceLength(b []byte) int {
+	if len(b) < 2 || (b[1] != '[' && b[1] != 'O') {
+		return 1
+	}
+	for i := 2; i < len(b); i++ {
+		if b[i] >= 0x40 && b[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(b)
+}
+
+// readKeys reads the keys typed on r in the background. The channel is
+// closed when r fails.
+func readKeys(r io.Reader) <-chan string {
+	keys := make(chan string, 16)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 256)
+		for {
+			n, err := r.Read(buf)
+			for _, key := range parseKeys(buf[:n]) {
+				keys <- key
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return keys
+}
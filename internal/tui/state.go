@@ -0,0 +1,274 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+// maxLines bounds the log kept for each job; older lines are dropped.
+const maxLines = 5000
+
+// Statuses of the jobs that have not finished, besides the engine's.
+const (
+	statusPending  = "pending"
+	statusWaiting  = "waiting"
+	statusApproval = "approval"
+	statusRunning  = "running"
+)
+
+// jobView is what the UI shows of a job. The first row of the list is
+// the pipeline itself, with the lines not attributed to a job.
+type jobView struct {
+	name     string
+	spec     config.Job
+	status   string
+	attempt  int
+	started  time.Time
+	finished time.Time
+	// note is a short explanation of the status, e.g. the lock waited for.
+	note  string
+	lines []runner.Entry
+	// decide answers the approval gate of the job while it is open.
+	decide chan bool
+}
+
+// state is the model of the UI, updated by the events and the log
+// entries of the runs and by the keys pressed.
+type state struct {
+	mu       sync.Mutex
+	jobs     []*jobView
+	byName   map[string]*jobView
+	selected int
+	// scroll is how many rows the log is scrolled up; 0 follows it.
+	scroll int
+	// running is set while a run is in progress, until the UI learns of
+	// its end.
+	running bool
+	message string
+}
+
+func newState(graph *engine.Graph) *state {
+	pipeline := &jobView{name: "", status: statusPending}
+	s := &state{jobs: []*jobView{pipeline}, byName: map[string]*jobView{"": pipeline}}
+	levels, _ := graph.TopologicalSort()
+	for _, level := range levels {
+		sort.Slice(level, func(i, j int) bool { return level[i].Name < level[j].Name })
+		for _, node := range level {
+			job := &jobView{name: node.Name, spec: node.Job, status: statusPending}
+			s.jobs = append(s.jobs, job)
+			s.byName[node.Name] = job
+		}
+	}
+	return s
+}
+
+// reset marks the jobs about to run again as pending, and clears their
+// logs. The log of the pipeline is kept.
+func (s *state) reset(jobs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range append([]string{""}, jobs...) {
+		if job, ok := s.byName[name]; ok {
+			job.status, job.attempt, job.note = statusPending, 0, ""
+			job.started, job.finished = time.Time{}, time.Time{}
+			if name != "" {
+				job.lines = nil
+			}
+		}
+	}
+	s.running = true
+	s.scroll = 0
+}
+
+// observe updates the jobs from the events of a run.
+func (s *state) observe(e engine.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.byName[e.Job]
+	if !ok {
+		return
+	}
+	switch e.Type {
+	case engine.EventRunStarted:
+		job.status, job.started = statusRunning, e.Time
+	case engine.EventRunFinished:
+		job.status, job.finished, job.note = e.Status, e.Time, e.Error
+	case engine.EventJobWaiting:
+		job.status, job.note = statusWaiting, "waiting for "+e.Message
+	case engine.EventApprovalRequested:
+		job.status, job.note, job.started = statusApproval, "waiting for approval", e.Time
+	case engine.EventApprovalResolved:
+		if e.Status == engine.StatusApproved {
+			job.status, job.note, job.started = statusPending, "approved", time.Time{}
+		}
+	case engine.EventJobStarted:
+		if e.Attempt <= 1 || job.started.IsZero() {
+			job.started = e.Time
+		}
+		job.status, job.attempt, job.note = statusRunning, e.Attempt, ""
+	case engine.EventJobFinished:
+		job.status, job.finished = e.Status, e.Time
+		if e.Error != "" {
+			job.note = e.Error
+		}
+	case engine.EventJobSkipped:
+		job.status, job.finished, job.note = engine.StatusSkipped, e.Time, ""
+	case engine.EventTestReport:
+		if e.Tests != nil {
+			job.note = "tests: " + e.Tests.String()
+		}
+	}
+}
+
+// log records an entry of the logger under its job.
+func (s *state) log(e runner.Entry) {
+	if e.Level == runner.LevelEndGroup {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.byName[e.Job]
+	if !ok {
+		job = s.jobs[0]
+	}
+	s.append(job, e)
+}
+
+func (s *state) append(job *jobView, e runner.Entry) {
+	job.lines = append(job.lines, e)
+	if len(job.lines) > maxLines {
+		job.lines = append(job.lines[:0:0], job.lines[len(job.lines)-maxLines:]...)
+	}
+}
+
+// approve is the engine.Approver of the UI: the gate stays open until
+// the job is approved or denied with a key.
+func (s *state) approve(ctx context.Context, req engine.ApprovalRequest) (bool, error) {
+	decide := make(chan bool, 1)
+	s.mu.Lock()
+	job := s.byName[req.Job]
+	job.decide = decide
+	s.append(job, runner.Entry{Time: time.Now(), Level: runner.LevelWarn,
+		Message: fmt.Sprintf("Approval required, the job is rejected in %s without an answer:", req.Timeout)})
+	for _, line := range req.Spec.Describe() {
+		s.append(job, runner.Entry{Time: time.Now(), Level: runner.LevelInfo, Message: line})
+	}
+	s.message = fmt.Sprintf("Job '%s' is waiting for approval: select it, then press a to approve or d to deny.", req.Job)
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		job.decide = nil
+		s.mu.Unlock()
+	}()
+	select {
+	case approved := <-decide:
+		return approved, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// decide answers the approval gate of the selected job.
+func (s *state) decide(approved bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[s.selected]
+	if job.decide == nil {
+		s.message = fmt.Sprintf("%s is not waiting for approval.", title(job))
+		return
+	}
+	select {
+	case job.decide <- approved:
+		if approved {
+			s.message = fmt.Sprintf("Job '%s' approved.", job.name)
+		} else {
+			s.message = fmt.Sprintf("Job '%s' denied.", job.name)
+		}
+	default:
+	}
+}
+
+// move moves the selection by delta rows.
+func (s *state) move(delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.selected = min(max(s.selected+delta, 0), len(s.jobs)-1)
+	s.scroll = 0
+}
+
+// scrollBy scrolls the log up by delta rows, or down when negative.
+// The renderer bounds it to the log's length.
+func (s *state) scrollBy(delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scroll = max(s.scroll+delta, 0)
+}
+
+// finished records the end of a run, with a message for the user.
+func (s *state) finished(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+	s.message = msg
+}
+
+func (s *state) setMessage(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.message = msg
+}
+
+// current returns the name and status of the selected job.
+func (s *state) current() (string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[s.selected]
+	return job.name, job.status
+}
+
+// succeeded returns the jobs whose last run succeeded, and the others.
+func (s *state) succeeded() (map[string]bool, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ok := make(map[string]bool)
+	var others []string
+	for _, job := range s.jobs[1:] {
+		if job.status == engine.StatusSuccess {
+			ok[job.name] = true
+		} else {
+			others = append(others, job.name)
+		}
+	}
+	return ok, others
+}
+
+func title(job *jobView) string {
+	if job.name == "" {
+		return "The pipeline"
+	}
+	return fmt.Sprintf("Job '%s'", job.name)
+}
@@ -0,0 +1,322 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tui is the full-screen terminal interface of 'flowcraft run
+// --tui': the jobs of the run with their status and timers on the left,
+// the log of the selected job on the right, and keys to cancel, retry
+// and approve jobs.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"golang.org/x/term"
+)
+
+const (
+	enterScreen = "\033[?1049h\033[?25l"
+	leaveScreen = "\033[?25h\033[?1049l"
+	// summaryLines is how many lines of the log of a failed job are
+	// printed when leaving the interface.
+	summaryLines = 30
+)
+
+// Available reports whether stdin and stdout are terminals, which the
+// interface needs.
+func Available() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// ui runs the pipeline under the interface.
+type ui struct {
+	cfg       *config.Config
+	graph     *engine.Graph
+	logger    *runner.Logger
+	options   []engine.Option
+	state     *state
+	out       io.Writer
+	canceller *engine.JobCanceller
+	// outputs carries the outputs of the jobs from one run to the next.
+	outputs map[string]string
+
+	cancel context.CancelFunc
+	done   chan error
+	// err is the result of the last run.
+	err error
+}
+
+// Run runs the pipeline in a full-screen interface on the terminal, and
+// returns once the user quits it. Failed, cancelled and skipped jobs
+// can be retried when no run is in progress: they run again along with
+// the jobs depending on them. The logger only writes to the interface
+// until Run returns, then the status of the jobs and the end of the log
+// of those that failed are printed.
+//
+// Approval gates are answered in the interface, unless options set an
+// approver of their own. Run returns the error of the last run, or an
+// error naming the jobs that did not succeed.
+func Run(ctx context.Context, cfg *config.Config, graph *engine.Graph, logger *runner.Logger, options ...engine.Option) error {
+	stdin, stdout := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	saved, err := term.MakeRaw(stdin)
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+
+	u := &ui{
+		cfg:       cfg,
+		graph:     graph,
+		logger:    logger,
+		options:   options,
+		state:     newState(graph),
+		out:       os.Stdout,
+		canceller: engine.NewJobCanceller(),
+		outputs:   make(map[string]string),
+	}
+	logger.AddSink(u.state.log)
+	logger.SetOutput(io.Discard)
+	fmt.Print(enterScreen)
+
+	err = u.loop(ctx, readKeys(os.Stdin), func() (int, int) {
+		width, height, err := term.GetSize(stdout)
+		if err != nil || width <= 0 || height <= 0 {
+			return 80, 24
+		}
+		return width, height
+	})
+
+	fmt.Print(leaveScreen)
+	_ = term.Restore(stdin, saved)
+	logger.SetOutput(os.Stdout)
+	u.summary()
+	return err
+}
+
+func (u *ui) loop(ctx context.Context, keys <-chan string, size func() (int, int)) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	var last string
+
+	var all []string
+	for name := range u.graph.Nodes {
+		all = append(all, name)
+	}
+	u.start(ctx, all)
+
+	for {
+		width, height := size()
+		if frame := "\033[H" + strings.Join(u.state.render(width, height, time.Now()), "\033[K\r\n") + "\033[J"; frame != last {
+			_, _ = io.WriteString(u.out, frame)
+			last = frame
+		}
+
+		select {
+		case <-ctx.Done():
+			u.stop()
+			return ctx.Err()
+		case err := <-u.done:
+			u.finish(err)
+		case key, ok := <-keys:
+			if !ok {
+				keys = nil
+				continue
+			}
+			if u.handle(ctx, key, height) {
+				if u.stop() {
+					return context.Canceled
+				}
+				return u.result()
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// start runs jobs of the pipeline.
+func (u *ui) start(ctx context.Context, jobs []string) {
+	runCtx, cancel := context.WithCancel(ctx)
+	u.cancel = cancel
+	u.done = make(chan error, 1)
+	u.state.reset(jobs)
+
+	options := append([]engine.Option{engine.WithApprover(u.state.approve)}, u.options...)
+	options = append(options,
+		engine.WithJobCanceller(u.canceller),
+		engine.WithListener(u.state.observe),
+		engine.WithOutputs(u.outputs))
+	go func() {
+		u.done <- engine.Run(runCtx, u.cfg, u.graph.Subgraph(jobs), u.logger, options...)
+	}()
+}
+
+func (u *ui) finish(err error) {
+	u.cancel()
+	u.done = nil
+	u.err = err
+	switch {
+	case errors.Is(err, context.Canceled):
+		u.state.finished("Run cancelled. Press r on a job to retry it, q to quit.")
+	case err != nil:
+		u.state.finished(fmt.Sprintf("Run failed: %v. Press r on a job to retry it, q to quit.", err))
+	default:
+		u.state.finished("Run finished successfully. Press q to quit.")
+	}
+}
+
+// stop cancels the run in progress and waits for it. It reports whether
+// a run was in progress.
+func (u *ui) stop() bool {
+	if u.done == nil {
+		return false
+	}
+	u.cancel()
+	u.finish(<-u.done)
+	return true
+}
+
+// handle acts on a key, and reports whether the user quits.
+func (u *ui) handle(ctx context.Context, key string, height int) bool {
+	page := max(height-4, 1)
+	switch key {
+	case "q", keyCtrlC:
+		return true
+	case keyUp, "k":
+		u.state.move(-1)
+	case keyDown, "j":
+		u.state.move(1)
+	case keyPageUp:
+		u.state.scrollBy(page)
+	case keyPageDown:
+		u.state.scrollBy(-page)
+	case keyHome, "g":
+		u.state.scrollBy(maxLines * 10)
+	case keyEnd, "G":
+		u.state.scrollBy(-maxLines * 10)
+	case "a":
+		u.state.decide(true)
+	case "d":
+		u.state.decide(false)
+	case "c":
+		u.cancelSelected()
+	case "r":
+		u.retrySelected(ctx)
+	}
+	return false
+}
+
+// cancelSelected cancels the selected job, or the whole run when the
+// pipeline is selected.
+func (u *ui) cancelSelected() {
+	name, status := u.state.current()
+	switch {
+	case u.done == nil:
+		u.state.setMessage("No run is in progress.")
+	case name == "":
+		u.state.setMessage("Cancelling the run...")
+		u.cancel()
+	case status == statusApproval:
+		u.state.setMessage(fmt.Sprintf("Job '%s' is waiting for approval: press d to deny it.", name))
+	case u.canceller.Cancel(name):
+		u.state.setMessage(fmt.Sprintf("Cancelling job '%s'...", name))
+	default:
+		u.state.setMessage(fmt.Sprintf("Job '%s' is not running.", name))
+	}
+}
+
+// retrySelected runs the selected job again, with the jobs depending on
+// it, or every job that did not succeed when the pipeline is selected.
+func (u *ui) retrySelected(ctx context.Context) {
+	name, status := u.state.current()
+	ok, others := u.state.succeeded()
+	jobs := []string{name}
+	switch {
+	case u.done != nil:
+		u.state.setMessage("Wait for the run in progress to end before retrying jobs.")
+		return
+	case name == "" && len(others) == 0:
+		u.state.setMessage("Every job succeeded.")
+		return
+	case name == "":
+		jobs = others
+	case status == engine.StatusSuccess:
+		u.state.setMessage(fmt.Sprintf("Job '%s' succeeded.", name))
+		return
+	}
+	jobs = u.graph.Rerun(jobs, ok)
+	u.state.setMessage(fmt.Sprintf("Retrying %s.", strings.Join(jobs, ", ")))
+	u.start(ctx, jobs)
+}
+
+// result is the outcome of the session: the error of the last run, or
+// the jobs that did not succeed.
+func (u *ui) result() error {
+	if u.err != nil {
+		return u.err
+	}
+	if _, others := u.state.succeeded(); len(others) > 0 {
+		return fmt.Errorf("job(s) %s did not succeed", strings.Join(others, ", "))
+	}
+	return nil
+}
+
+// summary prints the status of every job, and the end of the log of the
+// jobs that failed, once the interface is gone.
+func (u *ui) summary() {
+	s := u.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, job := range s.jobs[1:] {
+		if job.status != engine.StatusFailed {
+			continue
+		}
+		u.logger.Replay(runner.Entry{Level: runner.LevelGroup, Message: fmt.Sprintf("Log of job '%s'", job.name)})
+		lines := job.lines[max(len(job.lines)-summaryLines, 0):]
+		for _, e := range lines {
+			if e.Level != runner.LevelGroup {
+				u.logger.Replay(e)
+			}
+		}
+		u.logger.Replay(runner.Entry{Level: runner.LevelEndGroup})
+	}
+	for _, job := range s.jobs[1:] {
+		line := fmt.Sprintf("Job '%s': %s", job.name, job.status)
+		if d := elapsed(job, now); d != "" {
+			line += " (" + d + ")"
+		}
+		if job.note != "" {
+			line += ", " + job.note
+		}
+		level := runner.LevelInfo
+		switch job.status {
+		case engine.StatusSuccess:
+			level = runner.LevelSuccess
+		case engine.StatusFailed:
+			level = runner.LevelError
+		case engine.StatusCancelled, engine.StatusSkipped:
+			level = runner.LevelWarn
+		}
+		u.logger.Replay(runner.Entry{Time: now, Level: level, Message: line})
+	}
+}
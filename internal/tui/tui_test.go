@@ -0,0 +1,167 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tui
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("j\x1b[A\x1b[6~\x1bOBé\x03\r\x1b\x1b[99;5u"))
+	want := []string{"j", keyUp, keyPageDown, keyDown, "é", keyCtrlC, keyEnter, keyEscape}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys() = %q, want %q", got, want)
+	}
+}
+
+func newTestUI(t *testing.T, cfg *config.Config) *ui {
+	t.Helper()
+	graph, err := engine.BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+	u := &ui{
+		cfg:       cfg,
+		graph:     graph,
+		logger:    logger,
+		options:   []engine.Option{engine.WithWorkdir(t.TempDir())},
+		state:     newState(graph),
+		out:       io.Discard,
+		canceller: engine.NewJobCanceller(),
+		outputs:   make(map[string]string),
+	}
+	logger.AddSink(u.state.log)
+	return u
+}
+
+func TestRender(t *testing.T) {
+	u := newTestUI(t, &config.Config{
+		Jobs: map[string]config.Job{
+			"build":  {},
+			"deploy": {DependsOn: []string{"build"}},
+		},
+	})
+	s := u.state
+	start := time.Now()
+	s.observe(engine.Event{Type: engine.EventRunStarted, Time: start})
+	s.observe(engine.Event{Type: engine.EventJobStarted, Job: "build", Attempt: 1, Time: start})
+	s.observe(engine.Event{Type: engine.EventJobFinished, Job: "build", Status: engine.StatusSuccess, Time: start.Add(2 * time.Second)})
+	s.observe(engine.Event{Type: engine.EventJobStarted, Job: "deploy", Attempt: 1, Time: start.Add(2 * time.Second)})
+	u.logger.WithJob("deploy").Info("\x1b[32mUploading\x1b[0m\tthe release " + strings.Repeat("x", 80))
+	u.logger.WithJob("deploy").Error("Upload failed")
+	s.move(2)
+
+	rows := s.render(60, 8, start.Add(75*time.Second))
+	if len(rows) != 8 {
+		t.Fatalf("Expected 8 rows, got %d", len(rows))
+	}
+	var plain []string
+	for _, row := range rows {
+		text := escapes.ReplaceAllString(row, "")
+		if n := utf8.RuneCountInString(text); n > 60 {
+			t.Errorf("Expected rows of at most 60 columns, got %d: %q", n, text)
+		}
+		plain = append(plain, text)
+	}
+	screen := strings.Join(plain, "\n")
+	for _, want := range []string{"running · 1/2 job(s) done · 1:15", "✓ build", "0:02", "deploy · running", "Uploading    the release", "Upload failed"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("Expected the screen to show %q:\n%s", want, screen)
+		}
+	}
+	if !strings.HasPrefix(plain[2], "›") && !strings.HasPrefix(plain[3], "›") {
+		t.Errorf("Expected deploy to be selected:\n%s", screen)
+	}
+}
+
+// waitFor waits until cond holds for the state of u.
+func waitFor(t *testing.T, u *ui, what string, cond func(s *state) bool) {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		u.state.mu.Lock()
+		ok := cond(u.state)
+		u.state.mu.Unlock()
+		if ok {
+			return
+		}
+	}
+	t.Fatalf("Timed out waiting for %s", what)
+}
+
+func status(name, want string) func(s *state) bool {
+	return func(s *state) bool { return s.byName[name].status == want }
+}
+
+func TestLoop(t *testing.T) {
+	u := newTestUI(t, &config.Config{
+		Jobs: map[string]config.Job{
+			"gate":   {Approve: true},
+			"slow":   {Steps: []config.Step{{Name: "Sleep", Cmd: "sleep 30"}}},
+			"flaky":  {DependsOn: []string{"gate"}, Steps: []config.Step{{Name: "Test", Cmd: "test -f flaked || { touch flaked; exit 1; }"}}},
+			"deploy": {DependsOn: []string{"flaky"}, Steps: []config.Step{{Name: "Deploy", Cmd: "true"}}},
+		},
+	})
+	keys := make(chan string)
+	result := make(chan error, 1)
+	go func() {
+		result <- u.loop(context.Background(), keys, func() (int, int) { return 80, 24 })
+	}()
+	press := func(k ...string) {
+		for _, key := range k {
+			keys <- key
+		}
+	}
+
+	// Rows: pipeline, gate, slow, flaky, deploy.
+	waitFor(t, u, "the approval gate", status("gate", statusApproval))
+	waitFor(t, u, "slow to start", status("slow", statusRunning))
+	press("j", "j", "c")
+	waitFor(t, u, "slow to be cancelled", status("slow", engine.StatusCancelled))
+	press("k", "a")
+	waitFor(t, u, "the end of the run", func(s *state) bool { return !s.running })
+	for job, want := range map[string]string{"gate": engine.StatusSuccess, "slow": engine.StatusCancelled, "flaky": engine.StatusFailed, "deploy": engine.StatusSkipped} {
+		if got := u.state.byName[job].status; got != want {
+			t.Errorf("Expected '%s' to be %s, got %s", job, want, got)
+		}
+	}
+
+	// Retry flaky: deploy runs after it, and the approved gate is kept.
+	press("j", "j", "r")
+	waitFor(t, u, "the retry", status("deploy", engine.StatusSuccess))
+	if got := u.state.byName["gate"].status; got != engine.StatusSuccess {
+		t.Errorf("Expected the gate not to run again, got %s", got)
+	}
+	waitFor(t, u, "the end of the retry", func(s *state) bool { return !s.running })
+
+	press("q")
+	err := <-result
+	if err == nil || !strings.Contains(err.Error(), "slow") {
+		t.Errorf("Expected the cancelled job to fail the session, got: %v", err)
+	}
+}
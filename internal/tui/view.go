@@ -0,0 +1,253 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+const (
+	inverse = "\033[7m"
+	bold    = "\033[1m"
+	dim     = "\033[2m"
+)
+
+const help = "↑↓ select  PgUp/PgDn scroll  c cancel  r retry  a approve  d deny  q quit"
+
+var spinner = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+// escapes matches the ANSI escape sequences of the log lines, which
+// would break the layout.
+var escapes = regexp.MustCompile(`\x1b(\[[0-9;?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|[@-Z\\-_])`)
+
+// render returns the rows of the screen: a header, the job list on the
+// left and the log of the selected job on the right, and a footer with
+// the keys or the last message. Rows never exceed width.
+func (s *state) render(width, height int, now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	width, height = max(width, 20), max(height, 4)
+
+	rows := make([]string, 0, height)
+	rows = append(rows, inverse+fit(s.header(now), width)+runner.ColorReset)
+
+	// Room for the cursor, the icon, the name and a timer of up to 7
+	// characters.
+	listWidth := 0
+	for _, job := range s.jobs {
+		listWidth = max(listWidth, len([]rune(displayName(job)))+13)
+	}
+	listWidth = min(listWidth, width/2)
+	logWidth := width - listWidth - 1
+	body := height - 2
+
+	logRows := s.logRows(logWidth, body-1)
+	for i := 0; i < body; i++ {
+		left := strings.Repeat(" ", listWidth)
+		if i < len(s.jobs) {
+			left = s.jobRow(i, listWidth, now)
+		}
+		right := ""
+		switch {
+		case i == 0:
+			right = bold + fit(s.logTitle(now), logWidth) + runner.ColorReset
+		case i-1 < len(logRows):
+			right = logRows[i-1]
+		}
+		rows = append(rows, left+dim+"│"+runner.ColorReset+right)
+	}
+
+	footer := help
+	if s.message != "" {
+		footer = s.message
+	}
+	rows = append(rows, inverse+fit(footer, width)+runner.ColorReset)
+	return rows
+}
+
+func (s *state) header(now time.Time) string {
+	pipeline := s.jobs[0]
+	done := 0
+	for _, job := range s.jobs[1:] {
+		if finished(job.status) {
+			done++
+		}
+	}
+	status := pipeline.status
+	if s.running {
+		status = statusRunning
+	}
+	return fmt.Sprintf(" flowcraft run · %s · %d/%d job(s) done · %s", status, done, len(s.jobs)-1, elapsed(pipeline, now))
+}
+
+// jobRow renders the row of the i-th job of the list.
+func (s *state) jobRow(i, width int, now time.Time) string {
+	job := s.jobs[i]
+	timer := elapsed(job, now)
+	text := fit(displayName(job), width-5-len(timer)) + " " + timer
+	icon, color := icon(job.status, now)
+	cursor := " "
+	if i == s.selected {
+		cursor = "›"
+		text = inverse + text + runner.ColorReset
+	}
+	return cursor + color + icon + runner.ColorReset + " " + text + " "
+}
+
+func (s *state) logTitle(now time.Time) string {
+	job := s.jobs[s.selected]
+	parts := []string{displayName(job), job.status}
+	if job.attempt > 1 {
+		parts = append(parts, fmt.Sprintf("attempt %d", job.attempt))
+	}
+	if job.note != "" {
+		parts = append(parts, job.note)
+	}
+	return " " + strings.Join(parts, " · ")
+}
+
+// logRows returns the last height rows of the selected job's log, or
+// earlier ones when it is scrolled up. Long lines are wrapped.
+func (s *state) logRows(width, height int) []string {
+	var rows []string
+	for _, e := range s.jobs[s.selected].lines {
+		text := clean(e.Message)
+		color := ""
+		switch e.Level {
+		case runner.LevelError:
+			color = runner.ColorRed
+		case runner.LevelWarn:
+			color = runner.ColorYellow
+		case runner.LevelSuccess:
+			color = runner.ColorGreen
+		case runner.LevelGroup:
+			color, text = runner.ColorCyan, "▶ "+text
+		}
+		for _, line := range wrap(" "+text, width) {
+			if color != "" {
+				line = color + line + runner.ColorReset
+			}
+			rows = append(rows, line)
+		}
+	}
+	s.scroll = min(s.scroll, max(len(rows)-height, 0))
+	end := len(rows) - s.scroll
+	return rows[max(end-height, 0):end]
+}
+
+// displayName is the name of the job in the list: the first row is the
+// pipeline.
+func displayName(job *jobView) string {
+	if job.name == "" {
+		return "pipeline"
+	}
+	return job.name
+}
+
+func icon(status string, now time.Time) (string, string) {
+	switch status {
+	case statusRunning:
+		return string(spinner[now.UnixMilli()/100%int64(len(spinner))]), runner.ColorCyan
+	case statusWaiting:
+		return "◷", runner.ColorYellow
+	case statusApproval:
+		return "?", runner.ColorYellow
+	case engine.StatusSuccess:
+		return "✓", runner.ColorGreen
+	case engine.StatusFailed:
+		return "✗", runner.ColorRed
+	case engine.StatusCancelled:
+		return "⊘", runner.ColorYellow
+	case engine.StatusSkipped:
+		return "-", dim
+	default:
+		return "·", dim
+	}
+}
+
+func finished(status string) bool {
+	switch status {
+	case engine.StatusSuccess, engine.StatusFailed, engine.StatusCancelled, engine.StatusSkipped:
+		return true
+	}
+	return false
+}
+
+// elapsed is how long the job has been running, or ran.
+func elapsed(job *jobView, now time.Time) string {
+	switch {
+	case job.started.IsZero():
+		return ""
+	case job.finished.Before(job.started):
+		return formatDuration(now.Sub(job.started))
+	default:
+		return formatDuration(job.finished.Sub(job.started))
+	}
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	if d >= time.Hour {
+		return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// clean removes the escape sequences and control characters of s.
+func clean(s string) string {
+	s = escapes.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// fit truncates or pads s to width runes.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(r))
+}
+
+// wrap splits s into rows of width runes, padded.
+func wrap(s string, width int) []string {
+	if width <= 0 {
+		return nil
+	}
+	r := []rune(s)
+	var rows []string
+	for len(r) > width {
+		rows = append(rows, string(r[:width]))
+		r = r[width:]
+	}
+	return append(rows, fit(string(r), width))
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_ATTRIB |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// inotify watches every directory of the tree with inotify(7). New
+// directories are watched as they appear.
+type inotify struct {
+	root   string
+	fd     int
+	file   *os.File
+	filter *filter
+	// dirs maps the watch descriptors to their directory.
+	dirs map[int]string
+
+	changes chan string
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newNative(root string) (Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	w := &inotify{
+		root:    root,
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		filter:  newFilter(root),
+		dirs:    make(map[int]string),
+		changes: make(chan string),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	// Past the watch limit (fs.inotify.max_user_watches), New polls.
+	if _, err := w.add("."); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+	go w.loop()
+	return w, nil
+}
+
+func (w *inotify) Changes() <-chan string {
+	return w.changes
+}
+
+func (w *inotify) Close() error {
+	w.once.Do(func() {
+		close(w.stop)
+		// Closing the file interrupts the pending read.
+		w.file.Close()
+	})
+	<-w.done
+	return nil
+}
+
+// add watches dir and the directories under it, and returns the files
+// found in them.
+func (w *inotify) add(dir string) ([]string, error) {
+	var files []string
+	var watchErr error
+	err := walk(w.root, dir, w.filter, func(rel string, d fs.DirEntry) {
+		if !d.IsDir() {
+			files = append(files, rel)
+			return
+		}
+		if watchErr != nil {
+			return
+		}
+		wd, err := syscall.InotifyAddWatch(w.fd, filepath.Join(w.root, filepath.FromSlash(rel)), inotifyMask)
+		if err != nil {
+			watchErr = os.NewSyscallError("inotify_add_watch", err)
+			return
+		}
+		w.dirs[wd] = rel
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, watchErr
+}
+
+func (w *inotify) loop() {
+	defer close(w.done)
+	defer close(w.changes)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			name := string(buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)])
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+			for len(name) > 0 && name[len(name)-1] == 0 {
+				name = name[:len(name)-1]
+			}
+			if !w.handle(int(ev.Wd), ev.Mask, name) {
+				return
+			}
+		}
+	}
+}
+
+// handle reports the change described by an event. It returns false
+// once the watcher is closed.
+func (w *inotify) handle(wd int, mask uint32, name string) bool {
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(w.dirs, wd)
+		return true
+	}
+	dir, ok := w.dirs[wd]
+	if !ok || name == "" {
+		return true
+	}
+	rel := path.Join(dir, name)
+	isDir := mask&syscall.IN_ISDIR != 0
+	if w.filter.skip(rel, isDir) {
+		return true
+	}
+	if name == ".gitignore" {
+		w.reloadFilter()
+	}
+
+	changed := []string{rel}
+	if isDir && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		// Files created before the directory was watched are reported
+		// along with it. A directory past the watch limit is missed.
+		files, _ := w.add(rel)
+		changed = append(changed, files...)
+	}
+	for _, name := range changed {
+		select {
+		case w.changes <- name:
+		case <-w.stop:
+			return false
+		}
+	}
+	return true
+}
+
+// reloadFilter reads the .gitignore files of the watched directories
+// again, parents first.
+func (w *inotify) reloadFilter() {
+	dirs := make([]string, 0, len(w.dirs))
+	for _, dir := range w.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	w.filter = newFilter(w.root)
+	for _, dir := range dirs {
+		w.filter.enter(dir)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"io/fs"
+	"sort"
+	"sync"
+	"time"
+)
+
+// poller finds changes by comparing the size, modification time and
+// mode of the files of the tree every interval.
+type poller struct {
+	root     string
+	interval time.Duration
+	files    map[string]fileState
+
+	changes chan string
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+// NewPoller watches root by walking it every interval. It works on
+// every platform and file system, at the cost of latency and CPU.
+func NewPoller(root string, interval time.Duration) (Watcher, error) {
+	p := &poller{
+		root:     root,
+		interval: interval,
+		changes:  make(chan string),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	files, err := p.scan()
+	if err != nil {
+		return nil, err
+	}
+	p.files = files
+	go p.loop()
+	return p, nil
+}
+
+func (p *poller) Changes() <-chan string {
+	return p.changes
+}
+
+func (p *poller) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	<-p.done
+	return nil
+}
+
+func (p *poller) loop() {
+	defer close(p.done)
+	defer close(p.changes)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		files, err := p.scan()
+		if err != nil {
+			continue
+		}
+		var changed []string
+		for name, state := range files {
+			if old, ok := p.files[name]; !ok || !old.modTime.Equal(state.modTime) || old.size != state.size || old.mode != state.mode {
+				changed = append(changed, name)
+			}
+		}
+		for name := range p.files {
+			if _, ok := files[name]; !ok {
+				changed = append(changed, name)
+			}
+		}
+		p.files = files
+
+		sort.Strings(changed)
+		for _, name := range changed {
+			select {
+			case p.changes <- name:
+			case <-p.stop:
+				return
+			}
+		}
+	}
+}
+
+// scan lists the files of the tree. The .gitignore files are read anew
+// on every scan, so that changes to them apply.
+func (p *poller) scan() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := walk(p.root, ".", newFilter(p.root), func(rel string, d fs.DirEntry) {
+		if d.IsDir() {
+			return
+		}
+		info, err := d.Info()
+		if err != nil {
+			return
+		}
+		files[rel] = fileState{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+	})
+	return files, err
+}
@@ -0,0 +1,324 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
+)
+
+// DefaultDebounce is how long changes must settle before jobs re-run.
+const DefaultDebounce = 300 * time.Millisecond
+
+// Options configures a watch session.
+type Options struct {
+	// Jobs restricts the session to these jobs and their dependencies.
+	// Empty selects every job.
+	Jobs []string
+	// Debounce defaults to DefaultDebounce.
+	Debounce time.Duration
+	// RunOptions are passed to every run of the pipeline.
+	RunOptions []engine.Option
+}
+
+// session holds the state of Run.
+type session struct {
+	cfg      *config.Config
+	graph    *engine.Graph
+	watcher  Watcher
+	logger   *runner.Logger
+	opts     Options
+	selected map[string]bool
+	globs    map[string][]*workspace.Glob
+	// outputs carries the outputs of the jobs from one run to the next.
+	outputs map[string]string
+
+	mu sync.Mutex
+	// ok records the jobs whose last run succeeded.
+	ok map[string]bool
+}
+
+// run is a run of the pipeline in progress.
+type run struct {
+	jobs      []string
+	cancel    context.CancelFunc
+	canceller *engine.JobCanceller
+	done      chan error
+	// stopped lists the jobs cancelled because of a change.
+	stopped []string
+	// failed is set when one of the jobs failed.
+	failed atomic.Bool
+}
+
+func (r *run) record(e engine.Event) {
+	if e.Type == engine.EventJobFinished && e.Status == engine.StatusFailed {
+		r.failed.Store(true)
+	}
+}
+
+// Run runs the selected jobs, then re-runs the jobs whose watch globs
+// match the changed files, along with their dependents, until ctx is
+// cancelled. Changes are debounced. A change affecting jobs of the run
+// in progress cancels those of them that are running, and the affected
+// jobs run again once the rest of the run finished; other changes wait
+// for the run to end as well. Jobs whose
+// dependencies did not succeed in their last run are run with them.
+func Run(ctx context.Context, cfg *config.Config, graph *engine.Graph, watcher Watcher, logger *runner.Logger, opts Options) error {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	s := &session{
+		cfg:      cfg,
+		graph:    graph,
+		watcher:  watcher,
+		logger:   logger,
+		opts:     opts,
+		selected: make(map[string]bool),
+		globs:    make(map[string][]*workspace.Glob),
+		outputs:  make(map[string]string),
+		ok:       make(map[string]bool),
+	}
+
+	var names []string
+	if len(opts.Jobs) == 0 {
+		for name := range graph.Nodes {
+			names = append(names, name)
+		}
+	} else {
+		for _, name := range opts.Jobs {
+			if _, ok := graph.Nodes[name]; !ok {
+				return fmt.Errorf("unknown job '%s'", name)
+			}
+		}
+		names = graph.WithDependencies(opts.Jobs)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s.selected[name] = true
+		for _, pattern := range graph.Nodes[name].Job.Watch {
+			g, err := workspace.CompileGlob(pattern)
+			if err != nil {
+				return fmt.Errorf("job '%s': %w", name, err)
+			}
+			s.globs[name] = append(s.globs[name], g)
+		}
+	}
+	return s.loop(ctx, names)
+}
+
+func (s *session) loop(ctx context.Context, pending []string) error {
+	var current *run
+	var queued []string
+	changed := make(map[string]bool)
+	var settle <-chan time.Time
+	changes := s.watcher.Changes()
+
+	for {
+		if current == nil && len(pending) > 0 {
+			current = s.start(ctx, pending)
+			pending = nil
+		}
+		var done chan error
+		if current != nil {
+			done = current.done
+		}
+
+		select {
+		case <-ctx.Done():
+			if current != nil {
+				current.cancel()
+				<-current.done
+			}
+			return nil
+
+		case name, ok := <-changes:
+			if !ok {
+				if current != nil {
+					current.cancel()
+					<-current.done
+				}
+				return errors.New("the file watcher stopped")
+			}
+			changed[name] = true
+			settle = time.After(s.opts.Debounce)
+
+		case <-settle:
+			settle = nil
+			affected := s.affected(changed)
+			changed = make(map[string]bool)
+			if len(affected) == 0 {
+				continue
+			}
+			switch {
+			case current == nil:
+				pending = affected
+			case overlaps(current.jobs, affected):
+				var stopped []string
+				for _, name := range affected {
+					if current.canceller.Cancel(name) {
+						stopped = append(stopped, name)
+					}
+				}
+				if len(stopped) > 0 {
+					s.logger.Warn(fmt.Sprintf("Cancelled %s, the rest of the run goes on.", strings.Join(stopped, ", ")))
+					current.stopped = union(current.stopped, stopped)
+				}
+				s.logger.Info(fmt.Sprintf("%s will run after the run in progress.", strings.Join(affected, ", ")))
+				queued = union(queued, affected)
+			default:
+				s.logger.Info(fmt.Sprintf("%s will run after the run in progress.", strings.Join(affected, ", ")))
+				queued = union(queued, affected)
+			}
+
+		case err := <-done:
+			switch {
+			case err == nil:
+				s.logger.Success(fmt.Sprintf("Run of %s finished successfully.", strings.Join(current.jobs, ", ")))
+			case len(current.stopped) > 0 && !current.failed.Load():
+				s.logger.Info(fmt.Sprintf("Run of %s finished without the cancelled jobs.", strings.Join(current.jobs, ", ")))
+			case !errors.Is(err, context.Canceled):
+				s.logger.Error(fmt.Sprintf("Run of %s failed: %v", strings.Join(current.jobs, ", "), err))
+			}
+			current = nil
+			pending, queued = queued, nil
+			if len(pending) == 0 {
+				s.logger.Info("Watching for changes... (Ctrl+C to stop)")
+			}
+		}
+	}
+}
+
+// start runs jobs, along with the dependencies that did not succeed in
+// their last run and the dependents of those.
+func (s *session) start(ctx context.Context, jobs []string) *run {
+	s.mu.Lock()
+	jobs = s.only(s.graph.Rerun(jobs, s.ok))
+	s.mu.Unlock()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	r := &run{jobs: jobs, cancel: cancel, canceller: engine.NewJobCanceller(), done: make(chan error, 1)}
+	s.logger.Info(fmt.Sprintf("Running %s.", strings.Join(jobs, ", ")))
+
+	options := append(slices.Clone(s.opts.RunOptions),
+		engine.WithListener(s.record), engine.WithListener(r.record),
+		engine.WithJobCanceller(r.canceller), engine.WithOutputs(s.outputs))
+	go func() {
+		r.done <- engine.Run(runCtx, s.cfg, s.graph.Subgraph(jobs), s.logger, options...)
+	}()
+	return r
+}
+
+func (s *session) record(e engine.Event) {
+	switch e.Type {
+	case engine.EventJobFinished, engine.EventJobSkipped:
+		s.mu.Lock()
+		s.ok[e.Job] = e.Status == engine.StatusSuccess
+		s.mu.Unlock()
+	}
+}
+
+// affected returns the selected jobs watching one of the changed files,
+// along with their dependents.
+func (s *session) affected(changed map[string]bool) []string {
+	files := make([]string, 0, len(changed))
+	for name := range changed {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+
+	var matched []string
+	for name := range s.selected {
+		if s.watches(name, files) {
+			matched = append(matched, name)
+		}
+	}
+	affected := s.only(s.graph.WithDependents(matched))
+
+	shown := files
+	if len(shown) > 5 {
+		shown = append(slices.Clone(shown[:5]), fmt.Sprintf("and %d more", len(files)-5))
+	}
+	if len(affected) == 0 {
+		s.logger.Info(fmt.Sprintf("%d file(s) changed (%s), no job watches them.", len(files), strings.Join(shown, ", ")))
+	} else {
+		s.logger.Info(fmt.Sprintf("%d file(s) changed (%s), affecting %s.", len(files), strings.Join(shown, ", "), strings.Join(affected, ", ")))
+	}
+	return affected
+}
+
+// watches reports whether one of files matches the watch globs of the
+// job. A job without globs watches every file.
+func (s *session) watches(job string, files []string) bool {
+	globs := s.globs[job]
+	if len(globs) == 0 {
+		return len(files) > 0
+	}
+	for _, file := range files {
+		for _, g := range globs {
+			if g.Match(file) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// only keeps the selected jobs.
+func (s *session) only(jobs []string) []string {
+	var result []string
+	for _, name := range jobs {
+		if s.selected[name] {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+func overlaps(a, b []string) bool {
+	for _, name := range a {
+		if slices.Contains(b, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// union returns the sorted names found in any of the lists.
+func union(lists ...[]string) []string {
+	var result []string
+	for _, list := range lists {
+		for _, name := range list {
+			if !slices.Contains(result, name) {
+				result = append(result, name)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
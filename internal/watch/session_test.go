@@ -0,0 +1,221 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/engine"
+	"github.com/Purpose-Dev/flowcraft/internal/runner"
+)
+
+// fakeWatcher reports the changes sent to it.
+type fakeWatcher chan string
+
+func (f fakeWatcher) Changes() <-chan string { return f }
+func (f fakeWatcher) Close() error           { return nil }
+
+// startSession runs a watch session of cfg in dir and returns the
+// watcher feeding it and the events of its runs.
+func startSession(t *testing.T, cfg *config.Config, dir string, jobs ...string) (fakeWatcher, <-chan engine.Event) {
+	t.Helper()
+	graph, err := engine.BuildDag(cfg)
+	if err != nil {
+		t.Fatalf("BuildDag() returned an unexpected error: %v", err)
+	}
+	logger := runner.NewLogger()
+	logger.SetOutput(io.Discard)
+
+	watcher := make(fakeWatcher)
+	events := make(chan engine.Event, 1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, cfg, graph, watcher, logger, Options{
+			Jobs:     jobs,
+			Debounce: 20 * time.Millisecond,
+			RunOptions: []engine.Option{
+				engine.WithWorkdir(dir),
+				engine.WithListener(func(e engine.Event) { events <- e }),
+			},
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run() returned an unexpected error: %v", err)
+		}
+	})
+	return watcher, events
+}
+
+// nextRun returns the jobs started by the next run, and their status.
+func nextRun(t *testing.T, events <-chan engine.Event) ([]string, map[string]string) {
+	t.Helper()
+	var started []string
+	statuses := make(map[string]string)
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			switch e.Type {
+			case engine.EventJobStarted:
+				started = append(started, e.Job)
+			case engine.EventJobFinished, engine.EventJobSkipped:
+				statuses[e.Job] = e.Status
+			case engine.EventRunFinished:
+				sort.Strings(started)
+				return started, statuses
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for a run, started so far: %v", started)
+		}
+	}
+}
+
+func TestRun_RerunsAffectedJobs(t *testing.T) {
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{
+			"api": {Watch: []string{"api/**"}, Steps: []config.Step{{Name: "Build", Cmd: "true"}}},
+			"web": {Watch: []string{"web/**"}, Steps: []config.Step{{Name: "Build", Cmd: "test -f web-ok"}}},
+			"e2e": {
+				DependsOn: []string{"api", "web"},
+				Watch:     []string{"e2e/**"},
+				Steps:     []config.Step{{Name: "Test", Cmd: "true"}},
+			},
+			"docs": {Watch: []string{"docs/**"}, Steps: []config.Step{{Name: "Build", Cmd: "true"}}},
+		},
+	}
+	dir := t.TempDir()
+	watcher, events := startSession(t, cfg, dir, "e2e")
+
+	// docs is not selected; web fails, so e2e is skipped.
+	started, statuses := nextRun(t, events)
+	if want := []string{"api", "web"}; !reflect.DeepEqual(started, want) || statuses["e2e"] != engine.StatusSkipped {
+		t.Fatalf("Expected the first run to start %v and skip e2e, got %v %v", want, started, statuses)
+	}
+
+	// web did not succeed, so a change to api runs it too.
+	watcher <- "api/main.go"
+	watcher <- "api/routes.go"
+	if started, _ := nextRun(t, events); !reflect.DeepEqual(started, []string{"api", "web"}) {
+		t.Fatalf("Expected api and the failed web to run, got %v", started)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "web-ok"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	watcher <- "web/index.html"
+	if started, statuses := nextRun(t, events); !reflect.DeepEqual(started, []string{"e2e", "web"}) || statuses["e2e"] != engine.StatusSuccess {
+		t.Fatalf("Expected web and its dependent e2e to run, got %v %v", started, statuses)
+	}
+
+	watcher <- "docs/index.md"
+	watcher <- "e2e/login.spec.ts"
+	if started, _ := nextRun(t, events); !reflect.DeepEqual(started, []string{"e2e"}) {
+		t.Fatalf("Expected only e2e to run, got %v", started)
+	}
+}
+
+func TestRun_CancelsAffectedRun(t *testing.T) {
+	cfg := &config.Config{
+		Jobs: map[string]config.Job{
+			"slow": {Steps: []config.Step{{Name: "Sleep", Cmd: "test -f ran && exit 0; touch ran; exec sleep 30"}}},
+		},
+	}
+	dir := t.TempDir()
+	watcher, events := startSession(t, cfg, dir)
+
+	// Wait for the first run to be sleeping.
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(filepath.Join(dir, "ran")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the first run")
+		}
+	}
+	// A job without watch globs watches every file.
+	watcher <- "README.md"
+
+	if _, statuses := nextRun(t, events); statuses["slow"] != engine.StatusCancelled {
+		t.Fatalf("Expected the run in progress to be cancelled, got %v", statuses)
+	}
+	if _, statuses := nextRun(t, events); statuses["slow"] != engine.StatusSuccess {
+		t.Fatalf("Expected slow to run again, got %v", statuses)
+	}
+}
+
+func TestRun_ChangeKeepsUnaffectedJobsRunning(t *testing.T) {
+	cfg := &config.Config{
+		Settings: config.Settings{Parallelism: 2, WeightBudget: 2},
+		Jobs: map[string]config.Job{
+			"fast": {Watch: []string{"fast/**"}, Steps: []config.Step{{Name: "Sleep", Cmd: "test -f fast-ran && exit 0; touch fast-ran; exec sleep 30"}}},
+			"long": {Watch: []string{"long/**"}, Steps: []config.Step{{Name: "Wait", Cmd: "touch long-started; until test -f release; do sleep 0.05; done"}}},
+		},
+	}
+	dir := t.TempDir()
+	watcher, events := startSession(t, cfg, dir)
+
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		_, fastErr := os.Stat(filepath.Join(dir, "fast-ran"))
+		_, longErr := os.Stat(filepath.Join(dir, "long-started"))
+		if fastErr == nil && longErr == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for both jobs to run")
+		}
+	}
+	watcher <- "fast/main.go"
+
+	// fast is cancelled while long, which the change does not affect,
+	// keeps running.
+	statuses := make(map[string]string)
+	timeout := time.After(10 * time.Second)
+	for statuses["fast"] == "" {
+		select {
+		case e := <-events:
+			if e.Type == engine.EventJobFinished {
+				statuses[e.Job] = e.Status
+			}
+		case <-timeout:
+			t.Fatal("Timed out waiting for fast to be cancelled")
+		}
+	}
+	if statuses["fast"] != engine.StatusCancelled || statuses["long"] != "" {
+		t.Fatalf("Expected only fast to be cancelled, got %v", statuses)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "release"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, rest := nextRun(t, events); rest["long"] != engine.StatusSuccess {
+		t.Fatalf("Expected long to finish its run, got %v", rest)
+	}
+
+	if started, statuses := nextRun(t, events); !reflect.DeepEqual(started, []string{"fast"}) || statuses["fast"] != engine.StatusSuccess {
+		t.Fatalf("Expected only fast to run again, got %v %v", started, statuses)
+	}
+}
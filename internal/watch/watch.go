@@ -0,0 +1,112 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package watch reports the changes to the files of a directory tree,
+// and re-runs the jobs of a pipeline affected by them.
+package watch
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
+)
+
+// Watcher reports the files changed under a directory tree, as
+// slash-separated paths relative to its root. The .git directory and
+// the paths ignored by the .gitignore files of the tree are left out.
+type Watcher interface {
+	// Changes delivers the changed paths. It is closed by Close.
+	Changes() <-chan string
+	Close() error
+}
+
+// New watches root with the file system notifications of the platform.
+// Where they are not available, or the tree is too large for them, it
+// falls back to polling root every interval.
+func New(root string, interval time.Duration) (Watcher, error) {
+	if w, err := newNative(root); err == nil {
+		return w, nil
+	}
+	return NewPoller(root, interval)
+}
+
+// filter leaves out the .git directory and the paths ignored by the
+// .gitignore files of a tree. Directories must be entered before the
+// paths under them are checked.
+type filter struct {
+	ignore  *workspace.Ignore
+	entered map[string]bool
+}
+
+func newFilter(root string) *filter {
+	return &filter{ignore: workspace.NewIgnore(root), entered: make(map[string]bool)}
+}
+
+// enter loads the .gitignore of dir, once.
+func (f *filter) enter(dir string) {
+	if f.entered[dir] {
+		return
+	}
+	f.entered[dir] = true
+	// An unreadable .gitignore ignores nothing.
+	_ = f.ignore.Load(dir)
+}
+
+func (f *filter) skip(rel string, isDir bool) bool {
+	if rel == "." {
+		return false
+	}
+	if slices.Contains(strings.Split(rel, "/"), ".git") {
+		return true
+	}
+	return f.ignore.Match(rel, isDir)
+}
+
+// walk calls fn for every directory and file under dir, relative to
+// root, that the filter does not skip.
+func walk(root, dir string, f *filter, fn func(rel string, d fs.DirEntry)) error {
+	start := filepath.Join(root, filepath.FromSlash(dir))
+	return filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files may vanish while the tree is walked.
+			if p != start {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if f.skip(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			f.enter(rel)
+		}
+		fn(path.Clean(rel), d)
+		return nil
+	})
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// expectChange waits for want to be reported, failing on any other
+// change than the allowed ones. Native watchers may report a file more
+// than once per write.
+func expectChange(t *testing.T, w Watcher, want string, allowed ...string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case got, ok := <-w.Changes():
+			if !ok {
+				t.Fatal("Changes() was closed")
+			}
+			if got == want {
+				return
+			}
+			isAllowed := false
+			for _, a := range allowed {
+				isAllowed = isAllowed || got == a
+			}
+			if !isAllowed {
+				t.Fatalf("Expected a change of %s, got %s", want, got)
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for a change of %s", want)
+		}
+	}
+}
+
+func testWatcher(t *testing.T, newWatcher func(root string) (Watcher, error)) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".gitignore", "out/\n*.log\n")
+	writeFile(t, dir, "src/main.go", "package main")
+	writeFile(t, dir, "out/app", "binary")
+
+	w, err := newWatcher(dir)
+	if err != nil {
+		t.Fatalf("Failed to create the watcher: %v", err)
+	}
+	defer w.Close()
+
+	// Ignored files are never reported.
+	writeFile(t, dir, "out/app", "new binary")
+	writeFile(t, dir, "debug.log", "noise")
+	writeFile(t, dir, ".git/HEAD", "ref: refs/heads/main")
+	writeFile(t, dir, "src/main.go", "package main // edited")
+	expectChange(t, w, "src/main.go")
+
+	// Files in new directories are reported.
+	writeFile(t, dir, "api/v1/handler.go", "package v1")
+	expectChange(t, w, "api/v1/handler.go", "src/main.go", "api", "api/v1")
+
+	if err := os.Remove(filepath.Join(dir, "src/main.go")); err != nil {
+		t.Fatal(err)
+	}
+	expectChange(t, w, "src/main.go", "api", "api/v1", "api/v1/handler.go")
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() returned an unexpected error: %v", err)
+	}
+	for range w.Changes() {
+	}
+}
+
+func TestPoller(t *testing.T) {
+	testWatcher(t, func(root string) (Watcher, error) {
+		return NewPoller(root, 10*time.Millisecond)
+	})
+}
+
+func TestNative(t *testing.T) {
+	if _, err := newNative(t.TempDir()); err != nil {
+		t.Skipf("No native file watching: %v", err)
+	}
+	testWatcher(t, newNative)
+}
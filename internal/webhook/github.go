@@ -0,0 +1,176 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+type githubRepository struct {
+	FullName string `json:"full_name"`
+	CloneURL string `json:"clone_url"`
+}
+
+type githubCommit struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+	Author   struct {
+		Name string `json:"name"`
+	} `json:"author"`
+}
+
+type githubPush struct {
+	Ref        string           `json:"ref"`
+	After      string           `json:"after"`
+	Deleted    bool             `json:"deleted"`
+	Repository githubRepository `json:"repository"`
+	HeadCommit *githubCommit    `json:"head_commit"`
+	Commits    []githubCommit   `json:"commits"`
+}
+
+type githubPullRequest struct {
+	Action      string           `json:"action"`
+	Number      int              `json:"number"`
+	Repository  githubRepository `json:"repository"`
+	PullRequest struct {
+		Title string `json:"title"`
+		User  struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Head struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+}
+
+// ParseGitHub verifies the X-Hub-Signature-256 of a GitHub delivery
+// with secret and decodes its push or pull_request payload.
+func ParseGitHub(header http.Header, body []byte, secret string) (*Delivery, error) {
+	if err := verifyGitHub(header.Get("X-Hub-Signature-256"), body, secret); err != nil {
+		return nil, err
+	}
+
+	switch event := header.Get("X-GitHub-Event"); event {
+	case "ping":
+		return &Delivery{Skip: "ping"}, nil
+	case "push":
+		var p githubPush
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("invalid push payload: %w", err)
+		}
+		return p.delivery(), nil
+	case "pull_request":
+		var p githubPullRequest
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("invalid pull_request payload: %w", err)
+		}
+		return p.delivery(), nil
+	default:
+		return &Delivery{Skip: fmt.Sprintf("unsupported event '%s'", event)}, nil
+	}
+}
+
+// verifyGitHub checks a "sha256=<hex>" HMAC of the body.
+func verifyGitHub(signature string, body []byte, secret string) error {
+	if secret == "" {
+		return errors.New("no webhook secret is configured")
+	}
+	sum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return ErrSignature
+	}
+	got, err := hex.DecodeString(sum)
+	if err != nil {
+		return ErrSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrSignature
+	}
+	return nil
+}
+
+func (p *githubPush) delivery() *Delivery {
+	branch, ok := strings.CutPrefix(p.Ref, "refs/heads/")
+	switch {
+	case !ok:
+		return &Delivery{Skip: fmt.Sprintf("'%s' is not a branch", p.Ref)}
+	case p.Deleted:
+		return &Delivery{Skip: fmt.Sprintf("branch '%s' was deleted", branch)}
+	}
+
+	d := &Delivery{Trigger: api.Trigger{
+		Provider:   "github",
+		Event:      config.TriggerPush,
+		Repository: p.Repository.FullName,
+		CloneURL:   p.Repository.CloneURL,
+		Branch:     branch,
+		SHA:        p.After,
+	}}
+	if p.HeadCommit != nil {
+		d.Trigger.Message = p.HeadCommit.Message
+		d.Trigger.Author = p.HeadCommit.Author.Name
+	}
+	if len(p.Commits) < maxListedCommits {
+		var lists [][]string
+		for _, c := range p.Commits {
+			lists = append(lists, c.Added, c.Removed, c.Modified)
+		}
+		d.Changed = changedFiles(lists...)
+	}
+	return d
+}
+
+func (p *githubPullRequest) delivery() *Delivery {
+	switch p.Action {
+	case "opened", "synchronize", "reopened":
+	default:
+		return &Delivery{Skip: fmt.Sprintf("pull request action '%s'", p.Action)}
+	}
+
+	pr := p.PullRequest
+	return &Delivery{Trigger: api.Trigger{
+		Provider:    "github",
+		Event:       config.TriggerPullRequest,
+		Repository:  p.Repository.FullName,
+		CloneURL:    p.Repository.CloneURL,
+		Branch:      pr.Head.Ref,
+		SHA:         pr.Head.SHA,
+		Message:     pr.Title,
+		Author:      pr.User.Login,
+		PullRequest: p.Number,
+		BaseBranch:  pr.Base.Ref,
+	}}
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const testSecret = "It's a Secret to Everybody"
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	return data
+}
+
+func githubHeader(event string, body []byte, secret string) http.Header {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	h := http.Header{}
+	h.Set("X-GitHub-Event", event)
+	h.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return h
+}
+
+func TestVerifyGitHub(t *testing.T) {
+	// The example from GitHub's documentation on validating deliveries.
+	body := []byte("Hello, World!")
+	signature := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+	if err := verifyGitHub(signature, body, testSecret); err != nil {
+		t.Errorf("Expected the documented signature to verify, got: %v", err)
+	}
+	for _, bad := range []string{"", "sha256=zz", "sha1=757107ea0eb2509fc211221cce984b8a37570b6d", signature[:len(signature)-1] + "8"} {
+		if err := verifyGitHub(bad, body, testSecret); !errors.Is(err, ErrSignature) {
+			t.Errorf("verifyGitHub(%q) = %v, expected ErrSignature", bad, err)
+		}
+	}
+}
+
+func TestParseGitHub_Push(t *testing.T) {
+	body := readFixture(t, "github_push.json")
+	d, err := ParseGitHub(githubHeader("push", body, testSecret), body, testSecret)
+	if err != nil {
+		t.Fatalf("ParseGitHub() returned an unexpected error: %v", err)
+	}
+
+	tr := d.Trigger
+	if tr.Provider != "github" || tr.Event != "push" || tr.Repository != "Purpose-Dev/flowcraft" {
+		t.Errorf("Unexpected trigger: %+v", tr)
+	}
+	if tr.Branch != "main" || tr.SHA != "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c" {
+		t.Errorf("Expected main at 0d1a26e, got %s at %s", tr.Branch, tr.SHA)
+	}
+	if tr.Message != "Fix the timing report" || tr.Author != "Riyane El Qoqui" {
+		t.Errorf("Expected the head commit's message and author, got %q by %q", tr.Message, tr.Author)
+	}
+	want := []string{"internal/engine/scheduler.go", "internal/engine/engine.go", "README.md", "internal/engine/levels.go", "internal/engine/report.go"}
+	if !slices.Equal(d.Changed, want) {
+		t.Errorf("Expected changed files %v, got %v", want, d.Changed)
+	}
+}
+
+func TestParseGitHub_PullRequest(t *testing.T) {
+	body := readFixture(t, "github_pull_request.json")
+	d, err := ParseGitHub(githubHeader("pull_request", body, testSecret), body, testSecret)
+	if err != nil {
+		t.Fatalf("ParseGitHub() returned an unexpected error: %v", err)
+	}
+
+	tr := d.Trigger
+	if tr.Event != "pull_request" || tr.PullRequest != 42 || tr.Branch != "feature/approvals" || tr.BaseBranch != "main" {
+		t.Errorf("Unexpected trigger: %+v", tr)
+	}
+	if tr.SHA != "9f8e7d6c5b4a39281706f5e4d3c2b1a098765432" {
+		t.Errorf("Expected the head commit of the pull request, got %s", tr.SHA)
+	}
+	if d.Changed != nil {
+		t.Errorf("Expected the changed files of a pull request to be unknown, got %v", d.Changed)
+	}
+}
+
+func TestParseGitHub_Skips(t *testing.T) {
+	tests := []struct {
+		event string
+		body  string
+	}{
+		{"ping", `{"zen": "Design for failure."}`},
+		{"push", `{"ref": "refs/tags/v1.0.0", "after": "abc"}`},
+		{"push", `{"ref": "refs/heads/old", "deleted": true}`},
+		{"pull_request", `{"action": "closed", "number": 1}`},
+		{"issues", `{"action": "opened"}`},
+	}
+	for _, tt := range tests {
+		body := []byte(tt.body)
+		d, err := ParseGitHub(githubHeader(tt.event, body, testSecret), body, testSecret)
+		if err != nil {
+			t.Errorf("ParseGitHub(%s) returned an unexpected error: %v", tt.body, err)
+			continue
+		}
+		if d.Skip == "" {
+			t.Errorf("Expected %s %s to be skipped", tt.event, tt.body)
+		}
+	}
+}
+
+func TestParseGitHub_RejectsBadSignature(t *testing.T) {
+	body := readFixture(t, "github_push.json")
+	if _, err := ParseGitHub(githubHeader("push", body, "wrong"), body, testSecret); !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected ErrSignature, got: %v", err)
+	}
+	if _, err := ParseGitHub(githubHeader("push", body, ""), body, ""); err == nil {
+		t.Error("Expected deliveries to be refused without a secret")
+	}
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+// zeroSHA is the "after" revision of a push deleting a branch.
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+type gitlabProject struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+	GitHTTPURL        string `json:"git_http_url"`
+}
+
+type gitlabCommit struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+	Author   struct {
+		Name string `json:"name"`
+	} `json:"author"`
+}
+
+type gitlabPush struct {
+	Ref               string         `json:"ref"`
+	After             string         `json:"after"`
+	UserName          string         `json:"user_name"`
+	Project           gitlabProject  `json:"project"`
+	Commits           []gitlabCommit `json:"commits"`
+	TotalCommitsCount int            `json:"total_commits_count"`
+}
+
+type gitlabMergeRequest struct {
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project          gitlabProject `json:"project"`
+	ObjectAttributes struct {
+		IID          int          `json:"iid"`
+		Action       string       `json:"action"`
+		Title        string       `json:"title"`
+		SourceBranch string       `json:"source_branch"`
+		TargetBranch string       `json:"target_branch"`
+		OldRev       string       `json:"oldrev"`
+		LastCommit   gitlabCommit `json:"last_commit"`
+	} `json:"object_attributes"`
+}
+
+// ParseGitLab checks the X-Gitlab-Token of a GitLab delivery against
+// secret and decodes its push or merge request payload. GitLab sends
+// the secret token itself rather than a signature of the body.
+func ParseGitLab(header http.Header, body []byte, secret string) (*Delivery, error) {
+	if secret == "" {
+		return nil, errors.New("no webhook secret is configured")
+	}
+	if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+		return nil, ErrSignature
+	}
+
+	switch event := header.Get("X-Gitlab-Event"); event {
+	case "Push Hook":
+		var p gitlabPush
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("invalid push payload: %w", err)
+		}
+		return p.delivery(), nil
+	case "Merge Request Hook":
+		var p gitlabMergeRequest
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("invalid merge request payload: %w", err)
+		}
+		return p.delivery(), nil
+	default:
+		return &Delivery{Skip: fmt.Sprintf("unsupported event '%s'", event)}, nil
+	}
+}
+
+func (p *gitlabPush) delivery() *Delivery {
+	branch, ok := strings.CutPrefix(p.Ref, "refs/heads/")
+	switch {
+	case !ok:
+		return &Delivery{Skip: fmt.Sprintf("'%s' is not a branch", p.Ref)}
+	case p.After == zeroSHA:
+		return &Delivery{Skip: fmt.Sprintf("branch '%s' was deleted", branch)}
+	}
+
+	d := &Delivery{Trigger: api.Trigger{
+		Provider:   "gitlab",
+		Event:      config.TriggerPush,
+		Repository: p.Project.PathWithNamespace,
+		CloneURL:   p.Project.GitHTTPURL,
+		Branch:     branch,
+		SHA:        p.After,
+		Author:     p.UserName,
+	}}
+	for _, c := range p.Commits {
+		if c.ID == p.After {
+			d.Trigger.Message = c.Message
+			d.Trigger.Author = c.Author.Name
+		}
+	}
+	if p.TotalCommitsCount <= len(p.Commits) && len(p.Commits) <= maxListedCommits {
+		var lists [][]string
+		for _, c := range p.Commits {
+			lists = append(lists, c.Added, c.Removed, c.Modified)
+		}
+		d.Changed = changedFiles(lists...)
+	}
+	return d
+}
+
+func (p *gitlabMergeRequest) delivery() *Delivery {
+	mr := p.ObjectAttributes
+	switch {
+	case mr.Action == "open", mr.Action == "reopen":
+	case mr.Action == "update" && mr.OldRev != "":
+		// Only updates pushing new commits carry an oldrev.
+	default:
+		return &Delivery{Skip: fmt.Sprintf("merge request action '%s'", mr.Action)}
+	}
+
+	return &Delivery{Trigger: api.Trigger{
+		Provider:    "gitlab",
+		Event:       config.TriggerPullRequest,
+		Repository:  p.Project.PathWithNamespace,
+		CloneURL:    p.Project.GitHTTPURL,
+		Branch:      mr.SourceBranch,
+		SHA:         mr.LastCommit.ID,
+		Message:     mr.Title,
+		Author:      p.User.Username,
+		PullRequest: mr.IID,
+		BaseBranch:  mr.TargetBranch,
+	}}
+}
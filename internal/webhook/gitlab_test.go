@@ -0,0 +1,99 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func gitlabHeader(event, token string) http.Header {
+	h := http.Header{}
+	h.Set("X-Gitlab-Event", event)
+	h.Set("X-Gitlab-Token", token)
+	return h
+}
+
+func TestParseGitLab_Push(t *testing.T) {
+	body := readFixture(t, "gitlab_push.json")
+	d, err := ParseGitLab(gitlabHeader("Push Hook", testSecret), body, testSecret)
+	if err != nil {
+		t.Fatalf("ParseGitLab() returned an unexpected error: %v", err)
+	}
+
+	tr := d.Trigger
+	if tr.Provider != "gitlab" || tr.Event != "push" || tr.Repository != "mike/diaspora" {
+		t.Errorf("Unexpected trigger: %+v", tr)
+	}
+	if tr.Branch != "release/1.2" || tr.SHA != "da1560886d4f094c3e6c9ef40349f7d38b5d27d7" {
+		t.Errorf("Expected release/1.2 at da15608, got %s at %s", tr.Branch, tr.SHA)
+	}
+	if tr.Message != "fixed readme" || tr.CloneURL != "http://example.com/mike/diaspora.git" {
+		t.Errorf("Unexpected commit message or clone URL: %+v", tr)
+	}
+	want := []string{"CHANGELOG", "app/controller/application.rb", "docs/README.md"}
+	if !slices.Equal(d.Changed, want) {
+		t.Errorf("Expected changed files %v, got %v", want, d.Changed)
+	}
+}
+
+func TestParseGitLab_MergeRequest(t *testing.T) {
+	body := readFixture(t, "gitlab_merge_request.json")
+	d, err := ParseGitLab(gitlabHeader("Merge Request Hook", testSecret), body, testSecret)
+	if err != nil {
+		t.Fatalf("ParseGitLab() returned an unexpected error: %v", err)
+	}
+
+	tr := d.Trigger
+	if tr.Event != "pull_request" || tr.PullRequest != 1 || tr.Branch != "ms-viewport" || tr.BaseBranch != "master" {
+		t.Errorf("Unexpected trigger: %+v", tr)
+	}
+	if tr.SHA != "da1560886d4f094c3e6c9ef40349f7d38b5d27d7" || tr.Author != "root" {
+		t.Errorf("Expected the last commit and the user of the merge request, got %+v", tr)
+	}
+}
+
+func TestParseGitLab_Skips(t *testing.T) {
+	tests := []struct {
+		event string
+		body  string
+	}{
+		{"Push Hook", `{"ref": "refs/heads/old", "after": "0000000000000000000000000000000000000000"}`},
+		{"Tag Push Hook", `{"ref": "refs/tags/v1.0.0"}`},
+		{"Merge Request Hook", `{"object_attributes": {"action": "update", "iid": 3}}`},
+		{"Merge Request Hook", `{"object_attributes": {"action": "merge", "iid": 3}}`},
+	}
+	for _, tt := range tests {
+		d, err := ParseGitLab(gitlabHeader(tt.event, testSecret), []byte(tt.body), testSecret)
+		if err != nil {
+			t.Errorf("ParseGitLab(%s) returned an unexpected error: %v", tt.body, err)
+			continue
+		}
+		if d.Skip == "" {
+			t.Errorf("Expected %s %s to be skipped", tt.event, tt.body)
+		}
+	}
+}
+
+func TestParseGitLab_RejectsBadToken(t *testing.T) {
+	body := readFixture(t, "gitlab_push.json")
+	if _, err := ParseGitLab(gitlabHeader("Push Hook", "wrong"), body, testSecret); !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected ErrSignature, got: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package webhook decodes GitHub and GitLab webhook deliveries into run
+// triggers and matches them against the [triggers] of a pipeline.
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+	"github.com/Purpose-Dev/flowcraft/internal/workspace"
+)
+
+// ErrSignature is returned for a delivery whose signature or token
+// doesn't match the shared secret.
+var ErrSignature = errors.New("invalid webhook signature")
+
+// maxListedCommits is how many commits GitHub and GitLab describe in a
+// push payload. The changed files of larger pushes are unknown.
+const maxListedCommits = 20
+
+// Delivery is a decoded webhook delivery.
+type Delivery struct {
+	Trigger api.Trigger
+	// Changed lists the files touched by a push. It is nil when the
+	// payload doesn't tell, as for pull requests and large pushes.
+	Changed []string
+	// Skip explains why the delivery starts no run at all, e.g. a ping
+	// or a deleted branch.
+	Skip string
+}
+
+// Match reports whether the delivery starts a run of a pipeline with
+// the given triggers, and if not, why.
+func Match(t config.Triggers, d *Delivery) (bool, string) {
+	if d.Skip != "" {
+		return false, d.Skip
+	}
+	if !slices.Contains(t.EventTypes(), d.Trigger.Event) {
+		return false, fmt.Sprintf("event '%s' is not listed in triggers.events", d.Trigger.Event)
+	}
+
+	branch := d.Trigger.Branch
+	if d.Trigger.Event == config.TriggerPullRequest {
+		branch = d.Trigger.BaseBranch
+	}
+	if len(t.Branches) > 0 && !matchAny(t.Branches, branch) {
+		return false, fmt.Sprintf("branch '%s' does not match triggers.branches", branch)
+	}
+
+	if len(t.Paths) > 0 && d.Changed != nil {
+		for _, file := range d.Changed {
+			if matchAny(t.Paths, file) {
+				return true, ""
+			}
+		}
+		return false, "no changed file matches triggers.paths"
+	}
+	return true, ""
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if workspace.MatchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Env returns the CI_* variables describing the trigger of a run.
+func Env(t api.Trigger) map[string]string {
+	env := map[string]string{
+		"CI":                "true",
+		"CI_PROVIDER":       t.Provider,
+		"CI_EVENT":          t.Event,
+		"CI_REPOSITORY":     t.Repository,
+		"CI_REPOSITORY_URL": t.CloneURL,
+		"CI_BRANCH":         t.Branch,
+		"CI_SHA":            t.SHA,
+		"CI_COMMIT_MESSAGE": t.Message,
+		"CI_COMMIT_AUTHOR":  t.Author,
+	}
+	if t.PullRequest != 0 {
+		env["CI_PULL_REQUEST"] = strconv.Itoa(t.PullRequest)
+		env["CI_BASE_BRANCH"] = t.BaseBranch
+	}
+	return env
+}
+
+// changedFiles merges the files touched by the listed commits.
+func changedFiles(lists ...[]string) []string {
+	changed := []string{}
+	for _, list := range lists {
+		for _, file := range list {
+			if !slices.Contains(changed, file) {
+				changed = append(changed, file)
+			}
+		}
+	}
+	return changed
+}
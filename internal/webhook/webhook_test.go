@@ -0,0 +1,91 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/Purpose-Dev/flowcraft/internal/api"
+	"github.com/Purpose-Dev/flowcraft/internal/config"
+)
+
+func TestMatch(t *testing.T) {
+	push := &Delivery{
+		Trigger: api.Trigger{Event: "push", Branch: "release/1.2"},
+		Changed: []string{"docs/README.md", "CHANGELOG"},
+	}
+	pr := &Delivery{Trigger: api.Trigger{Event: "pull_request", Branch: "feature/x", BaseBranch: "main", PullRequest: 7}}
+	largePush := &Delivery{Trigger: api.Trigger{Event: "push", Branch: "main"}}
+
+	tests := []struct {
+		name     string
+		triggers config.Triggers
+		delivery *Delivery
+		want     bool
+	}{
+		{"no triggers runs on push", config.Triggers{}, push, true},
+		{"no triggers ignores pull requests", config.Triggers{}, pr, false},
+		{"listed event", config.Triggers{Events: []string{"pull_request"}}, pr, true},
+		{"branch glob", config.Triggers{Branches: []string{"main", "release/*"}}, push, true},
+		{"other branch", config.Triggers{Branches: []string{"main"}}, push, false},
+		{"pull request target branch", config.Triggers{Events: []string{"pull_request"}, Branches: []string{"main"}}, pr, true},
+		{"changed path", config.Triggers{Paths: []string{"docs/**"}}, push, true},
+		{"no changed path", config.Triggers{Paths: []string{"src/**", "go.mod"}}, push, false},
+		{"unknown changes", config.Triggers{Paths: []string{"src/**"}}, largePush, true},
+		{"skipped delivery", config.Triggers{}, &Delivery{Skip: "ping"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := Match(tt.triggers, tt.delivery)
+			if got != tt.want {
+				t.Errorf("Match() = %v (%s), expected %v", got, reason, tt.want)
+			}
+			if !got && reason == "" {
+				t.Error("Expected a reason for an ignored delivery")
+			}
+		})
+	}
+}
+
+func TestEnv(t *testing.T) {
+	env := Env(api.Trigger{
+		Provider:    "github",
+		Event:       "pull_request",
+		Repository:  "Purpose-Dev/flowcraft",
+		Branch:      "feature/approvals",
+		SHA:         "9f8e7d6",
+		PullRequest: 42,
+		BaseBranch:  "main",
+	})
+	want := map[string]string{
+		"CI":              "true",
+		"CI_BRANCH":       "feature/approvals",
+		"CI_SHA":          "9f8e7d6",
+		"CI_EVENT":        "pull_request",
+		"CI_PULL_REQUEST": "42",
+		"CI_BASE_BRANCH":  "main",
+	}
+	for key, value := range want {
+		if env[key] != value {
+			t.Errorf("Expected %s=%q, got %q", key, value, env[key])
+		}
+	}
+
+	if _, ok := Env(api.Trigger{Event: "push"})["CI_PULL_REQUEST"]; ok {
+		t.Error("Expected CI_PULL_REQUEST to be unset for a push")
+	}
+}
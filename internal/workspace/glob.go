@@ -0,0 +1,117 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workspace
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Glob is a compiled path pattern. Patterns use forward slashes:
+// '*' and '?' match within a path segment, '[...]' matches a character
+// class and '**' matches any number of segments, e.g. "src/**/*.go".
+type Glob struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// CompileGlob compiles a pattern matched against whole slash-separated
+// relative paths.
+func CompileGlob(pattern string) (*Glob, error) {
+	expr, err := globExpr(pattern)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+	}
+	return &Glob{pattern: pattern, re: re}, nil
+}
+
+// MatchGlob reports whether name matches pattern. An invalid pattern
+// matches nothing.
+func MatchGlob(pattern, name string) bool {
+	g, err := CompileGlob(pattern)
+	if err != nil {
+		return false
+	}
+	return g.Match(name)
+}
+
+// Match reports whether the slash-separated path name matches the glob.
+func (g *Glob) Match(name string) bool {
+	return g.re.MatchString(path.Clean(name))
+}
+
+func (g *Glob) String() string {
+	return g.pattern
+}
+
+// globExpr translates a glob into a regular expression.
+func globExpr(pattern string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				atStart := i == 0 || pattern[i-1] == '/'
+				j := i + 2
+				switch {
+				case atStart && j < len(pattern) && pattern[j] == '/':
+					// "**/" matches zero or more leading directories.
+					b.WriteString("(?:.*/)?")
+					i = j
+				case atStart && j == len(pattern):
+					b.WriteString(".*")
+					i = j - 1
+				default:
+					// '**' not delimited by slashes is a plain '*'.
+					b.WriteString("[^/]*")
+					i = j - 1
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("invalid pattern '%s': unterminated character class", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				c = pattern[i]
+			}
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String(), nil
+}
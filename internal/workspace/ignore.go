@@ -0,0 +1,134 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workspace
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile is the name of the files listing paths left out of a workspace.
+const IgnoreFile = ".gitignore"
+
+type ignoreRule struct {
+	// base is the directory holding the .gitignore, relative to the root.
+	base    string
+	glob    *Glob
+	negate  bool
+	dirOnly bool
+}
+
+// Ignore matches paths against the .gitignore files of a tree, with the
+// semantics of git: the last matching rule wins, '!' re-includes a
+// path, a trailing '/' only matches directories and a pattern holding
+// a '/' is anchored to the directory of its .gitignore.
+type Ignore struct {
+	root  string
+	rules []ignoreRule
+}
+
+// NewIgnore returns a matcher for the tree rooted at root. The
+// .gitignore files are loaded with Load as directories are visited.
+func NewIgnore(root string) *Ignore {
+	return &Ignore{root: root}
+}
+
+// Load reads the .gitignore of dir, relative to the root, if any.
+// Parents must be loaded before their subdirectories.
+func (ig *Ignore) Load(dir string) error {
+	f, err := os.Open(filepath.Join(ig.root, filepath.FromSlash(dir), IgnoreFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		ig.Add(dir, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// Add adds a .gitignore line as if it were read from dir. Blank lines,
+// comments and invalid patterns are ignored.
+func (ig *Ignore) Add(dir, line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	rule := ignoreRule{base: path.Clean(dir)}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return
+	}
+	if !strings.Contains(line, "/") {
+		line = "**/" + line
+	}
+	glob, err := CompileGlob(strings.TrimPrefix(line, "/"))
+	if err != nil {
+		return
+	}
+	rule.glob = glob
+	ig.rules = append(ig.rules, rule)
+}
+
+// Match reports whether the slash-separated path rel, relative to the
+// root, is ignored.
+func (ig *Ignore) Match(rel string, isDir bool) bool {
+	rel = path.Clean(rel)
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		name, ok := relTo(rule.base, rel)
+		if !ok {
+			continue
+		}
+		if rule.glob.Match(name) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// relTo returns rel relative to base, if base is one of its parents.
+func relTo(base, rel string) (string, bool) {
+	if base == "." {
+		return rel, true
+	}
+	if !strings.HasPrefix(rel, base+"/") {
+		return "", false
+	}
+	return rel[len(base)+1:], true
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workspace
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestIgnore_Match(t *testing.T) {
+	ig := NewIgnore("")
+	for _, line := range []string{
+		"# build outputs",
+		"*.log",
+		"!keep.log",
+		"/dist",
+		"node_modules/",
+		"docs/*.pdf",
+		"",
+	} {
+		ig.Add(".", line)
+	}
+	ig.Add("web", "cache")
+	ig.Add("web", "!important.log")
+
+	tests := []struct {
+		name  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"logs/app.log", false, true},
+		{"keep.log", false, false},
+		{"dist", true, true},
+		{"api/dist", true, false},
+		{"node_modules", true, true},
+		{"web/node_modules", true, true},
+		{"node_modules", false, false},
+		{"docs/guide.pdf", false, true},
+		{"docs/v1/guide.pdf", false, false},
+		{"web/cache", true, true},
+		{"cache", true, false},
+		{"web/important.log", false, false},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := ig.Match(tt.name, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, expected %v", tt.name, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestPack_RespectsGitignore(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		".gitignore":        "*.log\nbuild/\n",
+		"main.go":           "package main",
+		"debug.log":         "noise",
+		"build/app":         "binary",
+		"web/.gitignore":    "!web.log\n/tmp\n",
+		"web/web.log":       "kept",
+		"web/tmp/cache":     "cache",
+		"web/src/tmp/index": "kept",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Pack(src, &buf); err != nil {
+		t.Fatalf("Pack() returned an unexpected error: %v", err)
+	}
+
+	var packed []string
+	gz, _ := gzip.NewReader(&buf)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			packed = append(packed, hdr.Name)
+		}
+	}
+	sort.Strings(packed)
+
+	expected := []string{".gitignore", "main.go", "web/.gitignore", "web/src/tmp/index", "web/web.log"}
+	if len(packed) != len(expected) {
+		t.Fatalf("Expected %v to be packed, got %v", expected, packed)
+	}
+	for i := range expected {
+		if packed[i] != expected[i] {
+			t.Errorf("Expected %v to be packed, got %v", expected, packed)
+			break
+		}
+	}
+}
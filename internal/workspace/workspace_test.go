@@ -0,0 +1,123 @@
+/*
+ * Copyright 2025 Riyane El Qoqui
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package workspace
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackUnpack_RoundTrip(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "api", ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.MkdirAll(filepath.Join(src, ".git"), 0o755)
+	_ = os.WriteFile(filepath.Join(src, ".git", "HEAD"), []byte("ref"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "api", "main.go"), []byte("package main"), 0o644)
+	_ = os.WriteFile(filepath.Join(src, "run.sh"), []byte("#!/bin/sh"), 0o755)
+
+	var buf bytes.Buffer
+	if err := Pack(src, &buf); err != nil {
+		t.Fatalf("Pack() returned an unexpected error: %v", err)
+	}
+
+	dst := t.TempDir()
+	if err := Unpack(&buf, dst); err != nil {
+		t.Fatalf("Unpack() returned an unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dst, "api", "main.go"))
+	if err != nil || string(data) != "package main" {
+		t.Errorf("Expected api/main.go to be restored, got %q (%v)", data, err)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "run.sh")); err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("Expected run.sh to stay executable, got %v (%v)", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, ".git")); !os.IsNotExist(err) {
+		t.Error("Expected the .git directory to be left out")
+	}
+}
+
+func TestUnpack_RejectsTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	_ = tw.WriteHeader(&tar.Header{Name: "../evil.txt", Mode: 0o644, Size: 4, Typeflag: tar.TypeReg})
+	_, _ = tw.Write([]byte("evil"))
+	tw.Close()
+	gz.Close()
+
+	err := Unpack(&buf, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Errorf("Expected a path traversal error, got: %v", err)
+	}
+}
+
+// writeArchive builds a gzipped tarball of the given headers. Regular
+// files get their name as content.
+func writeArchive(t *testing.T, headers ...*tar.Header) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, hdr := range headers {
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(hdr.Name))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			_, _ = tw.Write([]byte(hdr.Name))
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return &buf
+}
+
+func TestUnpack_RejectsEscapeThroughSymlinks(t *testing.T) {
+	sub := &tar.Header{Name: "sub/", Mode: 0o755, Typeflag: tar.TypeDir}
+	up := &tar.Header{Name: "sub/l", Linkname: "..", Typeflag: tar.TypeSymlink}
+	x := &tar.Header{Name: "x", Linkname: "sub/l/..", Typeflag: tar.TypeSymlink}
+	evil := &tar.Header{Name: "x/evil", Mode: 0o644, Typeflag: tar.TypeReg}
+
+	cases := map[string][]*tar.Header{
+		"link through a link":         {sub, up, x, evil},
+		"link created before its hop": {sub, x, up, evil},
+	}
+	for name, headers := range cases {
+		t.Run(name, func(t *testing.T) {
+			parent := t.TempDir()
+			dst := filepath.Join(parent, "work")
+
+			err := Unpack(writeArchive(t, headers...), dst)
+			if err == nil || !strings.Contains(err.Error(), "symlink") {
+				t.Errorf("Expected a symlink error, got: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(parent, "evil")); err == nil {
+				t.Error("Expected nothing to be written outside the work directory")
+			}
+		})
+	}
+}